@@ -1,14 +1,130 @@
 package main
 
 import (
+	"fmt"
 	"log"
+	"os"
+	"sort"
 
+	"github.com/NdoleStudio/httpsms/pkg/entities"
 	"github.com/joho/godotenv"
+	"github.com/palantir/stacktrace"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
 )
 
+// migrations are one-off database migrations which are too slow or too destructive to run when the API starts.
+// They are run by name e.g. go run ./cmd/migration message-search
+var migrations = map[string]func(db *gorm.DB) error{
+	"message-search":     migrateMessageSearch,
+	"message-request-id": migrateMessageRequestID,
+	"drop-user-api-key":  dropUserAPIKey,
+}
+
 func main() {
 	err := godotenv.Load("../../.env")
 	if err != nil {
 		log.Fatal("Error loading .env file")
 	}
+
+	if len(os.Args) != 2 || migrations[os.Args[1]] == nil {
+		log.Fatalf("usage: migration <name> where name is one of %v", names())
+	}
+
+	db, err := gorm.Open(postgres.Open(os.Getenv("DATABASE_URL")), &gorm.Config{TranslateError: true})
+	if err != nil {
+		log.Fatal(stacktrace.Propagate(err, "cannot connect to the database"))
+	}
+
+	if err = migrations[os.Args[1]](db); err != nil {
+		log.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot run the [%s] migration", os.Args[1])))
+	}
+
+	log.Printf("ran the [%s] migration", os.Args[1])
+}
+
+func names() []string {
+	result := make([]string, 0, len(migrations))
+	for name := range migrations {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// migrateMessageSearch adds the generated tsvector column and GIN index used for full-text search on messages.
+// Encrypted messages have a NULL tsvector so that they are never matched.
+// The index is built concurrently so that writes to messages are not blocked while it is built.
+func migrateMessageSearch(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&entities.Message{}, "content_search") {
+		log.Println("adding the full-text search column to messages")
+		err := db.Exec(`ALTER TABLE messages ADD COLUMN IF NOT EXISTS content_search tsvector GENERATED ALWAYS AS (CASE WHEN encrypted THEN NULL ELSE to_tsvector('simple', coalesce(content, '')) END) STORED;`).Error
+		if err != nil {
+			return stacktrace.Propagate(err, "cannot add the content_search column to messages")
+		}
+	}
+
+	return createIndexConcurrently(db, "idx_messages__content_search", "messages USING GIN (content_search)")
+}
+
+// migrateMessageRequestID adds the index used to look up the messages of a user by the request_id.
+// It is built concurrently because the API no longer creates it with AutoMigrate which would lock messages.
+func migrateMessageRequestID(db *gorm.DB) error {
+	return createIndexConcurrently(db, "idx_messages__user_id__request_id", "messages (user_id, request_id)")
+}
+
+// createIndexConcurrently builds an index without blocking writes to the table.
+// A concurrent build which failed leaves an invalid index behind which is dropped before building it again.
+func createIndexConcurrently(db *gorm.DB, name string, definition string) error {
+	var invalid int64
+	err := db.Raw(`SELECT COUNT(*) FROM pg_index JOIN pg_class ON pg_class.oid = pg_index.indexrelid WHERE pg_class.relname = ? AND NOT pg_index.indisvalid;`, name).Scan(&invalid).Error
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot check if the index [%s] is valid", name))
+	}
+
+	if invalid > 0 {
+		log.Printf("dropping the invalid index [%s]", name)
+		if err = db.Exec(fmt.Sprintf(`DROP INDEX CONCURRENTLY IF EXISTS %s;`, name)).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot drop the invalid index [%s]", name))
+		}
+	}
+
+	log.Printf("building the index [%s] on %s", name, definition)
+	if err = db.Exec(fmt.Sprintf(`CREATE INDEX CONCURRENTLY IF NOT EXISTS %s ON %s;`, name, definition)).Error; err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot create the index [%s]", name))
+	}
+
+	return nil
+}
+
+// dropUserAPIKey drops the plaintext users.api_key column after the API starts hashing the keys of all users on boot.
+// It refuses to drop the column while any user still has a plaintext key which has not been hashed.
+func dropUserAPIKey(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&entities.User{}, "api_key") {
+		return nil
+	}
+
+	var count int64
+	if err := db.Table("users").Where("api_key IS NOT NULL").Count(&count).Error; err != nil {
+		return stacktrace.Propagate(err, "cannot count the users with a plaintext api key")
+	}
+
+	if count > 0 {
+		return stacktrace.NewError(fmt.Sprintf("[%d] users still have a plaintext api key, deploy the release which hashes the keys on boot first", count))
+	}
+
+	if err := db.Exec(`ALTER TABLE users DROP CONSTRAINT IF EXISTS uni_users_api_key;`).Error; err != nil {
+		return stacktrace.Propagate(err, "cannot drop the unique constraint on users.api_key")
+	}
+
+	if err := db.Exec(`DROP INDEX CONCURRENTLY IF EXISTS idx_users_api_key;`).Error; err != nil {
+		return stacktrace.Propagate(err, "cannot drop the index on users.api_key")
+	}
+
+	log.Println("dropping the plaintext api_key column of users")
+	if err := db.Migrator().DropColumn(&entities.User{}, "api_key"); err != nil {
+		return stacktrace.Propagate(err, "cannot drop the plaintext api_key column of users")
+	}
+
+	return nil
 }
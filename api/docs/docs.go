@@ -2552,6 +2552,7 @@ const docTemplate = `{
                 "can_be_polled",
                 "contact",
                 "content",
+                "content_purged_at",
                 "created_at",
                 "delivered_at",
                 "encrypted",
@@ -2590,6 +2591,11 @@ const docTemplate = `{
                     "type": "string",
                     "example": "This is a sample text message"
                 },
+                "content_purged_at": {
+                    "description": "ContentPurgedAt is when the content of the message was removed by the data retention policy of the user",
+                    "type": "string",
+                    "example": "2022-06-05T14:26:09.527976+03:00"
+                },
                 "created_at": {
                     "type": "string",
                     "example": "2022-06-05T14:26:02.302718+03:00"
@@ -9,4 +9,25 @@ import (
 type Cache interface {
 	Set(ctx context.Context, key string, value string, ttl time.Duration) error
 	Get(ctx context.Context, key string) (value string, err error)
+
+	// SetNX sets an item only when the key does not exist and returns true when the item was set
+	SetNX(ctx context.Context, key string, value string, ttl time.Duration) (bool, error)
+
+	// Delete an item from the cache
+	Delete(ctx context.Context, key string) error
+
+	// TakeToken atomically refills the token bucket of the key up to the burst and takes a token from it.
+	// It returns the tokens left in the bucket and true when a token was taken.
+	TakeToken(ctx context.Context, key string, burst uint, refillPerSecond float64, timestamp time.Time) (float64, bool, error)
+}
+
+// tokenBucketTTL is how long a token bucket is kept after it is full again
+const tokenBucketTTL = time.Minute
+
+// tokenBucketExpiry returns how long a token bucket with the tokens must be stored until it is full
+func tokenBucketExpiry(tokens float64, burst uint, refillPerSecond float64) time.Duration {
+	if refillPerSecond <= 0 {
+		return tokenBucketTTL
+	}
+	return time.Duration((float64(burst)-tokens)/refillPerSecond*float64(time.Second)) + tokenBucketTTL
 }
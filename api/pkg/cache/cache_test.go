@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hirosassa/zerodriver"
+	ttlCache "github.com/patrickmn/go-cache"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func testTracer() telemetry.Tracer {
+	nop := zerolog.Nop()
+	return telemetry.NewOtelLogger("test", telemetry.NewZerologLogger("test", nil, &zerodriver.Logger{Logger: &nop}, nil))
+}
+
+// testCaches returns the Cache implementations which are tested
+func testCaches(t *testing.T) map[string]Cache {
+	server := miniredis.RunT(t)
+	return map[string]Cache{
+		"memory": NewMemoryCache(testTracer(), ttlCache.New(time.Minute, time.Minute)),
+		"redis":  NewRedisCache(testTracer(), redis.NewClient(&redis.Options{Addr: server.Addr()})),
+	}
+}
+
+func TestCache_SetNX(t *testing.T) {
+	for name, cache := range testCaches(t) {
+		t.Run(name+" only sets the first value of a key", func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+
+			// Act
+			first, err1 := cache.SetNX(ctx, "key", "first", time.Minute)
+			second, err2 := cache.SetNX(ctx, "key", "second", time.Minute)
+
+			// Assert
+			assert.Nil(t, err1)
+			assert.Nil(t, err2)
+			assert.True(t, first)
+			assert.False(t, second)
+
+			value, err := cache.Get(ctx, "key")
+			assert.Nil(t, err)
+			assert.Equal(t, "first", value)
+		})
+
+		t.Run(name+" can set a key again after it is deleted", func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			_, _ = cache.SetNX(ctx, "deleted", "first", time.Minute)
+
+			// Act
+			err := cache.Delete(ctx, "deleted")
+			ok, err2 := cache.SetNX(ctx, "deleted", "second", time.Minute)
+
+			// Assert
+			assert.Nil(t, err)
+			assert.Nil(t, err2)
+			assert.True(t, ok)
+		})
+	}
+}
+
+func TestCache_TakeToken(t *testing.T) {
+	for name, cache := range testCaches(t) {
+		t.Run(name+" takes tokens until the bucket is empty", func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			timestamp := time.Now().UTC()
+
+			// Act
+			var results []bool
+			for i := 0; i < 4; i++ {
+				_, taken, err := cache.TakeToken(ctx, "empty", 3, 1, timestamp)
+				assert.Nil(t, err)
+				results = append(results, taken)
+			}
+
+			// Assert
+			assert.Equal(t, []bool{true, true, true, false}, results)
+		})
+
+		t.Run(name+" refills the bucket with time", func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			timestamp := time.Now().UTC()
+			for i := 0; i < 2; i++ {
+				_, _, _ = cache.TakeToken(ctx, "refill", 2, 0.5, timestamp)
+			}
+
+			// Act
+			_, takenNow, err1 := cache.TakeToken(ctx, "refill", 2, 0.5, timestamp)
+			tokens, takenLater, err2 := cache.TakeToken(ctx, "refill", 2, 0.5, timestamp.Add(3*time.Second))
+
+			// Assert
+			assert.Nil(t, err1)
+			assert.Nil(t, err2)
+			assert.False(t, takenNow)
+			assert.True(t, takenLater)
+			assert.InDelta(t, 0.5, tokens, 0.001)
+		})
+	}
+}
@@ -3,6 +3,8 @@ package cache
 import (
 	"context"
 	"fmt"
+	"math"
+	"sync"
 	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
@@ -14,6 +16,13 @@ import (
 type memoryCache struct {
 	tracer telemetry.Tracer
 	store  *ttlCache.Cache
+	mutex  sync.Mutex
+}
+
+// memoryTokenBucket is the state of a token bucket in the memoryCache
+type memoryTokenBucket struct {
+	tokens    float64
+	updatedAt time.Time
 }
 
 // NewMemoryCache creates a new instance of memoryCache
@@ -45,3 +54,48 @@ func (cache *memoryCache) Set(ctx context.Context, key string, value string, ttl
 	cache.store.Set(key, value, ttl)
 	return nil
 }
+
+// SetNX sets an item in the memory cache if the key does not exist
+func (cache *memoryCache) SetNX(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	ctx, span := cache.tracer.Start(ctx)
+	defer span.End()
+
+	return cache.store.Add(key, value, ttl) == nil, nil
+}
+
+// Delete an item from the memory cache
+func (cache *memoryCache) Delete(ctx context.Context, key string) error {
+	ctx, span := cache.tracer.Start(ctx)
+	defer span.End()
+
+	cache.store.Delete(key)
+	return nil
+}
+
+// TakeToken takes a token from a token bucket in the memory cache
+func (cache *memoryCache) TakeToken(ctx context.Context, key string, burst uint, refillPerSecond float64, timestamp time.Time) (float64, bool, error) {
+	ctx, span := cache.tracer.Start(ctx)
+	defer span.End()
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	bucket := memoryTokenBucket{tokens: float64(burst), updatedAt: timestamp}
+	if value, ok := cache.store.Get(key); ok {
+		if stored, ok := value.(memoryTokenBucket); ok {
+			bucket = stored
+		}
+	}
+
+	elapsed := math.Max(0, timestamp.Sub(bucket.updatedAt).Seconds())
+	bucket.tokens = math.Min(float64(burst), bucket.tokens+elapsed*refillPerSecond)
+	bucket.updatedAt = timestamp
+
+	taken := bucket.tokens >= 1
+	if taken {
+		bucket.tokens--
+	}
+
+	cache.store.Set(key, bucket, tokenBucketExpiry(bucket.tokens, burst, refillPerSecond))
+	return bucket.tokens, taken, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
@@ -11,6 +12,40 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// takeTokenScript refills and takes a token from the token bucket stored in a hash in one atomic operation.
+// Numbers are returned as strings because redis truncates lua numbers to integers.
+var takeTokenScript = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local ttl = tonumber(ARGV[4])
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "updated_at")
+local tokens = tonumber(bucket[1])
+local updated = tonumber(bucket[2])
+if tokens == nil or updated == nil then
+	tokens = burst
+	updated = now
+end
+
+tokens = math.min(burst, tokens + math.max(0, now - updated) * rate)
+
+local taken = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	taken = 1
+end
+
+local expiry = ttl
+if rate > 0 then
+	expiry = math.ceil((burst - tokens) / rate * 1000) + ttl
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "updated_at", tostring(now))
+redis.call("PEXPIRE", KEYS[1], expiry)
+return {taken, tostring(tokens)}
+`)
+
 // redisCache is the Cache implementation in redis
 type redisCache struct {
 	tracer telemetry.Tracer
@@ -51,3 +86,51 @@ func (cache *redisCache) Set(ctx context.Context, key string, value string, ttl
 	}
 	return nil
 }
+
+// SetNX sets an item in the redis cache if the key does not exist
+func (cache *redisCache) SetNX(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	ctx, span := cache.tracer.Start(ctx)
+	defer span.End()
+
+	ok, err := cache.client.SetNX(ctx, key, value, ttl).Result()
+	if err != nil {
+		return false, cache.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot set item in redis with key [%s] if it does not exist", key)))
+	}
+	return ok, nil
+}
+
+// Delete an item from the redis cache
+func (cache *redisCache) Delete(ctx context.Context, key string) error {
+	ctx, span := cache.tracer.Start(ctx)
+	defer span.End()
+
+	if err := cache.client.Del(ctx, key).Err(); err != nil {
+		return cache.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot delete item in redis with key [%s]", key)))
+	}
+	return nil
+}
+
+// TakeToken takes a token from a token bucket in redis
+func (cache *redisCache) TakeToken(ctx context.Context, key string, burst uint, refillPerSecond float64, timestamp time.Time) (float64, bool, error) {
+	ctx, span := cache.tracer.Start(ctx)
+	defer span.End()
+
+	now := float64(timestamp.UnixMicro()) / float64(time.Second/time.Microsecond)
+	result, err := takeTokenScript.Run(ctx, cache.client, []string{key}, burst, refillPerSecond, now, tokenBucketTTL.Milliseconds()).Slice()
+	if err != nil {
+		return float64(burst), true, cache.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot take token from bucket in redis with key [%s]", key)))
+	}
+
+	if len(result) != 2 {
+		return float64(burst), true, cache.tracer.WrapErrorSpan(span, stacktrace.NewError(fmt.Sprintf("invalid token bucket response [%v] for key [%s]", result, key)))
+	}
+
+	taken, _ := result[0].(int64)
+	value, _ := result[1].(string)
+	tokens, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return float64(burst), true, cache.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot parse tokens [%s] of bucket with key [%s]", value, key)))
+	}
+
+	return tokens, taken == 1, nil
+}
@@ -65,6 +65,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/NdoleStudio/httpsms/pkg/handlers"
+	"github.com/NdoleStudio/httpsms/pkg/pubsub"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 	"github.com/NdoleStudio/httpsms/pkg/validators"
 	"gorm.io/driver/postgres"
@@ -79,9 +80,16 @@ type Container struct {
 	version         string
 	app             *fiber.App
 	eventDispatcher *services.EventDispatcher
+	pubSub          pubsub.PubSub
+	streamLimiter   *pubsub.StreamLimiter
+	redisClient     *redis.Client
+	cache           cache.Cache
 	logger          telemetry.Logger
 }
 
+// maxStreamsPerUser is the number of concurrent SSE, WebSocket and long-poll streams a user can open on an instance
+const maxStreamsPerUser = 20
+
 // NewLiteContainer creates a Container without any routes or listeners
 func NewLiteContainer() (container *Container) {
 	// Set location to UTC
@@ -123,6 +131,11 @@ func NewContainer(projectID string, version string) (container *Container) {
 	container.RegisterUserListeners()
 
 	container.RegisterPhoneRoutes()
+	container.RegisterMaintenanceWindowRoutes()
+	container.RegisterPhoneCommandRoutes()
+	container.RegisterPhoneCommandListeners()
+	container.RegisterPhoneConfigRoutes()
+	container.RegisterPhoneConfigListeners()
 
 	container.RegisterEventRoutes()
 
@@ -143,6 +156,22 @@ func NewContainer(projectID string, version string) (container *Container) {
 	container.RegisterDiscordRoutes()
 	container.RegisterDiscordListeners()
 
+	container.RegisterOrganizationRoutes()
+	container.RegisterAPIKeyRoutes()
+
+	container.RegisterMessageExportRoutes()
+	container.RegisterMessageExportListeners()
+
+	container.RegisterRetentionListeners()
+
+	container.RegisterEventStreamRoutes()
+	container.RegisterEventStreamListeners()
+
+	container.RegisterAlertChannelRoutes()
+	container.RegisterAlertListeners()
+
+	container.RegisterPhoneFailoverListeners()
+
 	// this has to be last since it registers the /* route
 	container.RegisterSwaggerRoutes()
 
@@ -164,20 +193,83 @@ func (container *Container) App() (app *fiber.App) {
 	}
 
 	app.Use(otelfiber.Middleware())
-	app.Use(cors.New())
+	app.Use(cors.New(cors.Config{
+		ExposeHeaders: "X-RateLimit-Limit,X-RateLimit-Remaining,X-RateLimit-Reset,Retry-After,Idempotent-Replayed",
+	}))
 	app.Use(middlewares.HTTPRequestLogger(container.Tracer(), container.Logger()))
 
 	app.Use(middlewares.BearerAuth(container.Logger(), container.Tracer(), container.FirebaseAuthClient()))
-	app.Use(middlewares.APIKeyAuth(container.Logger(), container.Tracer(), container.UserRepository()))
+	app.Use(middlewares.APIKeyAuth(container.Logger(), container.Tracer(), container.UserRepository(), container.APIKeyRepository()))
+	app.Use(middlewares.OrganizationMember(container.Logger(), container.Tracer(), container.OrganizationRepository()))
+	app.Use(container.RateLimitMiddleware())
+	app.Use(middlewares.Idempotency(container.Logger(), container.Tracer(), container.LocalOrRedisCache()))
 
 	container.app = app
 	return app
 }
 
+// backfillUserAPIKeys hashes the plaintext users.api_key column of users created before API keys were hashed and clears the plaintext key.
+// The empty api_key column is dropped in a later release by the drop-user-api-key migration in cmd/migration.
+func (container *Container) backfillUserAPIKeys(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&entities.User{}, "api_key") {
+		return nil
+	}
+
+	container.logger.Info("hashing the plaintext API keys of users")
+
+	// the plaintext key of a user who rotated the API key is no longer valid so it is cleared without hashing it
+	err := db.Table("users").
+		Where("api_key IS NOT NULL").
+		Where("api_key_hash IS NOT NULL").
+		Update("api_key", nil).
+		Error
+	if err != nil {
+		return stacktrace.Propagate(err, "cannot clear the plaintext api keys of users whose api key is hashed")
+	}
+
+	type userAPIKey struct {
+		ID     entities.UserID
+		APIKey string
+	}
+
+	var rows []userAPIKey
+	err = db.Table("users").
+		Select("id", "api_key").
+		Where("api_key IS NOT NULL").
+		FindInBatches(&rows, 100, func(tx *gorm.DB, _ int) error {
+			for _, row := range rows {
+				err := db.Table("users").
+					Where("id = ?", row.ID).
+					Where("api_key_hash IS NULL").
+					Updates(map[string]any{
+						"api_key":        nil,
+						"api_key_prefix": repositories.APIKeyPrefix(row.APIKey),
+						"api_key_hash":   repositories.HashAPIKey(row.APIKey),
+					}).Error
+				if err != nil {
+					return stacktrace.Propagate(err, fmt.Sprintf("cannot hash the api key of user [%s]", row.ID))
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return stacktrace.Propagate(err, "cannot hash the api keys of users")
+	}
+
+	return nil
+}
+
 // BearerAPIKeyMiddleware creates a new instance of middlewares.BearerAPIKeyAuth
 func (container *Container) BearerAPIKeyMiddleware() fiber.Handler {
 	container.logger.Debug("creating middlewares.BearerAPIKeyAuth")
-	return middlewares.BearerAPIKeyAuth(container.Logger(), container.Tracer(), container.UserRepository())
+	return middlewares.BearerAPIKeyAuth(container.Logger(), container.Tracer(), container.UserRepository(), container.APIKeyRepository())
+}
+
+// RateLimitMiddleware creates a new instance of middlewares.RateLimit.
+// It must be used after every auth middleware because requests which are not authenticated yet are not rate limited.
+func (container *Container) RateLimitMiddleware() fiber.Handler {
+	container.logger.Debug("creating middlewares.RateLimit")
+	return middlewares.RateLimit(container.Logger(), container.Tracer(), container.RateLimitService())
 }
 
 // AuthenticatedMiddleware creates a new instance of middlewares.Authenticated
@@ -272,9 +364,7 @@ func (container *Container) DB() (db *gorm.DB) {
 	container.logger.Debug(fmt.Sprintf("Running migrations for %T", db))
 
 	// This prevents a bug in the Gorm AutoMigrate where it tries to delete this no existent constraints
-	db.Exec(`
-ALTER TABLE users ADD CONSTRAINT IF NOT EXISTS uni_users_api_key CHECK (api_key IS NOT NULL);
-ALTER TABLE discords ADD CONSTRAINT IF NOT EXISTS uni_discords_server_id CHECK (server_id IS NOT NULL);`)
+	db.Exec(`ALTER TABLE discords ADD CONSTRAINT IF NOT EXISTS uni_discords_server_id CHECK (server_id IS NOT NULL);`)
 
 	if err = db.AutoMigrate(&entities.Message{}); err != nil {
 		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Message{})))
@@ -288,6 +378,10 @@ ALTER TABLE discords ADD CONSTRAINT IF NOT EXISTS uni_discords_server_id CHECK (
 		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.User{})))
 	}
 
+	if err = container.backfillUserAPIKeys(db); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, "cannot hash the plaintext API keys of users"))
+	}
+
 	if err = db.AutoMigrate(&entities.Phone{}); err != nil {
 		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Phone{})))
 	}
@@ -304,6 +398,10 @@ ALTER TABLE discords ADD CONSTRAINT IF NOT EXISTS uni_discords_server_id CHECK (
 		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Webhook{})))
 	}
 
+	if err = db.AutoMigrate(&entities.APIKey{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.APIKey{})))
+	}
+
 	if err = db.AutoMigrate(&entities.Discord{}); err != nil {
 		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Discord{})))
 	}
@@ -312,6 +410,50 @@ ALTER TABLE discords ADD CONSTRAINT IF NOT EXISTS uni_discords_server_id CHECK (
 		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Integration3CX{})))
 	}
 
+	if err = db.AutoMigrate(&entities.Organization{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Organization{})))
+	}
+
+	if err = db.AutoMigrate(&entities.OrganizationMember{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.OrganizationMember{})))
+	}
+
+	if err = db.AutoMigrate(&entities.OrganizationPhoneGrant{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.OrganizationPhoneGrant{})))
+	}
+
+	if err = db.AutoMigrate(&entities.OrganizationInvitation{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.OrganizationInvitation{})))
+	}
+
+	if err = db.AutoMigrate(&entities.MessageExport{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.MessageExport{})))
+	}
+
+	if err = db.AutoMigrate(&entities.MessageExportChunk{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.MessageExportChunk{})))
+	}
+
+	if err = db.AutoMigrate(&entities.PhoneAvailabilityPeriod{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.PhoneAvailabilityPeriod{})))
+	}
+
+	if err = db.AutoMigrate(&entities.MaintenanceWindow{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.MaintenanceWindow{})))
+	}
+
+	if err = db.AutoMigrate(&entities.AlertChannel{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.AlertChannel{})))
+	}
+
+	if err = db.AutoMigrate(&entities.PhoneCommand{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.PhoneCommand{})))
+	}
+
+	if err = db.AutoMigrate(&entities.PhoneConfig{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.PhoneConfig{})))
+	}
+
 	return container.db
 }
 
@@ -333,9 +475,60 @@ func (container *Container) InMemoryCache() cache.Cache {
 	return cache.NewMemoryCache(container.Tracer(), c)
 }
 
+// LocalOrRedisCache creates a single in memory cache.Cache locally and a redis cache.Cache in production
+func (container *Container) LocalOrRedisCache() cache.Cache {
+	if container.cache != nil {
+		return container.cache
+	}
+
+	if isLocal() {
+		container.cache = container.InMemoryCache()
+	} else {
+		container.cache = container.Cache()
+	}
+	return container.cache
+}
+
 // Cache creates a new instance of cache.Cache
 func (container *Container) Cache() cache.Cache {
 	container.logger.Debug("creating cache.Cache")
+	return cache.NewRedisCache(container.Tracer(), container.RedisClient())
+}
+
+// PubSub creates an in memory pubsub.PubSub locally and a redis pubsub.PubSub in production
+func (container *Container) PubSub() pubsub.PubSub {
+	if container.pubSub != nil {
+		return container.pubSub
+	}
+
+	container.logger.Debug("creating pubsub.PubSub")
+	if isLocal() {
+		container.pubSub = pubsub.NewMemoryPubSub(container.Logger(), container.Tracer())
+	} else {
+		container.pubSub = pubsub.NewRedisPubSub(container.Logger(), container.Tracer(), container.RedisClient())
+	}
+
+	return container.pubSub
+}
+
+// StreamLimiter creates a single instance of pubsub.StreamLimiter which is shared by the SSE, WebSocket and long-poll streams
+func (container *Container) StreamLimiter() *pubsub.StreamLimiter {
+	if container.streamLimiter != nil {
+		return container.streamLimiter
+	}
+
+	container.logger.Debug("creating pubsub.StreamLimiter")
+	container.streamLimiter = pubsub.NewStreamLimiter(maxStreamsPerUser)
+	return container.streamLimiter
+}
+
+// RedisClient creates a single instance of redis.Client which shares its connection pool with all the caches
+func (container *Container) RedisClient() (client *redis.Client) {
+	if container.redisClient != nil {
+		return container.redisClient
+	}
+
+	container.logger.Debug(fmt.Sprintf("creating %T", client))
 	opt, err := redis.ParseURL(os.Getenv("REDIS_URL"))
 	if err != nil {
 		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot parse redis url [%s]", os.Getenv("REDIS_URL"))))
@@ -356,7 +549,8 @@ func (container *Container) Cache() cache.Cache {
 		container.logger.Fatal(stacktrace.Propagate(err, "cannot instrument redis metrics"))
 	}
 
-	return cache.NewRedisCache(container.Tracer(), redisClient)
+	container.redisClient = redisClient
+	return container.redisClient
 }
 
 // FirebaseAuthClient creates a new instance of auth.Client
@@ -535,6 +729,42 @@ func (container *Container) DiscordHandlerValidator() (validator *validators.Dis
 	)
 }
 
+// OrganizationHandlerValidator creates a new instance of validators.OrganizationHandlerValidator
+func (container *Container) OrganizationHandlerValidator() (validator *validators.OrganizationHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewOrganizationHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// APIKeyHandlerValidator creates a new instance of validators.APIKeyHandlerValidator
+func (container *Container) APIKeyHandlerValidator() (validator *validators.APIKeyHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewAPIKeyHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// MessageExportHandlerValidator creates a new instance of validators.MessageExportHandlerValidator
+func (container *Container) MessageExportHandlerValidator() (validator *validators.MessageExportHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewMessageExportHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// EventStreamHandlerValidator creates a new instance of validators.EventStreamHandlerValidator
+func (container *Container) EventStreamHandlerValidator() (validator *validators.EventStreamHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewEventStreamHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
 // WebhookHandlerValidator creates a new instance of validators.WebhookHandlerValidator
 func (container *Container) WebhookHandlerValidator() (validator *validators.WebhookHandlerValidator) {
 	container.logger.Debug(fmt.Sprintf("creating %T", validator))
@@ -565,6 +795,33 @@ func (container *Container) MessageThreadHandlerValidator() (validator *validato
 	)
 }
 
+// MaintenanceWindowHandlerValidator creates a new instance of validators.MaintenanceWindowHandlerValidator
+func (container *Container) MaintenanceWindowHandlerValidator() (validator *validators.MaintenanceWindowHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewMaintenanceWindowHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// PhoneCommandHandlerValidator creates a new instance of validators.PhoneCommandHandlerValidator
+func (container *Container) PhoneCommandHandlerValidator() (validator *validators.PhoneCommandHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewPhoneCommandHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// PhoneConfigHandlerValidator creates a new instance of validators.PhoneConfigHandlerValidator
+func (container *Container) PhoneConfigHandlerValidator() (validator *validators.PhoneConfigHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewPhoneConfigHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
 // PhoneHandlerValidator creates a new instance of validators.PhoneHandlerValidator
 func (container *Container) PhoneHandlerValidator() (validator *validators.PhoneHandlerValidator) {
 	container.logger.Debug(fmt.Sprintf("creating %T", validator))
@@ -616,6 +873,20 @@ func (container *Container) Float64Histogram(name, unit, description string) ote
 	return histogram
 }
 
+// Int64Counter creates a new instance of metric.Int64Counter
+func (container *Container) Int64Counter(name, unit, description string) otelMetric.Int64Counter {
+	container.logger.Debug(fmt.Sprintf("creating metric.Int64Counter [%s]", name))
+	meter := otel.GetMeterProvider().Meter(
+		container.projectID,
+		otelMetric.WithInstrumentationVersion(otel.Version()),
+	)
+	counter, err := meter.Int64Counter(name, otelMetric.WithUnit(unit), otelMetric.WithDescription(description))
+	if err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, "cannot create int64 counter"))
+	}
+	return counter
+}
+
 // MessageRepository creates a new instance of repositories.MessageRepository
 func (container *Container) MessageRepository() (repository repositories.MessageRepository) {
 	container.logger.Debug("creating GORM repositories.MessageRepository")
@@ -666,6 +937,48 @@ func (container *Container) DiscordRepository() (repository repositories.Discord
 	)
 }
 
+// OrganizationRepository creates a new instance of repositories.OrganizationRepository
+func (container *Container) OrganizationRepository() (repository repositories.OrganizationRepository) {
+	container.logger.Debug("creating GORM repositories.OrganizationRepository")
+	return repositories.NewGormOrganizationRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// AccountRepository creates a new instance of repositories.AccountRepository
+func (container *Container) AccountRepository() (repository repositories.AccountRepository) {
+	container.logger.Debug("creating GORM repositories.AccountRepository")
+	return repositories.NewGormAccountRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.RistrettoCache(),
+		container.DB(),
+	)
+}
+
+// APIKeyRepository creates a new instance of repositories.APIKeyRepository
+func (container *Container) APIKeyRepository() (repository repositories.APIKeyRepository) {
+	container.logger.Debug("creating GORM repositories.APIKeyRepository")
+	return repositories.NewGormAPIKeyRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.RistrettoCache(),
+		container.DB(),
+	)
+}
+
+// MessageExportRepository creates a new instance of repositories.MessageExportRepository
+func (container *Container) MessageExportRepository() (repository repositories.MessageExportRepository) {
+	container.logger.Debug("creating GORM repositories.MessageExportRepository")
+	return repositories.NewGormMessageExportRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
 // WebhookRepository creates a new instance of repositories.WebhookRepository
 func (container *Container) WebhookRepository() (repository repositories.WebhookRepository) {
 	container.logger.Debug("creating GORM repositories.WebhookRepository")
@@ -716,6 +1029,46 @@ func (container *Container) HeartbeatMonitorRepository() (repository repositorie
 	)
 }
 
+// MaintenanceWindowRepository creates a new instance of repositories.MaintenanceWindowRepository
+func (container *Container) MaintenanceWindowRepository() (repository repositories.MaintenanceWindowRepository) {
+	container.logger.Debug("creating GORM repositories.MaintenanceWindowRepository")
+	return repositories.NewGormMaintenanceWindowRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// PhoneCommandRepository creates a new instance of repositories.PhoneCommandRepository
+func (container *Container) PhoneCommandRepository() (repository repositories.PhoneCommandRepository) {
+	container.logger.Debug("creating GORM repositories.PhoneCommandRepository")
+	return repositories.NewGormPhoneCommandRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// PhoneConfigRepository creates a new instance of repositories.PhoneConfigRepository
+func (container *Container) PhoneConfigRepository() (repository repositories.PhoneConfigRepository) {
+	container.logger.Debug("creating GORM repositories.PhoneConfigRepository")
+	return repositories.NewGormPhoneConfigRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// PhoneAvailabilityRepository creates a new instance of repositories.PhoneAvailabilityRepository
+func (container *Container) PhoneAvailabilityRepository() (repository repositories.PhoneAvailabilityRepository) {
+	container.logger.Debug("creating GORM repositories.PhoneAvailabilityRepository")
+	return repositories.NewGormPhoneAvailabilityRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
 // EventListenerLogRepository creates a new instance of repositories.EventListenerLogRepository
 func (container *Container) EventListenerLogRepository() (repository repositories.EventListenerLogRepository) {
 	container.logger.Debug("creating GORM repositories.EventListenerLogRepository")
@@ -735,6 +1088,70 @@ func (container *Container) HeartbeatService() (service *services.HeartbeatServi
 		container.HeartbeatRepository(),
 		container.HeartbeatMonitorRepository(),
 		container.EventDispatcher(),
+		container.PhoneAvailabilityService(),
+		container.MaintenanceWindowService(),
+	)
+}
+
+// PhoneFailoverService creates a new instance of services.PhoneFailoverService
+func (container *Container) PhoneFailoverService() (service *services.PhoneFailoverService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewPhoneFailoverService(
+		container.Logger(),
+		container.Tracer(),
+		container.PhoneRepository(),
+		container.MessageRepository(),
+		container.HeartbeatMonitorRepository(),
+		container.EventDispatcher(),
+	)
+}
+
+// PhoneCommandService creates a new instance of services.PhoneCommandService
+func (container *Container) PhoneCommandService() (service *services.PhoneCommandService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewPhoneCommandService(
+		container.Logger(),
+		container.Tracer(),
+		container.PhoneCommandRepository(),
+		container.PhoneRepository(),
+		container.PhonePusher(),
+		container.EventDispatcher(),
+	)
+}
+
+// PhoneConfigService creates a new instance of services.PhoneConfigService
+func (container *Container) PhoneConfigService() (service *services.PhoneConfigService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewPhoneConfigService(
+		container.Logger(),
+		container.Tracer(),
+		container.PhoneConfigRepository(),
+		container.PhoneRepository(),
+		container.HeartbeatMonitorRepository(),
+		container.PhoneCommandService(),
+		container.EventDispatcher(),
+	)
+}
+
+// MaintenanceWindowService creates a new instance of services.MaintenanceWindowService
+func (container *Container) MaintenanceWindowService() (service *services.MaintenanceWindowService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewMaintenanceWindowService(
+		container.Logger(),
+		container.Tracer(),
+		container.MaintenanceWindowRepository(),
+	)
+}
+
+// PhoneAvailabilityService creates a new instance of services.PhoneAvailabilityService
+func (container *Container) PhoneAvailabilityService() (service *services.PhoneAvailabilityService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewPhoneAvailabilityService(
+		container.Logger(),
+		container.Tracer(),
+		container.PhoneAvailabilityRepository(),
+		container.PhoneRepository(),
+		container.UserRepository(),
 	)
 }
 
@@ -764,6 +1181,101 @@ func (container *Container) DiscordService() (service *services.DiscordService)
 	)
 }
 
+// OrganizationService creates a new instance of services.OrganizationService
+func (container *Container) OrganizationService() (service *services.OrganizationService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewOrganizationService(
+		container.Logger(),
+		container.Tracer(),
+		container.OrganizationRepository(),
+		container.PhoneRepository(),
+		container.Mailer(),
+		container.UserEmailFactory(),
+	)
+}
+
+// APIKeyService creates a new instance of services.APIKeyService
+func (container *Container) APIKeyService() (service *services.APIKeyService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewAPIKeyService(
+		container.Logger(),
+		container.Tracer(),
+		container.APIKeyRepository(),
+	)
+}
+
+// MessageExportService creates a new instance of services.MessageExportService
+func (container *Container) MessageExportService() (service *services.MessageExportService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewMessageExportService(
+		container.Logger(),
+		container.Tracer(),
+		container.MessageExportRepository(),
+		container.MessageRepository(),
+		container.Mailer(),
+		container.UserEmailFactory(),
+		container.EventDispatcher(),
+	)
+}
+
+// AccountService creates a new instance of services.AccountService
+func (container *Container) AccountService() (service *services.AccountService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewAccountService(
+		container.Logger(),
+		container.Tracer(),
+		container.UserService(),
+		container.MarketingService(),
+		container.HeartbeatService(),
+		container.EventDispatcher(),
+		container.FirebaseAuthClient(),
+		container.AccountRepository(),
+		container.UserRepository(),
+		container.PhoneRepository(),
+		container.MessageRepository(),
+		container.MessageThreadRepository(),
+		container.HeartbeatRepository(),
+		container.HeartbeatMonitorRepository(),
+		container.WebhookRepository(),
+		container.DiscordRepository(),
+		container.Integration3CXRepository(),
+		container.BillingUsageRepository(),
+	)
+}
+
+// RetentionService creates a new instance of services.RetentionService
+func (container *Container) RetentionService() (service *services.RetentionService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+
+	eventListenerLogRetention, err := services.ParseEventListenerLogRetention(os.Getenv("EVENT_LISTENER_LOG_RETENTION_DAYS"))
+	if err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, "cannot parse the EVENT_LISTENER_LOG_RETENTION_DAYS environment variable"))
+	}
+
+	return services.NewRetentionService(
+		container.Logger(),
+		container.Tracer(),
+		container.UserRepository(),
+		container.MessageRepository(),
+		container.HeartbeatRepository(),
+		container.PhoneNotificationRepository(),
+		container.EventListenerLogRepository(),
+		eventListenerLogRetention,
+		container.EventDispatcher(),
+	)
+}
+
+// EventStreamService creates a new instance of services.EventStreamService
+func (container *Container) EventStreamService() (service *services.EventStreamService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewEventStreamService(
+		container.Logger(),
+		container.Tracer(),
+		container.PubSub(),
+		container.StreamLimiter(),
+	)
+}
+
 // WebhookService creates a new instance of services.WebhookService
 func (container *Container) WebhookService() (service *services.WebhookService) {
 	container.logger.Debug(fmt.Sprintf("creating %T", service))
@@ -796,6 +1308,24 @@ func (container *Container) HTTPClient(name string) *http.Client {
 	}
 }
 
+// PublicHTTPClient creates an http.Client for URLs which are supplied by users.
+// It does not follow redirects or connect to private, loopback and link-local IP addresses.
+func (container *Container) PublicHTTPClient(name string) *http.Client {
+	container.logger.Debug(fmt.Sprintf("creating public %s %T", name, http.DefaultClient))
+	return &http.Client{
+		Timeout: 60 * time.Second,
+		Transport: otelroundtripper.New(
+			otelroundtripper.WithName(name),
+			otelroundtripper.WithParent(services.NewPublicHTTPTransport()),
+			otelroundtripper.WithMeter(otel.GetMeterProvider().Meter(container.projectID)),
+			otelroundtripper.WithAttributes(container.OtelResources(container.version, container.projectID).Attributes()...),
+		),
+		CheckRedirect: func(_ *http.Request, _ []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
 // HTTPRoundTripper creates an open telemetry http.RoundTripper
 func (container *Container) HTTPRoundTripper(name string) http.RoundTripper {
 	container.logger.Debug(fmt.Sprintf("Debug: initializing %s %T", name, http.DefaultTransport))
@@ -834,6 +1364,8 @@ func (container *Container) PhoneService() (service *services.PhoneService) {
 		container.Tracer(),
 		container.PhoneRepository(),
 		container.EventDispatcher(),
+		container.PhoneNotificationRepository(),
+		container.UserRepository(),
 	)
 }
 
@@ -911,6 +1443,25 @@ func (container *Container) MessageThreadService() (service *services.MessageThr
 	)
 }
 
+// RateLimitService creates a new instance of services.RateLimitService
+func (container *Container) RateLimitService() (service *services.RateLimitService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+
+	limits, err := services.ParseRateLimits(os.Getenv("RATE_LIMITS"))
+	if err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, "cannot parse the RATE_LIMITS environment variable"))
+	}
+
+	return services.NewRateLimitService(
+		container.Logger(),
+		container.Tracer(),
+		container.LocalOrRedisCache(),
+		container.Int64Counter("http.rate_limit.requests", "{request}", "counts the requests checked by the rate limiter"),
+		limits,
+		container.UserRepository(),
+	)
+}
+
 // EmailNotificationService creates a new instance of services.EmailNotificationService
 func (container *Container) EmailNotificationService() (service *services.EmailNotificationService) {
 	container.logger.Debug(fmt.Sprintf("creating %T", service))
@@ -956,6 +1507,88 @@ func (container *Container) UserHandler() (handler *handlers.UserHandler) {
 		container.Tracer(),
 		container.UserHandlerValidator(),
 		container.UserService(),
+		container.RetentionService(),
+		container.AccountService(),
+	)
+}
+
+// OrganizationHandler creates a new instance of handlers.OrganizationHandler
+func (container *Container) OrganizationHandler() (handler *handlers.OrganizationHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", handler))
+	return handlers.NewOrganizationHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.OrganizationHandlerValidator(),
+		container.OrganizationService(),
+	)
+}
+
+// APIKeyHandler creates a new instance of handlers.APIKeyHandler
+func (container *Container) APIKeyHandler() (handler *handlers.APIKeyHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", handler))
+	return handlers.NewAPIKeyHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.APIKeyHandlerValidator(),
+		container.APIKeyService(),
+	)
+}
+
+// MessageExportHandler creates a new instance of handlers.MessageExportHandler
+func (container *Container) MessageExportHandler() (handler *handlers.MessageExportHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", handler))
+	return handlers.NewMessageExportHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.MessageExportHandlerValidator(),
+		container.MessageExportService(),
+	)
+}
+
+// EventStreamHandler creates a new instance of handlers.EventStreamHandler
+func (container *Container) EventStreamHandler() (handler *handlers.EventStreamHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", handler))
+	return handlers.NewEventStreamHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.EventStreamHandlerValidator(),
+		container.EventStreamService(),
+	)
+}
+
+// MaintenanceWindowHandler creates a new instance of handlers.MaintenanceWindowHandler
+func (container *Container) MaintenanceWindowHandler() (handler *handlers.MaintenanceWindowHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", handler))
+	return handlers.NewMaintenanceWindowHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.MaintenanceWindowHandlerValidator(),
+		container.MaintenanceWindowService(),
+		container.PhoneService(),
+	)
+}
+
+// PhoneCommandHandler creates a new instance of handlers.PhoneCommandHandler
+func (container *Container) PhoneCommandHandler() (handler *handlers.PhoneCommandHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", handler))
+	return handlers.NewPhoneCommandHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.PhoneCommandHandlerValidator(),
+		container.PhoneCommandService(),
+		container.PhoneService(),
+	)
+}
+
+// PhoneConfigHandler creates a new instance of handlers.PhoneConfigHandler
+func (container *Container) PhoneConfigHandler() (handler *handlers.PhoneConfigHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", handler))
+	return handlers.NewPhoneConfigHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.PhoneConfigHandlerValidator(),
+		container.PhoneConfigService(),
+		container.PhoneService(),
 	)
 }
 
@@ -967,6 +1600,7 @@ func (container *Container) PhoneHandler() (handler *handlers.PhoneHandler) {
 		container.Tracer(),
 		container.PhoneService(),
 		container.PhoneHandlerValidator(),
+		container.PhoneAvailabilityService(),
 	)
 }
 
@@ -1085,7 +1719,7 @@ func (container *Container) RegisterLemonsqueezyRoutes() {
 // RegisterIntegration3CXRoutes registers routes for the /integration/3cx prefix
 func (container *Container) RegisterIntegration3CXRoutes() {
 	container.logger.Debug(fmt.Sprintf("registering [%T] routes", &handlers.Integration3CXHandler{}))
-	container.Integration3CXHandler().RegisterRoutes(container.App(), container.BearerAPIKeyMiddleware(), container.AuthenticatedMiddleware())
+	container.Integration3CXHandler().RegisterRoutes(container.App(), container.BearerAPIKeyMiddleware(), container.RateLimitMiddleware(), container.AuthenticatedMiddleware())
 }
 
 // RegisterDiscordRoutes registers routes for the /discord prefix
@@ -1164,6 +1798,98 @@ func (container *Container) RegisterUserListeners() {
 	}
 }
 
+// RegisterAlertListeners registers event listeners for listeners.AlertListener
+func (container *Container) RegisterAlertListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.AlertListener{}))
+	_, routes := listeners.NewAlertListener(
+		container.Logger(),
+		container.Tracer(),
+		container.AlertService(),
+	)
+
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
+	}
+}
+
+// RegisterPhoneFailoverListeners registers event listeners for listeners.PhoneFailoverListener
+func (container *Container) RegisterPhoneFailoverListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.PhoneFailoverListener{}))
+	_, routes := listeners.NewPhoneFailoverListener(
+		container.Logger(),
+		container.Tracer(),
+		container.PhoneFailoverService(),
+	)
+
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
+	}
+}
+
+// RegisterAlertChannelRoutes registers routes for the /alert-channels prefix
+func (container *Container) RegisterAlertChannelRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.AlertChannelHandler{}))
+	handler := container.AlertChannelHandler()
+	handler.RegisterRoutes(container.AuthRouter())
+	handler.RegisterPublicRoutes(container.App())
+}
+
+// AlertChannelHandler creates a new instance of handlers.AlertChannelHandler
+func (container *Container) AlertChannelHandler() (handler *handlers.AlertChannelHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", handler))
+	return handlers.NewAlertChannelHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.AlertChannelHandlerValidator(),
+		container.AlertService(),
+		container.BillingService(),
+	)
+}
+
+// AlertChannelHandlerValidator creates a new instance of validators.AlertChannelHandlerValidator
+func (container *Container) AlertChannelHandlerValidator() (validator *validators.AlertChannelHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewAlertChannelHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+		container.DiscordClient(),
+		container.DiscordService(),
+	)
+}
+
+// AlertService creates a new instance of services.AlertService
+func (container *Container) AlertService() (service *services.AlertService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewAlertService(
+		container.Logger(),
+		container.Tracer(),
+		container.AlertChannelRepository(),
+		container.UserRepository(),
+		container.PhoneRepository(),
+		container.HeartbeatRepository(),
+		container.HeartbeatMonitorRepository(),
+		container.MessageService(),
+		container.BillingService(),
+		container.EventDispatcher(),
+		container.UserEmailFactory(),
+		container.NotificationEmailFactory(),
+		container.Mailer(),
+		container.DiscordClient(),
+		container.HTTPClient("slack"),
+		container.Cache(),
+	)
+}
+
+// AlertChannelRepository creates a new instance of repositories.AlertChannelRepository
+func (container *Container) AlertChannelRepository() (repository repositories.AlertChannelRepository) {
+	container.logger.Debug("creating GORM repositories.AlertChannelRepository")
+	return repositories.NewGormAlertChannelRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
 // RegisterBillingListeners registers event listeners for listeners.BillingListener
 func (container *Container) RegisterBillingListeners() {
 	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.BillingListener{}))
@@ -1229,6 +1955,9 @@ func (container *Container) MessageService() (service *services.MessageService)
 		container.MessageRepository(),
 		container.EventDispatcher(),
 		container.PhoneService(),
+		container.PubSub(),
+		container.StreamLimiter(),
+		container.MaintenanceWindowService(),
 	)
 }
 
@@ -1238,11 +1967,41 @@ func (container *Container) NotificationService() (service *services.PhoneNotifi
 	return services.NewNotificationService(
 		container.Logger(),
 		container.Tracer(),
-		container.FirebaseMessagingClient(),
+		container.PhonePusher(),
 		container.PhoneRepository(),
 		container.PhoneNotificationRepository(),
 		container.EventDispatcher(),
+		container.PubSub(),
+		container.MessageRepository(),
+		container.UserRepository(),
+	)
+}
+
+// PhonePusher creates a services.PhonePusher which sends push notifications with the delivery mode of the phone
+func (container *Container) PhonePusher() (pusher services.PhonePusher) {
+	container.logger.Debug(fmt.Sprintf("creating %T", &pusher))
+
+	pushers := map[entities.PhoneDeliveryMode]services.PhonePusher{
+		entities.PhoneDeliveryModeUnifiedPush: services.NewUnifiedPushPhonePusher(
+			container.Logger(),
+			container.Tracer(),
+			container.PublicHTTPClient("unified_push"),
+		),
+	}
+
+	// self-hosted instances can run without Firebase when the phones use UnifiedPush or long-polling
+	if len(container.FirebaseCredentials()) == 0 {
+		container.logger.Info("FIREBASE_CREDENTIALS is not set, phones cannot be notified with FCM")
+		return services.NewDeliveryModePhonePusher(pushers)
+	}
+
+	pushers[entities.PhoneDeliveryModeFCM] = services.NewFCMPhonePusher(
+		container.Logger(),
+		container.Tracer(),
+		container.FirebaseMessagingClient(),
 	)
+
+	return services.NewDeliveryModePhonePusher(pushers)
 }
 
 // RegisterMessageRoutes registers routes for the /messages prefix
@@ -1287,6 +2046,120 @@ func (container *Container) RegisterPhoneRoutes() {
 	container.PhoneHandler().RegisterRoutes(container.AuthRouter())
 }
 
+// RegisterMaintenanceWindowRoutes registers routes for the /phones/:phoneID/maintenance-windows prefix
+func (container *Container) RegisterMaintenanceWindowRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.MaintenanceWindowHandler{}))
+	container.MaintenanceWindowHandler().RegisterRoutes(container.AuthRouter())
+}
+
+// RegisterPhoneCommandRoutes registers routes for the /phones/:phoneID/commands prefix
+func (container *Container) RegisterPhoneCommandRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.PhoneCommandHandler{}))
+	container.PhoneCommandHandler().RegisterRoutes(container.AuthRouter())
+}
+
+// RegisterPhoneConfigRoutes registers routes for the /phones/:phoneID/config prefix
+func (container *Container) RegisterPhoneConfigRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.PhoneConfigHandler{}))
+	container.PhoneConfigHandler().RegisterRoutes(container.AuthRouter())
+}
+
+// RegisterPhoneConfigListeners registers event listeners for listeners.PhoneConfigListener
+func (container *Container) RegisterPhoneConfigListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.PhoneConfigListener{}))
+	_, routes := listeners.NewPhoneConfigListener(
+		container.Logger(),
+		container.Tracer(),
+		container.PhoneConfigService(),
+	)
+
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
+	}
+}
+
+// RegisterPhoneCommandListeners registers event listeners for listeners.PhoneCommandListener
+func (container *Container) RegisterPhoneCommandListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.PhoneCommandListener{}))
+	_, routes := listeners.NewPhoneCommandListener(
+		container.Logger(),
+		container.Tracer(),
+		container.PhoneCommandService(),
+	)
+
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
+	}
+}
+
+// RegisterOrganizationRoutes registers routes for the /organizations prefix
+func (container *Container) RegisterOrganizationRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.OrganizationHandler{}))
+	container.OrganizationHandler().RegisterRoutes(container.AuthRouter())
+}
+
+// RegisterAPIKeyRoutes registers routes for the /api-keys prefix
+func (container *Container) RegisterAPIKeyRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.APIKeyHandler{}))
+	container.APIKeyHandler().RegisterRoutes(container.AuthRouter())
+}
+
+// RegisterMessageExportRoutes registers routes for the /message-exports prefix
+func (container *Container) RegisterMessageExportRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.MessageExportHandler{}))
+	handler := container.MessageExportHandler()
+	handler.RegisterRoutes(container.AuthRouter())
+	handler.RegisterPublicRoutes(container.App())
+}
+
+// RegisterMessageExportListeners registers event listeners for listeners.MessageExportListener
+func (container *Container) RegisterMessageExportListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.MessageExportListener{}))
+	_, routes := listeners.NewMessageExportListener(
+		container.Logger(),
+		container.Tracer(),
+		container.MessageExportService(),
+	)
+
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
+	}
+}
+
+// RegisterRetentionListeners registers event listeners for listeners.RetentionListener
+func (container *Container) RegisterRetentionListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.RetentionListener{}))
+	_, routes := listeners.NewRetentionListener(
+		container.Logger(),
+		container.Tracer(),
+		container.RetentionService(),
+	)
+
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
+	}
+}
+
+// RegisterEventStreamRoutes registers routes for the /events/stream and /events/ws prefix
+func (container *Container) RegisterEventStreamRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.EventStreamHandler{}))
+	container.EventStreamHandler().RegisterRoutes(container.AuthRouter())
+}
+
+// RegisterEventStreamListeners registers event listeners for listeners.EventStreamListener
+func (container *Container) RegisterEventStreamListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.EventStreamListener{}))
+	_, routes := listeners.NewEventStreamListener(
+		container.Logger(),
+		container.Tracer(),
+		container.EventStreamService(),
+	)
+
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
+	}
+}
+
 // RegisterUserRoutes registers routes for the /users prefix
 func (container *Container) RegisterUserRoutes() {
 	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.UserHandler{}))
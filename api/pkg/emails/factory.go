@@ -3,6 +3,7 @@ package emails
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/nyaruka/phonenumbers"
 )
@@ -14,6 +15,13 @@ func (factory *factory) formatPhoneNumber(number string) string {
 	return phonenumbers.Format(value, phonenumbers.INTERNATIONAL)
 }
 
+func (factory *factory) article(word string) string {
+	if strings.ContainsAny(strings.ToLower(word[:1]), "aeiou") {
+		return "an"
+	}
+	return "a"
+}
+
 func (factory *factory) formatBool(value bool) string {
 	if value == true {
 		return "Yes"
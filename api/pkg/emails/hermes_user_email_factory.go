@@ -2,6 +2,7 @@ package emails
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/entities"
@@ -63,6 +64,141 @@ func (factory *hermesUserEmailFactory) APIKeyRotated(emailAddress string, timest
 	}, nil
 }
 
+// OrganizationInvitation is the email sent when a user is invited to join an organization
+func (factory *hermesUserEmailFactory) OrganizationInvitation(invitation *entities.OrganizationInvitation, organization *entities.Organization, inviterEmail string, token string) (*Email, error) {
+	email := hermes.Email{
+		Body: hermes.Body{
+			Intros: []string{
+				fmt.Sprintf("%s has invited you to join the %s organization on httpSMS as %s %s.", inviterEmail, organization.Name, factory.article(invitation.Role.String()), invitation.Role),
+				fmt.Sprintf("This invitation expires on %s.", invitation.ExpiresAt.Format(time.RFC1123)),
+			},
+			Actions: []hermes.Action{
+				{
+					Instructions: "Click the button below to accept the invitation",
+					Button: hermes.Button{
+						Color:     "#329ef4",
+						TextColor: "#FFFFFF",
+						Text:      "ACCEPT INVITATION",
+						Link:      fmt.Sprintf("https://httpsms.com/organizations/invitations/%s", token),
+					},
+				},
+			},
+			Title:     "Hey,",
+			Signature: "Cheers",
+			Outros: []string{
+				"If you were not expecting this invitation, you can ignore this email.",
+			},
+		},
+	}
+
+	html, err := factory.generator.GenerateHTML(email)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot generate html email")
+	}
+
+	text, err := factory.generator.GeneratePlainText(email)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot generate text email")
+	}
+
+	return &Email{
+		ToEmail: invitation.Email,
+		Subject: fmt.Sprintf("You have been invited to join %s on httpSMS", organization.Name),
+		HTML:    html,
+		Text:    text,
+	}, nil
+}
+
+// MessageExportReady is the email sent with the download link of an export of messages
+func (factory *hermesUserEmailFactory) MessageExportReady(export *entities.MessageExport, token string) (*Email, error) {
+	email := hermes.Email{
+		Body: hermes.Body{
+			Intros: []string{
+				fmt.Sprintf("The %s export of your httpSMS messages is ready to download.", strings.ToUpper(export.Format.String())),
+				fmt.Sprintf("This download link expires on %s.", export.ExpiresAt.Format(time.RFC1123)),
+			},
+			Actions: []hermes.Action{
+				{
+					Instructions: "Click the button below to download your messages",
+					Button: hermes.Button{
+						Color:     "#329ef4",
+						TextColor: "#FFFFFF",
+						Text:      "DOWNLOAD MESSAGES",
+						Link:      fmt.Sprintf("https://api.httpsms.com/message-exports/%s/download?token=%s", export.ID, token),
+					},
+				},
+			},
+			Title:     "Hey,",
+			Signature: "Cheers",
+			Outros: []string{
+				"Anyone with this link can download your messages so do not forward this email.",
+			},
+		},
+	}
+
+	html, err := factory.generator.GenerateHTML(email)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot generate html email")
+	}
+
+	text, err := factory.generator.GeneratePlainText(email)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot generate text email")
+	}
+
+	return &Email{
+		ToEmail: export.Email,
+		Subject: "Your httpSMS messages export is ready",
+		HTML:    html,
+		Text:    text,
+	}, nil
+}
+
+// AlertChannelVerification is the email sent with the link which verifies the email address of an alert channel
+func (factory *hermesUserEmailFactory) AlertChannelVerification(channel *entities.AlertChannel, token string) (*Email, error) {
+	email := hermes.Email{
+		Body: hermes.Body{
+			Intros: []string{
+				fmt.Sprintf("This email address was added as the \"%s\" alert channel of an httpSMS account.", channel.Name),
+				"Alerts about phones going offline and messages failing to send will be delivered here after you verify this email address.",
+			},
+			Actions: []hermes.Action{
+				{
+					Instructions: "Click the button below to verify this email address",
+					Button: hermes.Button{
+						Color:     "#329ef4",
+						TextColor: "#FFFFFF",
+						Text:      "VERIFY EMAIL ADDRESS",
+						Link:      fmt.Sprintf("https://api.httpsms.com/alert-channels/%s/verify?token=%s", channel.ID, token),
+					},
+				},
+			},
+			Title:     "Hey,",
+			Signature: "Cheers",
+			Outros: []string{
+				"You can ignore this email if you did not expect it, no alerts will be sent to this email address.",
+			},
+		},
+	}
+
+	html, err := factory.generator.GenerateHTML(email)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot generate html email")
+	}
+
+	text, err := factory.generator.GeneratePlainText(email)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot generate text email")
+	}
+
+	return &Email{
+		ToEmail: channel.Target,
+		Subject: "Verify the email address of your httpSMS alert channel",
+		HTML:    html,
+		Text:    text,
+	}, nil
+}
+
 // UsageLimitExceeded is the email sent when the plan limit is reached
 func (factory *hermesUserEmailFactory) UsageLimitExceeded(user *entities.User) (*Email, error) {
 	email := hermes.Email{
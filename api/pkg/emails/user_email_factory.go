@@ -19,4 +19,13 @@ type UserEmailFactory interface {
 
 	// APIKeyRotated sends an email when the API key is rotated
 	APIKeyRotated(email string, timestamp time.Time, timezone string) (*Email, error)
+
+	// OrganizationInvitation sends an email when a user is invited to join an organization
+	OrganizationInvitation(invitation *entities.OrganizationInvitation, organization *entities.Organization, inviterEmail string, token string) (*Email, error)
+
+	// MessageExportReady sends an email with the download link of an export of messages
+	MessageExportReady(export *entities.MessageExport, token string) (*Email, error)
+
+	// AlertChannelVerification sends an email with the link which verifies the email address of an alert channel
+	AlertChannelVerification(channel *entities.AlertChannel, token string) (*Email, error)
 }
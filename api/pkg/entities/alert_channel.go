@@ -0,0 +1,83 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// AlertChannelType is the medium used to deliver an alert
+type AlertChannelType string
+
+const (
+	// AlertChannelTypeEmail delivers alerts to an email address
+	AlertChannelTypeEmail = AlertChannelType("email")
+
+	// AlertChannelTypeDiscord delivers alerts to a discord channel
+	AlertChannelTypeDiscord = AlertChannelType("discord")
+
+	// AlertChannelTypeSlack delivers alerts to a slack incoming webhook
+	AlertChannelTypeSlack = AlertChannelType("slack")
+
+	// AlertChannelTypeSMS delivers alerts as an SMS to a backup number from another healthy phone
+	AlertChannelTypeSMS = AlertChannelType("sms")
+)
+
+// String converts the AlertChannelType to a string
+func (channelType AlertChannelType) String() string {
+	return string(channelType)
+}
+
+// AlertChannel routes phone alerts to a destination in addition to the account email
+type AlertChannel struct {
+	ID     uuid.UUID        `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID UserID           `json:"user_id" gorm:"index:idx_alert_channels__user_id" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Name   string           `json:"name" example:"On-call SMS"`
+	Type   AlertChannelType `json:"type" example:"sms"`
+
+	// Target is the email address, discord channel ID, slack webhook URL or backup phone number of the channel
+	Target string `json:"target" example:"+18005550100"`
+
+	// AlertTypes are the event types which are routed to this channel
+	AlertTypes pq.StringArray `json:"alert_types" example:"[phone.heartbeat.offline]" gorm:"type:text[]" swaggertype:"array,string"`
+
+	// PhoneNumbers restricts the channel to alerts about these phones, the channel receives alerts about all phones when it is empty
+	PhoneNumbers pq.StringArray `json:"phone_numbers" example:"[+18005550199]" gorm:"type:text[]" swaggertype:"array,string"`
+
+	// EscalationDelaySeconds delays a phone offline alert and drops it if the phone recovers within the delay
+	EscalationDelaySeconds uint `json:"escalation_delay_seconds" example:"900"`
+
+	// VerificationTokenHash is the hash of the token in the link which is emailed to verify an email target
+	VerificationTokenHash string `json:"-"`
+
+	// VerifiedAt is the time when the target was verified, alerts are only delivered to an email target after it is verified
+	VerifiedAt *time.Time `json:"verified_at" example:"2022-06-05T14:26:10.303278+03:00"`
+
+	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// EscalationDelay returns the escalation delay as time.Duration
+func (channel *AlertChannel) EscalationDelay() time.Duration {
+	return time.Duration(channel.EscalationDelaySeconds) * time.Second
+}
+
+// IsVerified checks if alerts can be delivered to the target of the channel
+func (channel *AlertChannel) IsVerified() bool {
+	return channel.Type != AlertChannelTypeEmail || channel.VerifiedAt != nil
+}
+
+// Routes checks if an alert of a phone number is routed to the channel
+func (channel *AlertChannel) Routes(alertType string, owner string) bool {
+	return channel.contains(channel.AlertTypes, alertType) && (len(channel.PhoneNumbers) == 0 || channel.contains(channel.PhoneNumbers, owner))
+}
+
+func (channel *AlertChannel) contains(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// APIKeyScope is a permission granted to an APIKey
+type APIKeyScope string
+
+const (
+	// APIKeyScopeMessagesSend allows sending, updating and deleting messages
+	APIKeyScopeMessagesSend = APIKeyScope("messages:send")
+
+	// APIKeyScopeMessagesRead allows reading messages and message threads
+	APIKeyScopeMessagesRead = APIKeyScope("messages:read")
+
+	// APIKeyScopePhonesWrite allows updating phones and reporting message events from the android app
+	APIKeyScopePhonesWrite = APIKeyScope("phones:write")
+
+	// APIKeyScopeWebhooksManage allows managing webhooks and integrations
+	APIKeyScopeWebhooksManage = APIKeyScope("webhooks:manage")
+
+	// APIKeyScopeHeartbeatsWrite allows storing heartbeats from the android app
+	APIKeyScopeHeartbeatsWrite = APIKeyScope("heartbeats:write")
+)
+
+// String converts the APIKeyScope to a string
+func (scope APIKeyScope) String() string {
+	return string(scope)
+}
+
+// APIKeyScopes returns all the valid APIKeyScope values
+func APIKeyScopes() []APIKeyScope {
+	return []APIKeyScope{
+		APIKeyScopeMessagesSend,
+		APIKeyScopeMessagesRead,
+		APIKeyScopePhonesWrite,
+		APIKeyScopeWebhooksManage,
+		APIKeyScopeHeartbeatsWrite,
+	}
+}
+
+// APIKey is a named API key of a user with limited scopes.
+// The Key is only set in plaintext when it is created, only the KeyHash is stored in the database.
+type APIKey struct {
+	ID         uuid.UUID      `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID     UserID         `json:"user_id" gorm:"index" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Name       string         `json:"name" example:"Android Phone"`
+	Key        string         `json:"key,omitempty" gorm:"-" example:"x-api-key"`
+	KeyPrefix  string         `json:"key_prefix" gorm:"index:idx_api_keys_key_prefix" example:"x-api-ke"`
+	KeyHash    string         `json:"-" gorm:"uniqueIndex:idx_api_keys_key_hash"`
+	Scopes     pq.StringArray `json:"scopes" example:"[heartbeats:write,phones:write]" gorm:"type:text[]" swaggertype:"array,string"`
+	ExpiresAt  *time.Time     `json:"expires_at" example:"2023-06-05T14:26:02.302718+03:00"`
+	LastUsedAt *time.Time     `json:"last_used_at" example:"2022-06-05T14:26:10.303278+03:00"`
+	LastUsedIP *string        `json:"last_used_ip" example:"127.0.0.1"`
+	CreatedAt  time.Time      `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt  time.Time      `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// IsExpired checks if the APIKey can no longer be used
+func (key *APIKey) IsExpired(timestamp time.Time) bool {
+	return key.ExpiresAt != nil && timestamp.After(*key.ExpiresAt)
+}
+
+// GetScopes returns the scopes of the APIKey
+func (key *APIKey) GetScopes() []APIKeyScope {
+	scopes := make([]APIKeyScope, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		scopes = append(scopes, APIKeyScope(scope))
+	}
+	return scopes
+}
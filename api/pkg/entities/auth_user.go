@@ -1,12 +1,41 @@
 package entities
 
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
 // AuthUser is the user gotten from an auth request
 type AuthUser struct {
 	ID    UserID `json:"id"`
 	Email string `json:"email"`
+
+	// APIKeyID is set when the user is authenticated with a scoped APIKey
+	APIKeyID *uuid.UUID `json:"api_key_id,omitempty"`
+
+	// Scopes are the permissions of the APIKey. Nil means the user has full access
+	Scopes []APIKeyScope `json:"scopes,omitempty"`
+
+	// ExpiresAt is the time when the APIKey expires
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
 }
 
 // IsNoop checks if a user is empty
 func (user AuthUser) IsNoop() bool {
 	return user.ID == "" || user.Email == ""
 }
+
+// IsPhoneAPIKey checks if the user is authenticated with an APIKey which only has the scopes of the android app
+func (user AuthUser) IsPhoneAPIKey() bool {
+	if user.APIKeyID == nil || len(user.Scopes) == 0 {
+		return false
+	}
+
+	for _, scope := range user.Scopes {
+		if scope != APIKeyScopePhonesWrite && scope != APIKeyScopeHeartbeatsWrite {
+			return false
+		}
+	}
+	return true
+}
@@ -6,6 +6,41 @@ import (
 	"github.com/google/uuid"
 )
 
+// HeartbeatSIMState is the state of a SIM card when a heartbeat was sent
+type HeartbeatSIMState string
+
+const (
+	// HeartbeatSIMStateReady means the SIM card is ready to send and receive messages
+	HeartbeatSIMStateReady = HeartbeatSIMState("ready")
+
+	// HeartbeatSIMStateAbsent means there is no SIM card in the slot
+	HeartbeatSIMStateAbsent = HeartbeatSIMState("absent")
+
+	// HeartbeatSIMStateLocked means the SIM card requires a PIN, PUK or network unlock
+	HeartbeatSIMStateLocked = HeartbeatSIMState("locked")
+
+	// HeartbeatSIMStateNotReady means the SIM card is present but not ready
+	HeartbeatSIMStateNotReady = HeartbeatSIMState("not_ready")
+
+	// HeartbeatSIMStateError means the SIM card cannot be used because of an error or restriction
+	HeartbeatSIMStateError = HeartbeatSIMState("error")
+
+	// HeartbeatSIMStateUnknown means the phone cannot determine the state of the SIM card
+	HeartbeatSIMStateUnknown = HeartbeatSIMState("unknown")
+)
+
+// String returns the HeartbeatSIMState as a string
+func (state HeartbeatSIMState) String() string {
+	return string(state)
+}
+
+// HeartbeatSIM is the state of a SIM slot when a heartbeat was sent
+type HeartbeatSIM struct {
+	SIM     SIM               `json:"sim" example:"SIM1"`
+	Carrier *string           `json:"carrier" example:"T-Mobile"`
+	State   HeartbeatSIMState `json:"state" example:"ready"`
+}
+
 // Heartbeat represents is a pulse from an active phone
 type Heartbeat struct {
 	ID        uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
@@ -14,4 +49,38 @@ type Heartbeat struct {
 	Charging  bool      `json:"charging" example:"true"`
 	UserID    UserID    `json:"user_id" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
 	Timestamp time.Time `json:"timestamp" gorm:"index:idx_heartbeats_owner_timestamp" example:"2022-06-05T14:26:01.520828+03:00"`
+
+	// BatteryLevel is the battery percentage of the phone
+	BatteryLevel *uint `json:"battery_level" example:"87"`
+
+	// NetworkType is the type of the mobile network e.g. lte, 5g or wifi
+	NetworkType *string `json:"network_type" example:"lte"`
+
+	// SignalStrength is the strength of the mobile network signal in dBm
+	SignalStrength *int `json:"signal_strength" example:"-95"`
+
+	// SIMs is the carrier and state of each SIM slot of the phone
+	SIMs []HeartbeatSIM `json:"sims" gorm:"type:jsonb;serializer:json"`
+
+	// FreeStorage is the available storage of the phone in bytes
+	FreeStorage *uint64 `json:"free_storage" example:"2147483648"`
+
+	// PendingMessages is the number of messages in the local queue of the app which have not been sent yet
+	PendingMessages *uint `json:"pending_messages" example:"3"`
+}
+
+// IsBatteryLow checks if the phone is not charging and the battery is at or below the threshold
+func (heartbeat *Heartbeat) IsBatteryLow(threshold uint) bool {
+	return !heartbeat.Charging && heartbeat.BatteryLevel != nil && *heartbeat.BatteryLevel <= threshold
+}
+
+// MissingSIMs returns the SIM slots of the phone which have no SIM card
+func (heartbeat *Heartbeat) MissingSIMs() []SIM {
+	var sims []SIM
+	for _, sim := range heartbeat.SIMs {
+		if sim.State == HeartbeatSIMStateAbsent {
+			sims = append(sims, sim.SIM)
+		}
+	}
+	return sims
 }
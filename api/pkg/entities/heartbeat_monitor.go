@@ -6,6 +6,17 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	// HeartbeatMonitorDefaultIntervalSeconds is the default interval in seconds between heartbeats of a phone
+	HeartbeatMonitorDefaultIntervalSeconds = 16 * 60
+
+	// HeartbeatMonitorDefaultMissedThreshold is the default number of missed intervals before the phone is pushed to send a heartbeat
+	HeartbeatMonitorDefaultMissedThreshold = 1
+
+	// HeartbeatMonitorDefaultOfflineThreshold is the default number of missed intervals before the phone is offline
+	HeartbeatMonitorDefaultOfflineThreshold = 4
+)
+
 // HeartbeatMonitor is used to monitor heartbeats of a phone
 type HeartbeatMonitor struct {
 	ID          uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
@@ -14,8 +25,18 @@ type HeartbeatMonitor struct {
 	QueueID     string    `json:"queue_id" example:"0360259236613675274"`
 	Owner       string    `json:"owner" example:"+18005550199"`
 	PhoneOnline bool      `json:"phone_online" example:"true" default:"true"`
-	CreatedAt   time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
-	UpdatedAt   time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+
+	// IntervalSeconds is the expected interval in seconds between heartbeats of the phone
+	IntervalSeconds uint `json:"interval_seconds" gorm:"default:960" example:"960"`
+
+	// MissedThreshold is the number of missed intervals after which the phone is pushed to send a heartbeat
+	MissedThreshold uint `json:"missed_threshold" gorm:"default:1" example:"1"`
+
+	// OfflineThreshold is the number of missed intervals after which the phone is considered offline
+	OfflineThreshold uint `json:"offline_threshold" gorm:"default:4" example:"4"`
+
+	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
 }
 
 // RequiresCheck returns true if the heartbeat monitor requires a check
@@ -23,6 +44,32 @@ func (h *HeartbeatMonitor) RequiresCheck() bool {
 	return h.UpdatedAt.Add(2 * time.Hour).Before(time.Now())
 }
 
+// Interval returns the expected interval between heartbeats with a default of 16 minutes
+func (h *HeartbeatMonitor) Interval() time.Duration {
+	if h.IntervalSeconds == 0 {
+		return HeartbeatMonitorDefaultIntervalSeconds * time.Second
+	}
+	return time.Duration(h.IntervalSeconds) * time.Second
+}
+
+// MissedDuration returns the duration without a heartbeat after which the phone is pushed to send a heartbeat
+func (h *HeartbeatMonitor) MissedDuration() time.Duration {
+	threshold := h.MissedThreshold
+	if threshold == 0 {
+		threshold = HeartbeatMonitorDefaultMissedThreshold
+	}
+	return time.Duration(threshold) * h.Interval()
+}
+
+// OfflineDuration returns the duration without a heartbeat after which the phone is considered offline
+func (h *HeartbeatMonitor) OfflineDuration() time.Duration {
+	threshold := h.OfflineThreshold
+	if threshold == 0 {
+		threshold = HeartbeatMonitorDefaultOfflineThreshold
+	}
+	return time.Duration(threshold) * h.Interval()
+}
+
 // PhoneIsOffline returns true if the phone is offline
 func (h *HeartbeatMonitor) PhoneIsOffline() bool {
 	return !h.PhoneOnline
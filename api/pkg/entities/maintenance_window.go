@@ -0,0 +1,76 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaintenanceWindowRecurrence is how often a maintenance window repeats
+type MaintenanceWindowRecurrence string
+
+const (
+	// MaintenanceWindowRecurrenceNone means the maintenance window happens once
+	MaintenanceWindowRecurrenceNone = MaintenanceWindowRecurrence("none")
+
+	// MaintenanceWindowRecurrenceDaily means the maintenance window repeats every 24 hours
+	MaintenanceWindowRecurrenceDaily = MaintenanceWindowRecurrence("daily")
+
+	// MaintenanceWindowRecurrenceWeekly means the maintenance window repeats every 7 days
+	MaintenanceWindowRecurrenceWeekly = MaintenanceWindowRecurrence("weekly")
+)
+
+// String converts the MaintenanceWindowRecurrence to a string
+func (recurrence MaintenanceWindowRecurrence) String() string {
+	return string(recurrence)
+}
+
+// Period returns the time between the occurrences of a recurring maintenance window
+func (recurrence MaintenanceWindowRecurrence) Period() time.Duration {
+	switch recurrence {
+	case MaintenanceWindowRecurrenceDaily:
+		return 24 * time.Hour
+	case MaintenanceWindowRecurrenceWeekly:
+		return 7 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+// MaintenanceWindow is a period in which a phone is expected to be offline e.g. when it is rebooted or updated.
+// Offline heartbeat alerts are suppressed and messages are not expired during a maintenance window.
+type MaintenanceWindow struct {
+	ID      uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	PhoneID uuid.UUID `json:"phone_id" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID  UserID    `json:"user_id" gorm:"index:idx_maintenance_windows__user_id__owner,priority:1" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Owner   string    `json:"owner" gorm:"index:idx_maintenance_windows__user_id__owner,priority:2" example:"+18005550199"`
+	Reason  *string   `json:"reason" example:"Android system update"`
+
+	// StartsAt is the start of the first occurrence of the maintenance window
+	StartsAt time.Time `json:"starts_at" example:"2022-06-05T02:00:00+03:00"`
+	// EndsAt is the end of the first occurrence of the maintenance window
+	EndsAt time.Time `json:"ends_at" example:"2022-06-05T02:30:00+03:00"`
+
+	Recurrence MaintenanceWindowRecurrence `json:"recurrence" gorm:"default:none" example:"daily"`
+
+	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// ActiveUntil returns the end of the occurrence of the maintenance window which contains the timestamp
+func (window *MaintenanceWindow) ActiveUntil(timestamp time.Time) (time.Time, bool) {
+	if timestamp.Before(window.StartsAt) {
+		return time.Time{}, false
+	}
+
+	start := window.StartsAt
+	if period := window.Recurrence.Period(); period > 0 {
+		start = start.Add(timestamp.Sub(start) / period * period)
+	}
+
+	end := start.Add(window.EndsAt.Sub(window.StartsAt))
+	if !timestamp.Before(end) {
+		return time.Time{}, false
+	}
+	return end, true
+}
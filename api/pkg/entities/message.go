@@ -117,6 +117,13 @@ type Message struct {
 	MaxSendAttempts         uint       `json:"max_send_attempts" example:"1"`
 	ReceivedAt              *time.Time `json:"received_at" example:"2022-06-05T14:26:09.527976+03:00"`
 	FailureReason           *string    `json:"failure_reason" example:"UNKNOWN"`
+	// ContentPurgedAt is when the content of the message was removed by the data retention policy of the user
+	ContentPurgedAt *time.Time `json:"content_purged_at" example:"2022-06-05T14:26:09.527976+03:00"`
+
+	// SearchRank is the relevance of the message to the full-text search query
+	SearchRank *float64 `json:"search_rank,omitempty" gorm:"->;-:migration" example:"0.0607927"`
+	// SearchSnippet is the part of the content which matches the full-text search query with the matches wrapped in <b></b>
+	SearchSnippet *string `json:"search_snippet,omitempty" gorm:"->;-:migration" example:"Your <b>invoice</b> <b>4432</b> is ready"`
 }
 
 // IsSending determines if a message is being sent
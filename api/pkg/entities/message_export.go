@@ -0,0 +1,96 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// MessageExportFormat is the file format of a MessageExport
+type MessageExportFormat string
+
+const (
+	// MessageExportFormatCSV exports messages as comma separated values
+	MessageExportFormatCSV = MessageExportFormat("csv")
+
+	// MessageExportFormatNDJSON exports messages as newline delimited JSON
+	MessageExportFormatNDJSON = MessageExportFormat("ndjson")
+
+	// MessageExportFormatXLSX exports messages as an Excel spreadsheet
+	MessageExportFormatXLSX = MessageExportFormat("xlsx")
+)
+
+// String converts the MessageExportFormat to a string
+func (format MessageExportFormat) String() string {
+	return string(format)
+}
+
+// ContentType is the MIME type of the MessageExportFormat
+func (format MessageExportFormat) ContentType() string {
+	switch format {
+	case MessageExportFormatNDJSON:
+		return "application/x-ndjson"
+	case MessageExportFormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "text/csv"
+	}
+}
+
+// MessageExportStatus is the status of the file of a MessageExport
+type MessageExportStatus string
+
+const (
+	// MessageExportStatusPending means the file of the export is being generated
+	MessageExportStatusPending = MessageExportStatus("pending")
+
+	// MessageExportStatusReady means the file of the export has been generated and the download link was sent by email
+	MessageExportStatusReady = MessageExportStatus("ready")
+
+	// MessageExportStatusFailed means the file of the export could not be generated
+	MessageExportStatusFailed = MessageExportStatus("failed")
+)
+
+// MessageExport is an export of messages which is downloaded with a link sent by email
+type MessageExport struct {
+	ID            uuid.UUID           `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID        UserID              `json:"user_id" gorm:"index" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Email         string              `json:"email" example:"name@email.com"`
+	Format        MessageExportFormat `json:"format" example:"csv"`
+	Owners        pq.StringArray      `json:"owners" example:"[+18005550199]" gorm:"type:text[]" swaggertype:"array,string"`
+	Types         pq.StringArray      `json:"types" example:"[mobile-terminated]" gorm:"type:text[]" swaggertype:"array,string"`
+	Statuses      pq.StringArray      `json:"statuses" example:"[delivered]" gorm:"type:text[]" swaggertype:"array,string"`
+	CreatedAfter  *time.Time          `json:"created_after" example:"2022-06-05T14:26:02.302718+03:00"`
+	CreatedBefore *time.Time          `json:"created_before" example:"2022-06-05T14:26:02.302718+03:00"`
+	Status        MessageExportStatus `json:"status" example:"pending"`
+	Size          int64               `json:"size" example:"102400"`
+	Chunks        int                 `json:"-"`
+	TokenHash     string              `json:"-" gorm:"index"`
+	ExpiresAt     time.Time           `json:"expires_at" example:"2022-06-12T14:26:02.302718+03:00"`
+	CreatedAt     time.Time           `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt     time.Time           `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// IsExpired checks if the download link of the MessageExport has expired
+func (export *MessageExport) IsExpired(timestamp time.Time) bool {
+	return timestamp.After(export.ExpiresAt)
+}
+
+// FileName is the name of the downloaded file
+func (export *MessageExport) FileName() string {
+	return "messages-" + export.CreatedAt.Format("2006-01-02") + "." + export.Format.String()
+}
+
+// IsReady checks if the file of the MessageExport can be downloaded
+func (export *MessageExport) IsReady() bool {
+	return export.Status == MessageExportStatusReady
+}
+
+// MessageExportChunk is a part of the generated file of a MessageExport.
+// The file is stored in chunks so that it is never loaded in memory at once when it is downloaded.
+type MessageExportChunk struct {
+	MessageExportID uuid.UUID `gorm:"primaryKey;type:uuid;"`
+	Sequence        int       `gorm:"primaryKey;autoIncrement:false"`
+	Data            []byte
+}
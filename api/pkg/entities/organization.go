@@ -0,0 +1,113 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrganizationRole is the role of an OrganizationMember
+type OrganizationRole string
+
+const (
+	// OrganizationRoleOwner can manage members, billing and all resources of the organization
+	OrganizationRoleOwner = OrganizationRole("owner")
+
+	// OrganizationRoleAdmin can manage members and all resources of the organization
+	OrganizationRoleAdmin = OrganizationRole("admin")
+
+	// OrganizationRoleAgent can send and read messages on the phones granted to the member
+	OrganizationRoleAgent = OrganizationRole("agent")
+
+	// OrganizationRoleReadOnly can only read messages on the phones granted to the member
+	OrganizationRoleReadOnly = OrganizationRole("read-only")
+)
+
+// String converts the OrganizationRole to a string
+func (role OrganizationRole) String() string {
+	return string(role)
+}
+
+// CanManage checks if the role can manage members, phones and integrations of an organization
+func (role OrganizationRole) CanManage() bool {
+	return role == OrganizationRoleOwner || role == OrganizationRoleAdmin
+}
+
+// CanChange checks if the role can change or remove a member with the target role.
+// Only the owner can change admins and nobody can change the owner.
+func (role OrganizationRole) CanChange(target OrganizationRole) bool {
+	if target == OrganizationRoleOwner || !role.CanManage() {
+		return false
+	}
+	return role == OrganizationRoleOwner || target != OrganizationRoleAdmin
+}
+
+// CanWrite checks if the role can create or modify resources in an organization
+func (role OrganizationRole) CanWrite() bool {
+	return role != OrganizationRoleReadOnly
+}
+
+// Organization is a team of users sharing the same phones, messages and subscription.
+// Resources of the organization are owned by the OwnerID user so billing happens at the organization level.
+type Organization struct {
+	ID        uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	Name      string    `json:"name" example:"Acme Inc"`
+	OwnerID   UserID    `json:"owner_id" gorm:"index" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// OrganizationMember is a user who belongs to an Organization
+type OrganizationMember struct {
+	ID             uuid.UUID                `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	OrganizationID uuid.UUID                `json:"organization_id" gorm:"type:uuid;uniqueIndex:idx_organization_members_organization_id_user_id" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID         UserID                   `json:"user_id" gorm:"uniqueIndex:idx_organization_members_organization_id_user_id;index" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Email          string                   `json:"email" example:"name@email.com"`
+	Role           OrganizationRole         `json:"role" example:"agent"`
+	PhoneGrants    []OrganizationPhoneGrant `json:"phone_grants" gorm:"foreignKey:MemberID;constraint:OnDelete:CASCADE;"`
+	CreatedAt      time.Time                `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt      time.Time                `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// CanAccessPhone checks if the member has access to the phone with the owner phone number
+func (member *OrganizationMember) CanAccessPhone(owner string) bool {
+	if member.Role.CanManage() {
+		return true
+	}
+
+	for _, grant := range member.PhoneGrants {
+		if grant.Owner == owner {
+			return true
+		}
+	}
+	return false
+}
+
+// OrganizationPhoneGrant gives an OrganizationMember access to a Phone in the Organization
+type OrganizationPhoneGrant struct {
+	ID             uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	OrganizationID uuid.UUID `json:"organization_id" gorm:"type:uuid;index" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	MemberID       uuid.UUID `json:"member_id" gorm:"type:uuid;index" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	PhoneID        uuid.UUID `json:"phone_id" gorm:"type:uuid" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	Owner          string    `json:"owner" example:"+18005550199"`
+	CreatedAt      time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+}
+
+// OrganizationInvitation is an email invitation to join an Organization
+type OrganizationInvitation struct {
+	ID             uuid.UUID        `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	OrganizationID uuid.UUID        `json:"organization_id" gorm:"type:uuid;index" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	Email          string           `json:"email" example:"name@email.com"`
+	Role           OrganizationRole `json:"role" example:"agent"`
+	TokenHash      string           `json:"-" gorm:"uniqueIndex:idx_organization_invitations_token_hash"`
+	InvitedBy      UserID           `json:"invited_by" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	ExpiresAt      time.Time        `json:"expires_at" example:"2022-06-12T14:26:02.302718+03:00"`
+	AcceptedAt     *time.Time       `json:"accepted_at" example:"2022-06-05T14:26:09.527976+03:00"`
+	CreatedAt      time.Time        `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt      time.Time        `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// IsExpired checks if the invitation can no longer be accepted
+func (invitation *OrganizationInvitation) IsExpired(timestamp time.Time) bool {
+	return invitation.AcceptedAt != nil || timestamp.After(invitation.ExpiresAt)
+}
@@ -0,0 +1,75 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrganizationMember_CanAccessPhone(t *testing.T) {
+	t.Run("managers can access every phone", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		member := &OrganizationMember{Role: OrganizationRoleAdmin}
+
+		// Act
+		canAccess := member.CanAccessPhone("+18005550100")
+
+		// Assert
+		assert.True(t, canAccess)
+	})
+
+	t.Run("agents can access only granted phones", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		member := &OrganizationMember{
+			Role:        OrganizationRoleAgent,
+			PhoneGrants: []OrganizationPhoneGrant{{Owner: "+18005550199"}},
+		}
+
+		// Act & Assert
+		assert.True(t, member.CanAccessPhone("+18005550199"))
+		assert.False(t, member.CanAccessPhone("+18005550100"))
+	})
+
+	t.Run("read-only members without grants cannot access phones", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		member := &OrganizationMember{Role: OrganizationRoleReadOnly}
+
+		// Act
+		canAccess := member.CanAccessPhone("+18005550100")
+
+		// Assert
+		assert.False(t, canAccess)
+	})
+}
+
+func TestOrganizationRole_CanChange(t *testing.T) {
+	t.Run("only the owner can change admins", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act & Assert
+		assert.True(t, OrganizationRoleOwner.CanChange(OrganizationRoleAdmin))
+		assert.False(t, OrganizationRoleAdmin.CanChange(OrganizationRoleAdmin))
+		assert.True(t, OrganizationRoleAdmin.CanChange(OrganizationRoleAgent))
+		assert.True(t, OrganizationRoleAdmin.CanChange(OrganizationRoleReadOnly))
+	})
+
+	t.Run("nobody can change the owner and non managers cannot change anyone", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act & Assert
+		assert.False(t, OrganizationRoleOwner.CanChange(OrganizationRoleOwner))
+		assert.False(t, OrganizationRoleAgent.CanChange(OrganizationRoleReadOnly))
+		assert.False(t, OrganizationRoleReadOnly.CanChange(OrganizationRoleReadOnly))
+	})
+}
@@ -6,22 +6,84 @@ import (
 	"github.com/google/uuid"
 )
 
+// PhoneDeliveryMode is how outgoing messages are delivered to a phone
+type PhoneDeliveryMode string
+
+const (
+	// PhoneDeliveryModeFCM means the phone is notified of outgoing messages with Firebase Cloud Messaging
+	PhoneDeliveryModeFCM = PhoneDeliveryMode("fcm")
+
+	// PhoneDeliveryModeUnifiedPush means the phone is notified of outgoing messages by posting to its UnifiedPush endpoint
+	PhoneDeliveryModeUnifiedPush = PhoneDeliveryMode("unifiedpush")
+
+	// PhoneDeliveryModePoll means the phone fetches outgoing messages by long-polling the API
+	PhoneDeliveryModePoll = PhoneDeliveryMode("poll")
+)
+
+// String returns the PhoneDeliveryMode as a string
+func (mode PhoneDeliveryMode) String() string {
+	return string(mode)
+}
+
+// PhoneSIM is the configuration of a SIM slot of a dual-SIM phone which sends messages independently of the other SIM
+type PhoneSIM struct {
+	SIM SIM `json:"sim" example:"SIM2"`
+
+	// PhoneNumber is the phone number of the SIM card. Messages which are sent from this number are sent by the phone with this SIM.
+	PhoneNumber *string `json:"phone_number" example:"+18005550100"`
+
+	// MessagesPerMinute is the rate limit of the SIM. The MessagesPerMinute of the phone is used when it is 0.
+	MessagesPerMinute uint `json:"messages_per_minute" example:"10"`
+
+	// MaxMessagesPerDay is the daily limit of the carrier of the SIM which resets at midnight in the timezone of the user. There is no daily limit when it is 0.
+	MaxMessagesPerDay uint `json:"max_messages_per_day" example:"200"`
+}
+
 // Phone represents an android phone which has installed the http sms app
 type Phone struct {
-	ID                uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
-	UserID            UserID    `json:"user_id" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
-	FcmToken          *string   `json:"fcm_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJzd....."`
-	PhoneNumber       string    `json:"phone_number" example:"+18005550199"`
-	MessagesPerMinute uint      `json:"messages_per_minute" example:"1"`
-	SIM               SIM       `json:"sim" gorm:"default:SIM1"`
+	ID       uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID   UserID    `json:"user_id" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	FcmToken *string   `json:"fcm_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJzd....."`
+	// PushEndpoint is the UnifiedPush endpoint URL which is used instead of the FcmToken e.g. an ntfy topic
+	PushEndpoint      *string `json:"push_endpoint" example:"https://ntfy.sh/upAbCdEfGhIjKl?up=1"`
+	PhoneNumber       string  `json:"phone_number" example:"+18005550199"`
+	MessagesPerMinute uint    `json:"messages_per_minute" example:"1"`
+	SIM               SIM     `json:"sim" gorm:"default:SIM1"`
+
+	// SIMs are the SIM slots which are rate limited independently on a dual-SIM phone
+	SIMs []PhoneSIM `json:"sims" gorm:"type:jsonb;serializer:json"`
+
+	// DeliveryMode determines if the phone is notified of outgoing messages with FCM, UnifiedPush or if it long-polls the API
+	DeliveryMode PhoneDeliveryMode `json:"delivery_mode" gorm:"default:fcm" example:"fcm"`
+
+	// CoalescePushes sends a single push for all the messages which are due within a few seconds. The app must fetch them with GET /v1/messages/outstanding/batch.
+	CoalescePushes bool `json:"coalesce_pushes" example:"false"`
 	// MaxSendAttempts determines how many times to retry sending an SMS message
 	MaxSendAttempts uint `json:"max_send_attempts" example:"2"`
 
+	// MaxMessagesPerHour is the number of messages which the phone sends in an hour before messages are deferred to the next hour. There is no cap when it is 0.
+	MaxMessagesPerHour uint `json:"max_messages_per_hour" example:"100"`
+
+	// MaxMessagesPerDay is the number of messages which the phone sends in a day before messages are deferred to the next day. There is no cap when it is 0.
+	MaxMessagesPerDay uint `json:"max_messages_per_day" example:"1000"`
+
+	// MessagesThisHour is the number of messages which are scheduled on the phone in the current hour in the timezone of the user
+	MessagesThisHour uint `json:"messages_this_hour" gorm:"-" example:"12"`
+
+	// MessagesToday is the number of messages which are scheduled on the phone in the current day in the timezone of the user
+	MessagesToday uint `json:"messages_today" gorm:"-" example:"240"`
+
 	// MessageExpirationSeconds is the duration in seconds after sending a message when it is considered to be expired.
 	MessageExpirationSeconds uint `json:"message_expiration_seconds"`
 
 	MissedCallAutoReply *string `json:"missed_call_auto_reply" example:"This phone cannot receive calls. Please send an SMS instead."`
 
+	// FailoverPhoneID is the phone which sends the queued messages of this phone while it is offline
+	FailoverPhoneID *uuid.UUID `json:"failover_phone_id" gorm:"type:uuid" example:"32343a19-da5e-4b1b-a767-3298a73703ca"`
+
+	// FailoverActiveAt is when the messages of this phone started being routed to the failover phone. It is nil while the phone is online.
+	FailoverActiveAt *time.Time `json:"failover_active_at" example:"2022-06-05T14:26:10.303278+03:00"`
+
 	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
 	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
 }
@@ -39,6 +101,11 @@ func (phone *Phone) MessageExpirationSecondsSanitized() uint {
 	return phone.MessageExpirationSeconds
 }
 
+// IsPolling checks if the phone fetches outgoing messages by long-polling instead of FCM
+func (phone *Phone) IsPolling() bool {
+	return phone.DeliveryMode == PhoneDeliveryModePoll
+}
+
 // MaxSendAttemptsSanitized returns the max send attempts replacing 0 with 2
 func (phone *Phone) MaxSendAttemptsSanitized() uint {
 	if phone.MaxSendAttempts == 0 {
@@ -46,3 +113,36 @@ func (phone *Phone) MaxSendAttemptsSanitized() uint {
 	}
 	return phone.MaxSendAttempts
 }
+
+// IsFailingOver checks if the messages of the phone are routed to its failover phone
+func (phone *Phone) IsFailingOver() bool {
+	return phone.FailoverPhoneID != nil && phone.FailoverActiveAt != nil
+}
+
+// SIMSettings returns the PhoneSIM of a SIM slot or nil if the SIM is not configured
+func (phone *Phone) SIMSettings(sim SIM) *PhoneSIM {
+	for i := range phone.SIMs {
+		if phone.SIMs[i].SIM == sim {
+			return &phone.SIMs[i]
+		}
+	}
+	return nil
+}
+
+// SIMWithPhoneNumber returns the PhoneSIM which has a phone number or nil if no SIM has the phone number
+func (phone *Phone) SIMWithPhoneNumber(phoneNumber string) *PhoneSIM {
+	for i := range phone.SIMs {
+		if phone.SIMs[i].PhoneNumber != nil && *phone.SIMs[i].PhoneNumber == phoneNumber {
+			return &phone.SIMs[i]
+		}
+	}
+	return nil
+}
+
+// HasSIM checks if messages can be sent with a SIM slot. A phone without SIMs only sends with its default SIM.
+func (phone *Phone) HasSIM(sim SIM) bool {
+	if len(phone.SIMs) == 0 {
+		return phone.SIM == sim
+	}
+	return phone.SIMSettings(sim) != nil
+}
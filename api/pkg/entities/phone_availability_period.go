@@ -0,0 +1,43 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PhoneAvailabilityPeriod is a period of time in which a phone was continuously online or offline
+type PhoneAvailabilityPeriod struct {
+	ID        uuid.UUID  `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	PhoneID   uuid.UUID  `json:"phone_id" gorm:"index:idx_phone_availability_periods__phone_id__started_at,priority:1" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID    UserID     `json:"user_id" gorm:"index:idx_phone_availability_periods__user_id" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Owner     string     `json:"owner" example:"+18005550199"`
+	Online    bool       `json:"online" example:"false"`
+	StartedAt time.Time  `json:"started_at" gorm:"index:idx_phone_availability_periods__phone_id__started_at,priority:2" example:"2022-06-05T14:26:02.302718+03:00"`
+	EndedAt   *time.Time `json:"ended_at" example:"2022-06-05T14:46:02.302718+03:00"`
+	CreatedAt time.Time  `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time  `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// IsOpen checks if the period has not ended yet
+func (period *PhoneAvailabilityPeriod) IsOpen() bool {
+	return period.EndedAt == nil
+}
+
+// Overlap returns the duration of the period which falls between from and to. An open period ends at to.
+func (period *PhoneAvailabilityPeriod) Overlap(from time.Time, to time.Time) time.Duration {
+	start := period.StartedAt
+	if start.Before(from) {
+		start = from
+	}
+
+	end := to
+	if period.EndedAt != nil && period.EndedAt.Before(to) {
+		end = *period.EndedAt
+	}
+
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
@@ -0,0 +1,107 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PhoneCommandType is the action which a phone performs when it receives a PhoneCommand
+type PhoneCommandType string
+
+const (
+	// PhoneCommandTypeRefreshConfig makes the phone fetch its settings from the API
+	PhoneCommandTypeRefreshConfig = PhoneCommandType("refresh_config")
+
+	// PhoneCommandTypeForceHeartbeat makes the phone send a heartbeat immediately
+	PhoneCommandTypeForceHeartbeat = PhoneCommandType("force_heartbeat")
+
+	// PhoneCommandTypeResyncOutstanding makes the phone fetch all the outstanding messages
+	PhoneCommandTypeResyncOutstanding = PhoneCommandType("resync_outstanding")
+
+	// PhoneCommandTypeUploadLogs makes the phone upload its logs
+	PhoneCommandTypeUploadLogs = PhoneCommandType("upload_logs")
+
+	// PhoneCommandTypeSwitchSIM makes the phone use another SIM card by default
+	PhoneCommandTypeSwitchSIM = PhoneCommandType("switch_sim")
+)
+
+// String converts the PhoneCommandType to a string
+func (commandType PhoneCommandType) String() string {
+	return string(commandType)
+}
+
+// PhoneCommandStatus is the status of a PhoneCommand
+type PhoneCommandStatus string
+
+const (
+	// PhoneCommandStatusPending means the command has not been pushed to the phone
+	PhoneCommandStatusPending = PhoneCommandStatus("pending")
+
+	// PhoneCommandStatusDelivered means the command has been pushed to the phone
+	PhoneCommandStatusDelivered = PhoneCommandStatus("delivered")
+
+	// PhoneCommandStatusAcked means the phone has executed the command
+	PhoneCommandStatusAcked = PhoneCommandStatus("acked")
+
+	// PhoneCommandStatusFailed means the command could not be pushed to the phone or the phone could not execute it
+	PhoneCommandStatusFailed = PhoneCommandStatus("failed")
+)
+
+// String converts the PhoneCommandStatus to a string
+func (status PhoneCommandStatus) String() string {
+	return string(status)
+}
+
+// PhoneCommand is an action which is pushed to a phone e.g. to refresh its config or to send a heartbeat
+type PhoneCommand struct {
+	ID      uuid.UUID          `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	PhoneID uuid.UUID          `json:"phone_id" gorm:"index:idx_phone_commands__phone_id__created_at,priority:1" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID  UserID             `json:"user_id" gorm:"index" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Owner   string             `json:"owner" example:"+18005550199"`
+	Type    PhoneCommandType   `json:"type" example:"force_heartbeat"`
+	Status  PhoneCommandStatus `json:"status" example:"pending"`
+
+	// SIM is the SIM card which the phone switches to for the switch_sim command
+	SIM *SIM `json:"sim" example:"SIM2"`
+
+	ErrorMessage *string    `json:"error_message" example:"cannot send push notification"`
+	DeliveredAt  *time.Time `json:"delivered_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	AckedAt      *time.Time `json:"acked_at" example:"2022-06-05T14:26:09.527976+03:00"`
+	FailedAt     *time.Time `json:"failed_at" example:"2022-06-05T14:26:09.527976+03:00"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"index:idx_phone_commands__phone_id__created_at,priority:2" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// IsPending checks if the command has not been pushed to the phone
+func (command *PhoneCommand) IsPending() bool {
+	return command.Status == PhoneCommandStatusPending
+}
+
+// IsFinal checks if the command has been acknowledged or has failed
+func (command *PhoneCommand) IsFinal() bool {
+	return command.Status == PhoneCommandStatusAcked || command.Status == PhoneCommandStatusFailed
+}
+
+// Delivered marks the command as pushed to the phone
+func (command *PhoneCommand) Delivered(timestamp time.Time) *PhoneCommand {
+	command.Status = PhoneCommandStatusDelivered
+	command.DeliveredAt = &timestamp
+	return command
+}
+
+// Acked marks the command as executed by the phone
+func (command *PhoneCommand) Acked(timestamp time.Time) *PhoneCommand {
+	command.Status = PhoneCommandStatusAcked
+	command.AckedAt = &timestamp
+	return command
+}
+
+// Failed marks the command as failed with an error message
+func (command *PhoneCommand) Failed(errorMessage string, timestamp time.Time) *PhoneCommand {
+	command.Status = PhoneCommandStatusFailed
+	command.ErrorMessage = &errorMessage
+	command.FailedAt = &timestamp
+	return command
+}
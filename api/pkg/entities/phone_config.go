@@ -0,0 +1,106 @@
+package entities
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// PhoneConfig stores the settings of a phone which were only configurable on the device
+type PhoneConfig struct {
+	PhoneID uuid.UUID `json:"phone_id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID  UserID    `json:"user_id" gorm:"index" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+
+	// Version is incremented every time the config document of the phone is changed
+	Version uint `json:"version" example:"3"`
+
+	// DocumentHash is the PhoneConfigDocument.ContentHash of the last version which was pushed to the phone
+	DocumentHash string `json:"-"`
+
+	// ReceiveSIMs are the SIM cards which forward received messages to the API
+	ReceiveSIMs pq.StringArray `json:"receive_sims" example:"[SIM1,SIM2]" gorm:"column:receive_sims;type:text[]" swaggertype:"array,string"`
+
+	// DeliveryReportsEnabled determines if the phone requests delivery reports for sent messages
+	DeliveryReportsEnabled bool `json:"delivery_reports_enabled" example:"true"`
+
+	// EncryptionEnabled determines if the phone encrypts and decrypts the content of messages
+	EncryptionEnabled bool `json:"encryption_enabled" example:"false"`
+
+	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// NewPhoneConfig creates the default PhoneConfig of a phone
+func NewPhoneConfig(phone *Phone) *PhoneConfig {
+	return &PhoneConfig{
+		PhoneID:                phone.ID,
+		UserID:                 phone.UserID,
+		Version:                0,
+		ReceiveSIMs:            pq.StringArray{SIM1.String(), SIM2.String()},
+		DeliveryReportsEnabled: true,
+		EncryptionEnabled:      false,
+		CreatedAt:              time.Now().UTC(),
+		UpdatedAt:              time.Now().UTC(),
+	}
+}
+
+// PhoneConfigDocument is the complete config of a phone which is fetched by the Android app
+type PhoneConfigDocument struct {
+	PhoneID uuid.UUID `json:"phone_id" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	Owner   string    `json:"owner" example:"+18005550199"`
+
+	// Version is the version of the device settings in the PhoneConfig
+	Version uint `json:"version" example:"3"`
+
+	MessagesPerMinute        uint       `json:"messages_per_minute" example:"10"`
+	MaxSendAttempts          uint       `json:"max_send_attempts" example:"2"`
+	MessageExpirationSeconds uint       `json:"message_expiration_seconds" example:"600"`
+	SIM                      SIM        `json:"sim" example:"SIM1"`
+	SIMs                     []PhoneSIM `json:"sims"`
+	MissedCallAutoReply      *string    `json:"missed_call_auto_reply" example:"This phone cannot receive calls. Please send an SMS instead."`
+
+	HeartbeatIntervalSeconds uint     `json:"heartbeat_interval_seconds" example:"960"`
+	ReceiveSIMs              []string `json:"receive_sims" example:"[SIM1,SIM2]"`
+	DeliveryReportsEnabled   bool     `json:"delivery_reports_enabled" example:"true"`
+	EncryptionEnabled        bool     `json:"encryption_enabled" example:"false"`
+}
+
+// NewPhoneConfigDocument combines the settings of a Phone, its PhoneConfig and its heartbeat interval
+func NewPhoneConfigDocument(phone *Phone, config *PhoneConfig, heartbeatInterval time.Duration) *PhoneConfigDocument {
+	return &PhoneConfigDocument{
+		PhoneID:                  phone.ID,
+		Owner:                    phone.PhoneNumber,
+		Version:                  config.Version,
+		MessagesPerMinute:        phone.MessagesPerMinute,
+		MaxSendAttempts:          phone.MaxSendAttemptsSanitized(),
+		MessageExpirationSeconds: phone.MessageExpirationSecondsSanitized(),
+		SIM:                      phone.SIM,
+		SIMs:                     phone.SIMs,
+		MissedCallAutoReply:      phone.MissedCallAutoReply,
+		HeartbeatIntervalSeconds: uint(heartbeatInterval.Seconds()),
+		ReceiveSIMs:              config.ReceiveSIMs,
+		DeliveryReportsEnabled:   config.DeliveryReportsEnabled,
+		EncryptionEnabled:        config.EncryptionEnabled,
+	}
+}
+
+// ContentHash is the hash of the settings in the document without the version
+// which is used to detect when a change of the phone or its heartbeat monitor changes the document.
+func (document PhoneConfigDocument) ContentHash() string {
+	document.Version = 0
+	content, _ := json.Marshal(document)
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:])
+}
+
+// ETag is the entity tag of the document. It changes when any setting of the document changes including the settings of the phone.
+func (document *PhoneConfigDocument) ETag() string {
+	content, _ := json.Marshal(document)
+	hash := sha256.Sum256(content)
+	return fmt.Sprintf("\"%d-%s\"", document.Version, hex.EncodeToString(hash[:8]))
+}
@@ -28,4 +28,7 @@ type PhoneNotification struct {
 	ScheduledAt time.Time `json:"scheduled_at"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	// SIM is the SIM slot which rate limits the notification. It is nil when the notification is rate limited on the whole phone.
+	SIM *SIM `json:"sim"`
 }
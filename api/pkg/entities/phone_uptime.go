@@ -0,0 +1,42 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PhoneUptime is the availability report of a phone between 2 timestamps
+type PhoneUptime struct {
+	PhoneID  uuid.UUID `json:"phone_id" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	Owner    string    `json:"owner" example:"+18005550199"`
+	From     time.Time `json:"from" example:"2022-06-01T00:00:00+03:00"`
+	To       time.Time `json:"to" example:"2022-06-08T00:00:00+03:00"`
+	Timezone string    `json:"timezone" example:"Europe/Helsinki"`
+
+	// UptimePercentage is the percentage of time between from and to in which the phone was not offline
+	UptimePercentage float64 `json:"uptime_percentage" example:"99.952"`
+	DowntimeSeconds  int64   `json:"downtime_seconds" example:"290"`
+
+	// MeanTimeToRecoverySeconds is the average duration of the outages which have ended. It is null when no outage has ended.
+	MeanTimeToRecoverySeconds *int64 `json:"mean_time_to_recovery_seconds" example:"145"`
+
+	Outages []PhoneOutage    `json:"outages"`
+	Days    []PhoneUptimeDay `json:"days"`
+}
+
+// PhoneOutage is a period in which a phone was offline
+type PhoneOutage struct {
+	StartedAt time.Time  `json:"started_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	EndedAt   *time.Time `json:"ended_at" example:"2022-06-05T14:28:27.302718+03:00"`
+
+	// DurationSeconds is the full duration of the outage, an outage which has not ended lasts until now
+	DurationSeconds int64 `json:"duration_seconds" example:"145"`
+}
+
+// PhoneUptimeDay is the availability of a phone on a calendar day in the timezone of the user
+type PhoneUptimeDay struct {
+	Date             string  `json:"date" example:"2022-06-05"`
+	UptimePercentage float64 `json:"uptime_percentage" example:"99.832"`
+	DowntimeSeconds  int64   `json:"downtime_seconds" example:"145"`
+}
@@ -65,11 +65,14 @@ const SubscriptionName200KMonthly = SubscriptionName("200k-monthly")
 // SubscriptionName20KYearly represents a yearly 20k subscription
 const SubscriptionName20KYearly = SubscriptionName("20k-yearly")
 
-// User stores information about a user
+// User stores information about a user.
+// The APIKey is only set in plaintext when it is created or rotated, only the APIKeyHash is stored in the database.
 type User struct {
 	ID                               UserID           `json:"id" gorm:"primaryKey;type:string;" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
 	Email                            string           `json:"email" example:"name@email.com"`
-	APIKey                           string           `json:"api_key" gorm:"uniqueIndex:idx_users_api_key" example:"x-api-key"`
+	APIKey                           string           `json:"api_key,omitempty" gorm:"-" example:"x-api-key"`
+	APIKeyPrefix                     string           `json:"api_key_prefix" gorm:"index:idx_users_api_key_prefix" example:"x-api-ke"`
+	APIKeyHash                       string           `json:"-" gorm:"uniqueIndex:idx_users_api_key_hash"`
 	Timezone                         string           `json:"timezone" example:"Europe/Helsinki" gorm:"default:Africa/Accra"`
 	ActivePhoneID                    *uuid.UUID       `json:"active_phone_id" gorm:"type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
 	SubscriptionName                 SubscriptionName `json:"subscription_name" example:"free"`
@@ -80,10 +83,20 @@ type User struct {
 	NotificationMessageStatusEnabled bool             `json:"notification_message_status_enabled" gorm:"default:true" example:"true"`
 	NotificationWebhookEnabled       bool             `json:"notification_webhook_enabled" gorm:"default:true" example:"true"`
 	NotificationHeartbeatEnabled     bool             `json:"notification_heartbeat_enabled" gorm:"default:true" example:"true"`
+	MessageRetentionDays             *uint            `json:"message_retention_days" example:"90"`
+	HeartbeatRetentionDays           *uint            `json:"heartbeat_retention_days" example:"30"`
+	NotificationRetentionDays        *uint            `json:"notification_retention_days" example:"7"`
+	RetentionPurgeScheduledAt        *time.Time       `json:"-"`
+	RetentionPurgeQueueID            string           `json:"-"`
 	CreatedAt                        time.Time        `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
 	UpdatedAt                        time.Time        `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
 }
 
+// HasRetention checks if the user has at least one data retention policy
+func (user User) HasRetention() bool {
+	return user.MessageRetentionDays != nil || user.HeartbeatRetentionDays != nil || user.NotificationRetentionDays != nil
+}
+
 // IsOnProPlan checks if a user is on the pro plan
 func (user User) IsOnProPlan() bool {
 	return user.SubscriptionName == SubscriptionNameProLifetime || user.SubscriptionName == SubscriptionNameProMonthly || user.SubscriptionName == SubscriptionNameProYearly
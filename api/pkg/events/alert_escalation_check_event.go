@@ -0,0 +1,21 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypeAlertEscalationCheck is emitted after the escalation delay of an alert channel to check if the phone has recovered
+const EventTypeAlertEscalationCheck = "alert.escalation.check"
+
+// AlertEscalationCheckPayload is the payload of the EventTypeAlertEscalationCheck event
+type AlertEscalationCheckPayload struct {
+	AlertChannelID         uuid.UUID       `json:"alert_channel_id"`
+	UserID                 entities.UserID `json:"user_id"`
+	PhoneID                uuid.UUID       `json:"phone_id"`
+	Owner                  string          `json:"owner"`
+	LastHeartbeatTimestamp time.Time       `json:"last_heartbeat_timestamp"`
+	ScheduledAt            time.Time       `json:"scheduled_at"`
+}
@@ -0,0 +1,18 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// MessageContentPurged is emitted when the content of a batch of messages is removed by the retention policy of a user
+const MessageContentPurged = "message.content.purged"
+
+// MessageContentPurgedPayload is the payload of the MessageContentPurged event
+type MessageContentPurgedPayload struct {
+	UserID     entities.UserID `json:"user_id"`
+	MessageIDs []uuid.UUID     `json:"message_ids"`
+	Timestamp  time.Time       `json:"timestamp"`
+}
@@ -0,0 +1,20 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// MessageExportRequested is raised when a user requests an export of messages which is sent by email
+const MessageExportRequested = "message.export.requested"
+
+// MessageExportRequestedPayload stores the data for the MessageExportRequested event
+type MessageExportRequestedPayload struct {
+	MessageExportID uuid.UUID       `json:"message_export_id"`
+	UserID          entities.UserID `json:"user_id"`
+	Email           string          `json:"email"`
+	Format          string          `json:"format"`
+	Timestamp       time.Time       `json:"timestamp"`
+}
@@ -0,0 +1,26 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypeMessageSendRerouted is emitted when a queued message is moved to the failover phone of an offline phone
+const EventTypeMessageSendRerouted = "message.send.rerouted"
+
+// MessageSendReroutedPayload is the payload of the EventTypeMessageSendRerouted event
+type MessageSendReroutedPayload struct {
+	MessageID       uuid.UUID       `json:"message_id"`
+	UserID          entities.UserID `json:"user_id"`
+	PreviousPhoneID uuid.UUID       `json:"previous_phone_id"`
+	PreviousOwner   string          `json:"previous_owner"`
+	PhoneID         uuid.UUID       `json:"phone_id"`
+	Owner           string          `json:"owner"`
+	Contact         string          `json:"contact"`
+	Encrypted       bool            `json:"encrypted"`
+	Content         string          `json:"content"`
+	SIM             entities.SIM    `json:"sim"`
+	Timestamp       time.Time       `json:"timestamp"`
+}
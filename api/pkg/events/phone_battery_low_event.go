@@ -0,0 +1,20 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypePhoneBatteryLow is emitted when the battery of a phone which is not charging becomes low
+const EventTypePhoneBatteryLow = "phone.battery.low"
+
+// PhoneBatteryLowPayload is the payload of the EventTypePhoneBatteryLow event
+type PhoneBatteryLowPayload struct {
+	HeartbeatID  uuid.UUID       `json:"heartbeat_id"`
+	UserID       entities.UserID `json:"user_id"`
+	Owner        string          `json:"owner"`
+	BatteryLevel uint            `json:"battery_level"`
+	Timestamp    time.Time       `json:"timestamp"`
+}
@@ -0,0 +1,24 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypePhoneCapReached is emitted when messages of a phone are deferred because a sending cap was reached
+const EventTypePhoneCapReached = "phone.cap.reached"
+
+// PhoneCapReachedPayload is the payload of the EventTypePhoneCapReached event
+type PhoneCapReachedPayload struct {
+	PhoneID       uuid.UUID       `json:"phone_id"`
+	UserID        entities.UserID `json:"user_id"`
+	Owner         string          `json:"owner"`
+	MessageID     uuid.UUID       `json:"message_id"`
+	SIM           *entities.SIM   `json:"sim"`
+	Window        string          `json:"window"`
+	Limit         uint            `json:"limit"`
+	DeferredUntil time.Time       `json:"deferred_until"`
+	Timestamp     time.Time       `json:"timestamp"`
+}
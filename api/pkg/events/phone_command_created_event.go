@@ -0,0 +1,21 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypePhoneCommandCreated is emitted when a command is created for a phone
+const EventTypePhoneCommandCreated = "phone.command.created"
+
+// PhoneCommandCreatedPayload is the payload of the EventTypePhoneCommandCreated event
+type PhoneCommandCreatedPayload struct {
+	CommandID uuid.UUID                 `json:"command_id"`
+	PhoneID   uuid.UUID                 `json:"phone_id"`
+	UserID    entities.UserID           `json:"user_id"`
+	Owner     string                    `json:"owner"`
+	Type      entities.PhoneCommandType `json:"type"`
+	Timestamp time.Time                 `json:"timestamp"`
+}
@@ -0,0 +1,21 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypePhoneHeartbeatMonitorUpdated is emitted when the heartbeat interval or thresholds of a phone are updated
+const EventTypePhoneHeartbeatMonitorUpdated = "phone.heartbeat-monitor.updated"
+
+// PhoneHeartbeatMonitorUpdatedPayload is the payload of the EventTypePhoneHeartbeatMonitorUpdated event
+type PhoneHeartbeatMonitorUpdatedPayload struct {
+	PhoneID         uuid.UUID       `json:"phone_id"`
+	UserID          entities.UserID `json:"user_id"`
+	MonitorID       uuid.UUID       `json:"monitor_id"`
+	Owner           string          `json:"owner"`
+	IntervalSeconds uint            `json:"interval_seconds"`
+	Timestamp       time.Time       `json:"timestamp"`
+}
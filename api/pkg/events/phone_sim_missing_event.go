@@ -0,0 +1,20 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypePhoneSIMMissing is emitted when the SIM card of a phone is removed
+const EventTypePhoneSIMMissing = "phone.sim.missing"
+
+// PhoneSIMMissingPayload is the payload of the EventTypePhoneSIMMissing event
+type PhoneSIMMissingPayload struct {
+	HeartbeatID uuid.UUID       `json:"heartbeat_id"`
+	UserID      entities.UserID `json:"user_id"`
+	Owner       string          `json:"owner"`
+	SIM         entities.SIM    `json:"sim"`
+	Timestamp   time.Time       `json:"timestamp"`
+}
@@ -0,0 +1,16 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// UserRetentionPurge is raised when the data of a user which is older than the retention policy should be deleted
+const UserRetentionPurge = "user.retention.purge"
+
+// UserRetentionPurgePayload stores the data for the UserRetentionPurge event
+type UserRetentionPurgePayload struct {
+	UserID      entities.UserID `json:"user_id"`
+	ScheduledAt time.Time       `json:"scheduled_at"`
+}
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// alertChannelsLimit is the maximum number of alert channels of a user
+const alertChannelsLimit = 10
+
+// AlertChannelHandler handles alert channel requests
+type AlertChannelHandler struct {
+	handler
+	logger         telemetry.Logger
+	tracer         telemetry.Tracer
+	validator      *validators.AlertChannelHandlerValidator
+	service        *services.AlertService
+	billingService *services.BillingService
+}
+
+// NewAlertChannelHandler creates a new AlertChannelHandler
+func NewAlertChannelHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	validator *validators.AlertChannelHandlerValidator,
+	service *services.AlertService,
+	billingService *services.BillingService,
+) (h *AlertChannelHandler) {
+	return &AlertChannelHandler{
+		logger:         logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:         tracer,
+		validator:      validator,
+		service:        service,
+		billingService: billingService,
+	}
+}
+
+// RegisterRoutes registers the routes for the AlertChannelHandler
+func (h *AlertChannelHandler) RegisterRoutes(router fiber.Router) {
+	router.Get("/alert-channels", h.requireScope(entities.APIKeyScopeWebhooksManage, h.Index))
+	router.Post("/alert-channels", h.requireScope(entities.APIKeyScopeWebhooksManage, h.Store))
+	router.Delete("/alert-channels/:channelID", h.requireScope(entities.APIKeyScopeWebhooksManage, h.Delete))
+}
+
+// RegisterPublicRoutes registers the routes of the AlertChannelHandler which are authenticated with the token in the verification link
+func (h *AlertChannelHandler) RegisterPublicRoutes(app *fiber.App) {
+	app.Get("/alert-channels/:channelID/verify", h.Verify)
+}
+
+// Index returns the alert channels of a user
+// @Summary      Get alert channels of a user
+// @Description  Get the channels which receive phone offline and send failure alerts in addition to the account email
+// @Security	 ApiKeyAuth
+// @Tags         Alerts
+// @Accept       json
+// @Produce      json
+// @Success      200 		{object}	responses.AlertChannelsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /alert-channels [get]
+func (h *AlertChannelHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	channels, err := h.service.Index(ctx, h.userIDFomContext(c))
+	if err != nil {
+		msg := fmt.Sprintf("cannot get alert channels for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(channels), h.pluralize("alert channel", len(channels))), channels)
+}
+
+// Store an alert channel
+// @Summary      Store an alert channel
+// @Description  Route alerts to an email address, a discord channel, a slack incoming webhook or an SMS to a backup number which is sent from another online phone. A phone offline alert is dropped when the phone recovers within the escalation delay.
+// @Security	 ApiKeyAuth
+// @Tags         Alerts
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.AlertChannelStore  	true "Payload of the alert channel"
+// @Success      201 		{object}	responses.AlertChannelResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 403	    {object}	responses.Forbidden
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /alert-channels [post]
+func (h *AlertChannelHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.AlertChannelStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body [%s] into [%T]", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateStore(ctx, h.userIDFomContext(c), request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing alert channel [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing alert channel")
+	}
+
+	if !h.canManage(c) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot create alert channels", h.actingUserFromContext(c).ID)))
+		return h.responseForbidden(c)
+	}
+
+	channels, err := h.service.Index(ctx, h.userIDFomContext(c))
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot index alert channels for user [%s]", h.userIDFomContext(c))))
+		return h.responseInternalServerError(c)
+	}
+
+	if len(channels) >= alertChannelsLimit {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] wants to create more than [%d] alert channels", h.userIDFomContext(c), alertChannelsLimit)))
+		return h.responsePaymentRequired(c, fmt.Sprintf("You can't create more than %d alert channels contact us to upgrade to our enterprise plan.", alertChannelsLimit))
+	}
+
+	if entities.AlertChannelType(request.Type) == entities.AlertChannelTypeSMS {
+		if msg := h.billingService.IsEntitled(ctx, h.userIDFomContext(c)); msg != nil {
+			ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] can't create an SMS alert channel", h.userIDFomContext(c))))
+			return h.responsePaymentRequired(c, *msg)
+		}
+	}
+
+	channel, err := h.service.Store(ctx, request.ToStoreParams(h.userIDFomContext(c)))
+	if err != nil {
+		msg := fmt.Sprintf("cannot store alert channel with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	if !channel.IsVerified() {
+		return h.responseCreated(c, fmt.Sprintf("alert channel created successfully, alerts will be sent after the link which was emailed to %s is clicked", channel.Target), channel)
+	}
+
+	return h.responseCreated(c, "alert channel created successfully", channel)
+}
+
+// Verify verifies the email address of an alert channel using the token in the link which was sent by email.
+// It is not under the /v1 prefix because the request is authenticated by the token and not by an API key.
+func (h *AlertChannelHandler) Verify(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	channelID := c.Params("channelID")
+	if errors := h.validator.ValidateUUID(ctx, channelID, "channelID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while verifying alert channel with ID [%s]", spew.Sdump(errors), channelID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while verifying alert channel")
+	}
+
+	channel, err := h.service.Verify(ctx, uuid.MustParse(channelID), c.Query("token"))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find alert channel with ID [%s]", channelID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot verify alert channel with ID [%s]", channelID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("alerts will be sent to %s", channel.Target), channel)
+}
+
+// Delete an alert channel
+// @Summary      Delete an alert channel
+// @Description  Delete an alert channel of a user
+// @Security	 ApiKeyAuth
+// @Tags         Alerts
+// @Accept       json
+// @Produce      json
+// @Param 		 channelID 	path		string 	true 	"ID of the alert channel"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      204		{object}    responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403	    {object}	responses.Forbidden
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /alert-channels/{channelID} [delete]
+func (h *AlertChannelHandler) Delete(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	channelID := c.Params("channelID")
+	if errors := h.validator.ValidateUUID(ctx, channelID, "channelID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting alert channel with ID [%s]", spew.Sdump(errors), channelID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting alert channel")
+	}
+
+	if !h.canManage(c) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot delete alert channels", h.actingUserFromContext(c).ID)))
+		return h.responseForbidden(c)
+	}
+
+	err := h.service.Delete(ctx, h.userIDFomContext(c), uuid.MustParse(channelID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find alert channel with ID [%s]", channelID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete alert channel with ID [%s]", channelID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "alert channel deleted successfully")
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// APIKeyHandler handles api key requests
+type APIKeyHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	validator *validators.APIKeyHandlerValidator
+	service   *services.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new APIKeyHandler
+func NewAPIKeyHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	validator *validators.APIKeyHandlerValidator,
+	service *services.APIKeyService,
+) (h *APIKeyHandler) {
+	return &APIKeyHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		validator: validator,
+		service:   service,
+	}
+}
+
+// RegisterRoutes registers the routes for the APIKeyHandler
+func (h *APIKeyHandler) RegisterRoutes(router fiber.Router) {
+	router.Get("/api-keys", h.requireFullAccess(h.Index))
+	router.Post("/api-keys", h.requireFullAccess(h.Store))
+	router.Delete("/api-keys/:apiKeyID", h.requireFullAccess(h.Delete))
+}
+
+// Index returns the api keys of a user
+// @Summary      Get api keys of a user
+// @Description  Get the named API keys of the authenticated user
+// @Security	 ApiKeyAuth
+// @Tags         APIKeys
+// @Produce      json
+// @Param        skip		query  int  	false	"number of api keys to skip"		minimum(0)
+// @Param        query		query  string  	false 	"filter api keys containing query"
+// @Param        limit		query  int  	false	"number of api keys to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.APIKeysResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 403	    {object}	responses.Forbidden
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /api-keys 	[get]
+func (h *APIKeyHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	if !h.isAccountOwner(c) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot access the api keys of the organization owner", h.actingUserFromContext(c).ID)))
+		return h.responseForbidden(c)
+	}
+
+	var request requests.APIKeyIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching api keys [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching api keys")
+	}
+
+	apiKeys, err := h.service.Index(ctx, h.userIDFomContext(c), request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get api keys with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(apiKeys), h.pluralize("api key", len(apiKeys))), apiKeys)
+}
+
+// Store an api key
+// @Summary      Store an api key
+// @Description  Create a named API key with limited scopes and an optional expiry date
+// @Security	 ApiKeyAuth
+// @Tags         APIKeys
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.APIKeyStore  		true "Payload of the api key request"
+// @Success      201 		{object}	responses.APIKeyResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 403	    {object}	responses.Forbidden
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /api-keys [post]
+func (h *APIKeyHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	if !h.isAccountOwner(c) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot create api keys for the organization owner", h.actingUserFromContext(c).ID)))
+		return h.responseForbidden(c)
+	}
+
+	var request requests.APIKeyStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body [%s] into [%T]", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateStore(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing api key [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing api key")
+	}
+
+	apiKey, err := h.service.Store(ctx, request.ToStoreParams(h.userFromContext(c)))
+	if err != nil {
+		msg := fmt.Sprintf("cannot store api key with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "api key created successfully", apiKey)
+}
+
+// Delete an api key
+// @Summary      Delete api key
+// @Description  Revoke a named API key of the authenticated user
+// @Security	 ApiKeyAuth
+// @Tags         APIKeys
+// @Produce      json
+// @Param 		 apiKeyID 	path		string 							true 	"ID of the api key"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      204		{object}    responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403	    {object}	responses.Forbidden
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /api-keys/{apiKeyID} [delete]
+func (h *APIKeyHandler) Delete(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	if !h.isAccountOwner(c) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot delete api keys of the organization owner", h.actingUserFromContext(c).ID)))
+		return h.responseForbidden(c)
+	}
+
+	apiKeyID := c.Params("apiKeyID")
+	if errors := h.validator.ValidateUUID(ctx, apiKeyID, "apiKeyID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting api key with ID [%s]", spew.Sdump(errors), apiKeyID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting api key")
+	}
+
+	err := h.service.Delete(ctx, h.userIDFomContext(c), uuid.MustParse(apiKeyID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find api key with ID [%s]", apiKeyID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete api key with ID [%s]", apiKeyID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "api key deleted successfully")
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/NdoleStudio/httpsms/pkg/entities"
 	"github.com/NdoleStudio/httpsms/pkg/requests"
 	"github.com/google/uuid"
 
@@ -44,7 +45,7 @@ func NewBulkMessageHandler(
 
 // RegisterRoutes registers the routes for the MessageHandler
 func (h *BulkMessageHandler) RegisterRoutes(router fiber.Router) {
-	router.Post("/bulk-messages", h.Store)
+	router.Post("/bulk-messages", h.requireScope(entities.APIKeyScopeMessagesSend, h.Store))
 }
 
 // Store sends bulk SMS messages from a CSV file.
@@ -54,9 +55,11 @@ func (h *BulkMessageHandler) RegisterRoutes(router fiber.Router) {
 // @Tags         BulkSMS
 // @Accept       json
 // @Produce      json
+// @Param        Idempotency-Key   header string false "Unique key used to safely retry the request without sending the messages twice"
 // @Success      202 		{object}	responses.NoContent
 // @Failure      400		{object}	responses.BadRequest
 // @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 403	    {object}	responses.Forbidden
 // @Failure      422		{object}	responses.UnprocessableEntity
 // @Failure      500		{object}	responses.InternalServerError
 // @Router       /bulk-messages [post]
@@ -78,6 +81,13 @@ func (h *BulkMessageHandler) Store(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, validationErrors, "validation errors while sending bulk SMS")
 	}
 
+	for _, message := range messages {
+		if !h.canAccessPhone(c, message.FromPhoneNumber) {
+			ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot send bulk SMS from phone [%s]", h.actingUserFromContext(c).ID, message.FromPhoneNumber)))
+			return h.responseForbidden(c)
+		}
+	}
+
 	if msg := h.billingService.IsEntitledWithCount(ctx, h.userIDFomContext(c), uint(len(messages))); msg != nil {
 		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] is not entitled to send [%d] messages", h.userIDFomContext(c), len(messages))))
 		return h.responsePaymentRequired(c, *msg)
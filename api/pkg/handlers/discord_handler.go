@@ -11,6 +11,7 @@ import (
 
 	"github.com/google/uuid"
 
+	"github.com/NdoleStudio/httpsms/pkg/entities"
 	"github.com/NdoleStudio/httpsms/pkg/repositories"
 	"github.com/NdoleStudio/httpsms/pkg/requests"
 	"github.com/NdoleStudio/httpsms/pkg/services"
@@ -60,10 +61,10 @@ func (h *DiscordHandler) RegisterRoutes(app *fiber.App, authMiddleware fiber.Han
 	router.Post("/event", h.computeRoute(middlewares, h.Event)...)
 
 	authRouter := app.Group("v1/discord-integrations")
-	authRouter.Post("/", h.computeRoute(append(middlewares, authMiddleware), h.Store)...)
-	authRouter.Get("/", h.computeRoute(append(middlewares, authMiddleware), h.Index)...)
-	authRouter.Delete("/:discordID", h.computeRoute(append(middlewares, authMiddleware), h.Delete)...)
-	authRouter.Put("/:discordID", h.computeRoute(append(middlewares, authMiddleware), h.Update)...)
+	authRouter.Post("/", h.computeRoute(append(middlewares, authMiddleware), h.requireScope(entities.APIKeyScopeWebhooksManage, h.Store))...)
+	authRouter.Get("/", h.computeRoute(append(middlewares, authMiddleware), h.requireScope(entities.APIKeyScopeWebhooksManage, h.Index))...)
+	authRouter.Delete("/:discordID", h.computeRoute(append(middlewares, authMiddleware), h.requireScope(entities.APIKeyScopeWebhooksManage, h.Delete))...)
+	authRouter.Put("/:discordID", h.computeRoute(append(middlewares, authMiddleware), h.requireScope(entities.APIKeyScopeWebhooksManage, h.Update))...)
 }
 
 // Index returns the discord integrations of a user
@@ -134,6 +135,11 @@ func (h *DiscordHandler) Delete(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting discord integration")
 	}
 
+	if !h.canManage(c) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot delete discord integrations", h.actingUserFromContext(c).ID)))
+		return h.responseForbidden(c)
+	}
+
 	err := h.service.Delete(ctx, h.userIDFomContext(c), uuid.MustParse(discordID))
 	if err != nil {
 		msg := fmt.Sprintf("cannot delete discord integration with ID [%+#v]", discordID)
@@ -177,6 +183,11 @@ func (h *DiscordHandler) Update(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, errors, "validation errors while updating discord integration")
 	}
 
+	if !h.canManage(c) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot update discord integrations", h.actingUserFromContext(c).ID)))
+		return h.responseForbidden(c)
+	}
+
 	user, err := h.service.Update(ctx, request.ToUpdateParams(h.userFromContext(c)))
 	if err != nil {
 		msg := fmt.Sprintf("cannot update discord integration with params [%+#v]", request)
@@ -220,6 +231,11 @@ func (h *DiscordHandler) Store(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, errors, "validation errors while storing discord integration")
 	}
 
+	if !h.canManage(c) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot create discord integrations", h.actingUserFromContext(c).ID)))
+		return h.responseForbidden(c)
+	}
+
 	discordIntegrations, err := h.service.Index(ctx, h.userIDFomContext(c), repositories.IndexParams{Skip: 0, Limit: 1})
 	if err != nil {
 		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot index discord integrations for user [%s]", h.userIDFomContext(c))))
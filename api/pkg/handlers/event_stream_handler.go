@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	eventStreamParamsKey   = "event-stream-params"
+	eventStreamKeepAlive   = 15 * time.Second
+	eventStreamWriteWindow = 10 * time.Second
+)
+
+// EventStreamHandler handles the SSE and WebSocket streams of the events of a user
+type EventStreamHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	validator *validators.EventStreamHandlerValidator
+	service   *services.EventStreamService
+}
+
+// NewEventStreamHandler creates a new EventStreamHandler
+func NewEventStreamHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	validator *validators.EventStreamHandlerValidator,
+	service *services.EventStreamService,
+) (h *EventStreamHandler) {
+	return &EventStreamHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		validator: validator,
+		service:   service,
+	}
+}
+
+// RegisterRoutes registers the routes for the EventStreamHandler
+func (h *EventStreamHandler) RegisterRoutes(router fiber.Router) {
+	router.Get("/events/stream", h.requireScope(entities.APIKeyScopeMessagesRead, h.Stream))
+	router.Get("/events/ws", h.requireScope(entities.APIKeyScopeMessagesRead, h.Upgrade), websocket.New(h.WebSocket))
+}
+
+// Stream pushes the events of a user as server-sent events
+// @Summary      Stream events
+// @Description  Stream the events of the authenticated user as server-sent events. The same events are available over a WebSocket connection at `/v1/events/ws`.
+// @Security	 ApiKeyAuth
+// @Tags         Events
+// @Produce      text/event-stream
+// @Param        types		query  string  	false 	"the event types"	default(message.phone.received)
+// @Param        owners		query  string  	false 	"the owner's phone numbers" 		default(+18005550199,+18005550100)
+// @Success      200 		{string}	string
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403	    {object}	responses.Forbidden
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      429		{object}	responses.TooManyRequests
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /events/stream [get]
+func (h *EventStreamHandler) Stream(c *fiber.Ctx) error {
+	_, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	params, err := h.params(c, ctxLogger)
+	if params == nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := h.service.Subscribe(ctx, params)
+	if stacktrace.GetCode(err) == services.ErrCodeTooManyStreams {
+		cancel()
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot stream the events of user [%s]", params.UserID)))
+		return h.responseTooManyRequests(c, "you have too many concurrent streams, close a stream and try again")
+	}
+
+	if err != nil {
+		cancel()
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot subscribe to the events of user [%s]", params.UserID)))
+		return h.responseInternalServerError(c)
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(writer *bufio.Writer) {
+		defer cancel()
+
+		ticker := time.NewTicker(eventStreamKeepAlive)
+		defer ticker.Stop()
+
+		_, _ = writer.WriteString(": connected\n\n")
+		for {
+			if err := writer.Flush(); err != nil {
+				ctxLogger.Info(fmt.Sprintf("closing event stream of user [%s]: %s", params.UserID, err.Error()))
+				return
+			}
+
+			select {
+			case event, ok := <-stream:
+				if !ok {
+					return
+				}
+				data, err := json.Marshal(event)
+				if err != nil {
+					ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot marshal [%s] event with ID [%s]", event.Type(), event.ID())))
+					continue
+				}
+				_, _ = fmt.Fprintf(writer, "id: %s\nevent: %s\ndata: %s\n\n", event.ID(), event.Type(), data)
+			case <-ticker.C:
+				_, _ = writer.WriteString(": keep-alive\n\n")
+			}
+		}
+	})
+
+	return nil
+}
+
+// Upgrade validates a WebSocket request before the connection is upgraded
+func (h *EventStreamHandler) Upgrade(c *fiber.Ctx) error {
+	_, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	if !websocket.IsWebSocketUpgrade(c) {
+		return fiber.ErrUpgradeRequired
+	}
+
+	params, err := h.params(c, ctxLogger)
+	if params == nil {
+		return err
+	}
+
+	c.Locals(eventStreamParamsKey, params)
+	return c.Next()
+}
+
+// WebSocket pushes the events of a user as JSON messages on a WebSocket connection
+func (h *EventStreamHandler) WebSocket(conn *websocket.Conn) {
+	params, ok := conn.Locals(eventStreamParamsKey).(*services.EventStreamParams)
+	if !ok {
+		h.logger.Error(stacktrace.NewError(fmt.Sprintf("cannot find [%T] in the websocket locals", params)))
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := h.service.Subscribe(ctx, params)
+	if stacktrace.GetCode(err) == services.ErrCodeTooManyStreams {
+		h.logger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot stream the events of user [%s]", params.UserID)))
+		_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too many concurrent streams"), time.Now().Add(eventStreamWriteWindow))
+		return
+	}
+
+	if err != nil {
+		h.logger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot subscribe to the events of user [%s]", params.UserID)))
+		_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, ""), time.Now().Add(eventStreamWriteWindow))
+		return
+	}
+
+	// Messages from the client are ignored, reading is only needed to detect when the connection is closed.
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(eventStreamKeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-stream:
+			if !ok {
+				return
+			}
+			if err = conn.WriteJSON(event); err != nil {
+				h.logger.Info(fmt.Sprintf("closing websocket event stream of user [%s]: %s", params.UserID, err.Error()))
+				return
+			}
+		case <-ticker.C:
+			if err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(eventStreamWriteWindow)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// params parses and authorizes the filters of a stream. A nil result means the error response has been sent.
+func (h *EventStreamHandler) params(c *fiber.Ctx, ctxLogger telemetry.Logger) (*services.EventStreamParams, error) {
+	var request requests.EventStream
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params in [%s] into [%T]", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return nil, h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateStream(c.UserContext(), request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while streaming events [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return nil, h.responseUnprocessableEntity(c, errors, "validation errors while streaming events")
+	}
+
+	if !h.restrictOwners(c, &request) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot stream events of phones [%s]", h.actingUserFromContext(c).ID, request.Owners)))
+		return nil, h.responseForbidden(c)
+	}
+
+	return request.ToEventStreamParams(h.userIDFomContext(c)), nil
+}
+
+// restrictOwners limits the stream of an organization member to the phones which are granted to the member
+func (h *EventStreamHandler) restrictOwners(c *fiber.Ctx, request *requests.EventStream) bool {
+	if member := h.memberFromContext(c); member != nil && !member.Role.CanManage() && len(request.Owners) == 0 {
+		for _, grant := range member.PhoneGrants {
+			request.Owners = append(request.Owners, grant.Owner)
+		}
+		if len(request.Owners) == 0 {
+			return false
+		}
+	}
+
+	for _, owner := range request.Owners {
+		if !h.canAccessPhone(c, owner) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"fmt"
 	"net/url"
 
 	"github.com/NdoleStudio/httpsms/pkg/entities"
 	"github.com/NdoleStudio/httpsms/pkg/middlewares"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -57,6 +59,20 @@ func (h *handler) responseNotFound(c *fiber.Ctx, message string) error {
 	})
 }
 
+func (h *handler) responseConflict(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+		"status":  "error",
+		"message": message,
+	})
+}
+
+func (h *handler) responseTooManyRequests(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"status":  "error",
+		"message": message,
+	})
+}
+
 func (h *handler) responsePaymentRequired(c *fiber.Ctx, message string) error {
 	return c.Status(fiber.StatusPaymentRequired).JSON(fiber.Map{
 		"status":  "error",
@@ -86,6 +102,22 @@ func (h *handler) responseOK(c *fiber.Ctx, message string, data interface{}) err
 	})
 }
 
+// responseOKWithCursor returns a page of data with the cursor of the next page which is null on the last page
+func (h *handler) responseOKWithCursor(c *fiber.Ctx, message string, data interface{}, cursor *repositories.Cursor) error {
+	var nextCursor *string
+	if cursor != nil {
+		value := cursor.String()
+		nextCursor = &value
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":      "success",
+		"message":     message,
+		"data":        data,
+		"next_cursor": nextCursor,
+	})
+}
+
 func (h *handler) responseCreated(c *fiber.Ctx, message string, data interface{}) error {
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"status":  "success",
@@ -112,6 +144,87 @@ func (h *handler) userIDFomContext(c *fiber.Ctx) entities.UserID {
 	return h.userFromContext(c).ID
 }
 
+// memberFromContext returns the acting entities.OrganizationMember or nil if the request is not made on behalf of an organization
+func (h *handler) memberFromContext(c *fiber.Ctx) *entities.OrganizationMember {
+	if member, ok := c.Locals(middlewares.ContextKeyOrganizationMember).(*entities.OrganizationMember); ok {
+		return member
+	}
+	return nil
+}
+
+// actingUserFromContext returns the user making the request even when acting on behalf of an organization
+func (h *handler) actingUserFromContext(c *fiber.Ctx) entities.AuthUser {
+	if member := h.memberFromContext(c); member != nil {
+		return entities.AuthUser{ID: member.UserID, Email: h.userFromContext(c).Email}
+	}
+	return h.userFromContext(c)
+}
+
+// canManage checks if the acting user can manage phones, integrations and settings
+func (h *handler) canManage(c *fiber.Ctx) bool {
+	member := h.memberFromContext(c)
+	return member == nil || member.Role.CanManage()
+}
+
+// isAccountOwner checks if the acting user owns the account which the resources belong to
+func (h *handler) isAccountOwner(c *fiber.Ctx) bool {
+	member := h.memberFromContext(c)
+	return member == nil || member.Role == entities.OrganizationRoleOwner
+}
+
+// canAccessPhone checks if the acting user has access to the phone with the owner phone number
+func (h *handler) canAccessPhone(c *fiber.Ctx, owner string) bool {
+	member := h.memberFromContext(c)
+	return member == nil || member.CanAccessPhone(owner)
+}
+
+// hasScope checks if the API key used to authenticate the request has the entities.APIKeyScope
+func (h *handler) hasScope(c *fiber.Ctx, scope entities.APIKeyScope) bool {
+	scopes, ok := c.Locals(middlewares.ContextKeyAPIKeyScopes).([]entities.APIKeyScope)
+	if !ok || scopes == nil {
+		return true
+	}
+
+	for _, value := range scopes {
+		if value == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// isScopedAPIKey checks if the request is authenticated with a scoped entities.APIKey
+func (h *handler) isScopedAPIKey(c *fiber.Ctx) bool {
+	scopes, ok := c.Locals(middlewares.ContextKeyAPIKeyScopes).([]entities.APIKeyScope)
+	return ok && scopes != nil
+}
+
+// requireScope wraps a route so that it is only accessible with an API key having the entities.APIKeyScope
+func (h *handler) requireScope(scope entities.APIKeyScope, route fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !h.hasScope(c, scope) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"status":  "error",
+				"message": fmt.Sprintf("The API key used for this request does not have the [%s] scope", scope),
+			})
+		}
+		return route(c)
+	}
+}
+
+// requireFullAccess wraps a route so that it is not accessible with a scoped entities.APIKey
+func (h *handler) requireFullAccess(route fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if h.isScopedAPIKey(c) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"status":  "error",
+				"message": "This request cannot be carried out with a scoped API key",
+			})
+		}
+		return route(c)
+	}
+}
+
 func (h *handler) computeRoute(middlewares []fiber.Handler, route fiber.Handler) []fiber.Handler {
 	return append(append([]fiber.Handler{}, middlewares...), route)
 }
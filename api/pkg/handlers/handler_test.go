@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/middlewares"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hirosassa/zerodriver"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testGrantedPhone    = "+18005550199"
+	testRestrictedPhone = "+18005550100"
+)
+
+func testLoggerAndTracer() (telemetry.Logger, telemetry.Tracer) {
+	nop := zerolog.Nop()
+	logger := telemetry.NewZerologLogger("test", nil, &zerodriver.Logger{Logger: &nop}, nil)
+	return logger, telemetry.NewOtelLogger("test", logger)
+}
+
+// testAgentApp creates a fiber.App where requests are authenticated as an agent who only has access to testGrantedPhone
+func testAgentApp() *fiber.App {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals(middlewares.ContextKeyAuthUserID, entities.AuthUser{ID: "owner-user-id", Email: "owner@example.com"})
+		c.Locals(middlewares.ContextKeyOrganizationMember, &entities.OrganizationMember{
+			UserID: "agent-user-id",
+			Email:  "agent@example.com",
+			Role:   entities.OrganizationRoleAgent,
+			PhoneGrants: []entities.OrganizationPhoneGrant{
+				{Owner: testGrantedPhone},
+			},
+		})
+		return c.Next()
+	})
+	return app
+}
+
+func testRequest(t *testing.T, app *fiber.App, method string, target string, body string) *http.Response {
+	var reader io.Reader
+	if body != "" {
+		reader = bytes.NewBufferString(body)
+	}
+
+	request := httptest.NewRequest(method, target, reader)
+	request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	return response
+}
+
+func TestHeartbeatHandler_PhoneAccess(t *testing.T) {
+	logger, tracer := testLoggerAndTracer()
+	app := testAgentApp()
+	NewHeartbeatHandler(logger, tracer, validators.NewHeartbeatHandlerValidator(logger, tracer), nil).RegisterRoutes(app)
+
+	t.Run("agent cannot list heartbeats of a phone which is not granted", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		response := testRequest(t, app, fiber.MethodGet, "/heartbeats?owner=%2B18005550100&limit=10&skip=0", "")
+
+		// Assert
+		assert.Equal(t, fiber.StatusForbidden, response.StatusCode)
+	})
+
+	t.Run("agent cannot store heartbeats of a phone which is not granted", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		response := testRequest(t, app, fiber.MethodPost, "/heartbeats", `{"owner":"`+testRestrictedPhone+`","charging":true}`)
+
+		// Assert
+		assert.Equal(t, fiber.StatusForbidden, response.StatusCode)
+	})
+}
+
+func TestMessageThreadHandler_PhoneAccess(t *testing.T) {
+	logger, tracer := testLoggerAndTracer()
+	app := testAgentApp()
+	NewMessageThreadHandler(logger, tracer, validators.NewMessageThreadHandlerValidator(logger, tracer), nil).RegisterRoutes(app)
+
+	t.Run("agent cannot list threads of a phone which is not granted", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		response := testRequest(t, app, fiber.MethodGet, "/message-threads?owner=%2B18005550100&limit=10&skip=0&is_archived=false", "")
+
+		// Assert
+		assert.Equal(t, fiber.StatusForbidden, response.StatusCode)
+	})
+}
+
+func TestMessageHandler_PhoneAccess(t *testing.T) {
+	logger, tracer := testLoggerAndTracer()
+	app := testAgentApp()
+	NewMessageHandler(logger, tracer, validators.NewMessageHandlerValidator(logger, tracer, nil), nil, nil).RegisterRoutes(app)
+
+	t.Run("agent cannot receive messages on a phone which is not granted", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		response := testRequest(t, app, fiber.MethodPost, "/messages/receive", `{"from":"+18005550111","to":"`+testRestrictedPhone+`","content":"hello","sim":"SIM1"}`)
+
+		// Assert
+		assert.Equal(t, fiber.StatusForbidden, response.StatusCode)
+	})
+
+	t.Run("agent cannot store missed calls on a phone which is not granted", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		response := testRequest(t, app, fiber.MethodPost, "/messages/calls/missed", `{"from":"+18005550111","to":"`+testRestrictedPhone+`","sim":"SIM1","timestamp":"2022-06-05T14:26:09.527976+03:00"}`)
+
+		// Assert
+		assert.Equal(t, fiber.StatusForbidden, response.StatusCode)
+	})
+}
+
+// testScopedAPIKeyApp creates a fiber.App where requests are authenticated with a scoped API key which can only read messages
+func testScopedAPIKeyApp() *fiber.App {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		apiKeyID := uuid.New()
+		c.Locals(middlewares.ContextKeyAuthUserID, entities.AuthUser{ID: "user-id", Email: "name@example.com", APIKeyID: &apiKeyID})
+		c.Locals(middlewares.ContextKeyAPIKeyScopes, []entities.APIKeyScope{entities.APIKeyScopeMessagesRead})
+		return c.Next()
+	})
+	return app
+}
+
+func TestMessageHandler_APIKeyScopes(t *testing.T) {
+	logger, tracer := testLoggerAndTracer()
+	app := testScopedAPIKeyApp()
+	NewMessageHandler(logger, tracer, validators.NewMessageHandlerValidator(logger, tracer, nil), nil, nil).RegisterRoutes(app)
+
+	t.Run("scoped api key cannot send messages without the messages:send scope", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		response := testRequest(t, app, fiber.MethodPost, "/messages/send", `{"from":"`+testGrantedPhone+`","to":"+18005550111","content":"hello"}`)
+
+		// Assert
+		assert.Equal(t, fiber.StatusForbidden, response.StatusCode)
+	})
+}
+
+func TestAPIKeyHandler_APIKeyScopes(t *testing.T) {
+	logger, tracer := testLoggerAndTracer()
+	app := testScopedAPIKeyApp()
+	NewAPIKeyHandler(logger, tracer, validators.NewAPIKeyHandlerValidator(logger, tracer), nil).RegisterRoutes(app)
+
+	t.Run("scoped api key cannot create api keys", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		response := testRequest(t, app, fiber.MethodPost, "/api-keys", `{"name":"escalated","scopes":["messages:send"]}`)
+
+		// Assert
+		assert.Equal(t, fiber.StatusForbidden, response.StatusCode)
+	})
+
+	t.Run("scoped api key cannot list api keys", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		response := testRequest(t, app, fiber.MethodGet, "/api-keys", "")
+
+		// Assert
+		assert.Equal(t, fiber.StatusForbidden, response.StatusCode)
+	})
+}
@@ -3,6 +3,8 @@ package handlers
 import (
 	"fmt"
 
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
 	"github.com/NdoleStudio/httpsms/pkg/requests"
 	"github.com/NdoleStudio/httpsms/pkg/services"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
@@ -39,7 +41,8 @@ func NewHeartbeatHandler(
 // RegisterRoutes registers the routes for the MessageHandler
 func (h *HeartbeatHandler) RegisterRoutes(router fiber.Router) {
 	router.Get("/heartbeats", h.Index)
-	router.Post("/heartbeats", h.Store)
+	router.Post("/heartbeats", h.requireScope(entities.APIKeyScopeHeartbeatsWrite, h.Store))
+	router.Put("/heartbeats/monitor", h.requireScope(entities.APIKeyScopePhonesWrite, h.UpdateMonitor))
 }
 
 // Index returns the heartbeats of a phone number
@@ -53,9 +56,13 @@ func (h *HeartbeatHandler) RegisterRoutes(router fiber.Router) {
 // @Param        skip		query  int  	false	"number of heartbeats to skip"		minimum(0)
 // @Param        query		query  string  	false 	"filter containing query"
 // @Param        limit		query  int  	false	"number of heartbeats to return"	minimum(1)	maximum(20)
+// @Param        cursor		query  string  	false 	"cursor from the next_cursor field of the previous page"
+// @Param        created_after	query  string  	false 	"only return heartbeats sent at or after this RFC3339 timestamp"
+// @Param        created_before	query  string  	false 	"only return heartbeats sent before this RFC3339 timestamp"
 // @Success      200 		{object}	responses.HeartbeatsResponse
 // @Failure      400		{object}	responses.BadRequest
 // @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 403	    {object}	responses.Forbidden
 // @Failure      422		{object}	responses.UnprocessableEntity
 // @Failure      500		{object}	responses.InternalServerError
 // @Router       /heartbeats [get]
@@ -78,14 +85,25 @@ func (h *HeartbeatHandler) Index(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching heartbeats")
 	}
 
-	heartbeats, err := h.service.Index(ctx, h.userIDFomContext(c), request.Owner, request.ToIndexParams())
+	if !h.canAccessPhone(c, request.Owner) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot access phone [%s]", h.actingUserFromContext(c).ID, request.Owner)))
+		return h.responseForbidden(c)
+	}
+
+	params := request.ToIndexParams()
+	heartbeats, err := h.service.Index(ctx, h.userIDFomContext(c), request.Owner, params)
 	if err != nil {
 		msg := fmt.Sprintf("cannot get messgaes with params [%+#v]", request)
 		ctxLogger.Error(stacktrace.Propagate(err, msg))
 		return h.responseInternalServerError(c)
 	}
 
-	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(*heartbeats), h.pluralize("heartbeat", len(*heartbeats))), heartbeats)
+	var cursor *repositories.Cursor
+	if count := len(*heartbeats); count > 0 && count == params.Limit {
+		cursor = repositories.NewCursor((*heartbeats)[count-1].Timestamp, (*heartbeats)[count-1].ID)
+	}
+
+	return h.responseOKWithCursor(c, fmt.Sprintf("fetched %d %s", len(*heartbeats), h.pluralize("heartbeat", len(*heartbeats))), heartbeats, cursor)
 }
 
 // Store the heartbeat of a phone number
@@ -99,6 +117,7 @@ func (h *HeartbeatHandler) Index(c *fiber.Ctx) error {
 // @Success      200 		{object}	responses.HeartbeatResponse
 // @Failure      400		{object}	responses.BadRequest
 // @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 403	    {object}	responses.Forbidden
 // @Failure      422		{object}	responses.UnprocessableEntity
 // @Failure      500		{object}	responses.InternalServerError
 // @Router       /heartbeats [post]
@@ -121,6 +140,11 @@ func (h *HeartbeatHandler) Store(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, errors, "validation errors while storing heartbeat")
 	}
 
+	if !h.canAccessPhone(c, request.Owner) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot access phone [%s]", h.actingUserFromContext(c).ID, request.Owner)))
+		return h.responseForbidden(c)
+	}
+
 	heartbeat, err := h.service.Store(ctx, request.ToStoreParams(h.userFromContext(c), c.OriginalURL(), c.Get("X-Client-Version")))
 	if err != nil {
 		msg := fmt.Sprintf("cannot store heartbeat with params [%+#v]", request)
@@ -130,3 +154,55 @@ func (h *HeartbeatHandler) Store(c *fiber.Ctx) error {
 
 	return h.responseCreated(c, "heartbeat created successfully", heartbeat)
 }
+
+// UpdateMonitor updates the heartbeat interval and offline thresholds of a phone
+// @Summary      Update the heartbeat monitor of a phone
+// @Description  Set the expected interval between heartbeats, the number of missed intervals before the phone is pushed to send a heartbeat and the number of missed intervals before the phone is considered offline.
+// @Security	 ApiKeyAuth
+// @Tags         Heartbeats
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.HeartbeatMonitorUpdate  	true "Payload of the heartbeat monitor settings"
+// @Success      200 		{object}	responses.HeartbeatMonitorResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 403	    {object}	responses.Forbidden
+// @Failure 	 404	    {object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /heartbeats/monitor [put]
+func (h *HeartbeatHandler) UpdateMonitor(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.HeartbeatMonitorUpdate
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateMonitorUpdate(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while updating heartbeat monitor [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating heartbeat monitor")
+	}
+
+	if !h.canAccessPhone(c, request.Owner) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot update the heartbeat monitor of phone [%s]", h.actingUserFromContext(c).ID, request.Owner)))
+		return h.responseForbidden(c)
+	}
+
+	monitor, err := h.service.UpdateMonitor(ctx, request.ToUpdateParams(h.userIDFomContext(c), c.OriginalURL()))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find heartbeat monitor for phone [%s]", request.Owner))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot update heartbeat monitor with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "heartbeat monitor updated successfully", monitor)
+}
@@ -51,6 +51,7 @@ func (h *Integration3CXHandler) RegisterRoutes(app *fiber.App, middlewares ...fi
 // @Success      204 		{object}	responses.NoContent
 // @Failure      400		{object}	responses.BadRequest
 // @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
 // @Failure      422		{object}	responses.UnprocessableEntity
 // @Failure      500		{object}	responses.InternalServerError
 // @Router       /integration/3cx/messages [post]
@@ -73,6 +74,11 @@ func (h *Integration3CXHandler) Messages(c *fiber.Ctx) error {
 	}
 
 	request.Sanitize()
+	if !h.canAccessPhone(c, request.From) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot send a [3cx] message from phone [%s]", h.actingUserFromContext(c).ID, request.From)))
+		return h.responseForbidden(c)
+	}
+
 	message, err := h.messageService.SendMessage(ctx, request.ToMessageSendParams(h.userIDFomContext(c), c.OriginalURL()))
 	if err != nil {
 		msg := fmt.Sprintf("cannot send [3cx] message with paylod [%s]", c.Body())
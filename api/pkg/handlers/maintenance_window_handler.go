@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// MaintenanceWindowHandler handles the maintenance windows of phones
+type MaintenanceWindowHandler struct {
+	handler
+	logger       telemetry.Logger
+	tracer       telemetry.Tracer
+	validator    *validators.MaintenanceWindowHandlerValidator
+	service      *services.MaintenanceWindowService
+	phoneService *services.PhoneService
+}
+
+// NewMaintenanceWindowHandler creates a new MaintenanceWindowHandler
+func NewMaintenanceWindowHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	validator *validators.MaintenanceWindowHandlerValidator,
+	service *services.MaintenanceWindowService,
+	phoneService *services.PhoneService,
+) (h *MaintenanceWindowHandler) {
+	return &MaintenanceWindowHandler{
+		logger:       logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:       tracer,
+		validator:    validator,
+		service:      service,
+		phoneService: phoneService,
+	}
+}
+
+// RegisterRoutes registers the routes for the MaintenanceWindowHandler
+func (h *MaintenanceWindowHandler) RegisterRoutes(router fiber.Router) {
+	router.Get("/phones/:phoneID/maintenance-windows", h.Index)
+	router.Post("/phones/:phoneID/maintenance-windows", h.requireScope(entities.APIKeyScopePhonesWrite, h.Store))
+	router.Delete("/phones/:phoneID/maintenance-windows/:windowID", h.requireScope(entities.APIKeyScopePhonesWrite, h.Delete))
+}
+
+// Index returns the maintenance windows of a phone
+// @Summary      Get maintenance windows of a phone
+// @Description  Get the scheduled, ad-hoc and recurring maintenance windows of a phone
+// @Security	 ApiKeyAuth
+// @Tags         Phones
+// @Accept       json
+// @Produce      json
+// @Param 		 phoneID 	path		string 	true 	"ID of the phone"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      200 		{object}	responses.MaintenanceWindowsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403	    {object}	responses.Forbidden
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phones/{phoneID}/maintenance-windows [get]
+func (h *MaintenanceWindowHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	phone, err := h.phone(ctx, c, ctxLogger)
+	if phone == nil {
+		return err
+	}
+
+	windows, err := h.service.Index(ctx, h.userIDFomContext(c), phone.ID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch maintenance windows of phone [%s]", phone.ID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(windows), h.pluralize("maintenance window", len(windows))), windows)
+}
+
+// Store a maintenance window of a phone
+// @Summary      Store a maintenance window
+// @Description  Schedule a maintenance window for a phone. Offline heartbeat events and emails are suppressed and messages are not expired while the phone is in maintenance. An ad-hoc maintenance window starts immediately when `starts_at` is empty.
+// @Security	 ApiKeyAuth
+// @Tags         Phones
+// @Accept       json
+// @Produce      json
+// @Param 		 phoneID 	path		string 							true 	"ID of the phone"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.MaintenanceWindowStore true 	"Payload of the maintenance window"
+// @Success      201 		{object}	responses.MaintenanceWindowResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403	    {object}	responses.Forbidden
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phones/{phoneID}/maintenance-windows [post]
+func (h *MaintenanceWindowHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.MaintenanceWindowStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+	request.PhoneID = c.Params("phoneID")
+
+	if errors := h.validator.ValidateStore(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing maintenance window [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing maintenance window")
+	}
+
+	phone, err := h.phone(ctx, c, ctxLogger)
+	if phone == nil {
+		return err
+	}
+
+	window, err := h.service.Store(ctx, request.ToStoreParams(h.userIDFomContext(c), phone))
+	if err != nil {
+		msg := fmt.Sprintf("cannot store maintenance window with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "maintenance window created successfully", window)
+}
+
+// Delete a maintenance window of a phone
+// @Summary      Delete a maintenance window
+// @Description  Delete a maintenance window of a phone. Heartbeat alerts resume immediately for a maintenance window which is in progress.
+// @Security	 ApiKeyAuth
+// @Tags         Phones
+// @Accept       json
+// @Produce      json
+// @Param 		 phoneID 	path		string 	true 	"ID of the phone"				default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param 		 windowID 	path		string 	true 	"ID of the maintenance window"	default(32343a19-da5e-4b1b-a767-3298a73703cb)
+// @Success      204		{object}    responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403	    {object}	responses.Forbidden
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phones/{phoneID}/maintenance-windows/{windowID} [delete]
+func (h *MaintenanceWindowHandler) Delete(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	windowID := c.Params("windowID")
+	if errors := h.validator.ValidateUUID(ctx, windowID, "windowID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting maintenance window with ID [%s]", spew.Sdump(errors), windowID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting maintenance window")
+	}
+
+	phone, err := h.phone(ctx, c, ctxLogger)
+	if phone == nil {
+		return err
+	}
+
+	err = h.service.Delete(ctx, h.userIDFomContext(c), phone.ID, uuid.MustParse(windowID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find maintenance window with ID [%s]", windowID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete maintenance window with ID [%s]", windowID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "maintenance window deleted successfully")
+}
+
+// phone loads and authorizes the phone in the path. A nil result means the error response has been sent.
+func (h *MaintenanceWindowHandler) phone(ctx context.Context, c *fiber.Ctx, ctxLogger telemetry.Logger) (*entities.Phone, error) {
+	phoneID := c.Params("phoneID")
+	if errors := h.validator.ValidateUUID(ctx, phoneID, "phoneID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while loading phone with ID [%s]", spew.Sdump(errors), phoneID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return nil, h.responseUnprocessableEntity(c, errors, "validation errors while loading phone")
+	}
+
+	phone, err := h.phoneService.LoadByID(ctx, h.userIDFomContext(c), uuid.MustParse(phoneID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return nil, h.responseNotFound(c, fmt.Sprintf("cannot find phone with ID [%s]", phoneID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone with ID [%s]", phoneID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return nil, h.responseInternalServerError(c)
+	}
+
+	if !h.canAccessPhone(c, phone.PhoneNumber) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot access phone [%s]", h.actingUserFromContext(c).ID, phone.PhoneNumber)))
+		return nil, h.responseForbidden(c)
+	}
+
+	return phone, nil
+}
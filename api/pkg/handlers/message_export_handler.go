@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// MessageExportHandler handles message export requests
+type MessageExportHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	validator *validators.MessageExportHandlerValidator
+	service   *services.MessageExportService
+}
+
+// NewMessageExportHandler creates a new MessageExportHandler
+func NewMessageExportHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	validator *validators.MessageExportHandlerValidator,
+	service *services.MessageExportService,
+) (h *MessageExportHandler) {
+	return &MessageExportHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		validator: validator,
+		service:   service,
+	}
+}
+
+// RegisterRoutes registers the routes for the MessageExportHandler
+func (h *MessageExportHandler) RegisterRoutes(router fiber.Router) {
+	router.Get("/messages/export", h.requireScope(entities.APIKeyScopeMessagesRead, h.Export))
+	router.Post("/message-exports", h.requireScope(entities.APIKeyScopeMessagesRead, h.Store))
+}
+
+// RegisterPublicRoutes registers the routes of the MessageExportHandler which are authenticated with the token in the download link
+func (h *MessageExportHandler) RegisterPublicRoutes(app *fiber.App) {
+	app.Get("/message-exports/:exportID/download", h.Download)
+}
+
+// Export streams the messages of a user
+// @Summary      Export messages
+// @Description  Stream all the messages which match the filters as a CSV, NDJSON or XLSX file.
+// @Security	 ApiKeyAuth
+// @Tags         Messages
+// @Produce      text/csv,application/x-ndjson,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param        format			query  string  	false 	"file format of the export"	Enums(csv, ndjson, xlsx)	default(csv)
+// @Param        owners			query  string  	false 	"the owner's phone numbers" 		default(+18005550199,+18005550100)
+// @Param        types			query  string  	false 	"the message types"	default(mobile-terminated)
+// @Param        statuses		query  string  	false 	"the message statuses"	default(delivered)
+// @Param        created_after	query  string  	false 	"only export messages created at or after this RFC3339 timestamp"
+// @Param        created_before	query  string  	false 	"only export messages created before this RFC3339 timestamp"
+// @Success      200 			{file}		file
+// @Failure      400			{object}	responses.BadRequest
+// @Failure 	 401    		{object}	responses.Unauthorized
+// @Failure 	 403	    	{object}	responses.Forbidden
+// @Failure      422			{object}	responses.UnprocessableEntity
+// @Failure      500			{object}	responses.InternalServerError
+// @Router       /messages/export [get]
+func (h *MessageExportHandler) Export(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.MessageExport
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params in [%s] into [%T]", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateExport(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while exporting messages [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while exporting messages")
+	}
+
+	if !h.restrictOwners(c, &request) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot export messages of phones [%s]", h.actingUserFromContext(c).ID, request.Owners)))
+		return h.responseForbidden(c)
+	}
+
+	params := request.ToExportParams(h.userFromContext(c))
+	return h.stream(c, params, fmt.Sprintf("messages-%s.%s", time.Now().UTC().Format("2006-01-02"), params.Format))
+}
+
+// Store an export of messages which is sent by email
+// @Summary      Export messages by email
+// @Description  Email a download link of the messages which match the filters. Use this for large exports which take too long to stream in a single request.
+// @Security	 ApiKeyAuth
+// @Tags         Messages
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.MessageExport  		true "Payload of the message export request"
+// @Success      201 		{object}	responses.MessageExportResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 403	    {object}	responses.Forbidden
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /message-exports [post]
+func (h *MessageExportHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.MessageExport
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body [%s] into [%T]", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateExport(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing message export [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing message export")
+	}
+
+	if !h.restrictOwners(c, &request) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot export messages of phones [%s]", h.actingUserFromContext(c).ID, request.Owners)))
+		return h.responseForbidden(c)
+	}
+
+	export, err := h.service.Store(ctx, c.OriginalURL(), request.ToExportParams(h.userFromContext(c)))
+	if err != nil {
+		msg := fmt.Sprintf("cannot store message export with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, fmt.Sprintf("the download link of the export will be sent to %s", export.Email), export)
+}
+
+// Download streams an export of messages using the token in the download link which was sent by email.
+// It is not under the /v1 prefix because the request is authenticated by the token and not by an API key.
+func (h *MessageExportHandler) Download(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	exportID := c.Params("exportID")
+	if errors := h.validator.ValidateUUID(ctx, exportID, "exportID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while downloading message export with ID [%s]", spew.Sdump(errors), exportID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while downloading message export")
+	}
+
+	export, err := h.service.LoadByToken(ctx, uuid.MustParse(exportID), c.Query("token"))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find message export with ID [%s]", exportID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load message export with ID [%s]", exportID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	if export.IsExpired(time.Now().UTC()) || !export.IsReady() {
+		return h.responseNotFound(c, fmt.Sprintf("the download link of the message export with ID [%s] has expired", exportID))
+	}
+
+	c.Set(fiber.HeaderContentType, export.Format.ContentType())
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, export.FileName()))
+	return c.SendStream(h.service.Download(ctx, export), int(export.Size))
+}
+
+// restrictOwners limits the export of an organization member to the phones which are granted to the member
+func (h *MessageExportHandler) restrictOwners(c *fiber.Ctx, request *requests.MessageExport) bool {
+	if member := h.memberFromContext(c); member != nil && !member.Role.CanManage() && len(request.Owners) == 0 {
+		for _, grant := range member.PhoneGrants {
+			request.Owners = append(request.Owners, grant.Owner)
+		}
+		if len(request.Owners) == 0 {
+			return false
+		}
+	}
+
+	for _, owner := range request.Owners {
+		if !h.canAccessPhone(c, owner) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// stream writes the export to a temporary file and sends the file when all the messages were exported
+// so that an error while exporting the messages is not sent as a truncated file.
+func (h *MessageExportHandler) stream(c *fiber.Ctx, params *services.MessageExportParams, fileName string) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	file, size, err := h.service.ExportFile(ctx, params)
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot export [%s] messages for user [%s]", params.Format, params.UserID)))
+		return h.responseInternalServerError(c)
+	}
+
+	c.Set(fiber.HeaderContentType, params.Format.ContentType())
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	return c.SendStream(file, int(size))
+}
@@ -49,15 +49,18 @@ func NewMessageHandler(
 
 // RegisterRoutes registers the routes for the MessageHandler
 func (h *MessageHandler) RegisterRoutes(router fiber.Router) {
-	router.Post("/messages/send", h.PostSend)
-	router.Post("/messages/bulk-send", h.BulkSend)
-	router.Post("/messages/receive", h.PostReceive)
-	router.Post("/messages/calls/missed", h.PostCallMissed)
-	router.Get("/messages/outstanding", h.GetOutstanding)
-	router.Get("/messages", h.Index)
-	router.Get("/messages/search", h.Search)
-	router.Post("/messages/:messageID/events", h.PostEvent)
-	router.Delete("/messages/:messageID", h.Delete)
+	router.Post("/messages/send", h.requireScope(entities.APIKeyScopeMessagesSend, h.PostSend))
+	router.Post("/messages/bulk-send", h.requireScope(entities.APIKeyScopeMessagesSend, h.BulkSend))
+	router.Post("/messages/receive", h.requireScope(entities.APIKeyScopePhonesWrite, h.PostReceive))
+	router.Post("/messages/calls/missed", h.requireScope(entities.APIKeyScopePhonesWrite, h.PostCallMissed))
+	router.Get("/messages/outstanding", h.requireScope(entities.APIKeyScopePhonesWrite, h.GetOutstanding))
+	router.Get("/messages/outstanding/poll", h.requireScope(entities.APIKeyScopePhonesWrite, h.PollOutstanding))
+	router.Get("/messages/outstanding/batch", h.requireScope(entities.APIKeyScopePhonesWrite, h.GetOutstandingBatch))
+	router.Get("/messages", h.requireScope(entities.APIKeyScopeMessagesRead, h.Index))
+	router.Get("/messages/search", h.requireScope(entities.APIKeyScopeMessagesRead, h.Search))
+	router.Post("/messages/batch-get", h.requireScope(entities.APIKeyScopeMessagesRead, h.BatchGet))
+	router.Post("/messages/:messageID/events", h.requireScope(entities.APIKeyScopePhonesWrite, h.PostEvent))
+	router.Delete("/messages/:messageID", h.requireScope(entities.APIKeyScopeMessagesSend, h.Delete))
 }
 
 // PostSend a new entities.Message
@@ -68,6 +71,7 @@ func (h *MessageHandler) RegisterRoutes(router fiber.Router) {
 // @Accept       json
 // @Produce      json
 // @Param        payload   body requests.MessageSend  true  "PostSend message request payload"
+// @Param        Idempotency-Key   header string false "Unique key used to safely retry the request without sending the messages twice"
 // @Success      200  {object}  responses.MessageResponse
 // @Failure      400  {object}  responses.BadRequest
 // @Failure 	 401  {object}	responses.Unauthorized
@@ -93,6 +97,11 @@ func (h *MessageHandler) PostSend(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, errors, "validation errors while sending message")
 	}
 
+	if !h.canAccessPhone(c, request.From) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot send messages from phone [%s]", h.actingUserFromContext(c).ID, request.From)))
+		return h.responseForbidden(c)
+	}
+
 	if msg := h.billingService.IsEntitled(ctx, h.userIDFomContext(c)); msg != nil {
 		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] can't send a message", h.userIDFomContext(c))))
 		return h.responsePaymentRequired(c, *msg)
@@ -116,6 +125,7 @@ func (h *MessageHandler) PostSend(c *fiber.Ctx) error {
 // @Accept       json
 // @Produce      json
 // @Param        payload   body requests.MessageBulkSend  true  "Bulk send message request payload"
+// @Param        Idempotency-Key   header string false "Unique key used to safely retry the request without sending the messages twice"
 // @Success      200  {object}  []responses.MessagesResponse
 // @Failure      400  {object}  responses.BadRequest
 // @Failure 	 401  {object}	responses.Unauthorized
@@ -141,6 +151,11 @@ func (h *MessageHandler) BulkSend(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, errors, "validation errors while sending messages")
 	}
 
+	if !h.canAccessPhone(c, request.From) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot send messages from phone [%s]", h.actingUserFromContext(c).ID, request.From)))
+		return h.responseForbidden(c)
+	}
+
 	if msg := h.billingService.IsEntitledWithCount(ctx, h.userIDFomContext(c), uint(len(request.To))); msg != nil {
 		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] is not entitled to send [%d] messages", h.userIDFomContext(c), len(request.To))))
 		return h.responsePaymentRequired(c, *msg)
@@ -178,6 +193,7 @@ func (h *MessageHandler) BulkSend(c *fiber.Ctx) error {
 // @Success      200 		{object}	responses.MessageResponse
 // @Failure      400		{object}	responses.BadRequest
 // @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
 // @Failure      422		{object}	responses.UnprocessableEntity
 // @Failure      500		{object}	responses.InternalServerError
 // @Router       /messages/outstanding [get]
@@ -201,6 +217,24 @@ func (h *MessageHandler) GetOutstanding(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching outstanding messages")
 	}
 
+	if member := h.memberFromContext(c); member != nil && !member.Role.CanManage() {
+		message, err := h.service.GetMessage(ctx, h.userIDFomContext(c), uuid.MustParse(request.MessageID))
+		if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+			return h.responseNotFound(c, fmt.Sprintf("cannot find message with ID [%s]", request.MessageID))
+		}
+
+		if err != nil {
+			msg := fmt.Sprintf("cannot find message with id [%s]", request.MessageID)
+			ctxLogger.Error(h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+			return h.responseInternalServerError(c)
+		}
+
+		if !h.canAccessPhone(c, message.Owner) {
+			ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot access phone [%s]", h.actingUserFromContext(c).ID, message.Owner)))
+			return h.responseForbidden(c)
+		}
+	}
+
 	message, err := h.service.GetOutstanding(ctx, request.ToGetOutstandingParams(c.Path(), h.userIDFomContext(c), timestamp))
 	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
 		msg := fmt.Sprintf("outstanding message with id [%s] already fetched", request.MessageID)
@@ -217,18 +251,134 @@ func (h *MessageHandler) GetOutstanding(c *fiber.Ctx) error {
 	return h.responseOK(c, "outstanding message fetched successfully", message)
 }
 
+// GetOutstandingBatch returns the entities.Message which are due to be sent by the mobile phone
+// @Summary      Get outstanding messages
+// @Description  Get up to `limit` outstanding messages to be sent by an android phone in a single request. The messages are marked as sending like `GET /messages/outstanding`. Use it when the phone has `coalesce_pushes` enabled.
+// @Security	 ApiKeyAuth
+// @Tags         Messages
+// @Accept       json
+// @Produce      json
+// @Param        owner		query  		string  						true 	"the phone number of the android phone" 	default(+18005550199)
+// @Param        limit		query  		int  							false	"maximum number of messages to return"		minimum(1)	maximum(50)	default(10)
+// @Success      200 		{object}	responses.MessagesResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /messages/outstanding/batch [get]
+func (h *MessageHandler) GetOutstandingBatch(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.MessageOutstandingBatch
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateMessageOutstandingBatch(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching outstanding messages [%s]", spew.Sdump(errors), c.OriginalURL())
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching outstanding messages")
+	}
+
+	if !h.canAccessPhone(c, request.Owner) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot fetch outstanding messages of phone [%s]", h.actingUserFromContext(c).ID, request.Owner)))
+		return h.responseForbidden(c)
+	}
+
+	messages, err := h.service.GetOutstandingBatch(ctx, request.ToGetOutstandingBatchParams(c.Path(), h.userIDFomContext(c), time.Now().UTC()))
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch outstanding messages of phone [%s]", request.Owner)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d outstanding %s", len(messages), h.pluralize("message", len(messages))), messages)
+}
+
+// PollOutstanding waits for an entities.Message which is due to be sent by the mobile phone
+// @Summary      Long-poll an outstanding message
+// @Description  Wait until a message is due to be sent by an android phone which does not use Firebase Cloud Messaging. The message is returned with the same semantics as `GET /messages/outstanding` and a 204 response is returned when no message is due before the timeout.
+// @Security	 ApiKeyAuth
+// @Tags         Messages
+// @Accept       json
+// @Produce      json
+// @Param        owner		query  		string  						true 	"the phone number of the android phone" 	default(+18005550199)
+// @Param        timeout	query  		int  							false	"seconds to wait for a message"		minimum(1)	maximum(60)	default(30)
+// @Success      200 		{object}	responses.MessageResponse
+// @Success      204		{object}	responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      429		{object}	responses.TooManyRequests
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /messages/outstanding/poll [get]
+func (h *MessageHandler) PollOutstanding(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.MessagePollOutstanding
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateMessagePollOutstanding(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while polling outstanding messages [%s]", spew.Sdump(errors), c.OriginalURL())
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while polling outstanding messages")
+	}
+
+	if !h.canAccessPhone(c, request.Owner) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot poll outstanding messages of phone [%s]", h.actingUserFromContext(c).ID, request.Owner)))
+		return h.responseForbidden(c)
+	}
+
+	message, err := h.service.PollOutstanding(ctx, request.ToPollOutstandingParams(c.Path(), h.userIDFomContext(c)))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find phone with number [%s]", request.Owner))
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeTooManyStreams {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot poll outstanding message of phone [%s]", request.Owner)))
+		return h.responseTooManyRequests(c, "you have too many concurrent streams, close a stream and try again")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot poll outstanding message of phone [%s]", request.Owner)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	if message == nil {
+		return h.responseNoContent(c, "no outstanding message")
+	}
+
+	return h.responseOK(c, "outstanding message fetched successfully", message)
+}
+
 // Index returns messages sent between 2 phone numbers
 // @Summary      Get messages which are sent between 2 phone numbers
-// @Description  Get list of messages which are sent between 2 phone numbers. It will be sorted by timestamp in descending order.
+// @Description  Get list of messages which are sent between 2 phone numbers or with the same request_id. It will be sorted by timestamp in descending order.
 // @Security	 ApiKeyAuth
 // @Tags         Messages
 // @Accept       json
 // @Produce      json
 // @Param        owner		query  string  	true 	"the owner's phone number" 			default(+18005550199)
 // @Param        contact	query  string  	true 	"the contact's phone number" 		default(+18005550100)
+// @Param        request_id	query  string  	false 	"fetch messages sent with this request_id instead of the owner and contact"
 // @Param        skip		query  int  	false	"number of messages to skip"		minimum(0)
 // @Param        query		query  string  	false 	"filter messages containing query"
 // @Param        limit		query  int  	false	"number of messages to return"		minimum(1)	maximum(20)
+// @Param        cursor		query  string  	false 	"cursor from the next_cursor field of the previous page"
+// @Param        created_after	query  string  	false 	"only return items created at or after this RFC3339 timestamp"
+// @Param        created_before	query  string  	false 	"only return items created before this RFC3339 timestamp"
 // @Success      200 		{object}	responses.MessagesResponse
 // @Failure      400		{object}	responses.BadRequest
 // @Failure 	 401    	{object}	responses.Unauthorized
@@ -254,14 +404,83 @@ func (h *MessageHandler) Index(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching messages")
 	}
 
-	messages, err := h.service.GetMessages(ctx, request.ToGetParams(h.userIDFomContext(c)))
+	if request.RequestID == "" && !h.canAccessPhone(c, request.Owner) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot read messages of phone [%s]", h.actingUserFromContext(c).ID, request.Owner)))
+		return h.responseForbidden(c)
+	}
+
+	params := request.ToGetParams(h.userIDFomContext(c))
+	messages, err := h.service.GetMessages(ctx, params)
 	if err != nil {
 		msg := fmt.Sprintf("cannot get messgaes with params [%+#v]", request)
 		ctxLogger.Error(stacktrace.Propagate(err, msg))
 		return h.responseInternalServerError(c)
 	}
 
-	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(*messages), h.pluralize("message", len(*messages))), messages)
+	var cursor *repositories.Cursor
+	if count := len(*messages); count > 0 && count == params.Limit {
+		cursor = repositories.NewCursor((*messages)[count-1].OrderTimestamp, (*messages)[count-1].ID)
+	}
+
+	if request.RequestID != "" {
+		accessible := make([]entities.Message, 0, len(*messages))
+		for _, message := range *messages {
+			if h.canAccessPhone(c, message.Owner) {
+				accessible = append(accessible, message)
+			}
+		}
+		messages = &accessible
+	}
+
+	return h.responseOKWithCursor(c, fmt.Sprintf("fetched %d %s", len(*messages), h.pluralize("message", len(*messages))), messages, cursor)
+}
+
+// BatchGet returns the messages with the given IDs
+// @Summary      Get multiple messages by ID
+// @Description  Fetch up to 500 messages by ID in a single request e.g. to check the status of a bulk send.
+// @Security	 ApiKeyAuth
+// @Tags         Messages
+// @Accept       json
+// @Produce      json
+// @Param        payload   body requests.MessageBatchGet  true  "IDs of the messages to fetch"
+// @Success      200 		{object}	responses.MessagesResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /messages/batch-get [post]
+func (h *MessageHandler) BatchGet(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.MessageBatchGet
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateMessageBatchGet(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching messages [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching messages")
+	}
+
+	messages, err := h.service.GetMessagesByIDs(ctx, h.userIDFomContext(c), request.MessageIDs())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get [%d] messages by ID", len(request.IDs))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	accessible := make([]*entities.Message, 0, len(messages))
+	for _, message := range messages {
+		if h.canAccessPhone(c, message.Owner) {
+			accessible = append(accessible, message)
+		}
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(accessible), h.pluralize("message", len(accessible))), accessible)
 }
 
 // PostEvent registers an event on a message
@@ -276,6 +495,7 @@ func (h *MessageHandler) Index(c *fiber.Ctx) error {
 // @Success      200  		{object} 	responses.MessageResponse
 // @Failure      400  		{object}  	responses.BadRequest
 // @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
 // @Failure 	 404		{object}	responses.NotFound
 // @Failure      422  		{object} 	responses.UnprocessableEntity
 // @Failure      500  		{object}  	responses.InternalServerError
@@ -315,6 +535,11 @@ func (h *MessageHandler) PostEvent(c *fiber.Ctx) error {
 		return h.responseInternalServerError(c)
 	}
 
+	if !h.canAccessPhone(c, message.Owner) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot access phone [%s]", h.actingUserFromContext(c).ID, message.Owner)))
+		return h.responseForbidden(c)
+	}
+
 	message, err = h.service.StoreEvent(ctx, message, request.ToMessageStoreEventParams(c.OriginalURL()))
 	if err != nil {
 		msg := fmt.Sprintf("cannot store event for message [%s] with paylod [%s]", request.MessageID, c.Body())
@@ -335,6 +560,7 @@ func (h *MessageHandler) PostEvent(c *fiber.Ctx) error {
 // @Param        payload   body requests.MessageReceive  true  "Received message request payload"
 // @Success      200  {object}  responses.MessageResponse
 // @Failure      400  {object}  responses.BadRequest
+// @Failure      403  {object}  responses.Forbidden
 // @Failure      422  {object}  responses.UnprocessableEntity
 // @Failure      500  {object}  responses.InternalServerError
 // @Router       /messages/receive [post]
@@ -357,6 +583,11 @@ func (h *MessageHandler) PostReceive(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, errors, "validation errors while receiving message")
 	}
 
+	if !h.canAccessPhone(c, request.To) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot access phone [%s]", h.actingUserFromContext(c).ID, request.To)))
+		return h.responseForbidden(c)
+	}
+
 	if msg := h.billingService.IsEntitled(ctx, h.userIDFomContext(c)); msg != nil {
 		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] can't receive a message becasuse they have exceeded the limit", h.userIDFomContext(c))))
 		return h.responsePaymentRequired(c, *msg)
@@ -411,6 +642,11 @@ func (h *MessageHandler) Delete(c *fiber.Ctx) error {
 		return h.responseInternalServerError(c)
 	}
 
+	if !h.canAccessPhone(c, message.Owner) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot delete message [%s] of phone [%s]", h.actingUserFromContext(c).ID, message.ID, message.Owner)))
+		return h.responseForbidden(c)
+	}
+
 	if err = h.service.DeleteMessage(ctx, c.OriginalURL(), message); err != nil {
 		msg := fmt.Sprintf("cannot delete message with ID [%s] for user with ID [%s]", messageID, message.UserID)
 		ctxLogger.Error(h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
@@ -431,6 +667,7 @@ func (h *MessageHandler) Delete(c *fiber.Ctx) error {
 // @Success      200  		{object} 	responses.MessageResponse
 // @Failure      400  		{object}  	responses.BadRequest
 // @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
 // @Failure 	 404		{object}	responses.NotFound
 // @Failure      422  		{object} 	responses.UnprocessableEntity
 // @Failure      500  		{object}  	responses.InternalServerError
@@ -454,6 +691,11 @@ func (h *MessageHandler) PostCallMissed(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, errors, "validation errors while storing missed call event")
 	}
 
+	if !h.canAccessPhone(c, request.To) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot access phone [%s]", h.actingUserFromContext(c).ID, request.To)))
+		return h.responseForbidden(c)
+	}
+
 	message, err := h.service.RegisterMissedCall(ctx, request.ToCallMissedParams(h.userIDFomContext(c), c.OriginalURL()))
 	if err != nil {
 		msg := fmt.Sprintf("cannot store missed call event for user [%s] with paylod [%s]", h.userIDFomContext(c), c.Body())
@@ -466,7 +708,8 @@ func (h *MessageHandler) PostCallMissed(c *fiber.Ctx) error {
 
 // Search returns a filtered list of messages of a user
 // @Summary      Search all messages of a user
-// @Description  This returns the list of all messages based on the filter criteria including missed calls
+// @Description  This returns the list of all messages based on the filter criteria including missed calls. Use sort_by=order_timestamp to page through the messages by timestamp in descending order with the next_cursor of each page.
+// @Description  The query uses full-text search on the content of messages which are not encrypted e.g. "invoice 4432" and each result has a search_rank and a search_snippet with the matches.
 // @Security	 ApiKeyAuth
 // @Tags         Messages
 // @Accept       json
@@ -474,7 +717,11 @@ func (h *MessageHandler) PostCallMissed(c *fiber.Ctx) error {
 // @Param        owners		query  string  	true 	"the owner's phone numbers" 		default(+18005550199,+18005550100)
 // @Param        skip		query  int  	false	"number of messages to skip"		minimum(0)
 // @Param        query		query  string  	false 	"filter messages containing query"
+// @Param        sort_by	query  string  	false 	"field to sort the messages by, use rank to sort by relevance to the query"	Enums(created_at, owner, contact, type, status, rank, order_timestamp)
 // @Param        limit		query  int  	false	"number of messages to return"		minimum(1)	maximum(200)
+// @Param        cursor		query  string  	false 	"cursor from the next_cursor field of the previous page"
+// @Param        created_after	query  string  	false 	"only return items created at or after this RFC3339 timestamp"
+// @Param        created_before	query  string  	false 	"only return items created before this RFC3339 timestamp"
 // @Success      200 		{object}	responses.MessagesResponse
 // @Failure      400		{object}	responses.BadRequest
 // @Failure 	 401    	{object}	responses.Unauthorized
@@ -500,12 +747,34 @@ func (h *MessageHandler) Search(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, errors, "validation errors while searching messages")
 	}
 
-	messages, err := h.service.SearchMessages(ctx, request.ToSearchParams(h.userIDFomContext(c)))
+	if member := h.memberFromContext(c); member != nil && !member.Role.CanManage() && len(request.Owners) == 0 {
+		for _, grant := range member.PhoneGrants {
+			request.Owners = append(request.Owners, grant.Owner)
+		}
+		if len(request.Owners) == 0 {
+			return h.responseOKWithCursor(c, "found 0 messages", []*entities.Message{}, nil)
+		}
+	}
+
+	for _, owner := range request.Owners {
+		if !h.canAccessPhone(c, owner) {
+			ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot search messages of phone [%s]", h.actingUserFromContext(c).ID, owner)))
+			return h.responseForbidden(c)
+		}
+	}
+
+	params := request.ToSearchParams(h.userIDFomContext(c))
+	messages, err := h.service.SearchMessages(ctx, params)
 	if err != nil {
 		msg := fmt.Sprintf("cannot search messages with params [%+#v]", request)
 		ctxLogger.Error(stacktrace.Propagate(err, msg))
 		return h.responseInternalServerError(c)
 	}
 
-	return h.responseOK(c, fmt.Sprintf("found %d %s", len(messages), h.pluralize("message", len(messages))), messages)
+	var cursor *repositories.Cursor
+	if count := len(messages); count > 0 && count == params.Limit && params.IsCursorPaginated() {
+		cursor = repositories.NewCursor(messages[count-1].OrderTimestamp, messages[count-1].ID)
+	}
+
+	return h.responseOKWithCursor(c, fmt.Sprintf("found %d %s", len(messages), h.pluralize("message", len(messages))), messages, cursor)
 }
@@ -3,6 +3,7 @@ package handlers
 import (
 	"fmt"
 
+	"github.com/NdoleStudio/httpsms/pkg/entities"
 	"github.com/NdoleStudio/httpsms/pkg/repositories"
 	"github.com/google/uuid"
 
@@ -41,9 +42,9 @@ func NewMessageThreadHandler(
 
 // RegisterRoutes registers the routes for the MessageHandler
 func (h *MessageThreadHandler) RegisterRoutes(router fiber.Router) {
-	router.Get("/message-threads", h.Index)
-	router.Put("/message-threads/:messageThreadID", h.Update)
-	router.Delete("/message-threads/:messageThreadID", h.Delete)
+	router.Get("/message-threads", h.requireScope(entities.APIKeyScopeMessagesRead, h.Index))
+	router.Put("/message-threads/:messageThreadID", h.requireScope(entities.APIKeyScopeMessagesSend, h.Update))
+	router.Delete("/message-threads/:messageThreadID", h.requireScope(entities.APIKeyScopeMessagesSend, h.Delete))
 }
 
 // Index returns message threads for a phone number
@@ -57,9 +58,13 @@ func (h *MessageThreadHandler) RegisterRoutes(router fiber.Router) {
 // @Param        skip	query  int  	false	"number of messages to skip"				minimum(0)
 // @Param        query	query  string  	false 	"filter message threads containing query"
 // @Param        limit	query  int  	false	"number of messages to return"				minimum(1)	maximum(20)
+// @Param        cursor		query  string  	false 	"cursor from the next_cursor field of the previous page"
+// @Param        created_after	query  string  	false 	"only return message threads created at or after this RFC3339 timestamp"
+// @Param        created_before	query  string  	false 	"only return message threads created before this RFC3339 timestamp"
 // @Success      200 	{object}	responses.MessageThreadsResponse
 // @Failure      400	{object}	responses.BadRequest
 // @Failure 	 401    {object}	responses.Unauthorized
+// @Failure 	 403    {object}	responses.Forbidden
 // @Failure      422	{object}	responses.UnprocessableEntity
 // @Failure      500	{object}	responses.InternalServerError
 // @Router       /message-threads [get]
@@ -84,14 +89,25 @@ func (h *MessageThreadHandler) Index(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching message threads")
 	}
 
-	threads, err := h.service.GetThreads(ctx, request.ToGetParams(h.userIDFomContext(c)))
+	if !h.canAccessPhone(c, request.Owner) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot access phone [%s]", h.actingUserFromContext(c).ID, request.Owner)))
+		return h.responseForbidden(c)
+	}
+
+	params := request.ToGetParams(h.userIDFomContext(c))
+	threads, err := h.service.GetThreads(ctx, params)
 	if err != nil {
 		msg := fmt.Sprintf("cannot get message threads with params [%+#v]", request)
 		ctxLogger.Error(stacktrace.Propagate(err, msg))
 		return h.responseInternalServerError(c)
 	}
 
-	return h.responseOK(c, fmt.Sprintf("fetched %d message %s", len(*threads), h.pluralize("thread", len(*threads))), threads)
+	var cursor *repositories.Cursor
+	if count := len(*threads); count > 0 && count == params.Limit {
+		cursor = repositories.NewCursor((*threads)[count-1].OrderTimestamp, (*threads)[count-1].ID)
+	}
+
+	return h.responseOKWithCursor(c, fmt.Sprintf("fetched %d message %s", len(*threads), h.pluralize("thread", len(*threads))), threads, cursor)
 }
 
 // Update an entities.MessageThread
@@ -106,6 +122,8 @@ func (h *MessageThreadHandler) Index(c *fiber.Ctx) error {
 // @Success      200 				{object}	responses.PhoneResponse
 // @Failure      400				{object}	responses.BadRequest
 // @Failure 	 401    			{object}	responses.Unauthorized
+// @Failure 	 403    			{object}	responses.Forbidden
+// @Failure 	 404				{object}	responses.NotFound
 // @Failure      422				{object}	responses.UnprocessableEntity
 // @Failure      500				{object}	responses.InternalServerError
 // @Router       /message-threads/{messageThreadID} [put]
@@ -127,7 +145,23 @@ func (h *MessageThreadHandler) Update(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, errors, "validation errors while updating message thread")
 	}
 
-	thread, err := h.service.UpdateStatus(ctx, request.ToUpdateParams(h.userIDFomContext(c)))
+	thread, err := h.service.GetThread(ctx, h.userIDFomContext(c), uuid.MustParse(request.MessageThreadID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find message thread with ID [%s]", request.MessageThreadID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot find message thread with id [%s]", request.MessageThreadID)
+		ctxLogger.Error(h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return h.responseInternalServerError(c)
+	}
+
+	if !h.canAccessPhone(c, thread.Owner) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot access phone [%s]", h.actingUserFromContext(c).ID, thread.Owner)))
+		return h.responseForbidden(c)
+	}
+
+	thread, err = h.service.UpdateStatus(ctx, request.ToUpdateParams(h.userIDFomContext(c)))
 	if err != nil {
 		msg := fmt.Sprintf("cannot update message thread with params [%+#v]", request)
 		ctxLogger.Error(stacktrace.Propagate(err, msg))
@@ -148,6 +182,7 @@ func (h *MessageThreadHandler) Update(c *fiber.Ctx) error {
 // @Success      204  				{object} 	responses.NoContent
 // @Failure      400  				{object}  	responses.BadRequest
 // @Failure 	 401    			{object}	responses.Unauthorized
+// @Failure 	 403    			{object}	responses.Forbidden
 // @Failure 	 404				{object}	responses.NotFound
 // @Failure      422  				{object} 	responses.UnprocessableEntity
 // @Failure      500  				{object}  	responses.InternalServerError
@@ -174,6 +209,11 @@ func (h *MessageThreadHandler) Delete(c *fiber.Ctx) error {
 		return h.responseInternalServerError(c)
 	}
 
+	if !h.canAccessPhone(c, thread.Owner) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot access phone [%s]", h.actingUserFromContext(c).ID, thread.Owner)))
+		return h.responseForbidden(c)
+	}
+
 	if err = h.service.DeleteThread(ctx, c.OriginalURL(), thread); err != nil {
 		msg := fmt.Sprintf("cannot delete thread thread with ID [%s] for user with ID [%s]", messageThreadID, thread.UserID)
 		ctxLogger.Error(h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
@@ -0,0 +1,378 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// OrganizationHandler handles organization http requests.
+type OrganizationHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	validator *validators.OrganizationHandlerValidator
+	service   *services.OrganizationService
+}
+
+// NewOrganizationHandler creates a new OrganizationHandler
+func NewOrganizationHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	validator *validators.OrganizationHandlerValidator,
+	service *services.OrganizationService,
+) (h *OrganizationHandler) {
+	return &OrganizationHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		validator: validator,
+		service:   service,
+	}
+}
+
+// RegisterRoutes registers the routes for the OrganizationHandler
+func (h *OrganizationHandler) RegisterRoutes(router fiber.Router) {
+	router.Get("/organizations", h.requireFullAccess(h.Index))
+	router.Post("/organizations", h.requireFullAccess(h.Store))
+	router.Get("/organizations/:organizationID/members", h.requireFullAccess(h.IndexMembers))
+	router.Put("/organizations/:organizationID/members/:memberID", h.requireFullAccess(h.UpdateMember))
+	router.Delete("/organizations/:organizationID/members/:memberID", h.requireFullAccess(h.DeleteMember))
+	router.Post("/organizations/:organizationID/invitations", h.requireFullAccess(h.Invite))
+	router.Post("/organization-invitations/:token/accept", h.requireFullAccess(h.AcceptInvitation))
+}
+
+// Index returns the organizations of a user
+// @Summary      Get organizations of a user
+// @Description  Get the organizations which the authenticated user is a member of
+// @Security	 ApiKeyAuth
+// @Tags         Organizations
+// @Produce      json
+// @Success      200 		{object}	responses.OrganizationsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /organizations 	[get]
+func (h *OrganizationHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	organizations, err := h.service.Index(ctx, h.actingUserFromContext(c).ID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot get organizations for user [%s]", h.actingUserFromContext(c).ID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(organizations), h.pluralize("organization", len(organizations))), organizations)
+}
+
+// Store an entities.Organization
+// @Summary      Store an organization
+// @Description  Create a new organization where the authenticated user is the owner
+// @Security	 ApiKeyAuth
+// @Tags         Organizations
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.OrganizationStore  	true "Payload of the organization"
+// @Success      201 		{object}	responses.OrganizationResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /organizations [post]
+func (h *OrganizationHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.OrganizationStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body [%s] into [%T]", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateStore(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing organization [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing organization")
+	}
+
+	organization, err := h.service.Store(ctx, request.ToStoreParams(h.actingUserFromContext(c)))
+	if err != nil {
+		msg := fmt.Sprintf("cannot store organization with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "organization created successfully", organization)
+}
+
+// IndexMembers returns the members of an organization
+// @Summary      Get members of an organization
+// @Description  Get the members of an organization which the authenticated user belongs to
+// @Security	 ApiKeyAuth
+// @Tags         Organizations
+// @Produce      json
+// @Param 		 organizationID	path	string 	true 	"ID of the organization"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        skip		query  int  	false	"number of members to skip"		minimum(0)
+// @Param        query		query  string  	false 	"filter members containing query"
+// @Param        limit		query  int  	false	"number of members to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.OrganizationMembersResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404	    {object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /organizations/{organizationID}/members 	[get]
+func (h *OrganizationHandler) IndexMembers(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.OrganizationMemberIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.OrganizationID = c.Params("organizationID")
+	if errors := h.validator.ValidateMemberIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching organization members [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching organization members")
+	}
+
+	organizationID := uuid.MustParse(request.OrganizationID)
+	if _, err := h.service.Member(ctx, organizationID, h.actingUserFromContext(c).ID); err != nil {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("user [%s] is not a member of organization [%s]", h.actingUserFromContext(c).ID, organizationID)))
+		return h.responseNotFound(c, fmt.Sprintf("cannot find organization with ID [%s]", organizationID))
+	}
+
+	members, err := h.service.IndexMembers(ctx, organizationID, request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get organization members with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(members), h.pluralize("member", len(members))), members)
+}
+
+// UpdateMember updates an entities.OrganizationMember
+// @Summary      Update an organization member
+// @Description  Update the role and phone access grants of a member in an organization
+// @Security	 ApiKeyAuth
+// @Tags         Organizations
+// @Accept       json
+// @Produce      json
+// @Param 		 organizationID	path	string 	true 	"ID of the organization"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param 		 memberID		path	string 	true 	"ID of the member"			default(32343a19-da5e-4b1b-a767-3298a73703cb)
+// @Param        payload   		body 	requests.OrganizationMemberUpdate  	true 	"Payload of the member to update"
+// @Success      200 		{object}	responses.OrganizationMemberResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 403	    {object}	responses.Forbidden
+// @Failure 	 404	    {object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /organizations/{organizationID}/members/{memberID} 	[put]
+func (h *OrganizationHandler) UpdateMember(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.OrganizationMemberUpdate
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into [%T]", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.OrganizationID = c.Params("organizationID")
+	request.MemberID = c.Params("memberID")
+	if errors := h.validator.ValidateMemberUpdate(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while updating organization member [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating organization member")
+	}
+
+	actor, err := h.service.Member(ctx, uuid.MustParse(request.OrganizationID), h.actingUserFromContext(c).ID)
+	if err != nil || !actor.Role.CanManage() {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user [%s] cannot manage organization [%s]", h.actingUserFromContext(c).ID, uuid.MustParse(request.OrganizationID))))
+		return h.responseForbidden(c)
+	}
+
+	params := request.ToUpdateParams()
+	params.ActorRole = actor.Role
+
+	member, err := h.service.UpdateMember(ctx, params)
+	if stacktrace.GetCode(err) == services.ErrCodeForbidden {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("user [%s] cannot update member [%s]", h.actingUserFromContext(c).ID, request.MemberID)))
+		return h.responseForbidden(c)
+	}
+
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find member with ID [%s] or one of the phones [%v]", request.MemberID, request.PhoneIDs))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot update organization member with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "organization member updated successfully", member)
+}
+
+// DeleteMember removes an entities.OrganizationMember
+// @Summary      Delete an organization member
+// @Description  Remove a member from an organization
+// @Security	 ApiKeyAuth
+// @Tags         Organizations
+// @Produce      json
+// @Param 		 organizationID	path	string 	true 	"ID of the organization"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param 		 memberID		path	string 	true 	"ID of the member"			default(32343a19-da5e-4b1b-a767-3298a73703cb)
+// @Success      204		{object}    responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403	    {object}	responses.Forbidden
+// @Failure 	 404	    {object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /organizations/{organizationID}/members/{memberID} [delete]
+func (h *OrganizationHandler) DeleteMember(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	organizationID := c.Params("organizationID")
+	memberID := c.Params("memberID")
+	errors := h.validator.ValidateUUID(ctx, organizationID, "organizationID")
+	for key, value := range h.validator.ValidateUUID(ctx, memberID, "memberID") {
+		errors[key] = value
+	}
+	if len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting member [%s] of organization [%s]", spew.Sdump(errors), memberID, organizationID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting organization member")
+	}
+
+	actor, err := h.service.Member(ctx, uuid.MustParse(organizationID), h.actingUserFromContext(c).ID)
+	if err != nil || !actor.Role.CanManage() {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user [%s] cannot manage organization [%s]", h.actingUserFromContext(c).ID, uuid.MustParse(organizationID))))
+		return h.responseForbidden(c)
+	}
+
+	err = h.service.DeleteMember(ctx, uuid.MustParse(organizationID), uuid.MustParse(memberID), actor.Role)
+	if stacktrace.GetCode(err) == services.ErrCodeForbidden {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("user [%s] cannot delete member [%s]", h.actingUserFromContext(c).ID, memberID)))
+		return h.responseForbidden(c)
+	}
+
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find member with ID [%s]", memberID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete member [%s] of organization [%s]", memberID, organizationID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "organization member deleted successfully")
+}
+
+// Invite a user to an entities.Organization
+// @Summary      Invite a member
+// @Description  Send an email invitation to join an organization with a role
+// @Security	 ApiKeyAuth
+// @Tags         Organizations
+// @Accept       json
+// @Produce      json
+// @Param 		 organizationID	path	string 	true 	"ID of the organization"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   		body 	requests.OrganizationInvite  	true 	"Payload of the invitation"
+// @Success      201 		{object}	responses.OrganizationInvitationResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 403	    {object}	responses.Forbidden
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /organizations/{organizationID}/invitations [post]
+func (h *OrganizationHandler) Invite(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.OrganizationInvite
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body [%s] into [%T]", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.OrganizationID = c.Params("organizationID")
+	if errors := h.validator.ValidateInvite(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while inviting member [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while inviting organization member")
+	}
+
+	if member, err := h.service.Member(ctx, uuid.MustParse(request.OrganizationID), h.actingUserFromContext(c).ID); err != nil || !member.Role.CanChange(entities.OrganizationRole(request.Role)) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user [%s] cannot invite a [%s] to organization [%s]", h.actingUserFromContext(c).ID, request.Role, uuid.MustParse(request.OrganizationID))))
+		return h.responseForbidden(c)
+	}
+
+	invitation, err := h.service.Invite(ctx, request.ToInviteParams(h.actingUserFromContext(c)))
+	if err != nil {
+		msg := fmt.Sprintf("cannot invite member with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, fmt.Sprintf("invitation sent successfully to [%s]", invitation.Email), invitation)
+}
+
+// AcceptInvitation accepts an entities.OrganizationInvitation
+// @Summary      Accept an invitation
+// @Description  Join an organization using the token in the invitation email
+// @Security	 ApiKeyAuth
+// @Tags         Organizations
+// @Produce      json
+// @Param 		 token		path		string 	true 	"token of the invitation"
+// @Success      200 		{object}	responses.OrganizationMemberResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404	    {object}	responses.NotFound
+// @Failure 	 409	    {object}	responses.Conflict
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /organization-invitations/{token}/accept [post]
+func (h *OrganizationHandler) AcceptInvitation(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	member, err := h.service.AcceptInvitation(ctx, c.Params("token"), h.actingUserFromContext(c))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot accept invitation for user [%s]", h.actingUserFromContext(c).ID)))
+		return h.responseNotFound(c, "the invitation does not exist or it has expired")
+	}
+
+	if stacktrace.GetCode(err) == repositories.ErrCodeConflict {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("user [%s] is already a member of the organization", h.actingUserFromContext(c).ID)))
+		return h.responseConflict(c, "you are already a member of this organization")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot accept invitation for user [%s]", h.actingUserFromContext(c).ID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "invitation accepted successfully", member)
+}
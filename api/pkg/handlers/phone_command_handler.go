@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// PhoneCommandHandler handles the commands which are pushed to phones
+type PhoneCommandHandler struct {
+	handler
+	logger       telemetry.Logger
+	tracer       telemetry.Tracer
+	validator    *validators.PhoneCommandHandlerValidator
+	service      *services.PhoneCommandService
+	phoneService *services.PhoneService
+}
+
+// NewPhoneCommandHandler creates a new PhoneCommandHandler
+func NewPhoneCommandHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	validator *validators.PhoneCommandHandlerValidator,
+	service *services.PhoneCommandService,
+	phoneService *services.PhoneService,
+) (h *PhoneCommandHandler) {
+	return &PhoneCommandHandler{
+		logger:       logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:       tracer,
+		validator:    validator,
+		service:      service,
+		phoneService: phoneService,
+	}
+}
+
+// RegisterRoutes registers the routes for the PhoneCommandHandler
+func (h *PhoneCommandHandler) RegisterRoutes(router fiber.Router) {
+	router.Get("/phones/:phoneID/commands", h.Index)
+	router.Post("/phones/:phoneID/commands", h.requireScope(entities.APIKeyScopePhonesWrite, h.Store))
+	router.Post("/phones/:phoneID/commands/:commandID/ack", h.requireScope(entities.APIKeyScopePhonesWrite, h.Ack))
+}
+
+// Index returns the commands of a phone
+// @Summary      Get commands of a phone
+// @Description  Get the history of the commands which were sent to a phone. The app of a phone which uses the poll delivery mode fetches its pending commands with `status=pending`.
+// @Security	 ApiKeyAuth
+// @Tags         Phones
+// @Accept       json
+// @Produce      json
+// @Param 		 phoneID 	path		string 	true 	"ID of the phone"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        status		query  		string  false 	"comma separated list of statuses e.g. pending,delivered"
+// @Param        skip		query  		int  	false	"number of commands to skip"		minimum(0)
+// @Param        limit		query  		int  	false 	"number of commands to return"		minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.PhoneCommandsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403	    {object}	responses.Forbidden
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phones/{phoneID}/commands [get]
+func (h *PhoneCommandHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.PhoneCommandIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+	request.PhoneID = c.Params("phoneID")
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching phone commands [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching phone commands")
+	}
+
+	phone, err := h.phone(ctx, c, ctxLogger)
+	if phone == nil {
+		return err
+	}
+
+	commands, err := h.service.Index(ctx, request.ToIndexParams(h.userIDFomContext(c), phone.ID))
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch commands of phone [%s] with params [%+#v]", phone.ID, request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(commands), h.pluralize("phone command", len(commands))), commands)
+}
+
+// Store a command for a phone
+// @Summary      Send a command to a phone
+// @Description  Push a command to a phone to refresh its config, send a heartbeat, resync its outstanding messages, upload its logs or switch its default SIM. The phone acknowledges the command after executing it.
+// @Security	 ApiKeyAuth
+// @Tags         Phones
+// @Accept       json
+// @Produce      json
+// @Param 		 phoneID 	path		string 						true 	"ID of the phone"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.PhoneCommandStore 	true 	"Payload of the command"
+// @Success      201 		{object}	responses.PhoneCommandResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403	    {object}	responses.Forbidden
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phones/{phoneID}/commands [post]
+func (h *PhoneCommandHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.PhoneCommandStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+	request.PhoneID = c.Params("phoneID")
+
+	if errors := h.validator.ValidateStore(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing phone command [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing phone command")
+	}
+
+	phone, err := h.phone(ctx, c, ctxLogger)
+	if phone == nil {
+		return err
+	}
+
+	command, err := h.service.Store(ctx, request.ToStoreParams(h.userIDFomContext(c), phone, c.OriginalURL()))
+	if err != nil {
+		msg := fmt.Sprintf("cannot store phone command with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "phone command created successfully", command)
+}
+
+// Ack a command of a phone
+// @Summary      Acknowledge a phone command
+// @Description  Used by the mobile phone to report that a command was executed or that it failed
+// @Security	 ApiKeyAuth
+// @Tags         Phones
+// @Accept       json
+// @Produce      json
+// @Param 		 phoneID 	path		string 						true 	"ID of the phone"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param 		 commandID 	path		string 						true 	"ID of the command"	default(32343a19-da5e-4b1b-a767-3298a73703cb)
+// @Param        payload   	body 		requests.PhoneCommandAck 	true 	"Result of the command"
+// @Success      200 		{object}	responses.PhoneCommandResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403	    {object}	responses.Forbidden
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phones/{phoneID}/commands/{commandID}/ack [post]
+func (h *PhoneCommandHandler) Ack(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.PhoneCommandAck
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+	request.CommandID = c.Params("commandID")
+
+	if errors := h.validator.ValidateAck(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while acknowledging phone command [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while acknowledging phone command")
+	}
+
+	phone, err := h.phone(ctx, c, ctxLogger)
+	if phone == nil {
+		return err
+	}
+
+	command, err := h.service.Ack(ctx, request.ToAckParams(h.userIDFomContext(c), phone.ID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find phone command with ID [%s]", request.CommandID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot acknowledge phone command with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("phone command is %s", command.Status), command)
+}
+
+// phone loads and authorizes the phone in the path. A nil result means the error response has been sent.
+func (h *PhoneCommandHandler) phone(ctx context.Context, c *fiber.Ctx, ctxLogger telemetry.Logger) (*entities.Phone, error) {
+	phoneID := c.Params("phoneID")
+	if errors := h.validator.ValidateUUID(ctx, phoneID, "phoneID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while loading phone with ID [%s]", spew.Sdump(errors), phoneID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return nil, h.responseUnprocessableEntity(c, errors, "validation errors while loading phone")
+	}
+
+	phone, err := h.phoneService.LoadByID(ctx, h.userIDFomContext(c), uuid.MustParse(phoneID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return nil, h.responseNotFound(c, fmt.Sprintf("cannot find phone with ID [%s]", phoneID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone with ID [%s]", phoneID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return nil, h.responseInternalServerError(c)
+	}
+
+	if !h.canAccessPhone(c, phone.PhoneNumber) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot access phone [%s]", h.actingUserFromContext(c).ID, phone.PhoneNumber)))
+		return nil, h.responseForbidden(c)
+	}
+
+	return phone, nil
+}
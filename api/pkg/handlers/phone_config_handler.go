@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// PhoneConfigHandler handles the remote config of phones
+type PhoneConfigHandler struct {
+	handler
+	logger       telemetry.Logger
+	tracer       telemetry.Tracer
+	validator    *validators.PhoneConfigHandlerValidator
+	service      *services.PhoneConfigService
+	phoneService *services.PhoneService
+}
+
+// NewPhoneConfigHandler creates a new PhoneConfigHandler
+func NewPhoneConfigHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	validator *validators.PhoneConfigHandlerValidator,
+	service *services.PhoneConfigService,
+	phoneService *services.PhoneService,
+) (h *PhoneConfigHandler) {
+	return &PhoneConfigHandler{
+		logger:       logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:       tracer,
+		validator:    validator,
+		service:      service,
+		phoneService: phoneService,
+	}
+}
+
+// RegisterRoutes registers the routes for the PhoneConfigHandler
+func (h *PhoneConfigHandler) RegisterRoutes(router fiber.Router) {
+	router.Get("/phones/:phoneID/config", h.Show)
+	router.Put("/phones/:phoneID/config", h.requireScope(entities.APIKeyScopePhonesWrite, h.Update))
+}
+
+// Show returns the config of a phone
+// @Summary      Get the config of a phone
+// @Description  Get the config document which is applied by the Android app. The response has an ETag header and a request with a matching If-None-Match header returns 304 Not Modified.
+// @Security	 ApiKeyAuth
+// @Tags         Phones
+// @Accept       json
+// @Produce      json
+// @Param 		 phoneID 		path		string 	true 	"ID of the phone"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param 		 If-None-Match 	header		string 	false 	"ETag of the config which the phone has"
+// @Success      200 			{object}	responses.PhoneConfigResponse
+// @Success      304
+// @Failure      400			{object}	responses.BadRequest
+// @Failure 	 401    		{object}	responses.Unauthorized
+// @Failure 	 403	    	{object}	responses.Forbidden
+// @Failure      404			{object}	responses.NotFound
+// @Failure      422			{object}	responses.UnprocessableEntity
+// @Failure      500			{object}	responses.InternalServerError
+// @Router       /phones/{phoneID}/config [get]
+func (h *PhoneConfigHandler) Show(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	phone, err := h.phone(ctx, c, ctxLogger)
+	if phone == nil {
+		return err
+	}
+
+	document, err := h.service.Load(ctx, phone)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load config of phone [%s]", phone.ID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	etag := document.ETag()
+	c.Set(fiber.HeaderETag, etag)
+	if c.Get(fiber.HeaderIfNoneMatch) == etag {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	return h.responseOK(c, "fetched phone config", document)
+}
+
+// Update the config of a phone
+// @Summary      Update the config of a phone
+// @Description  Update the settings of a phone which are applied by the Android app. A refresh_config command is pushed to the phone after the config is updated.
+// @Security	 ApiKeyAuth
+// @Tags         Phones
+// @Accept       json
+// @Produce      json
+// @Param 		 phoneID 	path		string 						true 	"ID of the phone"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.PhoneConfigUpdate 	true 	"Payload of the config"
+// @Success      200 		{object}	responses.PhoneConfigResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403	    {object}	responses.Forbidden
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phones/{phoneID}/config [put]
+func (h *PhoneConfigHandler) Update(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.PhoneConfigUpdate
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+	request.PhoneID = c.Params("phoneID")
+
+	if errors := h.validator.ValidateUpdate(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while updating phone config [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating phone config")
+	}
+
+	if !h.canManage(c) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot update phone configs", h.actingUserFromContext(c).ID)))
+		return h.responseForbidden(c)
+	}
+
+	phone, err := h.phone(ctx, c, ctxLogger)
+	if phone == nil {
+		return err
+	}
+
+	document, err := h.service.Update(ctx, request.ToUpdateParams(phone, c.OriginalURL()))
+	if err != nil {
+		msg := fmt.Sprintf("cannot update config of phone [%s] with params [%+#v]", phone.ID, request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	c.Set(fiber.HeaderETag, document.ETag())
+	return h.responseOK(c, "phone config updated successfully", document)
+}
+
+// phone loads and authorizes the phone in the path. A nil result means the error response has been sent.
+func (h *PhoneConfigHandler) phone(ctx context.Context, c *fiber.Ctx, ctxLogger telemetry.Logger) (*entities.Phone, error) {
+	phoneID := c.Params("phoneID")
+	if errors := h.validator.ValidateUUID(ctx, phoneID, "phoneID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while loading phone with ID [%s]", spew.Sdump(errors), phoneID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return nil, h.responseUnprocessableEntity(c, errors, "validation errors while loading phone")
+	}
+
+	phone, err := h.phoneService.LoadByID(ctx, h.userIDFomContext(c), uuid.MustParse(phoneID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return nil, h.responseNotFound(c, fmt.Sprintf("cannot find phone with ID [%s]", phoneID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone with ID [%s]", phoneID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return nil, h.responseInternalServerError(c)
+	}
+
+	if !h.canAccessPhone(c, phone.PhoneNumber) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot access phone [%s]", h.actingUserFromContext(c).ID, phone.PhoneNumber)))
+		return nil, h.responseForbidden(c)
+	}
+
+	return phone, nil
+}
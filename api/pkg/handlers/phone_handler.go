@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
+	"net/url"
 
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
 	"github.com/NdoleStudio/httpsms/pkg/requests"
 	"github.com/NdoleStudio/httpsms/pkg/validators"
 	"github.com/davecgh/go-spew/spew"
@@ -10,6 +14,7 @@ import (
 	"github.com/NdoleStudio/httpsms/pkg/services"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"github.com/palantir/stacktrace"
 )
 
@@ -20,6 +25,7 @@ type PhoneHandler struct {
 	tracer    telemetry.Tracer
 	service   *services.PhoneService
 	validator *validators.PhoneHandlerValidator
+	uptime    *services.PhoneAvailabilityService
 }
 
 // NewPhoneHandler creates a new PhoneHandler
@@ -28,20 +34,23 @@ func NewPhoneHandler(
 	tracer telemetry.Tracer,
 	service *services.PhoneService,
 	validator *validators.PhoneHandlerValidator,
+	uptime *services.PhoneAvailabilityService,
 ) (h *PhoneHandler) {
 	return &PhoneHandler{
 		logger:    logger.WithService(fmt.Sprintf("%T", h)),
 		tracer:    tracer,
 		validator: validator,
 		service:   service,
+		uptime:    uptime,
 	}
 }
 
 // RegisterRoutes registers the routes for the PhoneHandler
 func (h *PhoneHandler) RegisterRoutes(router fiber.Router) {
 	router.Get("/phones", h.Index)
-	router.Put("/phones", h.Upsert)
-	router.Delete("/phones/:phoneID", h.Delete)
+	router.Put("/phones", h.requireScope(entities.APIKeyScopePhonesWrite, h.Upsert))
+	router.Delete("/phones/:phoneID", h.requireScope(entities.APIKeyScopePhonesWrite, h.Delete))
+	router.Get("/phones/:phoneID/uptime", h.Uptime)
 }
 
 // Index returns the phones of a user
@@ -86,12 +95,22 @@ func (h *PhoneHandler) Index(c *fiber.Ctx) error {
 		return h.responseInternalServerError(c)
 	}
 
+	if !h.canManage(c) {
+		granted := make([]entities.Phone, 0, len(*phones))
+		for _, phone := range *phones {
+			if h.canAccessPhone(c, phone.PhoneNumber) {
+				granted = append(granted, phone)
+			}
+		}
+		phones = &granted
+	}
+
 	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(*phones), h.pluralize("phone", len(*phones))), phones)
 }
 
 // Upsert a phone
 // @Summary      Upsert Phone
-// @Description  Updates properties of a user's phone. If the phone with this number does not exist, a new one will be created. Think of this method like an 'upsert'
+// @Description  Updates properties of a user's phone. If the phone with this number does not exist, a new one will be created. Think of this method like an 'upsert'. The queued messages of a phone are moved to its failover phone when it goes offline.
 // @Security	 ApiKeyAuth
 // @Tags         Phones
 // @Accept       json
@@ -122,6 +141,17 @@ func (h *PhoneHandler) Upsert(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, errors, "validation errors while updating phones")
 	}
 
+	if !h.canManage(c) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot update phones", h.actingUserFromContext(c).ID)))
+		return h.responseForbidden(c)
+	}
+
+	if errors := h.validateFailoverPhone(ctx, c, request); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while updating phones [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating phones")
+	}
+
 	phone, err := h.service.Upsert(ctx, request.ToUpsertParams(h.userFromContext(c), c.OriginalURL()))
 	if err != nil {
 		msg := fmt.Sprintf("cannot update phones with params [%+#v]", request)
@@ -132,6 +162,26 @@ func (h *PhoneHandler) Upsert(c *fiber.Ctx) error {
 	return h.responseOK(c, "phone updated successfully", phone)
 }
 
+// validateFailoverPhone checks that the failover phone is another phone of the user
+func (h *PhoneHandler) validateFailoverPhone(ctx context.Context, c *fiber.Ctx, request requests.PhoneUpsert) url.Values {
+	result := url.Values{}
+	if request.FailoverPhoneID == nil || *request.FailoverPhoneID == "" {
+		return result
+	}
+
+	failover, err := h.service.LoadByID(ctx, h.userIDFomContext(c), uuid.MustParse(*request.FailoverPhoneID))
+	if err != nil {
+		result.Add("failover_phone_id", fmt.Sprintf("cannot find phone with ID [%s]", *request.FailoverPhoneID))
+		return result
+	}
+
+	if failover.PhoneNumber == request.PhoneNumber {
+		result.Add("failover_phone_id", "failover_phone_id cannot be the ID of the phone which is being updated")
+	}
+
+	return result
+}
+
 // Delete a phone
 // @Summary      Delete Phone
 // @Description  Delete a phone that has been sored in the database
@@ -159,6 +209,11 @@ func (h *PhoneHandler) Delete(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting phone")
 	}
 
+	if !h.canManage(c) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot delete phones", h.actingUserFromContext(c).ID)))
+		return h.responseForbidden(c)
+	}
+
 	err := h.service.Delete(ctx, c.OriginalURL(), h.userIDFomContext(c), request.PhoneIDUuid())
 	if err != nil {
 		msg := fmt.Sprintf("cannot delete phones with params [%+#v]", request)
@@ -168,3 +223,58 @@ func (h *PhoneHandler) Delete(c *fiber.Ctx) error {
 
 	return h.responseOK(c, "phone deleted successfully", nil)
 }
+
+// Uptime returns the availability report of a phone
+// @Summary      Get phone uptime
+// @Description  Get the uptime percentage, outages, mean time to recovery and a daily breakdown in the timezone of the user for a phone. Outages are recorded by the heartbeat monitor, time which is not covered by an outage counts as online.
+// @Security	 ApiKeyAuth
+// @Tags         Phones
+// @Accept       json
+// @Produce      json
+// @Param 		 phoneID 	path		string 	true 	"ID of the phone"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        from		query  		string  false 	"start of the report as an RFC3339 timestamp, defaults to 7 days before to"	default(2022-06-01T00:00:00+03:00)
+// @Param        to			query  		string  false 	"end of the report as an RFC3339 timestamp, defaults to now"	default(2022-06-08T00:00:00+03:00)
+// @Success      200 		{object}	responses.PhoneUptimeResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403	    {object}	responses.Forbidden
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phones/{phoneID}/uptime [get]
+func (h *PhoneHandler) Uptime(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.PhoneUptime
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+	request.PhoneID = c.Params("phoneID")
+
+	if errors := h.validator.ValidateUptime(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching phone uptime [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching phone uptime")
+	}
+
+	uptime, err := h.uptime.Uptime(ctx, request.ToUptimeParams(h.userIDFomContext(c)))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find phone with ID [%s]", request.PhoneID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch uptime of phone with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	if !h.canAccessPhone(c, uptime.Owner) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot access phone [%s]", h.actingUserFromContext(c).ID, uptime.Owner)))
+		return h.responseForbidden(c)
+	}
+
+	return h.responseOK(c, "fetched phone uptime", uptime)
+}
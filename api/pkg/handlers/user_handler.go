@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"bufio"
 	"fmt"
+	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/requests"
 	"github.com/NdoleStudio/httpsms/pkg/validators"
@@ -20,6 +22,8 @@ type UserHandler struct {
 	tracer    telemetry.Tracer
 	validator *validators.UserHandlerValidator
 	service   *services.UserService
+	retention *services.RetentionService
+	account   *services.AccountService
 }
 
 // NewUserHandler creates a new UserHandler
@@ -28,23 +32,30 @@ func NewUserHandler(
 	tracer telemetry.Tracer,
 	validator *validators.UserHandlerValidator,
 	service *services.UserService,
+	retention *services.RetentionService,
+	account *services.AccountService,
 ) (h *UserHandler) {
 	return &UserHandler{
 		logger:    logger.WithService(fmt.Sprintf("%T", h)),
 		tracer:    tracer,
 		validator: validator,
 		service:   service,
+		retention: retention,
+		account:   account,
 	}
 }
 
 // RegisterRoutes registers the routes for the MessageHandler
 func (h *UserHandler) RegisterRoutes(router fiber.Router) {
-	router.Get("/users/me", h.Show)
-	router.Put("/users/me", h.Update)
-	router.Delete("/users/:userID/api-keys", h.DeleteAPIKey)
-	router.Put("/users/:userID/notifications", h.UpdateNotifications)
-	router.Get("/users/subscription-update-url", h.subscriptionUpdateURL)
-	router.Delete("/users/subscription", h.cancelSubscription)
+	router.Get("/users/me", h.requireFullAccess(h.Show))
+	router.Put("/users/me", h.requireFullAccess(h.Update))
+	router.Delete("/users/me", h.requireFullAccess(h.Delete))
+	router.Get("/users/me/export", h.requireFullAccess(h.Export))
+	router.Delete("/users/:userID/api-keys", h.requireFullAccess(h.DeleteAPIKey))
+	router.Put("/users/:userID/notifications", h.requireFullAccess(h.UpdateNotifications))
+	router.Put("/users/:userID/retention", h.requireFullAccess(h.UpdateRetention))
+	router.Get("/users/subscription-update-url", h.requireFullAccess(h.subscriptionUpdateURL))
+	router.Delete("/users/subscription", h.requireFullAccess(h.cancelSubscription))
 }
 
 // Show returns an entities.User
@@ -66,6 +77,11 @@ func (h *UserHandler) Show(c *fiber.Ctx) error {
 
 	ctxLogger := h.tracer.CtxLogger(h.logger, span)
 
+	if !h.isAccountOwner(c) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot access the account of the organization owner", h.actingUserFromContext(c).ID)))
+		return h.responseForbidden(c)
+	}
+
 	authUser := h.userFromContext(c)
 
 	user, err := h.service.Get(ctx, authUser)
@@ -98,6 +114,11 @@ func (h *UserHandler) Update(c *fiber.Ctx) error {
 
 	ctxLogger := h.tracer.CtxLogger(h.logger, span)
 
+	if !h.isAccountOwner(c) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot access the account of the organization owner", h.actingUserFromContext(c).ID)))
+		return h.responseForbidden(c)
+	}
+
 	var request requests.UserUpdate
 	if err := c.BodyParser(&request); err != nil {
 		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
@@ -121,6 +142,72 @@ func (h *UserHandler) Update(c *fiber.Ctx) error {
 	return h.responseOK(c, "user updated successfully", user)
 }
 
+// Export all the data of an entities.User
+// @Summary      Export account data
+// @Description  Download a zip archive with the user, phones, messages, threads, heartbeats, webhooks, integrations and billing usage of the currently authenticated user.
+// @Security	 ApiKeyAuth
+// @Tags         Users
+// @Produce      application/zip
+// @Success      200 		{file}		file
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403	    {object}	responses.Forbidden
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /users/me/export [get]
+func (h *UserHandler) Export(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	if !h.isAccountOwner(c) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot access the account of the organization owner", h.actingUserFromContext(c).ID)))
+		return h.responseForbidden(c)
+	}
+
+	userID := h.userIDFomContext(c)
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="httpsms-account-%s.zip"`, time.Now().UTC().Format("2006-01-02")))
+
+	c.Context().SetBodyStreamWriter(func(writer *bufio.Writer) {
+		if err := h.account.Export(ctx, userID, writer); err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot export account of user [%s]", userID)))
+		}
+		if err := writer.Flush(); err != nil {
+			ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot flush account export of user [%s]", userID)))
+		}
+	})
+
+	return nil
+}
+
+// Delete an entities.User and all the data of the user
+// @Summary      Delete account
+// @Description  Permanently delete the currently authenticated user. The subscription is cancelled and all the phones, messages, heartbeats, webhooks, integrations and billing usage are deleted.
+// @Security	 ApiKeyAuth
+// @Tags         Users
+// @Produce      json
+// @Success      204 		{object}	responses.NoContent
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403	    {object}	responses.Forbidden
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /users/me [delete]
+func (h *UserHandler) Delete(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	if !h.isAccountOwner(c) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot access the account of the organization owner", h.actingUserFromContext(c).ID)))
+		return h.responseForbidden(c)
+	}
+
+	if err := h.account.Delete(ctx, h.userIDFomContext(c)); err != nil {
+		msg := fmt.Sprintf("cannot delete account of user with ID [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "user account deleted successfully")
+}
+
 // UpdateNotifications an entities.User
 // @Summary      Update notification settings
 // @Description  Update the email notification settings for a user
@@ -142,6 +229,11 @@ func (h *UserHandler) UpdateNotifications(c *fiber.Ctx) error {
 
 	ctxLogger := h.tracer.CtxLogger(h.logger, span)
 
+	if !h.isAccountOwner(c) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot access the account of the organization owner", h.actingUserFromContext(c).ID)))
+		return h.responseForbidden(c)
+	}
+
 	var request requests.UserNotificationUpdate
 	if err := c.BodyParser(&request); err != nil {
 		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
@@ -159,6 +251,54 @@ func (h *UserHandler) UpdateNotifications(c *fiber.Ctx) error {
 	return h.responseOK(c, "user notification settings updated successfully", user)
 }
 
+// UpdateRetention an entities.User
+// @Summary      Update data retention settings
+// @Description  Update the number of days after which the content of the messages of a user is removed and the heartbeats and phone notifications of the user are deleted. The data is kept forever when the number of days is null.
+// @Security	 ApiKeyAuth
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Param 		 userID 	path		string 							true 	"ID of the user to update" 				default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.UserRetentionUpdate	true 	"User data retention settings to update"
+// @Success      200 		{object}	responses.UserResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403	    {object}	responses.Forbidden
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /users/{userID}/retention [put]
+func (h *UserHandler) UpdateRetention(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	if !h.isAccountOwner(c) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot access the account of the organization owner", h.actingUserFromContext(c).ID)))
+		return h.responseForbidden(c)
+	}
+
+	var request requests.UserRetentionUpdate
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateRetention(ctx, request); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while updating retention settings [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating retention settings")
+	}
+
+	user, err := h.retention.UpdateSettings(ctx, request.ToUserRetentionUpdateParams(c.OriginalURL(), h.userIDFomContext(c)))
+	if err != nil {
+		msg := fmt.Sprintf("cannot update retention settings for user with ID [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "user retention settings updated successfully", user)
+}
+
 // subscriptionUpdateURL returns the subscription update URL for the authenticated entities.User
 // @Summary      Currently authenticated user subscription update URL
 // @Description  Fetches the subscription URL of the authenticated user.
@@ -176,6 +316,11 @@ func (h *UserHandler) subscriptionUpdateURL(c *fiber.Ctx) error {
 	defer span.End()
 
 	ctxLogger := h.tracer.CtxLogger(h.logger, span)
+
+	if !h.isAccountOwner(c) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot access the account of the organization owner", h.actingUserFromContext(c).ID)))
+		return h.responseForbidden(c)
+	}
 	authUser := h.userFromContext(c)
 
 	url, err := h.service.GetSubscriptionUpdateURL(ctx, authUser.ID)
@@ -205,6 +350,11 @@ func (h *UserHandler) cancelSubscription(c *fiber.Ctx) error {
 	defer span.End()
 
 	ctxLogger := h.tracer.CtxLogger(h.logger, span)
+
+	if !h.isAccountOwner(c) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot access the account of the organization owner", h.actingUserFromContext(c).ID)))
+		return h.responseForbidden(c)
+	}
 	authUser := h.userFromContext(c)
 
 	err := h.service.InitiateSubscriptionCancel(ctx, authUser.ID)
@@ -237,6 +387,11 @@ func (h *UserHandler) DeleteAPIKey(c *fiber.Ctx) error {
 
 	ctxLogger := h.tracer.CtxLogger(h.logger, span)
 
+	if !h.isAccountOwner(c) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot access the account of the organization owner", h.actingUserFromContext(c).ID)))
+		return h.responseForbidden(c)
+	}
+
 	if c.Params("userID") != string(h.userIDFomContext(c)) {
 		return h.responseUnauthorized(c)
 	}
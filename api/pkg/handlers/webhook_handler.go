@@ -3,6 +3,7 @@ package handlers
 import (
 	"fmt"
 
+	"github.com/NdoleStudio/httpsms/pkg/entities"
 	"github.com/NdoleStudio/httpsms/pkg/repositories"
 
 	"github.com/NdoleStudio/httpsms/pkg/requests"
@@ -43,10 +44,10 @@ func NewWebhookHandler(
 // RegisterRoutes registers the routes for the WebhookHandler
 func (h *WebhookHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
 	router := app.Group("/v1/webhooks")
-	router.Get("/", h.computeRoute(middlewares, h.Index)...)
-	router.Post("/", h.computeRoute(middlewares, h.Store)...)
-	router.Put("/:webhookID", h.computeRoute(middlewares, h.Update)...)
-	router.Delete("/:webhookID", h.computeRoute(middlewares, h.Delete)...)
+	router.Get("/", h.computeRoute(middlewares, h.requireScope(entities.APIKeyScopeWebhooksManage, h.Index))...)
+	router.Post("/", h.computeRoute(middlewares, h.requireScope(entities.APIKeyScopeWebhooksManage, h.Store))...)
+	router.Put("/:webhookID", h.computeRoute(middlewares, h.requireScope(entities.APIKeyScopeWebhooksManage, h.Update))...)
+	router.Delete("/:webhookID", h.computeRoute(middlewares, h.requireScope(entities.APIKeyScopeWebhooksManage, h.Delete))...)
 }
 
 // Index returns the webhooks of a user
@@ -117,6 +118,11 @@ func (h *WebhookHandler) Delete(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting webhook")
 	}
 
+	if !h.canManage(c) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot delete webhooks", h.actingUserFromContext(c).ID)))
+		return h.responseForbidden(c)
+	}
+
 	err := h.service.Delete(ctx, h.userIDFomContext(c), uuid.MustParse(webhookID))
 	if err != nil {
 		msg := fmt.Sprintf("cannot delete webhook with ID [%+#v]", webhookID)
@@ -160,6 +166,11 @@ func (h *WebhookHandler) Store(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, errors, "validation errors while storing webhook")
 	}
 
+	if !h.canManage(c) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot create webhooks", h.actingUserFromContext(c).ID)))
+		return h.responseForbidden(c)
+	}
+
 	webhooks, err := h.service.Index(ctx, h.userIDFomContext(c), repositories.IndexParams{Skip: 0, Limit: 10})
 	if err != nil {
 		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot index webhooks for user [%s]", h.userIDFomContext(c))))
@@ -214,6 +225,11 @@ func (h *WebhookHandler) Update(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, errors, "validation errors while updating webhook")
 	}
 
+	if !h.canManage(c) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] cannot update webhooks", h.actingUserFromContext(c).ID)))
+		return h.responseForbidden(c)
+	}
+
 	user, err := h.service.Update(ctx, request.ToUpdateParams(h.userFromContext(c)))
 	if err != nil {
 		msg := fmt.Sprintf("cannot update user with params [%+#v]", request)
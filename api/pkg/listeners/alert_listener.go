@@ -0,0 +1,95 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// AlertListener routes alert events to the alert channels of a user
+type AlertListener struct {
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.AlertService
+}
+
+// NewAlertListener creates a new instance of AlertListener
+func NewAlertListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.AlertService,
+) (l *AlertListener, routes map[string]events.EventListener) {
+	l = &AlertListener{
+		logger:  logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:  tracer,
+		service: service,
+	}
+
+	return l, map[string]events.EventListener{
+		events.EventTypePhoneHeartbeatOffline: l.onPhoneHeartbeatOffline,
+		events.EventTypeMessageSendFailed:     l.onMessageSendFailed,
+		events.EventTypeAlertEscalationCheck:  l.onAlertEscalationCheck,
+	}
+}
+
+// onPhoneHeartbeatOffline handles the events.EventTypePhoneHeartbeatOffline event
+func (listener *AlertListener) onPhoneHeartbeatOffline(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.PhoneHeartbeatOfflinePayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.NotifyPhoneOffline(ctx, event.Source(), &payload); err != nil {
+		msg := fmt.Sprintf("cannot route [%s] alert for event with ID [%s]", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onMessageSendFailed handles the events.EventTypeMessageSendFailed event
+func (listener *AlertListener) onMessageSendFailed(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessageSendFailedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.NotifyMessageFailed(ctx, event.Source(), &payload); err != nil {
+		msg := fmt.Sprintf("cannot route [%s] alert for event with ID [%s]", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onAlertEscalationCheck handles the events.EventTypeAlertEscalationCheck event
+func (listener *AlertListener) onAlertEscalationCheck(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.AlertEscalationCheckPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Escalate(ctx, event.Source(), &payload); err != nil {
+		msg := fmt.Sprintf("cannot escalate alert for event with ID [%s]", event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// EventStreamListener publishes the events which are sent to webhooks to the clients connected to the event stream
+type EventStreamListener struct {
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.EventStreamService
+}
+
+// NewEventStreamListener creates a new instance of EventStreamListener
+func NewEventStreamListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.EventStreamService,
+) (l *EventStreamListener, routes map[string]events.EventListener) {
+	l = &EventStreamListener{
+		logger:  logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:  tracer,
+		service: service,
+	}
+
+	return l, map[string]events.EventListener{
+		events.EventTypeMessagePhoneReceived:  l.onEvent,
+		events.EventTypeMessageSendExpired:    l.onEvent,
+		events.EventTypeMessagePhoneDelivered: l.onEvent,
+		events.EventTypeMessageSendFailed:     l.onEvent,
+		events.EventTypeMessagePhoneSent:      l.onEvent,
+		events.EventTypePhoneHeartbeatOnline:  l.onEvent,
+		events.EventTypePhoneHeartbeatOffline: l.onEvent,
+		events.MessageCallMissed:              l.onEvent,
+		events.EventTypePhoneBatteryLow:       l.onEvent,
+		events.EventTypePhoneSIMMissing:       l.onEvent,
+		events.EventTypeMessageSendRerouted:   l.onEvent,
+		events.EventTypePhoneCapReached:       l.onEvent,
+	}
+}
+
+// onEvent publishes an event which has the user_id and owner fields in the payload
+func (listener *EventStreamListener) onEvent(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload struct {
+		UserID entities.UserID `json:"user_id"`
+		Owner  string          `json:"owner"`
+	}
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Publish(ctx, payload.UserID, payload.Owner, event); err != nil {
+		msg := fmt.Sprintf("cannot publish [%s] event with ID [%s] to the event stream", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// MessageExportListener handles cloud events which export messages
+type MessageExportListener struct {
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.MessageExportService
+}
+
+// NewMessageExportListener creates a new instance of MessageExportListener
+func NewMessageExportListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.MessageExportService,
+) (l *MessageExportListener, routes map[string]events.EventListener) {
+	l = &MessageExportListener{
+		logger:  logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:  tracer,
+		service: service,
+	}
+
+	return l, map[string]events.EventListener{
+		events.MessageExportRequested: l.onMessageExportRequested,
+	}
+}
+
+// onMessageExportRequested handles the events.MessageExportRequested event
+func (listener *MessageExportListener) onMessageExportRequested(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	payload := new(events.MessageExportRequestedPayload)
+	if err := event.DataAs(payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Generate(ctx, payload); err != nil {
+		msg := fmt.Sprintf("cannot generate message export [%s] for event with ID [%s]", payload.MessageExportID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
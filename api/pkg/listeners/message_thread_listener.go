@@ -35,6 +35,7 @@ func NewMessageThreadListener(
 	return l, map[string]events.EventListener{
 		events.EventTypeMessageAPISent:               l.OnMessageAPISent,
 		events.MessageAPIDeleted:                     l.onMessageDeleted,
+		events.MessageContentPurged:                  l.onMessageContentPurged,
 		events.EventTypeMessagePhoneSending:          l.OnMessagePhoneSending,
 		events.EventTypeMessagePhoneSent:             l.OnMessagePhoneSent,
 		events.EventTypeMessagePhoneDelivered:        l.OnMessagePhoneDelivered,
@@ -93,6 +94,25 @@ func (listener *MessageThreadListener) onMessageDeleted(ctx context.Context, eve
 	return nil
 }
 
+// onMessageContentPurged handles the events.MessageContentPurged event
+func (listener *MessageThreadListener) onMessageContentPurged(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	payload := new(events.MessageContentPurgedPayload)
+	if err := event.DataAs(payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.PurgeLastMessageContent(ctx, payload); err != nil {
+		msg := fmt.Sprintf("cannot purge threads for [%d] messages for event with ID [%s]", len(payload.MessageIDs), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
 // OnMessagePhoneSending handles the events.EventTypeMessagePhoneSending event
 func (listener *MessageThreadListener) OnMessagePhoneSending(ctx context.Context, event cloudevents.Event) error {
 	ctx, span := listener.tracer.Start(ctx)
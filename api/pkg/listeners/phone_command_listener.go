@@ -0,0 +1,55 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// PhoneCommandListener pushes commands to phones
+type PhoneCommandListener struct {
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.PhoneCommandService
+}
+
+// NewPhoneCommandListener creates a new instance of PhoneCommandListener
+func NewPhoneCommandListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.PhoneCommandService,
+) (l *PhoneCommandListener, routes map[string]events.EventListener) {
+	l = &PhoneCommandListener{
+		logger:  logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:  tracer,
+		service: service,
+	}
+
+	return l, map[string]events.EventListener{
+		events.EventTypePhoneCommandCreated: l.onPhoneCommandCreated,
+	}
+}
+
+// onPhoneCommandCreated handles the events.EventTypePhoneCommandCreated event
+func (listener *PhoneCommandListener) onPhoneCommandCreated(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.PhoneCommandCreatedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Deliver(ctx, &payload); err != nil {
+		msg := fmt.Sprintf("cannot deliver command [%s] for event with ID [%s]", payload.CommandID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// PhoneConfigListener pushes the config document to phones when the phone or its heartbeat monitor is updated
+type PhoneConfigListener struct {
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.PhoneConfigService
+}
+
+// NewPhoneConfigListener creates a new instance of PhoneConfigListener
+func NewPhoneConfigListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.PhoneConfigService,
+) (l *PhoneConfigListener, routes map[string]events.EventListener) {
+	l = &PhoneConfigListener{
+		logger:  logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:  tracer,
+		service: service,
+	}
+
+	return l, map[string]events.EventListener{
+		events.EventTypePhoneUpdated:                 l.onPhoneUpdated,
+		events.EventTypePhoneHeartbeatMonitorUpdated: l.onPhoneHeartbeatMonitorUpdated,
+	}
+}
+
+// onPhoneUpdated handles the events.EventTypePhoneUpdated event
+func (listener *PhoneConfigListener) onPhoneUpdated(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.PhoneUpdatedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Refresh(ctx, event.Source(), payload.UserID, payload.PhoneID); err != nil {
+		msg := fmt.Sprintf("cannot refresh the config of phone [%s] for event with ID [%s]", payload.PhoneID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onPhoneHeartbeatMonitorUpdated handles the events.EventTypePhoneHeartbeatMonitorUpdated event
+func (listener *PhoneConfigListener) onPhoneHeartbeatMonitorUpdated(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.PhoneHeartbeatMonitorUpdatedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Refresh(ctx, event.Source(), payload.UserID, payload.PhoneID); err != nil {
+		msg := fmt.Sprintf("cannot refresh the config of phone [%s] for event with ID [%s]", payload.PhoneID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
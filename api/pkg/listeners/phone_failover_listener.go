@@ -0,0 +1,89 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// PhoneFailoverListener routes the messages of offline phones to their failover phones
+type PhoneFailoverListener struct {
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.PhoneFailoverService
+}
+
+// NewPhoneFailoverListener creates a new instance of PhoneFailoverListener
+func NewPhoneFailoverListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.PhoneFailoverService,
+) (l *PhoneFailoverListener, routes map[string]events.EventListener) {
+	l = &PhoneFailoverListener{
+		logger:  logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:  tracer,
+		service: service,
+	}
+
+	return l, map[string]events.EventListener{
+		events.EventTypePhoneHeartbeatOffline: l.onPhoneHeartbeatOffline,
+		events.EventTypePhoneHeartbeatOnline:  l.onPhoneHeartbeatOnline,
+	}
+}
+
+// onPhoneHeartbeatOffline handles the events.EventTypePhoneHeartbeatOffline event
+func (listener *PhoneFailoverListener) onPhoneHeartbeatOffline(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.PhoneHeartbeatOfflinePayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	params := &services.PhoneFailoverParams{
+		Source:    event.Source(),
+		UserID:    payload.UserID,
+		PhoneID:   payload.PhoneID,
+		Timestamp: payload.Timestamp,
+	}
+
+	if err := listener.service.Activate(ctx, params); err != nil {
+		msg := fmt.Sprintf("cannot activate failover of phone [%s] for event with ID [%s]", payload.PhoneID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onPhoneHeartbeatOnline handles the events.EventTypePhoneHeartbeatOnline event
+func (listener *PhoneFailoverListener) onPhoneHeartbeatOnline(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.PhoneHeartbeatOnlinePayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	params := &services.PhoneFailoverParams{
+		Source:    event.Source(),
+		UserID:    payload.UserID,
+		PhoneID:   payload.PhoneID,
+		Timestamp: payload.Timestamp,
+	}
+
+	if err := listener.service.Deactivate(ctx, params); err != nil {
+		msg := fmt.Sprintf("cannot deactivate failover of phone [%s] for event with ID [%s]", payload.PhoneID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
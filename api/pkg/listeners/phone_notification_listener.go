@@ -35,6 +35,7 @@ func NewNotificationListener(
 	return l, map[string]events.EventListener{
 		events.EventTypeMessageAPISent:          l.onMessageAPISent,
 		events.EventTypeMessageSendRetry:        l.onMessageSendRetry,
+		events.EventTypeMessageSendRerouted:     l.onMessageSendRerouted,
 		events.EventTypeMessageNotificationSend: l.onMessageNotificationSend,
 		events.PhoneHeartbeatMissed:             l.onPhoneHeartbeatMissed,
 	}
@@ -100,6 +101,36 @@ func (listener *PhoneNotificationListener) onMessageSendRetry(ctx context.Contex
 	return nil
 }
 
+// onMessageSendRerouted handles the events.EventTypeMessageSendRerouted event
+func (listener *PhoneNotificationListener) onMessageSendRerouted(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessageSendReroutedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	sendParams := &services.PhoneNotificationScheduleParams{
+		UserID:    payload.UserID,
+		Owner:     payload.Owner,
+		Contact:   payload.Contact,
+		Content:   payload.Content,
+		SIM:       payload.SIM,
+		Encrypted: payload.Encrypted,
+		Source:    event.Source(),
+		MessageID: payload.MessageID,
+	}
+
+	if err := listener.service.Schedule(ctx, sendParams); err != nil {
+		msg := fmt.Sprintf("cannot send notification with params [%s] for event with ID [%s]", spew.Sdump(sendParams), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
 // onPhoneHeartbeatMissed handles the events.PhoneHeartbeatMissed event
 func (listener *PhoneNotificationListener) onPhoneHeartbeatMissed(ctx context.Context, event cloudevents.Event) error {
 	ctx, span := listener.tracer.Start(ctx)
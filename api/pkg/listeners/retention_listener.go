@@ -0,0 +1,55 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// RetentionListener handles cloud events which purge data older than the retention policy
+type RetentionListener struct {
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.RetentionService
+}
+
+// NewRetentionListener creates a new instance of RetentionListener
+func NewRetentionListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.RetentionService,
+) (l *RetentionListener, routes map[string]events.EventListener) {
+	l = &RetentionListener{
+		logger:  logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:  tracer,
+		service: service,
+	}
+
+	return l, map[string]events.EventListener{
+		events.UserRetentionPurge: l.onUserRetentionPurge,
+	}
+}
+
+// onUserRetentionPurge handles the events.UserRetentionPurge event
+func (listener *RetentionListener) onUserRetentionPurge(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	payload := new(events.UserRetentionPurgePayload)
+	if err := event.DataAs(payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Purge(ctx, event.Source(), payload); err != nil {
+		msg := fmt.Sprintf("cannot purge data of user [%s] for event with ID [%s]", payload.UserID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
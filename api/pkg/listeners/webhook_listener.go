@@ -39,6 +39,10 @@ func NewWebhookListener(
 		events.EventTypePhoneHeartbeatOnline:  l.onPhoneHeartbeatOnline,
 		events.EventTypePhoneHeartbeatOffline: l.onPhoneHeartbeatOffline,
 		events.MessageCallMissed:              l.onMessageCallMissed,
+		events.EventTypePhoneBatteryLow:       l.onPhoneBatteryLow,
+		events.EventTypePhoneSIMMissing:       l.onPhoneSIMMissing,
+		events.EventTypeMessageSendRerouted:   l.onMessageSendRerouted,
+		events.EventTypePhoneCapReached:       l.onPhoneCapReached,
 	}
 }
 
@@ -193,3 +197,79 @@ func (listener *WebhookListener) onMessageCallMissed(ctx context.Context, event
 
 	return nil
 }
+
+// onPhoneBatteryLow handles the events.EventTypePhoneBatteryLow event
+func (listener *WebhookListener) onPhoneBatteryLow(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.PhoneBatteryLowPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Send(ctx, payload.UserID, event, payload.Owner); err != nil {
+		msg := fmt.Sprintf("cannot process [%s] event with ID [%s]", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onPhoneSIMMissing handles the events.EventTypePhoneSIMMissing event
+func (listener *WebhookListener) onPhoneSIMMissing(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.PhoneSIMMissingPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Send(ctx, payload.UserID, event, payload.Owner); err != nil {
+		msg := fmt.Sprintf("cannot process [%s] event with ID [%s]", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onMessageSendRerouted handles the events.EventTypeMessageSendRerouted event
+func (listener *WebhookListener) onMessageSendRerouted(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessageSendReroutedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Send(ctx, payload.UserID, event, payload.PreviousOwner); err != nil {
+		msg := fmt.Sprintf("cannot process [%s] event with ID [%s]", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onPhoneCapReached handles the events.EventTypePhoneCapReached event
+func (listener *WebhookListener) onPhoneCapReached(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.PhoneCapReachedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Send(ctx, payload.UserID, event, payload.Owner); err != nil {
+		msg := fmt.Sprintf("cannot process [%s] event with ID [%s]", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -1,16 +1,22 @@
 package middlewares
 
 import (
+	"context"
 	"fmt"
+	"sync"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
 
 	"github.com/NdoleStudio/httpsms/pkg/repositories"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"github.com/palantir/stacktrace"
 )
 
 // APIKeyAuth authenticates a user from the X-API-Key header
-func APIKeyAuth(logger telemetry.Logger, tracer telemetry.Tracer, userRepository repositories.UserRepository) fiber.Handler {
+func APIKeyAuth(logger telemetry.Logger, tracer telemetry.Tracer, userRepository repositories.UserRepository, apiKeyRepository repositories.APIKeyRepository) fiber.Handler {
 	logger = logger.WithService("middlewares.APIKeyAuth")
 
 	return func(c *fiber.Ctx) error {
@@ -25,18 +31,91 @@ func APIKeyAuth(logger telemetry.Logger, tracer telemetry.Tracer, userRepository
 			return c.Next()
 		}
 
-		authUser, err := userRepository.LoadAuthUser(ctx, apiKey)
+		authUser, err := loadAPIKeyUser(ctx, c, logger, userRepository, apiKeyRepository, apiKey)
 		if err != nil {
 			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot load user with api key [%s]", apiKey)))
 			return c.Next()
 		}
 
 		c.Locals(ContextKeyAuthUserID, authUser)
+		c.Locals(ContextKeyAPIKeyScopes, authUser.Scopes)
 		ctxLogger.Info(fmt.Sprintf("[%T] set successfully for user with ID [%s]", authUser, authUser.ID))
 		return c.Next()
 	}
 }
 
+// apiKeyUsageInterval is the minimum interval between the usage records of a scoped entities.APIKey
+const apiKeyUsageInterval = time.Minute
+
+// apiKeyUsage throttles the usage records of scoped API keys across the API key middlewares
+var apiKeyUsage = &apiKeyUsageThrottle{recordedAt: map[uuid.UUID]time.Time{}}
+
+// apiKeyUsageThrottle keeps the last time when the usage of each scoped entities.APIKey was recorded
+type apiKeyUsageThrottle struct {
+	mutex      sync.Mutex
+	recordedAt map[uuid.UUID]time.Time
+}
+
+// shouldRecord checks if the usage of an API key was not recorded within the apiKeyUsageInterval and marks it as recorded
+func (throttle *apiKeyUsageThrottle) shouldRecord(apiKeyID uuid.UUID, timestamp time.Time) bool {
+	throttle.mutex.Lock()
+	defer throttle.mutex.Unlock()
+
+	if recordedAt, ok := throttle.recordedAt[apiKeyID]; ok && timestamp.Sub(recordedAt) < apiKeyUsageInterval {
+		return false
+	}
+
+	for id, recordedAt := range throttle.recordedAt {
+		if timestamp.Sub(recordedAt) >= apiKeyUsageInterval {
+			delete(throttle.recordedAt, id)
+		}
+	}
+
+	throttle.recordedAt[apiKeyID] = timestamp
+	return true
+}
+
+// loadAPIKeyUser loads the entities.AuthUser of the user's main API key or one of the scoped entities.APIKey
+func loadAPIKeyUser(
+	ctx context.Context,
+	c *fiber.Ctx,
+	logger telemetry.Logger,
+	userRepository repositories.UserRepository,
+	apiKeyRepository repositories.APIKeyRepository,
+	apiKey string,
+) (entities.AuthUser, error) {
+	authUser, err := userRepository.LoadAuthUser(ctx, apiKey)
+	if err == nil || stacktrace.GetCode(err) != repositories.ErrCodeNotFound {
+		return authUser, err
+	}
+
+	authUser, err = apiKeyRepository.LoadAuthUser(ctx, apiKey)
+	if err != nil {
+		return authUser, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), "cannot load scoped api key")
+	}
+
+	recordAPIKeyUsage(ctx, logger, apiKeyRepository, *authUser.APIKeyID, c.IP())
+	return authUser, nil
+}
+
+// recordAPIKeyUsage records the usage of a scoped entities.APIKey in the background at most once in the apiKeyUsageInterval.
+// The request is authenticated even when the usage cannot be recorded.
+func recordAPIKeyUsage(ctx context.Context, logger telemetry.Logger, apiKeyRepository repositories.APIKeyRepository, apiKeyID uuid.UUID, ipAddress string) {
+	timestamp := time.Now().UTC()
+	if !apiKeyUsage.shouldRecord(apiKeyID, timestamp) {
+		return
+	}
+
+	go func(ctx context.Context) {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		if err := apiKeyRepository.RecordUsage(ctx, apiKeyID, ipAddress, timestamp); err != nil {
+			logger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot record usage of api key [%s] from IP [%s]", apiKeyID, ipAddress)))
+		}
+	}(context.WithoutCancel(ctx))
+}
+
 func getAPIKeyFromRequest(c *fiber.Ctx) string {
 	apiKey := c.Get(authHeaderAPIKey)
 	if len(apiKey) != 0 {
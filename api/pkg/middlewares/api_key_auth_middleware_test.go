@@ -0,0 +1,122 @@
+package middlewares
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+)
+
+// testUserRepository is a repositories.UserRepository which has no user for any API key
+type testUserRepository struct {
+	repositories.UserRepository
+}
+
+func (repository *testUserRepository) LoadAuthUser(_ context.Context, _ string) (entities.AuthUser, error) {
+	return entities.AuthUser{}, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, "user does not exist")
+}
+
+// testAPIKeyRepository is a repositories.APIKeyRepository with a single scoped API key which counts the usage records
+type testAPIKeyRepository struct {
+	repositories.APIKeyRepository
+	apiKeyID  uuid.UUID
+	recordErr error
+	records   int32
+}
+
+func (repository *testAPIKeyRepository) LoadAuthUser(_ context.Context, key string) (entities.AuthUser, error) {
+	if key != "scoped-key" {
+		return entities.AuthUser{}, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, "api key does not exist")
+	}
+	return entities.AuthUser{
+		ID:       "user-id",
+		Email:    "name@example.com",
+		APIKeyID: &repository.apiKeyID,
+		Scopes:   []entities.APIKeyScope{entities.APIKeyScopeMessagesRead},
+	}, nil
+}
+
+func (repository *testAPIKeyRepository) RecordUsage(_ context.Context, _ uuid.UUID, _ string, _ time.Time) error {
+	atomic.AddInt32(&repository.records, 1)
+	return repository.recordErr
+}
+
+// testAPIKeyAuthApp creates a fiber.App with the APIKeyAuth middleware where the route returns the ID of the authenticated user
+func testAPIKeyAuthApp(apiKeyRepository *testAPIKeyRepository) *fiber.App {
+	logger, tracer := testLoggerAndTracer()
+
+	app := fiber.New()
+	app.Use(APIKeyAuth(logger, tracer, &testUserRepository{}, apiKeyRepository))
+	app.Get("/v1/messages", func(c *fiber.Ctx) error {
+		if authUser, ok := c.Locals(ContextKeyAuthUserID).(entities.AuthUser); ok {
+			return c.SendString(string(authUser.ID))
+		}
+		return c.SendStatus(fiber.StatusUnauthorized)
+	})
+	return app
+}
+
+func testAPIKeyRequest(t *testing.T, app *fiber.App) int {
+	request := httptest.NewRequest(fiber.MethodGet, "/v1/messages", nil)
+	request.Header.Set(authHeaderAPIKey, "scoped-key")
+
+	response, err := app.Test(request, -1)
+	assert.Nil(t, err)
+	return response.StatusCode
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	t.Run("the usage of a scoped api key is recorded at most once per minute", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		repository := &testAPIKeyRepository{apiKeyID: uuid.New()}
+		app := testAPIKeyAuthApp(repository)
+
+		// Act
+		statusCodes := []int{testAPIKeyRequest(t, app), testAPIKeyRequest(t, app), testAPIKeyRequest(t, app)}
+
+		// Assert
+		assert.Equal(t, []int{fiber.StatusOK, fiber.StatusOK, fiber.StatusOK}, statusCodes)
+		assert.Eventually(t, func() bool { return atomic.LoadInt32(&repository.records) == 1 }, time.Second, time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&repository.records))
+	})
+
+	t.Run("the request is authenticated when the usage cannot be recorded", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		repository := &testAPIKeyRepository{apiKeyID: uuid.New(), recordErr: stacktrace.NewError("database is down")}
+		app := testAPIKeyAuthApp(repository)
+
+		// Act
+		statusCode := testAPIKeyRequest(t, app)
+
+		// Assert
+		assert.Equal(t, fiber.StatusOK, statusCode)
+		assert.Eventually(t, func() bool { return atomic.LoadInt32(&repository.records) == 1 }, time.Second, time.Millisecond)
+	})
+}
+
+func TestAPIKeyUsageThrottle_ShouldRecord(t *testing.T) {
+	t.Run("the usage is recorded again after the interval", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		throttle := &apiKeyUsageThrottle{recordedAt: map[uuid.UUID]time.Time{}}
+		apiKeyID := uuid.New()
+		timestamp := time.Now().UTC()
+
+		// Act & Assert
+		assert.True(t, throttle.shouldRecord(apiKeyID, timestamp))
+		assert.False(t, throttle.shouldRecord(apiKeyID, timestamp.Add(30*time.Second)))
+		assert.True(t, throttle.shouldRecord(uuid.New(), timestamp.Add(30*time.Second)))
+		assert.True(t, throttle.shouldRecord(apiKeyID, timestamp.Add(apiKeyUsageInterval)))
+	})
+}
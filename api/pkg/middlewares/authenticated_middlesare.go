@@ -1,6 +1,9 @@
 package middlewares
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/NdoleStudio/httpsms/pkg/entities"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 	"github.com/gofiber/fiber/v2"
@@ -9,12 +12,20 @@ import (
 const (
 	authHeaderBearer = "Authorization"
 	authHeaderAPIKey = "x-api-key"
-	bearerScheme     = "Bearer"
+
+	authHeaderOrganizationID = "x-organization-id"
+	bearerScheme             = "Bearer"
 )
 
 const (
 	// ContextKeyAuthUserID is the context key used to store the ID of an authenticated user
 	ContextKeyAuthUserID = "auth.user.id"
+
+	// ContextKeyOrganizationMember is the context key used to store the acting entities.OrganizationMember
+	ContextKeyOrganizationMember = "auth.organization.member"
+
+	// ContextKeyAPIKeyScopes is the context key used to store the []entities.APIKeyScope of a scoped API key
+	ContextKeyAPIKeyScopes = "auth.api_key.scopes"
 )
 
 // Authenticated checks if the request is authenticated
@@ -31,6 +42,17 @@ func Authenticated(tracer telemetry.Tracer) fiber.Handler {
 			})
 		}
 
+		member, ok := c.Locals(ContextKeyOrganizationMember).(*entities.OrganizationMember)
+		if ok && !member.Role.CanWrite() && !isReadRequest(c) {
+			span.AddEvent(fmt.Sprintf("member [%s] with role [%s] cannot carry out [%s] requests", member.ID, member.Role, c.Method()))
+			return responseForbidden(c)
+		}
+
 		return c.Next()
 	}
 }
+
+// isReadRequest checks if a request only reads resources. Batch get endpoints read resources with a POST request.
+func isReadRequest(c *fiber.Ctx) bool {
+	return c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead || (c.Method() == fiber.MethodPost && strings.HasSuffix(c.Path(), "/batch-get"))
+}
@@ -0,0 +1,63 @@
+package middlewares
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// testAuthenticatedApp creates a fiber.App with the Authenticated middleware for a member with the role
+func testAuthenticatedApp(role entities.OrganizationRole) *fiber.App {
+	_, tracer := testLoggerAndTracer()
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals(ContextKeyAuthUserID, entities.AuthUser{ID: "user-id", Email: "name@example.com"})
+		c.Locals(ContextKeyOrganizationMember, &entities.OrganizationMember{Role: role})
+		return c.Next()
+	})
+	app.Use(Authenticated(tracer))
+
+	handler := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Get("/v1/messages", handler)
+	app.Post("/v1/messages/send", handler)
+	app.Post("/v1/messages/batch-get", handler)
+	return app
+}
+
+func TestAuthenticated(t *testing.T) {
+	t.Run("read-only members can read and batch get but cannot write", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		app := testAuthenticatedApp(entities.OrganizationRoleReadOnly)
+
+		// Act
+		getResponse, getErr := app.Test(httptest.NewRequest(fiber.MethodGet, "/v1/messages", nil))
+		batchResponse, batchErr := app.Test(httptest.NewRequest(fiber.MethodPost, "/v1/messages/batch-get", nil))
+		sendResponse, sendErr := app.Test(httptest.NewRequest(fiber.MethodPost, "/v1/messages/send", nil))
+
+		// Assert
+		assert.Nil(t, getErr)
+		assert.Nil(t, batchErr)
+		assert.Nil(t, sendErr)
+		assert.Equal(t, fiber.StatusOK, getResponse.StatusCode)
+		assert.Equal(t, fiber.StatusOK, batchResponse.StatusCode)
+		assert.Equal(t, fiber.StatusForbidden, sendResponse.StatusCode)
+	})
+
+	t.Run("agents can write", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		app := testAuthenticatedApp(entities.OrganizationRoleAgent)
+
+		// Act
+		response, err := app.Test(httptest.NewRequest(fiber.MethodPost, "/v1/messages/send", nil))
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, fiber.StatusOK, response.StatusCode)
+	})
+}
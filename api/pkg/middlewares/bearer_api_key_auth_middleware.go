@@ -11,7 +11,7 @@ import (
 )
 
 // BearerAPIKeyAuth authenticates an API key using the Bearer header
-func BearerAPIKeyAuth(logger telemetry.Logger, tracer telemetry.Tracer, userRepository repositories.UserRepository) fiber.Handler {
+func BearerAPIKeyAuth(logger telemetry.Logger, tracer telemetry.Tracer, userRepository repositories.UserRepository, apiKeyRepository repositories.APIKeyRepository) fiber.Handler {
 	logger = logger.WithService("middlewares.APIKeyAuth")
 
 	return func(c *fiber.Ctx) error {
@@ -26,13 +26,14 @@ func BearerAPIKeyAuth(logger telemetry.Logger, tracer telemetry.Tracer, userRepo
 			return c.Next()
 		}
 
-		authUser, err := userRepository.LoadAuthUser(ctx, apiKey)
+		authUser, err := loadAPIKeyUser(ctx, c, logger, userRepository, apiKeyRepository, apiKey)
 		if err != nil {
 			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot load user with api key [%s] using header [%s]", apiKey, c.Get(authHeaderBearer))))
 			return c.Next()
 		}
 
 		c.Locals(ContextKeyAuthUserID, authUser)
+		c.Locals(ContextKeyAPIKeyScopes, authUser.Scopes)
 
 		ctxLogger.Info(fmt.Sprintf("[%T] set successfully for user with ID [%s]", authUser, authUser.ID))
 
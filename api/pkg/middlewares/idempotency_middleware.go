@@ -0,0 +1,253 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/cache"
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	headerIdempotencyKey          = "Idempotency-Key"
+	headerIdempotencyUseRequestID = "Idempotency-Use-Request-ID"
+	headerIdempotentReplayed      = "Idempotent-Replayed"
+
+	idempotencyTTL          = 24 * time.Hour
+	idempotencyMaxKeyLength = 255
+
+	// idempotencyReservationTTL releases the key of a request which crashed before its response was stored
+	idempotencyReservationTTL = time.Minute
+)
+
+// idempotentRoutes are the routes which honour the Idempotency-Key header
+var idempotentRoutes = map[string]bool{
+	"/v1/messages/send":      true,
+	"/v1/messages/bulk-send": true,
+	"/v1/bulk-messages":      true,
+}
+
+// idempotencyRecord is the response of an idempotent request which is stored in the cache.Cache
+type idempotencyRecord struct {
+	RequestHash string `json:"request_hash"`
+	Completed   bool   `json:"completed"`
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// Idempotency replays the stored response when a send request is retried with the same Idempotency-Key.
+// The request_id in the payload is used as the key when the Idempotency-Use-Request-ID header is set to true.
+func Idempotency(logger telemetry.Logger, tracer telemetry.Tracer, store cache.Cache) fiber.Handler {
+	logger = logger.WithService("middlewares.Idempotency")
+
+	return func(c *fiber.Ctx) error {
+		if c.Method() != fiber.MethodPost || !idempotentRoutes[strings.TrimSuffix(c.Path(), "/")] {
+			return c.Next()
+		}
+
+		ctx, span, ctxLogger := tracer.StartFromFiberCtxWithLogger(c, logger)
+		defer span.End()
+
+		authUser, ok := c.Locals(ContextKeyAuthUserID).(entities.AuthUser)
+		if !ok || authUser.IsNoop() {
+			return c.Next()
+		}
+
+		idempotencyKey := idempotencyKeyFromRequest(c)
+		if idempotencyKey == "" {
+			return c.Next()
+		}
+
+		if len(idempotencyKey) > idempotencyMaxKeyLength {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"status":  "error",
+				"message": fmt.Sprintf("The %s header must not be longer than %d characters.", headerIdempotencyKey, idempotencyMaxKeyLength),
+			})
+		}
+
+		requestHash, err := idempotencyRequestHash(c)
+		if err != nil {
+			ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot hash request with idempotency key [%s]", idempotencyKey)))
+			return c.Next()
+		}
+
+		key := fmt.Sprintf("idempotency:%s:%s:%s", authUser.ID, c.Path(), idempotencyKey)
+		reserved, err := reserveIdempotencyKey(ctx, store, key, requestHash)
+		if err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot reserve idempotency key [%s]", key)))
+			return c.Next()
+		}
+
+		if !reserved {
+			return replayIdempotentResponse(ctx, c, store, key, requestHash)
+		}
+
+		if err = c.Next(); err != nil {
+			if err = store.Delete(ctx, key); err != nil {
+				ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot release idempotency key [%s]", key)))
+			}
+			return err
+		}
+
+		// requests which failed because of a server error or rate limit can be retried with the same key
+		statusCode := c.Response().StatusCode()
+		if statusCode >= fiber.StatusInternalServerError || statusCode == fiber.StatusTooManyRequests {
+			if err = store.Delete(ctx, key); err != nil {
+				ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot release idempotency key [%s]", key)))
+			}
+			return nil
+		}
+
+		record := &idempotencyRecord{
+			RequestHash: requestHash,
+			Completed:   true,
+			StatusCode:  statusCode,
+			ContentType: string(c.Response().Header.ContentType()),
+			Body:        c.Response().Body(),
+		}
+
+		if err = storeIdempotencyRecord(ctx, store, key, record, idempotencyTTL); err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot store response for idempotency key [%s]", key)))
+		}
+
+		return nil
+	}
+}
+
+// reserveIdempotencyKey atomically stores an in progress record for the key so that only one request with the key is processed
+func reserveIdempotencyKey(ctx context.Context, store cache.Cache, key string, requestHash string) (bool, error) {
+	content, err := json.Marshal(&idempotencyRecord{RequestHash: requestHash})
+	if err != nil {
+		return false, stacktrace.Propagate(err, fmt.Sprintf("cannot marshal [%T]", &idempotencyRecord{}))
+	}
+	return store.SetNX(ctx, key, string(content), idempotencyReservationTTL)
+}
+
+// replayIdempotentResponse sends the stored response of a key which is already reserved
+func replayIdempotentResponse(ctx context.Context, c *fiber.Ctx, store cache.Cache, key string, requestHash string) error {
+	record := new(idempotencyRecord)
+	if value, err := store.Get(ctx, key); err == nil {
+		_ = json.Unmarshal([]byte(value), record)
+	}
+
+	if record.RequestHash != "" && record.RequestHash != requestHash {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "error",
+			"message": fmt.Sprintf("The %s has already been used with a different request payload.", headerIdempotencyKey),
+		})
+	}
+
+	if !record.Completed {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "error",
+			"message": fmt.Sprintf("A request with the same %s is still being processed.", headerIdempotencyKey),
+		})
+	}
+
+	c.Set(headerIdempotentReplayed, "true")
+	c.Set(fiber.HeaderContentType, record.ContentType)
+	return c.Status(record.StatusCode).Send(record.Body)
+}
+
+func storeIdempotencyRecord(ctx context.Context, store cache.Cache, key string, record *idempotencyRecord, ttl time.Duration) error {
+	content, err := json.Marshal(record)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot marshal [%T]", record))
+	}
+	return store.Set(ctx, key, string(content), ttl)
+}
+
+// idempotencyKeyFromRequest returns the Idempotency-Key header or the request_id of the payload when opted in
+func idempotencyKeyFromRequest(c *fiber.Ctx) string {
+	if key := strings.TrimSpace(c.Get(headerIdempotencyKey)); key != "" {
+		return key
+	}
+
+	if !strings.EqualFold(c.Get(headerIdempotencyUseRequestID), "true") {
+		return ""
+	}
+
+	var payload struct {
+		RequestID string `json:"request_id"`
+	}
+	if err := json.Unmarshal(c.Body(), &payload); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(payload.RequestID)
+}
+
+// idempotencyRequestHash returns the SHA-256 hash of the request payload.
+// JSON payloads are canonicalized so that a retry with different whitespace or key order gives the same hash.
+// Multipart forms are hashed by field so that a different boundary on a retry gives the same hash.
+func idempotencyRequestHash(c *fiber.Ctx) (string, error) {
+	hash := sha256.New()
+	if !strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		hash.Write(canonicalJSON(c.Body()))
+		return hex.EncodeToString(hash.Sum(nil)), nil
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return "", stacktrace.Propagate(err, "cannot parse multipart form")
+	}
+
+	for _, name := range sortedKeys(form.Value) {
+		hash.Write([]byte(name + "=" + strings.Join(form.Value[name], ",") + "\n"))
+	}
+
+	for _, name := range sortedKeys(form.File) {
+		for _, header := range form.File[name] {
+			file, err := header.Open()
+			if err != nil {
+				return "", stacktrace.Propagate(err, fmt.Sprintf("cannot open file [%s]", header.Filename))
+			}
+
+			hash.Write([]byte(name + "=" + header.Filename + "\n"))
+			_, err = io.Copy(hash, file)
+			_ = file.Close()
+			if err != nil {
+				return "", stacktrace.Propagate(err, fmt.Sprintf("cannot read file [%s]", header.Filename))
+			}
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// canonicalJSON encodes a JSON payload with sorted keys and without whitespace. Other payloads are returned as is.
+func canonicalJSON(body []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var payload any
+	if err := decoder.Decode(&payload); err != nil || decoder.More() {
+		return body
+	}
+
+	content, err := json.Marshal(payload)
+	if err != nil {
+		return body
+	}
+	return content
+}
+
+func sortedKeys[T any](values map[string]T) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
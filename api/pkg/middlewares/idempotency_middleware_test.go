@@ -0,0 +1,196 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/cache"
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/gofiber/fiber/v2"
+	"github.com/hirosassa/zerodriver"
+	ttlCache "github.com/patrickmn/go-cache"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func testLoggerAndTracer() (telemetry.Logger, telemetry.Tracer) {
+	nop := zerolog.Nop()
+	logger := telemetry.NewZerologLogger("test", nil, &zerodriver.Logger{Logger: &nop}, nil)
+	return logger, telemetry.NewOtelLogger("test", logger)
+}
+
+// testIdempotencyApp creates a fiber.App with the Idempotency middleware where the send route waits for the release channel
+func testIdempotencyApp(calls *int32, release chan struct{}) *fiber.App {
+	logger, tracer := testLoggerAndTracer()
+	store := cache.NewMemoryCache(tracer, ttlCache.New(time.Minute, time.Minute))
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals(ContextKeyAuthUserID, entities.AuthUser{ID: "user-id", Email: "name@example.com"})
+		return c.Next()
+	})
+	app.Use(Idempotency(logger, tracer, store))
+	app.Post("/v1/messages/send", func(c *fiber.Ctx) error {
+		count := atomic.AddInt32(calls, 1)
+		if release != nil {
+			<-release
+		}
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"calls": count})
+	})
+	return app
+}
+
+func testIdempotentRequest(t *testing.T, app *fiber.App, key string, body string) *http.Response {
+	request := httptest.NewRequest(fiber.MethodPost, "/v1/messages/send", bytes.NewBufferString(body))
+	request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	request.Header.Set(headerIdempotencyKey, key)
+
+	response, err := app.Test(request, -1)
+	assert.Nil(t, err)
+	return response
+}
+
+// testTTLCache is a cache.Cache which records the TTL of the items which are set
+type testTTLCache struct {
+	cache.Cache
+	mutex sync.Mutex
+	ttls  []time.Duration
+}
+
+func (store *testTTLCache) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	store.record(ttl)
+	return store.Cache.Set(ctx, key, value, ttl)
+}
+
+func (store *testTTLCache) SetNX(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	store.record(ttl)
+	return store.Cache.SetNX(ctx, key, value, ttl)
+}
+
+func (store *testTTLCache) record(ttl time.Duration) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.ttls = append(store.ttls, ttl)
+}
+
+func TestIdempotency(t *testing.T) {
+	t.Run("a retry with the same payload replays the stored response", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		var calls int32
+		app := testIdempotencyApp(&calls, nil)
+
+		// Arrange
+		first := testIdempotentRequest(t, app, "key-1", `{"to":"+18005550100","content":"hello"}`)
+
+		// Act
+		response := testIdempotentRequest(t, app, "key-1", `{ "content": "hello", "to": "+18005550100" }`)
+
+		// Assert
+		assert.Equal(t, fiber.StatusOK, first.StatusCode)
+		assert.Equal(t, fiber.StatusOK, response.StatusCode)
+		assert.Equal(t, "true", response.Header.Get(headerIdempotentReplayed))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("a retry with a different payload is rejected", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		var calls int32
+		app := testIdempotencyApp(&calls, nil)
+
+		// Arrange
+		testIdempotentRequest(t, app, "key-1", `{"to":"+18005550100","content":"hello"}`)
+
+		// Act
+		response := testIdempotentRequest(t, app, "key-1", `{"to":"+18005550100","content":"goodbye"}`)
+
+		// Assert
+		assert.Equal(t, fiber.StatusConflict, response.StatusCode)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("requests with different keys are not replayed", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		var calls int32
+		app := testIdempotencyApp(&calls, nil)
+
+		// Arrange
+		testIdempotentRequest(t, app, "key-1", `{"to":"+18005550100","content":"hello"}`)
+
+		// Act
+		response := testIdempotentRequest(t, app, "key-2", `{"to":"+18005550100","content":"hello"}`)
+
+		// Assert
+		assert.Equal(t, fiber.StatusOK, response.StatusCode)
+		assert.Equal(t, "", response.Header.Get(headerIdempotentReplayed))
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("only one of concurrent requests with the same key is processed", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		var calls int32
+		release := make(chan struct{})
+		app := testIdempotencyApp(&calls, release)
+
+		// Arrange
+		requests := 5
+		var wg sync.WaitGroup
+		statusCodes := make(chan int, requests)
+		for i := 0; i < requests; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				statusCodes <- testIdempotentRequest(t, app, "key-1", `{"to":"+18005550100","content":"hello"}`).StatusCode
+			}()
+		}
+
+		// Act
+		assert.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 1 }, time.Second, time.Millisecond)
+		assert.Eventually(t, func() bool { return len(statusCodes) == requests-1 }, time.Second, time.Millisecond)
+		close(release)
+		wg.Wait()
+		close(statusCodes)
+
+		// Assert
+		counts := map[int]int{}
+		for statusCode := range statusCodes {
+			counts[statusCode]++
+		}
+		assert.Equal(t, map[int]int{fiber.StatusOK: 1, fiber.StatusConflict: requests - 1}, counts)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("the key is reserved for a short time and the response is stored for a day", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		store := &testTTLCache{Cache: cache.NewMemoryCache(tracer, ttlCache.New(time.Minute, time.Minute))}
+
+		app := fiber.New()
+		app.Use(func(c *fiber.Ctx) error {
+			c.Locals(ContextKeyAuthUserID, entities.AuthUser{ID: "user-id", Email: "name@example.com"})
+			return c.Next()
+		})
+		app.Use(Idempotency(logger, tracer, store))
+		app.Post("/v1/messages/send", func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusOK)
+		})
+
+		// Act
+		response := testIdempotentRequest(t, app, "key-1", `{"to":"+18005550100","content":"hello"}`)
+
+		// Assert
+		assert.Equal(t, fiber.StatusOK, response.StatusCode)
+		assert.Equal(t, []time.Duration{idempotencyReservationTTL, idempotencyTTL}, store.ttls)
+	})
+}
@@ -0,0 +1,70 @@
+package middlewares
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// OrganizationMember resolves the acting entities.OrganizationMember from the X-Organization-ID header.
+// The authenticated user is replaced with the owner of the organization so billing happens at the organization level
+// and the repositories.OrganizationScope limits the phones which the repositories load to the phones granted to the member.
+func OrganizationMember(logger telemetry.Logger, tracer telemetry.Tracer, organizationRepository repositories.OrganizationRepository) fiber.Handler {
+	logger = logger.WithService("middlewares.OrganizationMember")
+
+	return func(c *fiber.Ctx) error {
+		ctx, span, ctxLogger := tracer.StartFromFiberCtxWithLogger(c, logger)
+		defer span.End()
+
+		organizationID := c.Get(authHeaderOrganizationID)
+		if len(organizationID) == 0 {
+			span.AddEvent(fmt.Sprintf("the request header has no [%s] header", authHeaderOrganizationID))
+			return c.Next()
+		}
+
+		authUser, ok := c.Locals(ContextKeyAuthUserID).(entities.AuthUser)
+		if !ok || authUser.IsNoop() {
+			span.AddEvent(fmt.Sprintf("cannot resolve organization [%s] for an unauthenticated request", organizationID))
+			return c.Next()
+		}
+
+		ID, err := uuid.Parse(organizationID)
+		if err != nil {
+			ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("invalid organization ID [%s] in the [%s] header", organizationID, authHeaderOrganizationID)))
+			return responseForbidden(c)
+		}
+
+		member, err := organizationRepository.LoadMember(ctx, ID, authUser.ID)
+		if err != nil {
+			ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot load member [%s] of organization [%s]", authUser.ID, ID)))
+			return responseForbidden(c)
+		}
+
+		organization, err := organizationRepository.Load(ctx, ID)
+		if err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot load organization with ID [%s]", ID)))
+			return responseForbidden(c)
+		}
+
+		c.Locals(ContextKeyOrganizationMember, member)
+		c.SetUserContext(repositories.WithOrganizationScope(c.UserContext(), repositories.NewOrganizationScope(organization, member)))
+		owner := authUser
+		owner.ID = organization.OwnerID
+		c.Locals(ContextKeyAuthUserID, owner)
+
+		ctxLogger.Info(fmt.Sprintf("user [%s] is acting as [%s] in organization [%s]", authUser.ID, member.Role, organization.ID))
+		return c.Next()
+	}
+}
+
+func responseForbidden(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"status":  "error",
+		"message": fiber.ErrForbidden.Message,
+	})
+}
@@ -0,0 +1,104 @@
+package middlewares
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	headerRateLimitLimit     = "X-RateLimit-Limit"
+	headerRateLimitRemaining = "X-RateLimit-Remaining"
+	headerRateLimitReset     = "X-RateLimit-Reset"
+
+	// contextKeyRateLimited marks a request which was already rate limited by an earlier RateLimit middleware
+	contextKeyRateLimited = "rate_limit.consumed"
+)
+
+// RateLimit limits the rate of requests of an authenticated user or API key using a token bucket per services.RateLimitGroup.
+// It is used after every auth middleware and a request is only limited by the first one which sees it authenticated.
+func RateLimit(logger telemetry.Logger, tracer telemetry.Tracer, service *services.RateLimitService) fiber.Handler {
+	logger = logger.WithService("middlewares.RateLimit")
+
+	return func(c *fiber.Ctx) error {
+		ctx, span := tracer.StartFromFiberCtx(c, "middlewares.RateLimit")
+		defer span.End()
+
+		authUser, ok := c.Locals(ContextKeyAuthUserID).(entities.AuthUser)
+		if !ok || authUser.IsNoop() {
+			span.AddEvent("the request is not authenticated so it is not rate limited")
+			return c.Next()
+		}
+
+		if consumed, _ := c.Locals(contextKeyRateLimited).(bool); consumed {
+			return c.Next()
+		}
+		c.Locals(contextKeyRateLimited, true)
+
+		group := rateLimitGroup(c, authUser)
+		result := service.Consume(ctx, authUser, group)
+
+		c.Set(headerRateLimitLimit, strconv.FormatUint(uint64(result.Limit), 10))
+		c.Set(headerRateLimitRemaining, strconv.FormatUint(uint64(result.Remaining), 10))
+		c.Set(headerRateLimitReset, strconv.FormatInt(result.ResetAt.Unix(), 10))
+
+		if !result.Allowed {
+			retryAfter := int64(math.Ceil(result.RetryAfter.Seconds()))
+			c.Set(fiber.HeaderRetryAfter, strconv.FormatInt(retryAfter, 10))
+			logger.Info(fmt.Sprintf("rate limited [%s] request of user [%s] for [%d] seconds", group, authUser.ID, retryAfter))
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"status":  "error",
+				"message": fmt.Sprintf("You have exceeded the rate limit for this endpoint, retry after %d seconds.", retryAfter),
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// rateLimitGroup returns the services.RateLimitGroup of the request.
+// Requests which are authenticated with an API key of the android app use the phone budget on every route.
+func rateLimitGroup(c *fiber.Ctx, authUser entities.AuthUser) services.RateLimitGroup {
+	if authUser.IsPhoneAPIKey() {
+		return services.RateLimitGroupPhone
+	}
+
+	path := strings.TrimSuffix(c.Path(), "/")
+	switch {
+	case c.Method() == fiber.MethodPost && (path == "/v1/messages/send" || path == "/v1/messages/bulk-send" || path == "/v1/bulk-messages"):
+		return services.RateLimitGroupSend
+	case isPhoneRoute(c.Method(), strings.Split(strings.TrimPrefix(path, "/"), "/")):
+		return services.RateLimitGroupPhone
+	default:
+		return services.RateLimitGroupDefault
+	}
+}
+
+// isPhoneRoute checks if the path segments of a request are for a route which is called by the android app with a full access API key
+func isPhoneRoute(method string, segments []string) bool {
+	if len(segments) < 2 || segments[0] != "v1" {
+		return false
+	}
+
+	switch segments[1] {
+	case "messages":
+		// /v1/messages/receive, /v1/messages/calls/missed, /v1/messages/outstanding[/poll|/batch] and /v1/messages/:messageID/events
+		return len(segments) >= 3 && (segments[2] == "receive" || segments[2] == "calls" || segments[2] == "outstanding" ||
+			(method == fiber.MethodPost && len(segments) == 4 && segments[3] == "events"))
+	case "heartbeats":
+		return method == fiber.MethodPost && len(segments) == 2
+	case "phones":
+		// PUT /v1/phones, GET /v1/phones/:phoneID/config and POST /v1/phones/:phoneID/commands/:commandID/ack
+		return (method == fiber.MethodPut && len(segments) == 2) ||
+			(method == fiber.MethodGet && len(segments) == 4 && segments[3] == "config") ||
+			(method == fiber.MethodPost && len(segments) == 6 && segments[3] == "commands" && segments[5] == "ack")
+	default:
+		return false
+	}
+}
@@ -0,0 +1,170 @@
+package middlewares
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/cache"
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	ttlCache "github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// testRateLimitApp creates a fiber.App with the RateLimit middleware for a user on the free plan
+func testRateLimitApp(t *testing.T, authUser entities.AuthUser, limits services.RateLimits) *fiber.App {
+	logger, tracer := testLoggerAndTracer()
+	store := cache.NewMemoryCache(tracer, ttlCache.New(time.Minute, time.Minute))
+	assert.Nil(t, store.Set(context.Background(), "rate-limit:subscription:"+string(authUser.ID), string(entities.SubscriptionNameFree), time.Minute))
+
+	counter, err := noop.NewMeterProvider().Meter("test").Int64Counter("test")
+	assert.Nil(t, err)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals(ContextKeyAuthUserID, authUser)
+		return c.Next()
+	})
+	app.Use(RateLimit(logger, tracer, services.NewRateLimitService(logger, tracer, store, counter, limits, nil)))
+	app.All("/*", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	return app
+}
+
+func TestRateLimit(t *testing.T) {
+	limits := services.RateLimits{
+		services.RateLimitGroupSend:    {entities.SubscriptionNameFree: {Burst: 1, PerMinute: 1}},
+		services.RateLimitGroupPhone:   {entities.SubscriptionNameFree: {Burst: 3, PerMinute: 3}},
+		services.RateLimitGroupDefault: {entities.SubscriptionNameFree: {Burst: 2, PerMinute: 2}},
+	}
+
+	t.Run("requests are rejected with 429 when the bucket is empty", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		app := testRateLimitApp(t, entities.AuthUser{ID: "user-id", Email: "name@example.com"}, limits)
+
+		// Arrange
+		first, err := app.Test(httptest.NewRequest(fiber.MethodPost, "/v1/messages/send", nil))
+		assert.Nil(t, err)
+
+		// Act
+		second, err := app.Test(httptest.NewRequest(fiber.MethodPost, "/v1/messages/send", nil))
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, fiber.StatusOK, first.StatusCode)
+		assert.Equal(t, "1", first.Header.Get(headerRateLimitLimit))
+		assert.Equal(t, "0", first.Header.Get(headerRateLimitRemaining))
+		assert.Equal(t, fiber.StatusTooManyRequests, second.StatusCode)
+		assert.Equal(t, "60", second.Header.Get(fiber.HeaderRetryAfter))
+	})
+
+	t.Run("requests authenticated by a route middleware are limited once by the rate limiter after it", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		store := cache.NewMemoryCache(tracer, ttlCache.New(time.Minute, time.Minute))
+		assert.Nil(t, store.Set(context.Background(), "rate-limit:subscription:user-id", string(entities.SubscriptionNameFree), time.Minute))
+		counter, err := noop.NewMeterProvider().Meter("test").Int64Counter("test")
+		assert.Nil(t, err)
+		rateLimit := RateLimit(logger, tracer, services.NewRateLimitService(logger, tracer, store, counter, limits, nil))
+
+		// Arrange
+		app := fiber.New()
+		app.Use(rateLimit)
+		app.Get("/integration/3cx/messages", func(c *fiber.Ctx) error {
+			c.Locals(ContextKeyAuthUserID, entities.AuthUser{ID: "user-id", Email: "name@example.com"})
+			return c.Next()
+		}, rateLimit, rateLimit, func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusOK)
+		})
+
+		// Act
+		statusCodes := make([]int, 0, 3)
+		for i := 0; i < 3; i++ {
+			response, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/integration/3cx/messages", nil))
+			assert.Nil(t, err)
+			statusCodes = append(statusCodes, response.StatusCode)
+		}
+
+		// Assert
+		assert.Equal(t, []int{fiber.StatusOK, fiber.StatusOK, fiber.StatusTooManyRequests}, statusCodes)
+	})
+
+	t.Run("route groups have separate buckets", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		app := testRateLimitApp(t, entities.AuthUser{ID: "user-id", Email: "name@example.com"}, limits)
+
+		// Arrange
+		_, err := app.Test(httptest.NewRequest(fiber.MethodPost, "/v1/messages/send", nil))
+		assert.Nil(t, err)
+
+		// Act
+		response, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/v1/messages", nil))
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, fiber.StatusOK, response.StatusCode)
+		assert.Equal(t, "2", response.Header.Get(headerRateLimitLimit))
+	})
+}
+
+func TestRateLimitGroup(t *testing.T) {
+	apiKeyID := uuid.New()
+	user := entities.AuthUser{ID: "user-id", Email: "name@example.com"}
+	phoneKey := entities.AuthUser{ID: "user-id", Email: "name@example.com", APIKeyID: &apiKeyID, Scopes: []entities.APIKeyScope{entities.APIKeyScopePhonesWrite, entities.APIKeyScopeHeartbeatsWrite}}
+	sendKey := entities.AuthUser{ID: "user-id", Email: "name@example.com", APIKeyID: &apiKeyID, Scopes: []entities.APIKeyScope{entities.APIKeyScopeMessagesSend, entities.APIKeyScopePhonesWrite}}
+
+	tests := []struct {
+		name     string
+		authUser entities.AuthUser
+		method   string
+		path     string
+		group    services.RateLimitGroup
+	}{
+		{"send", user, fiber.MethodPost, "/v1/messages/send", services.RateLimitGroupSend},
+		{"bulk messages", user, fiber.MethodPost, "/v1/bulk-messages", services.RateLimitGroupSend},
+		{"messages index", user, fiber.MethodGet, "/v1/messages", services.RateLimitGroupDefault},
+		{"outstanding", user, fiber.MethodGet, "/v1/messages/outstanding", services.RateLimitGroupPhone},
+		{"outstanding poll", user, fiber.MethodGet, "/v1/messages/outstanding/poll", services.RateLimitGroupPhone},
+		{"outstanding batch", user, fiber.MethodGet, "/v1/messages/outstanding/batch", services.RateLimitGroupPhone},
+		{"message events", user, fiber.MethodPost, "/v1/messages/" + apiKeyID.String() + "/events", services.RateLimitGroupPhone},
+		{"heartbeats store", user, fiber.MethodPost, "/v1/heartbeats", services.RateLimitGroupPhone},
+		{"heartbeats index", user, fiber.MethodGet, "/v1/heartbeats", services.RateLimitGroupDefault},
+		{"phone config", user, fiber.MethodGet, "/v1/phones/" + apiKeyID.String() + "/config", services.RateLimitGroupPhone},
+		{"command ack", user, fiber.MethodPost, "/v1/phones/" + apiKeyID.String() + "/commands/" + apiKeyID.String() + "/ack", services.RateLimitGroupPhone},
+		{"phones delete", user, fiber.MethodDelete, "/v1/phones/" + apiKeyID.String(), services.RateLimitGroupDefault},
+		{"phone api key on any route", phoneKey, fiber.MethodGet, "/v1/users/me", services.RateLimitGroupPhone},
+		{"api key with other scopes", sendKey, fiber.MethodGet, "/v1/users/me", services.RateLimitGroupDefault},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			// Setup
+			t.Parallel()
+
+			// Arrange
+			var group services.RateLimitGroup
+			app := fiber.New()
+			app.All("/*", func(c *fiber.Ctx) error {
+				group = rateLimitGroup(c, test.authUser)
+				return nil
+			})
+
+			// Act
+			_, err := app.Test(httptest.NewRequest(test.method, test.path, nil))
+
+			// Assert
+			assert.Nil(t, err)
+			assert.Equal(t, test.group, group)
+		})
+	}
+}
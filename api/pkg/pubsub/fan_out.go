@@ -0,0 +1,64 @@
+package pubsub
+
+import (
+	"sync"
+)
+
+// subscriberBufferSize is the number of messages which are buffered for a slow subscriber before messages are dropped
+const subscriberBufferSize = 64
+
+// fanOut delivers the messages of a channel to the subscribers of the channel in this process
+type fanOut struct {
+	mutex       sync.RWMutex
+	subscribers map[string]map[chan string]struct{}
+}
+
+func newFanOut() *fanOut {
+	return &fanOut{subscribers: map[string]map[chan string]struct{}{}}
+}
+
+// add a subscriber to a channel and return true when it is the first subscriber of the channel
+func (fanOut *fanOut) add(channel string) (chan string, bool) {
+	subscriber := make(chan string, subscriberBufferSize)
+
+	fanOut.mutex.Lock()
+	defer fanOut.mutex.Unlock()
+
+	first := len(fanOut.subscribers[channel]) == 0
+	if first {
+		fanOut.subscribers[channel] = map[chan string]struct{}{}
+	}
+	fanOut.subscribers[channel][subscriber] = struct{}{}
+
+	return subscriber, first
+}
+
+// remove and close a subscriber of a channel and return true when it was the last subscriber of the channel
+func (fanOut *fanOut) remove(channel string, subscriber chan string) bool {
+	fanOut.mutex.Lock()
+	defer fanOut.mutex.Unlock()
+
+	delete(fanOut.subscribers[channel], subscriber)
+	close(subscriber)
+
+	if len(fanOut.subscribers[channel]) == 0 {
+		delete(fanOut.subscribers, channel)
+		return true
+	}
+	return false
+}
+
+// publish a message to the subscribers of a channel and return the number of slow subscribers which dropped the message
+func (fanOut *fanOut) publish(channel string, message string) (dropped int) {
+	fanOut.mutex.RLock()
+	defer fanOut.mutex.RUnlock()
+
+	for subscriber := range fanOut.subscribers[channel] {
+		select {
+		case subscriber <- message:
+		default:
+			dropped++
+		}
+	}
+	return dropped
+}
@@ -0,0 +1,49 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+// memoryPubSub is the PubSub implementation in memory which only works with a single instance of the API
+type memoryPubSub struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	fanOut *fanOut
+}
+
+// NewMemoryPubSub creates a new instance of memoryPubSub
+func NewMemoryPubSub(logger telemetry.Logger, tracer telemetry.Tracer) PubSub {
+	return &memoryPubSub{
+		logger: logger.WithService(fmt.Sprintf("%T", &memoryPubSub{})),
+		tracer: tracer,
+		fanOut: newFanOut(),
+	}
+}
+
+// Publish a message to the subscribers of a channel
+func (pubSub *memoryPubSub) Publish(ctx context.Context, channel string, message string) error {
+	_, span, ctxLogger := pubSub.tracer.StartWithLogger(ctx, pubSub.logger)
+	defer span.End()
+
+	if dropped := pubSub.fanOut.publish(channel, message); dropped > 0 {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("dropped message for [%d] slow subscribers of channel [%s]", dropped, channel)))
+	}
+
+	return nil
+}
+
+// Subscribe to the messages of a channel
+func (pubSub *memoryPubSub) Subscribe(ctx context.Context, channel string) (<-chan string, error) {
+	subscriber, _ := pubSub.fanOut.add(channel)
+
+	go func() {
+		<-ctx.Done()
+		pubSub.fanOut.remove(channel, subscriber)
+	}()
+
+	return subscriber, nil
+}
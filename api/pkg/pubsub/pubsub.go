@@ -0,0 +1,14 @@
+package pubsub
+
+import (
+	"context"
+)
+
+// PubSub fans out messages to the subscribers of a channel
+type PubSub interface {
+	// Publish a message to all the subscribers of a channel
+	Publish(ctx context.Context, channel string, message string) error
+
+	// Subscribe to the messages of a channel until the context is done
+	Subscribe(ctx context.Context, channel string) (<-chan string, error)
+}
@@ -0,0 +1,136 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hirosassa/zerodriver"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func testLoggerAndTracer() (telemetry.Logger, telemetry.Tracer) {
+	nop := zerolog.Nop()
+	logger := telemetry.NewZerologLogger("test", nil, &zerodriver.Logger{Logger: &nop}, nil)
+	return logger, telemetry.NewOtelLogger("test", logger)
+}
+
+// testReceive waits for a message on a subscriber
+func testReceive(t *testing.T, subscriber <-chan string) string {
+	select {
+	case message := <-subscriber:
+		return message
+	case <-time.After(time.Second):
+		t.Fatal("no message was received")
+		return ""
+	}
+}
+
+func TestMemoryPubSub(t *testing.T) {
+	t.Run("messages are fanned out to the subscribers of the channel only", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		pubSub := NewMemoryPubSub(logger, tracer)
+
+		// Arrange
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		first, _ := pubSub.Subscribe(ctx, "channel-1")
+		second, _ := pubSub.Subscribe(ctx, "channel-1")
+		other, _ := pubSub.Subscribe(ctx, "channel-2")
+
+		// Act
+		err := pubSub.Publish(ctx, "channel-1", "hello")
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, "hello", testReceive(t, first))
+		assert.Equal(t, "hello", testReceive(t, second))
+		assert.Equal(t, 0, len(other))
+	})
+
+	t.Run("the subscriber is closed when the context is done", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		pubSub := NewMemoryPubSub(logger, tracer)
+
+		// Arrange
+		ctx, cancel := context.WithCancel(context.Background())
+		subscriber, _ := pubSub.Subscribe(ctx, "channel-1")
+
+		// Act
+		cancel()
+
+		// Assert
+		assert.Eventually(t, func() bool {
+			_, ok := <-subscriber
+			return !ok
+		}, time.Second, time.Millisecond)
+	})
+}
+
+func TestRedisPubSub(t *testing.T) {
+	t.Run("subscribers of an instance share a single redis subscription", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		server := miniredis.RunT(t)
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		pubSub := NewRedisPubSub(logger, tracer, client)
+
+		// Arrange
+		firstCtx, cancelFirst := context.WithCancel(context.Background())
+		secondCtx, cancelSecond := context.WithCancel(context.Background())
+		defer cancelSecond()
+
+		first, err1 := pubSub.Subscribe(firstCtx, "channel-1")
+		second, err2 := pubSub.Subscribe(secondCtx, "channel-1")
+		assert.Eventually(t, func() bool { return server.PubSubNumSub("channel-1")["channel-1"] == 1 }, time.Second, time.Millisecond)
+
+		// Act
+		err := pubSub.Publish(context.Background(), "channel-1", "hello")
+
+		// Assert
+		assert.Nil(t, err1)
+		assert.Nil(t, err2)
+		assert.Nil(t, err)
+		assert.Equal(t, "hello", testReceive(t, first))
+		assert.Equal(t, "hello", testReceive(t, second))
+		assert.Equal(t, 1, len(server.PubSubChannels("")))
+
+		cancelFirst()
+		assert.Eventually(t, func() bool {
+			_, ok := <-first
+			return !ok
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, 1, server.PubSubNumSub("channel-1")["channel-1"])
+	})
+
+	t.Run("the redis channel is unsubscribed when the last subscriber leaves", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		server := miniredis.RunT(t)
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		pubSub := NewRedisPubSub(logger, tracer, client)
+
+		// Arrange
+		ctx, cancel := context.WithCancel(context.Background())
+		_, err := pubSub.Subscribe(ctx, "channel-1")
+		assert.Nil(t, err)
+		assert.Eventually(t, func() bool { return server.PubSubNumSub("channel-1")["channel-1"] == 1 }, time.Second, time.Millisecond)
+
+		// Act
+		cancel()
+
+		// Assert
+		assert.Eventually(t, func() bool { return server.PubSubNumSub("channel-1")["channel-1"] == 0 }, time.Second, time.Millisecond)
+	})
+}
@@ -0,0 +1,94 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"github.com/redis/go-redis/v9"
+)
+
+// redisPubSub is the PubSub implementation in redis which fans out messages across all the instances of the API.
+// Each instance has a single redis subscription which fans out the messages to the subscribers in the process.
+type redisPubSub struct {
+	logger       telemetry.Logger
+	tracer       telemetry.Tracer
+	client       *redis.Client
+	mutex        sync.Mutex
+	subscription *redis.PubSub
+	fanOut       *fanOut
+}
+
+// NewRedisPubSub creates a new instance of redisPubSub
+func NewRedisPubSub(logger telemetry.Logger, tracer telemetry.Tracer, client *redis.Client) PubSub {
+	return &redisPubSub{
+		logger: logger.WithService(fmt.Sprintf("%T", &redisPubSub{})),
+		tracer: tracer,
+		client: client,
+		fanOut: newFanOut(),
+	}
+}
+
+// Publish a message to the subscribers of a channel
+func (pubSub *redisPubSub) Publish(ctx context.Context, channel string, message string) error {
+	ctx, span := pubSub.tracer.Start(ctx)
+	defer span.End()
+
+	if err := pubSub.client.Publish(ctx, channel, message).Err(); err != nil {
+		msg := fmt.Sprintf("cannot publish message to redis channel [%s]", channel)
+		return pubSub.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Subscribe to the messages of a channel. The redis channel is subscribed when the first subscriber joins and unsubscribed when the last one leaves.
+func (pubSub *redisPubSub) Subscribe(ctx context.Context, channel string) (<-chan string, error) {
+	pubSub.mutex.Lock()
+	defer pubSub.mutex.Unlock()
+
+	if pubSub.subscription == nil {
+		pubSub.subscription = pubSub.client.Subscribe(context.Background())
+		go pubSub.receive(pubSub.subscription)
+	}
+
+	subscriber, first := pubSub.fanOut.add(channel)
+	if first {
+		if err := pubSub.subscription.Subscribe(ctx, channel); err != nil {
+			pubSub.fanOut.remove(channel, subscriber)
+			return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot subscribe to redis channel [%s]", channel))
+		}
+	}
+
+	go func() {
+		<-ctx.Done()
+		pubSub.unsubscribe(channel, subscriber)
+	}()
+
+	return subscriber, nil
+}
+
+// unsubscribe removes a subscriber and unsubscribes from the redis channel when it was the last subscriber of the channel
+func (pubSub *redisPubSub) unsubscribe(channel string, subscriber chan string) {
+	pubSub.mutex.Lock()
+	defer pubSub.mutex.Unlock()
+
+	if !pubSub.fanOut.remove(channel, subscriber) {
+		return
+	}
+
+	if err := pubSub.subscription.Unsubscribe(context.Background(), channel); err != nil {
+		pubSub.logger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot unsubscribe from redis channel [%s]", channel)))
+	}
+}
+
+// receive fans out the messages of the redis subscription until the subscription is closed
+func (pubSub *redisPubSub) receive(subscription *redis.PubSub) {
+	for message := range subscription.Channel() {
+		if dropped := pubSub.fanOut.publish(message.Channel, message.Payload); dropped > 0 {
+			pubSub.logger.Warn(stacktrace.NewError(fmt.Sprintf("dropped message for [%d] slow subscribers of redis channel [%s]", dropped, message.Channel)))
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package pubsub
+
+import (
+	"sync"
+)
+
+// StreamLimiter caps the number of concurrent streams of a key e.g. the SSE, WebSocket and long-poll connections of a user on an instance
+type StreamLimiter struct {
+	mutex   sync.Mutex
+	max     int
+	streams map[string]int
+}
+
+// NewStreamLimiter creates a new StreamLimiter which allows max concurrent streams per key
+func NewStreamLimiter(max int) *StreamLimiter {
+	return &StreamLimiter{
+		max:     max,
+		streams: map[string]int{},
+	}
+}
+
+// Acquire a stream for the key. It returns false when the key already has the maximum number of streams.
+// The release function must be called exactly once when the stream is closed.
+func (limiter *StreamLimiter) Acquire(key string) (release func(), ok bool) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	if limiter.streams[key] >= limiter.max {
+		return nil, false
+	}
+	limiter.streams[key]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			limiter.mutex.Lock()
+			defer limiter.mutex.Unlock()
+
+			limiter.streams[key]--
+			if limiter.streams[key] == 0 {
+				delete(limiter.streams, key)
+			}
+		})
+	}, true
+}
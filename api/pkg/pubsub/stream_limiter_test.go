@@ -0,0 +1,48 @@
+package pubsub
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamLimiter_Acquire(t *testing.T) {
+	t.Run("a key cannot have more than the maximum number of streams", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		limiter := NewStreamLimiter(2)
+
+		// Arrange
+		_, first := limiter.Acquire("user-1")
+		_, second := limiter.Acquire("user-1")
+
+		// Act
+		_, third := limiter.Acquire("user-1")
+		_, otherUser := limiter.Acquire("user-2")
+
+		// Assert
+		assert.True(t, first)
+		assert.True(t, second)
+		assert.False(t, third)
+		assert.True(t, otherUser)
+	})
+
+	t.Run("a released stream can be acquired again and releasing twice has no effect", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		limiter := NewStreamLimiter(1)
+
+		// Arrange
+		release, _ := limiter.Acquire("user-1")
+		release()
+		release()
+
+		// Act
+		_, first := limiter.Acquire("user-1")
+		_, second := limiter.Acquire("user-1")
+
+		// Assert
+		assert.True(t, first)
+		assert.False(t, second)
+	})
+}
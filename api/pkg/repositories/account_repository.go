@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// AccountRepository deletes all the data of an entities.User
+type AccountRepository interface {
+	// Delete an entities.User and all the data of the user which is in the same database in a single transaction
+	Delete(ctx context.Context, user *entities.User) error
+}
@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// AlertChannelRepository loads and persists an entities.AlertChannel
+type AlertChannelRepository interface {
+	// Store a new entities.AlertChannel
+	Store(ctx context.Context, channel *entities.AlertChannel) error
+
+	// Index fetches all the entities.AlertChannel of a user
+	Index(ctx context.Context, userID entities.UserID) ([]*entities.AlertChannel, error)
+
+	// Load an entities.AlertChannel by ID
+	Load(ctx context.Context, userID entities.UserID, channelID uuid.UUID) (*entities.AlertChannel, error)
+
+	// Verify marks the entities.AlertChannel with the verification token as verified
+	Verify(ctx context.Context, channelID uuid.UUID, token string) (*entities.AlertChannel, error)
+
+	// LoadByAlertType fetches the entities.AlertChannel of a user which are subscribed to an alert type
+	LoadByAlertType(ctx context.Context, userID entities.UserID, alertType string) ([]*entities.AlertChannel, error)
+
+	// Delete an entities.AlertChannel
+	Delete(ctx context.Context, userID entities.UserID, channelID uuid.UUID) error
+
+	// DeleteAllForUser deletes all the entities.AlertChannel of a user
+	DeleteAllForUser(ctx context.Context, userID entities.UserID) error
+}
@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+)
+
+// apiKeyPrefixLength is the number of characters of an API key which are stored in plaintext for lookups
+const apiKeyPrefixLength = 8
+
+// HashAPIKey returns the hex encoded SHA-256 hash of an API key which is stored in the database
+func HashAPIKey(apiKey string) string {
+	return HashToken(apiKey)
+}
+
+// HashToken returns the hex encoded SHA-256 hash of a secret token which is stored in the database instead of the token
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyPrefix returns the plaintext prefix of an API key which is used for lookups and to identify the key
+func APIKeyPrefix(apiKey string) string {
+	if len(apiKey) < apiKeyPrefixLength {
+		return apiKey
+	}
+	return apiKey[:apiKeyPrefixLength]
+}
+
+// apiKeyHashMatches compares the hash of an API key with a stored hash in constant time
+func apiKeyHashMatches(hash string, storedHash string) bool {
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(storedHash)) == 1
+}
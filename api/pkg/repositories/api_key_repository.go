@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// APIKeyRepository loads and persists an entities.APIKey
+type APIKeyRepository interface {
+	// Store a new entities.APIKey
+	Store(ctx context.Context, apiKey *entities.APIKey) error
+
+	// Index entities.APIKey of a user
+	Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.APIKey, error)
+
+	// Load an entities.APIKey by ID
+	Load(ctx context.Context, userID entities.UserID, apiKeyID uuid.UUID) (*entities.APIKey, error)
+
+	// Delete an entities.APIKey
+	Delete(ctx context.Context, apiKey *entities.APIKey) error
+
+	// LoadAuthUser fetches an entities.AuthUser with the scopes of the entities.APIKey
+	LoadAuthUser(ctx context.Context, key string) (entities.AuthUser, error)
+
+	// RecordUsage stores the last time and IP address where an entities.APIKey was used
+	RecordUsage(ctx context.Context, apiKeyID uuid.UUID, ipAddress string, timestamp time.Time) error
+
+	// DeleteAllForUser deletes all the entities.APIKey of a user
+	DeleteAllForUser(ctx context.Context, userID entities.UserID) error
+}
@@ -20,4 +20,7 @@ type BillingUsageRepository interface {
 
 	// GetHistory returns past billing usage by entities.UserID
 	GetHistory(ctx context.Context, userID entities.UserID, params IndexParams) (*[]entities.BillingUsage, error)
+
+	// DeleteAllForUser deletes all the entities.BillingUsage of a user
+	DeleteAllForUser(ctx context.Context, userID entities.UserID) error
 }
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/entities"
 )
@@ -13,4 +14,7 @@ type EventListenerLogRepository interface {
 
 	// Has verifies that the listener has not already been called
 	Has(ctx context.Context, eventID string, handler string) (bool, error)
+
+	// DeleteOlderThan deletes at most limit entities.EventListenerLog which were created before the timestamp
+	DeleteOlderThan(ctx context.Context, timestamp time.Time, limit int) (int64, error)
 }
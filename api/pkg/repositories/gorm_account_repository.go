@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cockroachdb/cockroach-go/v2/crdb/crdbgorm"
+	"github.com/dgraph-io/ristretto"
+	"gorm.io/gorm/clause"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormAccountRepository is responsible for deleting the data of an entities.User
+type gormAccountRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	cache  *ristretto.Cache
+	db     *gorm.DB
+}
+
+// NewGormAccountRepository creates the GORM version of the AccountRepository
+func NewGormAccountRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	cache *ristretto.Cache,
+	db *gorm.DB,
+) AccountRepository {
+	return &gormAccountRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormAccountRepository{})),
+		tracer: tracer,
+		cache:  cache,
+		db:     db,
+	}
+}
+
+// Delete an entities.User and all the data of the user which is in the same database in a single transaction
+func (repository *gormAccountRepository) Delete(ctx context.Context, user *entities.User) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	var apiKeys []*entities.APIKey
+	err := crdbgorm.ExecuteTx(ctx, repository.db, nil, func(tx *gorm.DB) error {
+		deletes := []struct {
+			name  string
+			value any
+		}{
+			{"messages", &entities.Message{}},
+			{"message threads", &entities.MessageThread{}},
+			{"phone availability periods", &entities.PhoneAvailabilityPeriod{}},
+			{"maintenance windows", &entities.MaintenanceWindow{}},
+			{"alert channels", &entities.AlertChannel{}},
+			{"phone commands", &entities.PhoneCommand{}},
+			{"phone configs", &entities.PhoneConfig{}},
+			{"phone notifications", &entities.PhoneNotification{}},
+			{"phones", &entities.Phone{}},
+			{"webhooks", &entities.Webhook{}},
+			{"discord integrations", &entities.Discord{}},
+			{"3CX integrations", &entities.Integration3CX{}},
+			{"billing usages", &entities.BillingUsage{}},
+			{"message exports", &entities.MessageExport{}},
+		}
+
+		exports := tx.WithContext(ctx).Model(&entities.MessageExport{}).Select("id").Where("user_id = ?", user.ID)
+		if err := tx.WithContext(ctx).Where("message_export_id IN (?)", exports).Delete(&entities.MessageExportChunk{}).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot delete message export files for user [%s]", user.ID))
+		}
+
+		for _, item := range deletes {
+			if err := tx.WithContext(ctx).Where("user_id = ?", user.ID).Delete(item.value).Error; err != nil {
+				return stacktrace.Propagate(err, fmt.Sprintf("cannot delete %s for user [%s]", item.name, user.ID))
+			}
+		}
+
+		if err := tx.WithContext(ctx).Clauses(clause.Returning{}).Where("user_id = ?", user.ID).Delete(&apiKeys).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot delete API keys for user [%s]", user.ID))
+		}
+
+		organizations := tx.WithContext(ctx).Model(&entities.Organization{}).Select("id").Where("owner_id = ?", user.ID)
+		members := tx.WithContext(ctx).Model(&entities.OrganizationMember{}).Select("id").Where("user_id = ? OR organization_id IN (?)", user.ID, organizations)
+
+		if err := tx.WithContext(ctx).Where("member_id IN (?)", members).Delete(&entities.OrganizationPhoneGrant{}).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot delete phone grants for user [%s]", user.ID))
+		}
+		if err := tx.WithContext(ctx).Where("user_id = ? OR organization_id IN (?)", user.ID, organizations).Delete(&entities.OrganizationMember{}).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot delete organization members for user [%s]", user.ID))
+		}
+		if err := tx.WithContext(ctx).Where("organization_id IN (?)", organizations).Delete(&entities.OrganizationInvitation{}).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot delete organization invitations for user [%s]", user.ID))
+		}
+		if err := tx.WithContext(ctx).Where("owner_id = ?", user.ID).Delete(&entities.Organization{}).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot delete organizations for user [%s]", user.ID))
+		}
+
+		return tx.WithContext(ctx).Where("id = ?", user.ID).Delete(&entities.User{}).Error
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete the account of user with ID [%s]", user.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	repository.cache.Del(user.APIKeyHash)
+	for _, apiKey := range apiKeys {
+		repository.cache.Del(apiKey.KeyHash)
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func testAccountDB(t *testing.T) *gorm.DB {
+	return testDB(
+		t,
+		&entities.User{},
+		&entities.Message{},
+		&entities.MessageThread{},
+		&entities.PhoneAvailabilityPeriod{},
+		&entities.MaintenanceWindow{},
+		&entities.AlertChannel{},
+		&entities.PhoneCommand{},
+		&entities.PhoneConfig{},
+		&entities.PhoneNotification{},
+		&entities.Phone{},
+		&entities.Webhook{},
+		&entities.Discord{},
+		&entities.Integration3CX{},
+		&entities.BillingUsage{},
+		&entities.APIKey{},
+		&entities.MessageExport{},
+		&entities.MessageExportChunk{},
+		&entities.Organization{},
+		&entities.OrganizationMember{},
+		&entities.OrganizationPhoneGrant{},
+		&entities.OrganizationInvitation{},
+	)
+}
+
+func testAccount(t *testing.T, db *gorm.DB, userID entities.UserID) *entities.User {
+	user := &entities.User{ID: userID, Email: string(userID) + "@example.com", APIKeyHash: HashAPIKey(string(userID))}
+	assert.Nil(t, db.Create(user).Error)
+
+	message := testMessage("+18005550199", entities.MessageStatusPending, nil)
+	message.UserID = userID
+	assert.Nil(t, db.Create(message).Error)
+
+	organization := &entities.Organization{ID: uuid.New(), OwnerID: userID, CreatedAt: time.Now().UTC(), UpdatedAt: time.Now().UTC()}
+	assert.Nil(t, db.Create(organization).Error)
+	assert.Nil(t, db.Create(&entities.OrganizationMember{ID: uuid.New(), OrganizationID: organization.ID, UserID: userID + "-member"}).Error)
+	return user
+}
+
+func TestGormAccountRepository_Delete(t *testing.T) {
+	t.Run("all the data of the user is deleted without deleting the data of other users", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testAccountDB(t)
+		repository := NewGormAccountRepository(logger, tracer, testRistrettoCache(t), db)
+
+		// Arrange
+		user := testAccount(t, db, "user-id")
+		testAccount(t, db, "other-user-id")
+
+		// Act
+		err := repository.Delete(context.Background(), user)
+
+		// Assert
+		assert.Nil(t, err)
+
+		var users, messages, organizations, members int64
+		assert.Nil(t, db.Model(&entities.User{}).Count(&users).Error)
+		assert.Nil(t, db.Model(&entities.Message{}).Count(&messages).Error)
+		assert.Nil(t, db.Model(&entities.Organization{}).Count(&organizations).Error)
+		assert.Nil(t, db.Model(&entities.OrganizationMember{}).Count(&members).Error)
+		assert.Equal(t, []int64{1, 1, 1, 1}, []int64{users, messages, organizations, members})
+	})
+
+	t.Run("nothing is deleted when a delete in the transaction fails", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testAccountDB(t)
+		repository := NewGormAccountRepository(logger, tracer, testRistrettoCache(t), db)
+
+		// Arrange
+		user := testAccount(t, db, "user-id")
+		assert.Nil(t, db.Migrator().DropTable(&entities.Organization{}))
+
+		// Act
+		err := repository.Delete(context.Background(), user)
+
+		// Assert
+		assert.NotNil(t, err)
+
+		var users, messages int64
+		assert.Nil(t, db.Model(&entities.User{}).Count(&users).Error)
+		assert.Nil(t, db.Model(&entities.Message{}).Count(&messages).Error)
+		assert.Equal(t, []int64{1, 1}, []int64{users, messages})
+	})
+}
@@ -0,0 +1,170 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormAlertChannelRepository is responsible for persisting entities.AlertChannel
+type gormAlertChannelRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormAlertChannelRepository creates the GORM version of the AlertChannelRepository
+func NewGormAlertChannelRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) AlertChannelRepository {
+	return &gormAlertChannelRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormAlertChannelRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store a new entities.AlertChannel
+func (repository *gormAlertChannelRepository) Store(ctx context.Context, channel *entities.AlertChannel) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(channel).Error; err != nil {
+		msg := fmt.Sprintf("cannot save alert channel with ID [%s]", channel.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Index fetches all the entities.AlertChannel of a user
+func (repository *gormAlertChannelRepository) Index(ctx context.Context, userID entities.UserID) ([]*entities.AlertChannel, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	channels := make([]*entities.AlertChannel, 0)
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at ASC").Find(&channels).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch alert channels for user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return channels, nil
+}
+
+// Load an entities.AlertChannel by ID
+func (repository *gormAlertChannelRepository) Load(ctx context.Context, userID entities.UserID, channelID uuid.UUID) (*entities.AlertChannel, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	channel := new(entities.AlertChannel)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", channelID).First(channel).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("alert channel with ID [%s] for user [%s] does not exist", channelID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load alert channel with ID [%s] for user [%s]", channelID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return channel, nil
+}
+
+// Verify marks the entities.AlertChannel with the verification token as verified
+func (repository *gormAlertChannelRepository) Verify(ctx context.Context, channelID uuid.UUID, token string) (*entities.AlertChannel, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	channel := new(entities.AlertChannel)
+	err := repository.db.WithContext(ctx).
+		Where("id = ?", channelID).
+		Where("verification_token_hash = ?", HashToken(token)).
+		First(channel).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("alert channel with ID [%s] and verification token does not exist", channelID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load alert channel with ID [%s]", channelID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if channel.VerifiedAt != nil {
+		return channel, nil
+	}
+
+	verifiedAt := time.Now().UTC()
+	err = repository.db.WithContext(ctx).
+		Model(channel).
+		Updates(map[string]any{"verified_at": verifiedAt, "updated_at": verifiedAt}).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot verify alert channel with ID [%s]", channelID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	channel.VerifiedAt = &verifiedAt
+	return channel, nil
+}
+
+// LoadByAlertType fetches the entities.AlertChannel of a user which are subscribed to an alert type
+func (repository *gormAlertChannelRepository) LoadByAlertType(ctx context.Context, userID entities.UserID, alertType string) ([]*entities.AlertChannel, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	channels := make([]*entities.AlertChannel, 0)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("CAST(? as TEXT) = ANY(alert_types)", alertType).
+		Find(&channels).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot load alert channels for user with ID [%s] and alert type [%s]", userID, alertType)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return channels, nil
+}
+
+// Delete an entities.AlertChannel
+func (repository *gormAlertChannelRepository) Delete(ctx context.Context, userID entities.UserID, channelID uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("id = ?", channelID).
+		Delete(&entities.AlertChannel{}).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete alert channel with ID [%s] and userID [%s]", channelID, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// DeleteAllForUser deletes all the entities.AlertChannel of a user
+func (repository *gormAlertChannelRepository) DeleteAllForUser(ctx context.Context, userID entities.UserID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&entities.AlertChannel{}).Error; err != nil {
+		msg := fmt.Sprintf("cannot delete alert channels for user with ID [%s]", userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
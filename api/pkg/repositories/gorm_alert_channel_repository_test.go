@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGormAlertChannelRepository_Verify(t *testing.T) {
+	t.Run("the channel is verified with the token in the verification link", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testDB(t, &entities.AlertChannel{})
+		repository := NewGormAlertChannelRepository(logger, tracer, db)
+
+		// Arrange
+		channel := &entities.AlertChannel{
+			ID:                    uuid.New(),
+			UserID:                "user-id",
+			Type:                  entities.AlertChannelTypeEmail,
+			Target:                "alerts@example.com",
+			VerificationTokenHash: HashToken("token"),
+			CreatedAt:             time.Now().UTC(),
+			UpdatedAt:             time.Now().UTC(),
+		}
+		assert.Nil(t, repository.Store(context.Background(), channel))
+
+		// Act
+		verified, err := repository.Verify(context.Background(), channel.ID, "token")
+
+		// Assert
+		assert.Nil(t, err)
+		assert.True(t, verified.IsVerified())
+
+		stored, err := repository.Load(context.Background(), "user-id", channel.ID)
+		assert.Nil(t, err)
+		assert.NotNil(t, stored.VerifiedAt)
+	})
+
+	t.Run("the channel is not verified with a wrong token", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testDB(t, &entities.AlertChannel{})
+		repository := NewGormAlertChannelRepository(logger, tracer, db)
+
+		// Arrange
+		channel := &entities.AlertChannel{
+			ID:                    uuid.New(),
+			UserID:                "user-id",
+			Type:                  entities.AlertChannelTypeEmail,
+			Target:                "alerts@example.com",
+			VerificationTokenHash: HashToken("token"),
+			CreatedAt:             time.Now().UTC(),
+			UpdatedAt:             time.Now().UTC(),
+		}
+		assert.Nil(t, repository.Store(context.Background(), channel))
+
+		// Act
+		_, err := repository.Verify(context.Background(), channel.ID, "wrong-token")
+
+		// Assert
+		assert.Equal(t, ErrCodeNotFound, stacktrace.GetCode(err))
+
+		stored, err := repository.Load(context.Background(), "user-id", channel.ID)
+		assert.Nil(t, err)
+		assert.False(t, stored.IsVerified())
+	})
+}
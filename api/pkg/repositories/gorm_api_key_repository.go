@@ -0,0 +1,214 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dgraph-io/ristretto"
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// apiKeyUsageInterval is the minimum interval between updates of the last used timestamp of an entities.APIKey
+const apiKeyUsageInterval = time.Minute
+
+// gormAPIKeyRepository is responsible for persisting entities.APIKey
+type gormAPIKeyRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	cache  *ristretto.Cache
+	db     *gorm.DB
+}
+
+// NewGormAPIKeyRepository creates the GORM version of the APIKeyRepository
+func NewGormAPIKeyRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	cache *ristretto.Cache,
+	db *gorm.DB,
+) APIKeyRepository {
+	return &gormAPIKeyRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormAPIKeyRepository{})),
+		tracer: tracer,
+		cache:  cache,
+		db:     db,
+	}
+}
+
+func (repository *gormAPIKeyRepository) Store(ctx context.Context, apiKey *entities.APIKey) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(apiKey).Error; err != nil {
+		msg := fmt.Sprintf("cannot save api key with ID [%s] for user [%s]", apiKey.ID, apiKey.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormAPIKeyRepository) Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.APIKey, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).Where("user_id = ?", userID)
+	if len(params.Query) > 0 {
+		queryPattern := "%" + params.Query + "%"
+		query.Where("name ILIKE ?", queryPattern)
+	}
+
+	apiKeys := make([]*entities.APIKey, 0)
+	if err := query.Order("created_at DESC").Limit(params.Limit).Offset(params.Skip).Find(&apiKeys).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch api keys for user [%s] and params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return apiKeys, nil
+}
+
+func (repository *gormAPIKeyRepository) Load(ctx context.Context, userID entities.UserID, apiKeyID uuid.UUID) (*entities.APIKey, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	apiKey := new(entities.APIKey)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", apiKeyID).First(apiKey).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("api key with ID [%s] for user [%s] does not exist", apiKeyID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load api key with ID [%s] for user [%s]", apiKeyID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return apiKey, nil
+}
+
+func (repository *gormAPIKeyRepository) Delete(ctx context.Context, apiKey *entities.APIKey) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", apiKey.UserID).
+		Where("id = ?", apiKey.ID).
+		Delete(&entities.APIKey{}).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete api key with ID [%s] and userID [%s]", apiKey.ID, apiKey.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	repository.cache.Del(apiKey.KeyHash)
+	return nil
+}
+
+func (repository *gormAPIKeyRepository) LoadAuthUser(ctx context.Context, key string) (entities.AuthUser, error) {
+	ctx, span, ctxLogger := repository.tracer.StartWithLogger(ctx, repository.logger)
+	defer span.End()
+
+	hash := HashAPIKey(key)
+	if authUser, found := repository.cache.Get(hash); found {
+		ctxLogger.Info(fmt.Sprintf("cache hit for api key of user with ID [%s]", authUser.(entities.AuthUser).ID))
+		return repository.unexpiredAuthUser(authUser.(entities.AuthUser))
+	}
+
+	var apiKeys []*entities.APIKey
+	if err := repository.db.WithContext(ctx).Where("key_prefix = ?", APIKeyPrefix(key)).Find(&apiKeys).Error; err != nil {
+		msg := fmt.Sprintf("cannot load api keys with prefix [%s]", APIKeyPrefix(key))
+		return entities.AuthUser{}, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	apiKey := repository.apiKeyWithHash(apiKeys, hash)
+	if apiKey == nil {
+		msg := fmt.Sprintf("api key with prefix [%s] does not exist", APIKeyPrefix(key))
+		return entities.AuthUser{}, repository.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeNotFound, msg))
+	}
+
+	user := new(entities.User)
+	if err := repository.db.WithContext(ctx).First(user, apiKey.UserID).Error; err != nil {
+		msg := fmt.Sprintf("cannot load user with ID [%s] for api key [%s]", apiKey.UserID, apiKey.ID)
+		return entities.AuthUser{}, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	authUser := entities.AuthUser{
+		ID:        user.ID,
+		Email:     user.Email,
+		APIKeyID:  &apiKey.ID,
+		Scopes:    apiKey.GetScopes(),
+		ExpiresAt: apiKey.ExpiresAt,
+	}
+
+	if result := repository.cache.SetWithTTL(hash, authUser, 1, 2*time.Hour); !result {
+		msg := fmt.Sprintf("cannot cache [%T] with ID [%s] and result [%t]", authUser, user.ID, result)
+		ctxLogger.Error(repository.tracer.WrapErrorSpan(span, stacktrace.NewError(msg)))
+	}
+
+	return repository.unexpiredAuthUser(authUser)
+}
+
+func (repository *gormAPIKeyRepository) RecordUsage(ctx context.Context, apiKeyID uuid.UUID, ipAddress string, timestamp time.Time) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).
+		Model(&entities.APIKey{}).
+		Where("id = ?", apiKeyID).
+		Where(repository.db.Where("last_used_at IS NULL").Or("last_used_at < ?", timestamp.Add(-apiKeyUsageInterval)).Or("last_used_ip != ?", ipAddress)).
+		Updates(map[string]any{
+			"last_used_at": timestamp,
+			"last_used_ip": ipAddress,
+		}).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot record usage of api key with ID [%s] from IP [%s]", apiKeyID, ipAddress)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormAPIKeyRepository) unexpiredAuthUser(authUser entities.AuthUser) (entities.AuthUser, error) {
+	if authUser.ExpiresAt != nil && time.Now().UTC().After(*authUser.ExpiresAt) {
+		msg := fmt.Sprintf("api key with ID [%s] for user [%s] expired at [%s]", authUser.APIKeyID, authUser.ID, authUser.ExpiresAt)
+		return entities.AuthUser{}, stacktrace.NewErrorWithCode(ErrCodeNotFound, msg)
+	}
+	return authUser, nil
+}
+
+// apiKeyWithHash finds the entities.APIKey whose hash matches the hash
+func (repository *gormAPIKeyRepository) apiKeyWithHash(apiKeys []*entities.APIKey, hash string) *entities.APIKey {
+	for _, apiKey := range apiKeys {
+		if apiKeyHashMatches(hash, apiKey.KeyHash) {
+			return apiKey
+		}
+	}
+	return nil
+}
+
+// DeleteAllForUser deletes all the entities.APIKey of a user
+func (repository *gormAPIKeyRepository) DeleteAllForUser(ctx context.Context, userID entities.UserID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	var apiKeys []*entities.APIKey
+	err := repository.db.WithContext(ctx).
+		Clauses(clause.Returning{}).
+		Where("user_id = ?", userID).
+		Delete(&apiKeys).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete API keys for user with ID [%s]", userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	for _, apiKey := range apiKeys {
+		repository.cache.Del(apiKey.KeyHash)
+	}
+	return nil
+}
@@ -141,3 +141,16 @@ func (repository *gormBillingUsageRepository) createBillingUsage(userID entities
 		EndTimestamp:     now.New(timestamp).EndOfMonth(),
 	}
 }
+
+// DeleteAllForUser deletes all the entities.BillingUsage of a user
+func (repository *gormBillingUsageRepository) DeleteAllForUser(ctx context.Context, userID entities.UserID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&entities.BillingUsage{}).Error; err != nil {
+		msg := fmt.Sprintf("cannot delete billing usages for user with ID [%s]", userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -136,3 +136,16 @@ func (repository *gormDiscordRepository) Delete(ctx context.Context, userID enti
 
 	return nil
 }
+
+// DeleteAllForUser deletes all the entities.Discord of a user
+func (repository *gormDiscordRepository) DeleteAllForUser(ctx context.Context, userID entities.UserID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&entities.Discord{}).Error; err != nil {
+		msg := fmt.Sprintf("cannot delete discord integrations for user with ID [%s]", userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/entities"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
@@ -62,3 +63,22 @@ func (repository *gormEventListenerLogRepository) Has(ctx context.Context, event
 
 	return exists, nil
 }
+
+// DeleteOlderThan deletes the oldest entities.EventListenerLog which were created before the timestamp
+func (repository *gormEventListenerLogRepository) DeleteOlderThan(ctx context.Context, timestamp time.Time, limit int) (int64, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	subQuery := repository.db.Model(&entities.EventListenerLog{}).
+		Select("id").
+		Where("created_at < ?", timestamp).
+		Limit(limit)
+
+	result := repository.db.WithContext(ctx).Where("id IN (?)", subQuery).Delete(&entities.EventListenerLog{})
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot delete event listener logs older than [%s]", timestamp)
+		return 0, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected, nil
+}
@@ -107,7 +107,7 @@ func (repository *gormHeartbeatMonitorRepository) Index(ctx context.Context, use
 	ctx, cancel := context.WithTimeout(ctx, dbOperationDuration)
 	defer cancel()
 
-	query := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("owner = ?", owner)
+	query := repository.db.WithContext(ctx).Where("user_id = ?", userID).Scopes(scopeOrganization(ctx, "owner")).Where("owner = ?", owner)
 	heartbeats := new([]entities.Heartbeat)
 	if err := query.Order("timestamp DESC").Limit(params.Limit).Offset(params.Skip).Find(&heartbeats).Error; err != nil {
 		msg := fmt.Sprintf("cannot fetch heartbeats with owner [%s] and params [%+#v]", owner, params)
@@ -133,6 +133,27 @@ func (repository *gormHeartbeatMonitorRepository) Store(ctx context.Context, hea
 	return nil
 }
 
+// Update the settings of an entities.HeartbeatMonitor
+func (repository *gormHeartbeatMonitorRepository) Update(ctx context.Context, monitor *entities.HeartbeatMonitor) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, dbOperationDuration)
+	defer cancel()
+
+	err := repository.db.WithContext(ctx).
+		Model(monitor).
+		Select("interval_seconds", "missed_threshold", "offline_threshold").
+		Updates(monitor).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot update heartbeat monitor with ID [%s]", monitor.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
 // Load a heartbeat monitor by userID and owner
 func (repository *gormHeartbeatMonitorRepository) Load(ctx context.Context, userID entities.UserID, owner string) (*entities.HeartbeatMonitor, error) {
 	ctx, span := repository.tracer.Start(ctx)
@@ -143,7 +164,7 @@ func (repository *gormHeartbeatMonitorRepository) Load(ctx context.Context, user
 
 	phone := new(entities.HeartbeatMonitor)
 	err := repository.db.WithContext(ctx).
-		Where("user_id = ?", userID).
+		Where("user_id = ?", userID).Scopes(scopeOrganization(ctx, "owner")).
 		Where("owner = ?", owner).
 		First(&phone).Error
 
@@ -182,3 +203,16 @@ func (repository *gormHeartbeatMonitorRepository) Exists(ctx context.Context, us
 
 	return exists, nil
 }
+
+// DeleteAllForUser deletes all the entities.HeartbeatMonitor of a user
+func (repository *gormHeartbeatMonitorRepository) DeleteAllForUser(ctx context.Context, userID entities.UserID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&entities.HeartbeatMonitor{}).Error; err != nil {
+		msg := fmt.Sprintf("cannot delete heartbeat monitors for user with ID [%s]", userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
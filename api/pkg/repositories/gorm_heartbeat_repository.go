@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -41,7 +42,7 @@ func (repository *gormHeartbeatRepository) Last(ctx context.Context, userID enti
 
 	heartbeat := new(entities.Heartbeat)
 	err := repository.db.WithContext(ctx).
-		Where("user_id = ?", userID).
+		Where("user_id = ?", userID).Scopes(scopeOrganization(ctx, "owner")).
 		Where("owner = ?", owner).
 		Order("timestamp DESC").
 		First(&heartbeat).Error
@@ -66,14 +67,14 @@ func (repository *gormHeartbeatRepository) Index(ctx context.Context, userID ent
 	ctx, cancel := context.WithTimeout(ctx, dbOperationDuration)
 	defer cancel()
 
-	query := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("owner = ?", owner)
+	query := repository.db.WithContext(ctx).Where("user_id = ?", userID).Scopes(scopeOrganization(ctx, "owner")).Where("owner = ?", owner)
 	if len(params.Query) > 0 {
 		queryPattern := "%" + params.Query + "%"
 		query.Where("version LIKE ?", queryPattern)
 	}
 
 	heartbeats := new([]entities.Heartbeat)
-	if err := query.Order("timestamp DESC").Limit(params.Limit).Offset(params.Skip).Find(&heartbeats).Error; err != nil {
+	if err := paginate(query, params, "timestamp", "timestamp").Find(&heartbeats).Error; err != nil {
 		msg := fmt.Sprintf("cannot fetch heartbeats with owner [%s] and params [%+#v]", owner, params)
 		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
@@ -96,3 +97,36 @@ func (repository *gormHeartbeatRepository) Store(ctx context.Context, heartbeat
 
 	return nil
 }
+
+// DeleteOlderThan deletes the oldest entities.Heartbeat of a user which were received before the timestamp
+func (repository *gormHeartbeatRepository) DeleteOlderThan(ctx context.Context, userID entities.UserID, timestamp time.Time, limit int) (int64, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	subQuery := repository.db.Model(&entities.Heartbeat{}).
+		Select("id").
+		Where("user_id = ?", userID).
+		Where("timestamp < ?", timestamp).
+		Limit(limit)
+
+	result := repository.db.WithContext(ctx).Where("id IN (?)", subQuery).Delete(&entities.Heartbeat{})
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot delete heartbeats older than [%s] for user with ID [%s]", timestamp, userID)
+		return 0, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected, nil
+}
+
+// DeleteAllForUser deletes all the entities.Heartbeat of a user
+func (repository *gormHeartbeatRepository) DeleteAllForUser(ctx context.Context, userID entities.UserID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&entities.Heartbeat{}).Error; err != nil {
+		msg := fmt.Sprintf("cannot delete heartbeats for user with ID [%s]", userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
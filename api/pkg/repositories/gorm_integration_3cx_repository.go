@@ -63,3 +63,16 @@ func (repository *gormIntegration3CxRepository) Save(ctx context.Context, integr
 
 	return nil
 }
+
+// DeleteAllForUser deletes all the entities.Integration3CX of a user
+func (repository *gormIntegration3CxRepository) DeleteAllForUser(ctx context.Context, userID entities.UserID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&entities.Integration3CX{}).Error; err != nil {
+		msg := fmt.Sprintf("cannot delete 3CX integrations for user with ID [%s]", userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormMaintenanceWindowRepository is responsible for persisting entities.MaintenanceWindow
+type gormMaintenanceWindowRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormMaintenanceWindowRepository creates the GORM version of the MaintenanceWindowRepository
+func NewGormMaintenanceWindowRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) MaintenanceWindowRepository {
+	return &gormMaintenanceWindowRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormMaintenanceWindowRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store a new entities.MaintenanceWindow
+func (repository *gormMaintenanceWindowRepository) Store(ctx context.Context, window *entities.MaintenanceWindow) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(window).Error; err != nil {
+		msg := fmt.Sprintf("cannot save maintenance window with ID [%s]", window.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Index fetches the entities.MaintenanceWindow of a phone
+func (repository *gormMaintenanceWindowRepository) Index(ctx context.Context, userID entities.UserID, phoneID uuid.UUID) ([]*entities.MaintenanceWindow, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	var windows []*entities.MaintenanceWindow
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("phone_id = ?", phoneID).
+		Order("starts_at desc").
+		Find(&windows).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch maintenance windows for phone [%s] and user [%s]", phoneID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return windows, nil
+}
+
+// LoadStarted fetches the entities.MaintenanceWindow of a phone number which started before the timestamp and can still be active
+func (repository *gormMaintenanceWindowRepository) LoadStarted(ctx context.Context, userID entities.UserID, owner string, timestamp time.Time) ([]*entities.MaintenanceWindow, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	var windows []*entities.MaintenanceWindow
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("owner = ?", owner).
+		Where("starts_at <= ?", timestamp).
+		Where("(recurrence <> ? OR ends_at > ?)", entities.MaintenanceWindowRecurrenceNone, timestamp).
+		Find(&windows).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch maintenance windows for owner [%s] and user [%s] at [%s]", owner, userID, timestamp)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return windows, nil
+}
+
+// Delete an entities.MaintenanceWindow of a phone
+func (repository *gormMaintenanceWindowRepository) Delete(ctx context.Context, userID entities.UserID, phoneID uuid.UUID, windowID uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	result := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("phone_id = ?", phoneID).
+		Where("id = ?", windowID).
+		Delete(&entities.MaintenanceWindow{})
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot delete maintenance window with ID [%s] for user [%s]", windowID, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	if result.RowsAffected == 0 {
+		msg := fmt.Sprintf("maintenance window with ID [%s] does not exist for phone [%s] and user [%s]", windowID, phoneID, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeNotFound, msg))
+	}
+
+	return nil
+}
+
+// DeleteAllForUser deletes all the entities.MaintenanceWindow of a user
+func (repository *gormMaintenanceWindowRepository) DeleteAllForUser(ctx context.Context, userID entities.UserID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&entities.MaintenanceWindow{}).Error; err != nil {
+		msg := fmt.Sprintf("cannot delete maintenance windows for user with ID [%s]", userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
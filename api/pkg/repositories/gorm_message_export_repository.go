@@ -0,0 +1,199 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/cockroachdb/cockroach-go/v2/crdb/crdbgorm"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// messageExportChunkSize is the maximum number of bytes in an entities.MessageExportChunk
+const messageExportChunkSize = 1024 * 1024
+
+// gormMessageExportRepository is responsible for persisting entities.MessageExport
+type gormMessageExportRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormMessageExportRepository creates the GORM version of the MessageExportRepository
+func NewGormMessageExportRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) MessageExportRepository {
+	return &gormMessageExportRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormMessageExportRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+func (repository *gormMessageExportRepository) Store(ctx context.Context, export *entities.MessageExport) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(export).Error; err != nil {
+		msg := fmt.Sprintf("cannot save message export with ID [%s] for user [%s]", export.ID, export.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormMessageExportRepository) Update(ctx context.Context, export *entities.MessageExport) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(export).Error; err != nil {
+		msg := fmt.Sprintf("cannot update message export with ID [%s] for user [%s]", export.ID, export.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormMessageExportRepository) StoreFile(ctx context.Context, export *entities.MessageExport, file io.Reader) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := crdbgorm.ExecuteTx(ctx, repository.db, nil, func(tx *gorm.DB) error {
+		if err := tx.WithContext(ctx).Where("message_export_id = ?", export.ID).Delete(&entities.MessageExportChunk{}).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot delete the previous chunks of message export [%s]", export.ID))
+		}
+
+		export.Size, export.Chunks = 0, 0
+		buffer := make([]byte, messageExportChunkSize)
+		for {
+			n, err := io.ReadFull(file, buffer)
+			if n > 0 {
+				chunk := &entities.MessageExportChunk{MessageExportID: export.ID, Sequence: export.Chunks, Data: buffer[:n]}
+				if err := tx.WithContext(ctx).Create(chunk).Error; err != nil {
+					return stacktrace.Propagate(err, fmt.Sprintf("cannot store chunk [%d] of message export [%s]", chunk.Sequence, export.ID))
+				}
+				export.Size += int64(n)
+				export.Chunks++
+			}
+
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			if err != nil {
+				return stacktrace.Propagate(err, fmt.Sprintf("cannot read the file of message export [%s]", export.ID))
+			}
+		}
+
+		return tx.WithContext(ctx).Save(export).Error
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot store the file of message export with ID [%s] for user [%s]", export.ID, export.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormMessageExportRepository) LoadChunk(ctx context.Context, exportID uuid.UUID, sequence int) (*entities.MessageExportChunk, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	chunk := new(entities.MessageExportChunk)
+	err := repository.db.WithContext(ctx).Where("message_export_id = ?", exportID).Where("sequence = ?", sequence).First(chunk).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("chunk [%d] of message export with ID [%s] does not exist", sequence, exportID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load chunk [%d] of message export with ID [%s]", sequence, exportID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return chunk, nil
+}
+
+func (repository *gormMessageExportRepository) DeleteExpired(ctx context.Context, userID entities.UserID, timestamp time.Time) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := crdbgorm.ExecuteTx(ctx, repository.db, nil, func(tx *gorm.DB) error {
+		expired := tx.WithContext(ctx).Model(&entities.MessageExport{}).Select("id").Where("user_id = ?", userID).Where("expires_at < ?", timestamp)
+		if err := tx.WithContext(ctx).Where("message_export_id IN (?)", expired).Delete(&entities.MessageExportChunk{}).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot delete the chunks of expired message exports for user [%s]", userID))
+		}
+		return tx.WithContext(ctx).Where("user_id = ?", userID).Where("expires_at < ?", timestamp).Delete(&entities.MessageExport{}).Error
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete message exports which expired before [%s] for user [%s]", timestamp, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormMessageExportRepository) Load(ctx context.Context, userID entities.UserID, exportID uuid.UUID) (*entities.MessageExport, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	export := new(entities.MessageExport)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", exportID).First(export).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("message export with ID [%s] for user [%s] does not exist", exportID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load message export with ID [%s] for user [%s]", exportID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return export, nil
+}
+
+func (repository *gormMessageExportRepository) LoadByToken(ctx context.Context, exportID uuid.UUID, token string) (*entities.MessageExport, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	export := new(entities.MessageExport)
+	err := repository.db.WithContext(ctx).Where("id = ?", exportID).Where("token_hash = ?", HashToken(token)).First(export).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("message export with ID [%s] and token does not exist", exportID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load message export with ID [%s]", exportID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return export, nil
+}
+
+// DeleteAllForUser deletes all the entities.MessageExport of a user
+func (repository *gormMessageExportRepository) DeleteAllForUser(ctx context.Context, userID entities.UserID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := crdbgorm.ExecuteTx(ctx, repository.db, nil, func(tx *gorm.DB) error {
+		exports := tx.WithContext(ctx).Model(&entities.MessageExport{}).Select("id").Where("user_id = ?", userID)
+		if err := tx.WithContext(ctx).Where("message_export_id IN (?)", exports).Delete(&entities.MessageExportChunk{}).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot delete the chunks of message exports for user [%s]", userID))
+		}
+		return tx.WithContext(ctx).Where("user_id = ?", userID).Delete(&entities.MessageExport{}).Error
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete message exports for user with ID [%s]", userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+)
+
+func testMessageExport() *entities.MessageExport {
+	return &entities.MessageExport{
+		ID:        uuid.New(),
+		UserID:    "user-id",
+		Email:     "name@example.com",
+		Format:    entities.MessageExportFormatCSV,
+		Status:    entities.MessageExportStatusPending,
+		ExpiresAt: time.Now().UTC().Add(time.Hour),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+}
+
+func TestGormMessageExportRepository_LoadByToken(t *testing.T) {
+	t.Run("the export is loaded with the token whose hash is stored", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testDB(t, &entities.MessageExport{})
+		repository := NewGormMessageExportRepository(logger, tracer, db)
+
+		// Arrange
+		export := testMessageExport()
+		export.TokenHash = HashToken("token")
+		assert.Nil(t, repository.Store(context.Background(), export))
+
+		// Act
+		loaded, err := repository.LoadByToken(context.Background(), export.ID, "token")
+		_, hashErr := repository.LoadByToken(context.Background(), export.ID, export.TokenHash)
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, export.ID, loaded.ID)
+		assert.Equal(t, ErrCodeNotFound, stacktrace.GetCode(hashErr))
+	})
+}
+
+func TestGormMessageExportRepository_StoreFile(t *testing.T) {
+	t.Run("the file is stored in chunks and the export is ready", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testDB(t, &entities.MessageExport{}, &entities.MessageExportChunk{})
+		repository := NewGormMessageExportRepository(logger, tracer, db)
+
+		// Arrange
+		export := testMessageExport()
+		assert.Nil(t, repository.Store(context.Background(), export))
+
+		file := bytes.Repeat([]byte("a"), messageExportChunkSize+10)
+		export.Status = entities.MessageExportStatusReady
+
+		// Act
+		err := repository.StoreFile(context.Background(), export, bytes.NewReader(file))
+
+		// Assert
+		assert.Nil(t, err)
+
+		stored, err := repository.Load(context.Background(), "user-id", export.ID)
+		assert.Nil(t, err)
+		assert.True(t, stored.IsReady())
+		assert.Equal(t, int64(len(file)), stored.Size)
+		assert.Equal(t, 2, stored.Chunks)
+
+		last, err := repository.LoadChunk(context.Background(), export.ID, 1)
+		assert.Nil(t, err)
+		assert.Equal(t, 10, len(last.Data))
+	})
+}
@@ -2,8 +2,10 @@ package repositories
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"gorm.io/gorm/clause"
 
@@ -17,9 +19,10 @@ import (
 
 // gormMessageRepository is responsible for persisting entities.Message
 type gormMessageRepository struct {
-	logger telemetry.Logger
-	tracer telemetry.Tracer
-	db     *gorm.DB
+	logger         telemetry.Logger
+	tracer         telemetry.Tracer
+	db             *gorm.DB
+	fullTextSearch bool
 }
 
 // NewGormMessageRepository creates the GORM version of the MessageRepository
@@ -28,11 +31,18 @@ func NewGormMessageRepository(
 	tracer telemetry.Tracer,
 	db *gorm.DB,
 ) MessageRepository {
-	return &gormMessageRepository{
-		logger: logger.WithService(fmt.Sprintf("%T", &gormMessageRepository{})),
-		tracer: tracer,
-		db:     db,
+	repository := &gormMessageRepository{
+		logger:         logger.WithService(fmt.Sprintf("%T", &gormMessageRepository{})),
+		tracer:         tracer,
+		db:             db,
+		fullTextSearch: db.Migrator().HasColumn(&entities.Message{}, "content_search"),
 	}
+
+	if !repository.fullTextSearch {
+		repository.logger.Warn(stacktrace.NewError("the [content_search] column does not exist on messages, run cmd/migration to enable full text search. Falling back to ILIKE"))
+	}
+
+	return repository
 }
 
 // DeleteByOwnerAndContact deletes all the messages between and owner and a contact
@@ -41,7 +51,7 @@ func (repository *gormMessageRepository) DeleteByOwnerAndContact(ctx context.Con
 	defer span.End()
 
 	err := repository.db.WithContext(ctx).
-		Where("user_id = ?", userID).
+		Where("user_id = ?", userID).Scopes(scopeOrganization(ctx, "owner")).
 		Where("owner = ?", owner).
 		Where("contact = ?", contact).
 		Delete(&entities.Message{}).
@@ -59,7 +69,7 @@ func (repository *gormMessageRepository) Delete(ctx context.Context, userID enti
 	ctx, span := repository.tracer.Start(ctx)
 	defer span.End()
 
-	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", messageID).Delete(&entities.Message{}).Error
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Scopes(scopeOrganization(ctx, "owner")).Where("id = ?", messageID).Delete(&entities.Message{}).Error
 	if err != nil {
 		msg := fmt.Sprintf("cannot delete message with ID [%s] for user with ID [%s]", messageID, userID)
 		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
@@ -68,6 +78,52 @@ func (repository *gormMessageRepository) Delete(ctx context.Context, userID enti
 	return nil
 }
 
+// LoadOlderThan fetches the oldest entities.Message of a user which were sent or received before the timestamp and still have content
+func (repository *gormMessageRepository) LoadOlderThan(ctx context.Context, userID entities.UserID, timestamp time.Time, limit int) ([]*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	var messages []*entities.Message
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("order_timestamp < ?", timestamp).
+		Where("content_purged_at IS NULL").
+		Order("order_timestamp ASC").
+		Limit(limit).
+		Find(&messages).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot load messages older than [%s] for user with ID [%s]", timestamp, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return messages, nil
+}
+
+// PurgeContent removes the content of the entities.Message of a user with the given IDs and keeps the messages for the usage history
+func (repository *gormMessageRepository) PurgeContent(ctx context.Context, userID entities.UserID, messageIDs []uuid.UUID, timestamp time.Time) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).
+		Model(&entities.Message{}).
+		Where("user_id = ?", userID).
+		Where("id IN ?", messageIDs).
+		Updates(map[string]any{
+			"content":           "",
+			"failure_reason":    nil,
+			"content_purged_at": timestamp,
+			"updated_at":        timestamp,
+		}).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot purge the content of [%d] messages for user with ID [%s]", len(messageIDs), userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
 // Index entities.Message between 2 parties
 func (repository *gormMessageRepository) Index(ctx context.Context, userID entities.UserID, owner string, contact string, params IndexParams) (*[]entities.Message, error) {
 	ctx, span := repository.tracer.Start(ctx)
@@ -75,7 +131,7 @@ func (repository *gormMessageRepository) Index(ctx context.Context, userID entit
 
 	query := repository.db.
 		WithContext(ctx).
-		Where("user_id = ?", userID).
+		Where("user_id = ?", userID).Scopes(scopeOrganization(ctx, "owner")).
 		Where("owner = ?", owner).
 		Where("contact =  ?", contact)
 	if len(params.Query) > 0 {
@@ -84,7 +140,7 @@ func (repository *gormMessageRepository) Index(ctx context.Context, userID entit
 	}
 
 	messages := new([]entities.Message)
-	if err := query.Order("order_timestamp DESC").Limit(params.Limit).Offset(params.Skip).Find(&messages).Error; err != nil {
+	if err := paginate(query, params, "order_timestamp", "created_at").Find(&messages).Error; err != nil {
 		msg := fmt.Sprintf("cannot fetch messges with owner [%s] and contact [%s] and params [%+#v]", owner, contact, params)
 		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
@@ -92,13 +148,59 @@ func (repository *gormMessageRepository) Index(ctx context.Context, userID entit
 	return messages, nil
 }
 
+func (repository *gormMessageRepository) IndexByRequestID(ctx context.Context, userID entities.UserID, requestID string, params IndexParams) (*[]entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.
+		WithContext(ctx).
+		Where("user_id = ?", userID).Scopes(scopeOrganization(ctx, "owner")).
+		Where("request_id = ?", requestID)
+	if len(params.Query) > 0 {
+		queryPattern := "%" + params.Query + "%"
+		query.Where("content ILIKE ?", queryPattern)
+	}
+
+	messages := new([]entities.Message)
+	if err := paginate(query, params, "order_timestamp", "created_at").Find(&messages).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch messges with request ID [%s] and params [%+#v]", requestID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return messages, nil
+}
+
+func (repository *gormMessageRepository) LoadMany(ctx context.Context, userID entities.UserID, messageIDs []uuid.UUID) ([]*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	messages := make([]*entities.Message, 0, len(messageIDs))
+	if len(messageIDs) == 0 {
+		return messages, nil
+	}
+
+	err := repository.db.
+		WithContext(ctx).
+		Where("user_id = ?", userID).Scopes(scopeOrganization(ctx, "owner")).
+		Where("id IN ?", messageIDs).
+		Order("order_timestamp DESC").
+		Find(&messages).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot load [%d] messages for user [%s]", len(messageIDs), userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return messages, nil
+}
+
 func (repository *gormMessageRepository) LastMessage(ctx context.Context, userID entities.UserID, owner string, contact string) (*entities.Message, error) {
 	ctx, span := repository.tracer.Start(ctx)
 	defer span.End()
 
 	query := repository.db.
 		WithContext(ctx).
-		Where("user_id = ?", userID).
+		Where("user_id = ?", userID).Scopes(scopeOrganization(ctx, "owner")).
 		Where("owner = ?", owner).
 		Where("contact =  ?", contact)
 
@@ -124,7 +226,7 @@ func (repository *gormMessageRepository) Search(ctx context.Context, userID enti
 
 	query := repository.db.
 		WithContext(ctx).
-		Where("user_id = ?", userID)
+		Where("user_id = ?", userID).Scopes(scopeOrganization(ctx, "owner"))
 
 	if len(owners) > 0 {
 		query = query.Where("owner IN ?", owners)
@@ -136,9 +238,11 @@ func (repository *gormMessageRepository) Search(ctx context.Context, userID enti
 		query = query.Where("status IN ?", statuses)
 	}
 
-	if len(params.Query) > 0 {
+	if len(params.Query) > 0 && !repository.fullTextSearch {
+		query = repository.searchWithoutFullText(query, params)
+	} else if len(params.Query) > 0 {
 		queryPattern := "%" + params.Query + "%"
-		subQuery := repository.db.Where("content ILIKE ?", queryPattern).
+		subQuery := repository.db.Where("content_search @@ websearch_to_tsquery('simple', ?)", params.Query).
 			Or("contact ILIKE ?", queryPattern).
 			Or("failure_reason ILIKE ?", queryPattern).
 			Or("request_id ILIKE ?", queryPattern)
@@ -147,15 +251,32 @@ func (repository *gormMessageRepository) Search(ctx context.Context, userID enti
 			subQuery = subQuery.Or("id = ?", params.Query)
 		}
 
-		query = query.Where(subQuery)
+		query = query.Where(subQuery).Select(
+			"*, "+
+				"ts_rank(content_search, websearch_to_tsquery('simple', @query)) AS search_rank, "+
+				"CASE WHEN encrypted THEN NULL ELSE ts_headline('simple', content, websearch_to_tsquery('simple', @query), 'StartSel=<b>, StopSel=</b>, MaxFragments=2') END AS search_snippet",
+			sql.Named("query", params.Query),
+		)
+	}
+
+	switch {
+	case params.IsCursorPaginated():
+		query = paginate(query, params, "order_timestamp", "created_at")
+	case params.SortBy == MessageSortByRank && len(params.Query) > 0:
+		query = filterCreatedAt(query, params, "created_at").
+			Order("search_rank DESC NULLS LAST").
+			Order("order_timestamp DESC").
+			Limit(params.Limit).
+			Offset(params.Skip)
+	default:
+		query = filterCreatedAt(query, params, "created_at").
+			Order(repository.order(params, "created_at")).
+			Limit(params.Limit).
+			Offset(params.Skip)
 	}
 
 	messages := make([]*entities.Message, 0, params.Limit)
-	err := query.Order(repository.order(params, "created_at")).
-		Limit(params.Limit).
-		Offset(params.Skip).
-		Find(&messages).
-		Error
+	err := query.Find(&messages).Error
 	if err != nil {
 		msg := fmt.Sprintf("cannot search messages with for user [%s] params [%+#v]", userID, params)
 		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
@@ -164,6 +285,21 @@ func (repository *gormMessageRepository) Search(ctx context.Context, userID enti
 	return messages, nil
 }
 
+// searchWithoutFullText filters messages with ILIKE when the content_search column has not been migrated
+func (repository *gormMessageRepository) searchWithoutFullText(query *gorm.DB, params IndexParams) *gorm.DB {
+	queryPattern := "%" + params.Query + "%"
+	subQuery := repository.db.Where("encrypted = ? AND content ILIKE ?", false, queryPattern).
+		Or("contact ILIKE ?", queryPattern).
+		Or("failure_reason ILIKE ?", queryPattern).
+		Or("request_id ILIKE ?", queryPattern)
+
+	if _, err := uuid.Parse(params.Query); err == nil {
+		subQuery = subQuery.Or("id = ?", params.Query)
+	}
+
+	return query.Where(subQuery).Select("*, NULL AS search_rank, NULL AS search_snippet")
+}
+
 // Store a new entities.Message
 func (repository *gormMessageRepository) Store(ctx context.Context, message *entities.Message) error {
 	ctx, span := repository.tracer.Start(ctx)
@@ -183,7 +319,7 @@ func (repository *gormMessageRepository) Load(ctx context.Context, userID entiti
 	defer span.End()
 
 	message := new(entities.Message)
-	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", messageID).First(message).Error
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Scopes(scopeOrganization(ctx, "owner")).Where("id = ?", messageID).First(message).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		msg := fmt.Sprintf("message with ID [%s] and userID [%s] does not exist", messageID, userID)
 		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
@@ -244,6 +380,177 @@ func (repository *gormMessageRepository) GetOutstanding(ctx context.Context, use
 	return message, nil
 }
 
+func (repository *gormMessageRepository) GetOutstandingBatch(ctx context.Context, userID entities.UserID, owner string, timestamp time.Time, limit int) ([]*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	var messages []*entities.Message
+	err := crdbgorm.ExecuteTx(ctx, repository.db, nil,
+		func(tx *gorm.DB) error {
+			messages = nil
+			err := tx.WithContext(ctx).
+				Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Where("user_id = ?", userID).
+				Where("owner = ?", owner).
+				Where("type = ?", entities.MessageTypeMobileTerminated).
+				Where("status = ?", entities.MessageStatusScheduled).
+				Where("notification_scheduled_at <= ?", timestamp).
+				Order("notification_scheduled_at ASC").
+				Limit(limit).
+				Find(&messages).
+				Error
+			if err != nil || len(messages) == 0 {
+				return err
+			}
+
+			ids := make([]uuid.UUID, 0, len(messages))
+			for _, message := range messages {
+				ids = append(ids, message.ID)
+				message.Status = entities.MessageStatusSending
+			}
+
+			return tx.WithContext(ctx).
+				Model(&entities.Message{}).
+				Where("id IN ?", ids).
+				Update("status", entities.MessageStatusSending).
+				Error
+		},
+	)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch [%d] outstanding messages of phone [%s] for user [%s]", limit, owner, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return messages, nil
+}
+
+// Reroute moves the due pending and scheduled entities.Message of a phone to another phone and deletes their pending entities.PhoneNotification in one transaction so that messages which are being sent are not moved
+func (repository *gormMessageRepository) Reroute(ctx context.Context, userID entities.UserID, owner string, phone *entities.Phone, timestamp time.Time) ([]*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	var messages []*entities.Message
+	err := crdbgorm.ExecuteTx(ctx, repository.db, nil,
+		func(tx *gorm.DB) error {
+			messages = nil
+			err := tx.WithContext(ctx).
+				Model(&messages).
+				Clauses(clause.Returning{}).
+				Where("user_id = ?", userID).
+				Where("owner = ?", owner).
+				Where("type = ?", entities.MessageTypeMobileTerminated).
+				Where("status IN ?", []entities.MessageStatus{entities.MessageStatusPending, entities.MessageStatusScheduled}).
+				Where(tx.Where("scheduled_send_time IS NULL").Or("scheduled_send_time <= ?", timestamp)).
+				Updates(map[string]any{"owner": phone.PhoneNumber, "sim": phone.SIM, "updated_at": time.Now().UTC()}).
+				Error
+			if err != nil || len(messages) == 0 {
+				return err
+			}
+
+			messageIDs := make([]uuid.UUID, 0, len(messages))
+			for _, message := range messages {
+				messageIDs = append(messageIDs, message.ID)
+			}
+
+			// the notifications which are queued for the old phone are skipped when they are due since they no longer exist
+			return tx.WithContext(ctx).
+				Where("user_id = ?", userID).
+				Where("message_id IN ?", messageIDs).
+				Where("phone_id <> ?", phone.ID).
+				Where("status = ?", entities.PhoneNotificationStatusPending).
+				Delete(&entities.PhoneNotification{}).
+				Error
+		},
+	)
+	if err != nil {
+		msg := fmt.Sprintf("cannot reroute messages of phone [%s] to phone [%s] for user [%s]", owner, phone.PhoneNumber, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return messages, nil
+}
+
+func (repository *gormMessageRepository) LoadDueOutstanding(ctx context.Context, userID entities.UserID, owner string, timestamp time.Time) (*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	message := new(entities.Message)
+	err := repository.db.
+		WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("owner = ?", owner).
+		Where("type = ?", entities.MessageTypeMobileTerminated).
+		Where("status = ?", entities.MessageStatusScheduled).
+		Where("notification_scheduled_at <= ?", timestamp).
+		Order("notification_scheduled_at ASC").
+		First(message).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("phone [%s] of user [%s] has no outstanding message at [%s]", owner, userID, timestamp)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load outstanding message of phone [%s] for user [%s] at [%s]", owner, userID, timestamp)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return message, nil
+}
+
+func (repository *gormMessageRepository) Export(ctx context.Context, userID entities.UserID, owners []string, types []entities.MessageType, statuses []entities.MessageStatus, params IndexParams, callback func(messages []*entities.Message) error) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	cursor := params.Cursor
+	for {
+		query := repository.db.
+			WithContext(ctx).
+			Where("user_id = ?", userID).Scopes(scopeOrganization(ctx, "owner"))
+
+		if len(owners) > 0 {
+			query = query.Where("owner IN ?", owners)
+		}
+		if len(types) > 0 {
+			query = query.Where("type IN ?", types)
+		}
+		if len(statuses) > 0 {
+			query = query.Where("status IN ?", statuses)
+		}
+		if cursor != nil {
+			query = query.Where("(order_timestamp, id) > (?, ?)", cursor.Timestamp, cursor.ID)
+		}
+
+		messages := make([]*entities.Message, 0, params.Limit)
+		err := filterCreatedAt(query, params, "created_at").
+			Order("order_timestamp ASC").
+			Order("id ASC").
+			Limit(params.Limit).
+			Find(&messages).
+			Error
+		if err != nil {
+			msg := fmt.Sprintf("cannot export messages for user [%s] after cursor [%+#v]", userID, cursor)
+			return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		if len(messages) == 0 {
+			return nil
+		}
+
+		if err = callback(messages); err != nil {
+			msg := fmt.Sprintf("cannot process [%d] exported messages for user [%s]", len(messages), userID)
+			return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		if len(messages) < params.Limit {
+			return nil
+		}
+
+		last := messages[len(messages)-1]
+		cursor = NewCursor(last.OrderTimestamp, last.ID)
+	}
+}
+
 func (repository *gormMessageRepository) order(params IndexParams, defaultSortBy string) string {
 	sortBy := defaultSortBy
 	if len(params.SortBy) > 0 {
@@ -257,3 +564,16 @@ func (repository *gormMessageRepository) order(params IndexParams, defaultSortBy
 
 	return fmt.Sprintf("%s %s", sortBy, direction)
 }
+
+// DeleteAllForUser deletes all the entities.Message of a user
+func (repository *gormMessageRepository) DeleteAllForUser(ctx context.Context, userID entities.UserID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&entities.Message{}).Error; err != nil {
+		msg := fmt.Sprintf("cannot delete messages for user with ID [%s]", userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,280 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/hirosassa/zerodriver"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+func testLoggerAndTracer() (telemetry.Logger, telemetry.Tracer) {
+	nop := zerolog.Nop()
+	logger := telemetry.NewZerologLogger("test", nil, &zerodriver.Logger{Logger: &nop}, nil)
+	return logger, telemetry.NewOtelLogger("test", logger)
+}
+
+// testDB creates an in-memory database with the tables of the models
+func testDB(t *testing.T, models ...any) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", uuid.NewString())), &gorm.Config{
+		Logger: gormLogger.Default.LogMode(gormLogger.Silent),
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, db.AutoMigrate(models...))
+	return db
+}
+
+func testMessage(owner string, status entities.MessageStatus, scheduledSendTime *time.Time) *entities.Message {
+	return &entities.Message{
+		ID:                uuid.New(),
+		Owner:             owner,
+		UserID:            "user-id",
+		Contact:           "+18005550111",
+		Content:           "hello",
+		SIM:               entities.SIM1,
+		Type:              entities.MessageTypeMobileTerminated,
+		Status:            status,
+		ScheduledSendTime: scheduledSendTime,
+		RequestReceivedAt: time.Now().UTC(),
+		CreatedAt:         time.Now().UTC(),
+		UpdatedAt:         time.Now().UTC(),
+		OrderTimestamp:    time.Now().UTC(),
+	}
+}
+
+func TestGormMessageRepository_Reroute(t *testing.T) {
+	t.Run("due messages are moved to the failover phone and their pending notifications are deleted", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testDB(t, &entities.Message{}, &entities.PhoneNotification{})
+		repository := NewGormMessageRepository(logger, tracer, db)
+
+		// Arrange
+		oldPhoneID := uuid.New()
+		failover := &entities.Phone{ID: uuid.New(), UserID: "user-id", PhoneNumber: "+18005550199", SIM: entities.SIM2}
+		future := time.Now().UTC().Add(time.Hour)
+
+		pending := testMessage("+18005550100", entities.MessageStatusPending, nil)
+		scheduled := testMessage("+18005550100", entities.MessageStatusScheduled, &future)
+		sending := testMessage("+18005550100", entities.MessageStatusSending, nil)
+		for _, message := range []*entities.Message{pending, scheduled, sending} {
+			assert.Nil(t, db.Create(message).Error)
+		}
+
+		notification := &entities.PhoneNotification{
+			ID:          uuid.New(),
+			MessageID:   pending.ID,
+			UserID:      "user-id",
+			PhoneID:     oldPhoneID,
+			Status:      entities.PhoneNotificationStatusPending,
+			ScheduledAt: time.Now().UTC(),
+		}
+		assert.Nil(t, db.Create(notification).Error)
+
+		// Act
+		messages, err := repository.Reroute(context.Background(), "user-id", "+18005550100", failover, time.Now().UTC())
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(messages))
+		assert.Equal(t, pending.ID, messages[0].ID)
+
+		rerouted, err := repository.Load(context.Background(), "user-id", pending.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, failover.PhoneNumber, rerouted.Owner)
+		assert.Equal(t, failover.SIM, rerouted.SIM)
+
+		notScheduled, err := repository.Load(context.Background(), "user-id", scheduled.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, "+18005550100", notScheduled.Owner)
+
+		notSending, err := repository.Load(context.Background(), "user-id", sending.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, "+18005550100", notSending.Owner)
+
+		var count int64
+		assert.Nil(t, db.Model(&entities.PhoneNotification{}).Where("id = ?", notification.ID).Count(&count).Error)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("notifications of other messages are not deleted", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testDB(t, &entities.Message{}, &entities.PhoneNotification{})
+		repository := NewGormMessageRepository(logger, tracer, db)
+
+		// Arrange
+		failover := &entities.Phone{ID: uuid.New(), UserID: "user-id", PhoneNumber: "+18005550199", SIM: entities.SIM1}
+		sending := testMessage("+18005550100", entities.MessageStatusSending, nil)
+		assert.Nil(t, db.Create(sending).Error)
+
+		notification := &entities.PhoneNotification{
+			ID:          uuid.New(),
+			MessageID:   sending.ID,
+			UserID:      "user-id",
+			PhoneID:     uuid.New(),
+			Status:      entities.PhoneNotificationStatusPending,
+			ScheduledAt: time.Now().UTC(),
+		}
+		assert.Nil(t, db.Create(notification).Error)
+
+		// Act
+		messages, err := repository.Reroute(context.Background(), "user-id", "+18005550100", failover, time.Now().UTC())
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(messages))
+
+		var count int64
+		assert.Nil(t, db.Model(&entities.PhoneNotification{}).Where("id = ?", notification.ID).Count(&count).Error)
+		assert.Equal(t, int64(1), count)
+	})
+}
+
+func TestGormMessageRepository_Search(t *testing.T) {
+	// testMessages stores a message which is an hour older than the second message
+	testMessages := func(t *testing.T, db *gorm.DB) (*entities.Message, *entities.Message) {
+		first := testMessage("+18005550100", entities.MessageStatusSent, nil)
+		first.CreatedAt = time.Now().UTC().Add(-time.Hour)
+		first.OrderTimestamp = first.CreatedAt
+
+		second := testMessage("+18005550100", entities.MessageStatusSent, nil)
+
+		assert.Nil(t, db.Create(first).Error)
+		assert.Nil(t, db.Create(second).Error)
+		return first, second
+	}
+
+	t.Run("messages are sorted by created_at when sort_by is not set", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testDB(t, &entities.Message{})
+		repository := NewGormMessageRepository(logger, tracer, db)
+
+		// Arrange
+		first, second := testMessages(t, db)
+
+		// Act
+		messages, err := repository.Search(context.Background(), "user-id", nil, nil, nil, IndexParams{Limit: 10})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(messages))
+		assert.Equal(t, first.ID, messages[0].ID)
+		assert.Equal(t, second.ID, messages[1].ID)
+	})
+
+	t.Run("messages are sorted by created_at in descending order when sort_descending is set", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testDB(t, &entities.Message{})
+		repository := NewGormMessageRepository(logger, tracer, db)
+
+		// Arrange
+		first, second := testMessages(t, db)
+
+		// Act
+		messages, err := repository.Search(context.Background(), "user-id", nil, nil, nil, IndexParams{Limit: 10, SortDescending: true})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(messages))
+		assert.Equal(t, second.ID, messages[0].ID)
+		assert.Equal(t, first.ID, messages[1].ID)
+	})
+
+	t.Run("messages are sorted by order_timestamp when it is requested", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testDB(t, &entities.Message{})
+		repository := NewGormMessageRepository(logger, tracer, db)
+
+		// Arrange
+		first, second := testMessages(t, db)
+
+		// Act
+		messages, err := repository.Search(context.Background(), "user-id", nil, nil, nil, IndexParams{Limit: 10, SortBy: MessageSortByOrderTimestamp})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(messages))
+		assert.Equal(t, second.ID, messages[0].ID)
+		assert.Equal(t, first.ID, messages[1].ID)
+	})
+}
+
+func TestNewGormMessageRepository(t *testing.T) {
+	t.Run("full text search is disabled when the content_search column does not exist", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testDB(t, &entities.Message{})
+
+		// Act
+		repository := NewGormMessageRepository(logger, tracer, db)
+
+		// Assert
+		assert.False(t, repository.(*gormMessageRepository).fullTextSearch)
+	})
+
+	t.Run("full text search is enabled when the content_search column exists", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testDB(t, &entities.Message{})
+
+		// Arrange
+		assert.Nil(t, db.Exec("ALTER TABLE messages ADD COLUMN content_search TEXT").Error)
+
+		// Act
+		repository := NewGormMessageRepository(logger, tracer, db)
+
+		// Assert
+		assert.True(t, repository.(*gormMessageRepository).fullTextSearch)
+	})
+}
+
+func TestGormMessageRepository_PurgeContent(t *testing.T) {
+	t.Run("the content of the messages is removed and the messages are not loaded again", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testDB(t, &entities.Message{})
+		repository := NewGormMessageRepository(logger, tracer, db)
+
+		// Arrange
+		message := testMessage("+18005550100", entities.MessageStatusDelivered, nil)
+		message.OrderTimestamp = time.Now().UTC().Add(-48 * time.Hour)
+		assert.Nil(t, db.Create(message).Error)
+
+		// Act
+		err := repository.PurgeContent(context.Background(), "user-id", []uuid.UUID{message.ID}, time.Now().UTC())
+
+		// Assert
+		assert.Nil(t, err)
+
+		purged, err := repository.Load(context.Background(), "user-id", message.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, "", purged.Content)
+		assert.Equal(t, entities.MessageStatus(entities.MessageStatusDelivered), purged.Status)
+		assert.NotNil(t, purged.ContentPurgedAt)
+
+		messages, err := repository.LoadOlderThan(context.Background(), "user-id", time.Now().UTC().Add(-24*time.Hour), 10)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(messages))
+	})
+}
@@ -40,7 +40,7 @@ func (repository *gormMessageThreadRepository) Delete(ctx context.Context, userI
 	ctx, span := repository.tracer.Start(ctx)
 	defer span.End()
 
-	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", messageThreadID).Delete(&entities.MessageThread{}).Error
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Scopes(scopeOrganization(ctx, "owner")).Where("id = ?", messageThreadID).Delete(&entities.MessageThread{}).Error
 	if err != nil {
 		msg := fmt.Sprintf("cannot delete message thread with ID [%s] for user with ID [%s]", messageThreadID, userID)
 		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
@@ -70,6 +70,23 @@ func (repository *gormMessageThreadRepository) UpdateAfterDeletedMessage(ctx con
 	return nil
 }
 
+// PurgeLastMessageContent removes the content of the last message of the threads whose last message is one of the messageIDs
+func (repository *gormMessageThreadRepository) PurgeLastMessageContent(ctx context.Context, userID entities.UserID, messageIDs []uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).Model(&entities.MessageThread{}).
+		Where("user_id = ?", userID).
+		Where("last_message_id IN ?", messageIDs).
+		Update("last_message_content", "").Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot purge the last message content of threads with userID [%s] for [%d] messages", userID, len(messageIDs))
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
 // Store a new entities.MessageThread
 func (repository *gormMessageThreadRepository) Store(ctx context.Context, thread *entities.MessageThread) error {
 	ctx, span := repository.tracer.Start(ctx)
@@ -105,7 +122,7 @@ func (repository *gormMessageThreadRepository) LoadByOwnerContact(ctx context.Co
 
 	err := repository.db.
 		WithContext(ctx).
-		Where("user_id = ?", userID).
+		Where("user_id = ?", userID).Scopes(scopeOrganization(ctx, "owner")).
 		Where("owner = ?", owner).
 		Where("contact = ?", contact).
 		First(thread).
@@ -132,7 +149,7 @@ func (repository *gormMessageThreadRepository) Load(ctx context.Context, userID
 
 	err := repository.db.
 		WithContext(ctx).
-		Where("user_id = ?", userID).
+		Where("user_id = ?", userID).Scopes(scopeOrganization(ctx, "owner")).
 		Where("id = ?", ID).
 		First(thread).
 		Error
@@ -156,7 +173,7 @@ func (repository *gormMessageThreadRepository) Index(ctx context.Context, userID
 
 	query := repository.db.
 		WithContext(ctx).
-		Where("user_id = ?", userID).
+		Where("user_id = ?", userID).Scopes(scopeOrganization(ctx, "owner")).
 		Where("owner = ?", owner)
 
 	if isArchived {
@@ -175,10 +192,23 @@ func (repository *gormMessageThreadRepository) Index(ctx context.Context, userID
 	}
 
 	threads := new([]entities.MessageThread)
-	if err := query.Order("order_timestamp DESC").Limit(params.Limit).Offset(params.Skip).Find(&threads).Error; err != nil {
+	if err := paginate(query, params, "order_timestamp", "created_at").Find(&threads).Error; err != nil {
 		msg := fmt.Sprintf("cannot fetch message threads with owner [%s] and params [%+#v]", owner, params)
 		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
 	return threads, nil
 }
+
+// DeleteAllForUser deletes all the entities.MessageThread of a user
+func (repository *gormMessageThreadRepository) DeleteAllForUser(ctx context.Context, userID entities.UserID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&entities.MessageThread{}).Error; err != nil {
+		msg := fmt.Sprintf("cannot delete message threads for user with ID [%s]", userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,317 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cockroachdb/cockroach-go/v2/crdb/crdbgorm"
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormOrganizationRepository is responsible for persisting entities.Organization
+type gormOrganizationRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormOrganizationRepository creates the GORM version of the OrganizationRepository
+func NewGormOrganizationRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) OrganizationRepository {
+	return &gormOrganizationRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormOrganizationRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+func (repository *gormOrganizationRepository) Store(ctx context.Context, organization *entities.Organization, owner *entities.OrganizationMember) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := crdbgorm.ExecuteTx(ctx, repository.db, nil, func(tx *gorm.DB) error {
+		if err := tx.WithContext(ctx).Create(organization).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot create organization with ID [%s]", organization.ID))
+		}
+		if err := tx.WithContext(ctx).Create(owner).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot create owner [%s] for organization with ID [%s]", owner.UserID, organization.ID))
+		}
+		return nil
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot store organization with ID [%s] for user [%s]", organization.ID, organization.OwnerID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormOrganizationRepository) Load(ctx context.Context, organizationID uuid.UUID) (*entities.Organization, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	organization := new(entities.Organization)
+	err := repository.db.WithContext(ctx).Where("id = ?", organizationID).First(organization).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("organization with ID [%s] does not exist", organizationID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load organization with ID [%s]", organizationID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return organization, nil
+}
+
+func (repository *gormOrganizationRepository) Index(ctx context.Context, userID entities.UserID) ([]*entities.Organization, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	organizations := make([]*entities.Organization, 0)
+	err := repository.db.WithContext(ctx).
+		Where("id IN (?)", repository.db.Model(&entities.OrganizationMember{}).Select("organization_id").Where("user_id = ?", userID)).
+		Order("created_at DESC").
+		Find(&organizations).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch organizations for user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return organizations, nil
+}
+
+func (repository *gormOrganizationRepository) LoadMember(ctx context.Context, organizationID uuid.UUID, userID entities.UserID) (*entities.OrganizationMember, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	member := new(entities.OrganizationMember)
+	err := repository.db.WithContext(ctx).
+		Preload("PhoneGrants").
+		Where("organization_id = ?", organizationID).
+		Where("user_id = ?", userID).
+		First(member).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("user [%s] is not a member of organization [%s]", userID, organizationID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load member [%s] of organization [%s]", userID, organizationID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return member, nil
+}
+
+func (repository *gormOrganizationRepository) LoadMemberByID(ctx context.Context, organizationID uuid.UUID, memberID uuid.UUID) (*entities.OrganizationMember, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	member := new(entities.OrganizationMember)
+	err := repository.db.WithContext(ctx).
+		Preload("PhoneGrants").
+		Where("organization_id = ?", organizationID).
+		Where("id = ?", memberID).
+		First(member).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("member with ID [%s] does not exist in organization [%s]", memberID, organizationID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load member with ID [%s] of organization [%s]", memberID, organizationID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return member, nil
+}
+
+func (repository *gormOrganizationRepository) IndexMembers(ctx context.Context, organizationID uuid.UUID, params IndexParams) ([]*entities.OrganizationMember, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).Preload("PhoneGrants").Where("organization_id = ?", organizationID)
+	if len(params.Query) > 0 {
+		queryPattern := "%" + params.Query + "%"
+		query.Where(repository.db.Where("email ILIKE ?", queryPattern).Or("role ILIKE ?", queryPattern))
+	}
+
+	members := make([]*entities.OrganizationMember, 0)
+	if err := query.Order("created_at ASC").Limit(params.Limit).Offset(params.Skip).Find(&members).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch members of organization [%s] with params [%+#v]", organizationID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return members, nil
+}
+
+func (repository *gormOrganizationRepository) UpdateMember(ctx context.Context, member *entities.OrganizationMember) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Omit("PhoneGrants").Save(member).Error; err != nil {
+		msg := fmt.Sprintf("cannot update member with ID [%s] of organization [%s]", member.ID, member.OrganizationID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormOrganizationRepository) DeleteMember(ctx context.Context, organizationID uuid.UUID, memberID uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := crdbgorm.ExecuteTx(ctx, repository.db, nil, func(tx *gorm.DB) error {
+		if err := tx.WithContext(ctx).Where("member_id = ?", memberID).Delete(&entities.OrganizationPhoneGrant{}).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot delete phone grants for member [%s]", memberID))
+		}
+		member := new(entities.OrganizationMember)
+		if err := tx.WithContext(ctx).Where("organization_id = ?", organizationID).Where("id = ?", memberID).First(member).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot load member [%s] of organization [%s]", memberID, organizationID))
+		}
+		err := tx.WithContext(ctx).
+			Where("organization_id = ?", organizationID).
+			Where("LOWER(email) = LOWER(?)", member.Email).
+			Delete(&entities.OrganizationInvitation{}).
+			Error
+		if err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot revoke invitations of member [%s]", memberID))
+		}
+		return tx.WithContext(ctx).Delete(member).Error
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete member with ID [%s] from organization [%s]", memberID, organizationID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormOrganizationRepository) ReplacePhoneGrants(ctx context.Context, member *entities.OrganizationMember, grants []entities.OrganizationPhoneGrant) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := crdbgorm.ExecuteTx(ctx, repository.db, nil, func(tx *gorm.DB) error {
+		if err := tx.WithContext(ctx).Where("member_id = ?", member.ID).Delete(&entities.OrganizationPhoneGrant{}).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot delete phone grants for member [%s]", member.ID))
+		}
+		if len(grants) == 0 {
+			return nil
+		}
+		return tx.WithContext(ctx).Create(&grants).Error
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot replace [%d] phone grants for member with ID [%s]", len(grants), member.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	member.PhoneGrants = grants
+	return nil
+}
+
+func (repository *gormOrganizationRepository) StoreInvitation(ctx context.Context, invitation *entities.OrganizationInvitation) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(invitation).Error; err != nil {
+		msg := fmt.Sprintf("cannot save invitation with ID [%s] for organization [%s]", invitation.ID, invitation.OrganizationID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormOrganizationRepository) LoadInvitationByToken(ctx context.Context, token string) (*entities.OrganizationInvitation, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	invitation := new(entities.OrganizationInvitation)
+	err := repository.db.WithContext(ctx).
+		Where("token_hash = ?", HashToken(token)).
+		Where("accepted_at IS NULL").
+		First(invitation).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := "organization invitation with token does not exist"
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := "cannot load organization invitation by token"
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return invitation, nil
+}
+
+func (repository *gormOrganizationRepository) AcceptInvitation(ctx context.Context, invitation *entities.OrganizationInvitation, member *entities.OrganizationMember) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := crdbgorm.ExecuteTx(ctx, repository.db, nil, func(tx *gorm.DB) error {
+		result := tx.WithContext(ctx).
+			Model(invitation).
+			Where("accepted_at IS NULL").
+			Updates(map[string]any{"accepted_at": invitation.AcceptedAt, "updated_at": invitation.UpdatedAt})
+		if result.Error != nil {
+			return stacktrace.Propagate(result.Error, fmt.Sprintf("cannot update invitation with ID [%s]", invitation.ID))
+		}
+		if result.RowsAffected == 0 {
+			return stacktrace.NewErrorWithCode(ErrCodeNotFound, fmt.Sprintf("invitation with ID [%s] has already been accepted", invitation.ID))
+		}
+		if err := tx.WithContext(ctx).Create(member).Error; errors.Is(err, gorm.ErrDuplicatedKey) {
+			return stacktrace.PropagateWithCode(err, ErrCodeConflict, fmt.Sprintf("user [%s] is already a member of organization [%s]", member.UserID, member.OrganizationID))
+		} else if err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot create member [%s] in organization [%s]", member.UserID, member.OrganizationID))
+		}
+		return nil
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot accept invitation with ID [%s] for user [%s]", invitation.ID, member.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// DeleteAllForUser deletes the organizations owned by a user together with their members, phone grants and invitations
+func (repository *gormOrganizationRepository) DeleteAllForUser(ctx context.Context, userID entities.UserID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := crdbgorm.ExecuteTx(ctx, repository.db, nil, func(tx *gorm.DB) error {
+		organizations := tx.WithContext(ctx).Model(&entities.Organization{}).Select("id").Where("owner_id = ?", userID)
+		members := tx.WithContext(ctx).Model(&entities.OrganizationMember{}).Select("id").Where("user_id = ? OR organization_id IN (?)", userID, organizations)
+
+		if err := tx.WithContext(ctx).Where("member_id IN (?)", members).Delete(&entities.OrganizationPhoneGrant{}).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot delete phone grants for user [%s]", userID))
+		}
+		if err := tx.WithContext(ctx).Where("user_id = ? OR organization_id IN (?)", userID, organizations).Delete(&entities.OrganizationMember{}).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot delete organization members for user [%s]", userID))
+		}
+		if err := tx.WithContext(ctx).Where("organization_id IN (?)", organizations).Delete(&entities.OrganizationInvitation{}).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot delete organization invitations for user [%s]", userID))
+		}
+		return tx.WithContext(ctx).Where("owner_id = ?", userID).Delete(&entities.Organization{}).Error
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete organizations for user with ID [%s]", userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
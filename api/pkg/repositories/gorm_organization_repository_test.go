@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+)
+
+func testOrganizationInvitation(organizationID uuid.UUID, email string, token string) *entities.OrganizationInvitation {
+	return &entities.OrganizationInvitation{
+		ID:             uuid.New(),
+		OrganizationID: organizationID,
+		Email:          email,
+		Role:           entities.OrganizationRoleAgent,
+		TokenHash:      HashToken(token),
+		InvitedBy:      "user-id",
+		ExpiresAt:      time.Now().UTC().Add(time.Hour),
+		CreatedAt:      time.Now().UTC(),
+		UpdatedAt:      time.Now().UTC(),
+	}
+}
+
+func testOrganizationMember(organizationID uuid.UUID, userID entities.UserID, email string) *entities.OrganizationMember {
+	return &entities.OrganizationMember{
+		ID:             uuid.New(),
+		OrganizationID: organizationID,
+		UserID:         userID,
+		Email:          email,
+		Role:           entities.OrganizationRoleAgent,
+		CreatedAt:      time.Now().UTC(),
+		UpdatedAt:      time.Now().UTC(),
+	}
+}
+
+func TestGormOrganizationRepository_AcceptInvitation(t *testing.T) {
+	t.Run("an invitation can be accepted only once", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testDB(t, &entities.OrganizationInvitation{}, &entities.OrganizationMember{})
+		repository := NewGormOrganizationRepository(logger, tracer, db)
+
+		// Arrange
+		organizationID := uuid.New()
+		invitation := testOrganizationInvitation(organizationID, "name@email.com", "token")
+		assert.Nil(t, repository.StoreInvitation(context.Background(), invitation))
+
+		loaded, err := repository.LoadInvitationByToken(context.Background(), "token")
+		assert.Nil(t, err)
+
+		acceptedAt := time.Now().UTC()
+		loaded.AcceptedAt = &acceptedAt
+
+		// Act
+		err = repository.AcceptInvitation(context.Background(), loaded, testOrganizationMember(organizationID, "user-1", "name@email.com"))
+		replayErr := repository.AcceptInvitation(context.Background(), loaded, testOrganizationMember(organizationID, "user-2", "name@email.com"))
+		_, reloadErr := repository.LoadInvitationByToken(context.Background(), "token")
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, ErrCodeNotFound, stacktrace.GetCode(replayErr))
+		assert.Equal(t, ErrCodeNotFound, stacktrace.GetCode(reloadErr))
+
+		var count int64
+		assert.Nil(t, db.Model(&entities.OrganizationMember{}).Count(&count).Error)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("the token is not stored in plain text", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testDB(t, &entities.OrganizationInvitation{})
+		repository := NewGormOrganizationRepository(logger, tracer, db)
+
+		// Arrange
+		invitation := testOrganizationInvitation(uuid.New(), "name@email.com", "token")
+		assert.Nil(t, repository.StoreInvitation(context.Background(), invitation))
+
+		// Act
+		_, err := repository.LoadInvitationByToken(context.Background(), invitation.TokenHash)
+
+		// Assert
+		assert.NotEqual(t, "token", invitation.TokenHash)
+		assert.Equal(t, ErrCodeNotFound, stacktrace.GetCode(err))
+	})
+}
+
+func TestGormOrganizationRepository_DeleteMember(t *testing.T) {
+	t.Run("the invitations of the member are revoked", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testDB(t, &entities.OrganizationInvitation{}, &entities.OrganizationMember{}, &entities.OrganizationPhoneGrant{})
+		repository := NewGormOrganizationRepository(logger, tracer, db)
+
+		// Arrange
+		organizationID := uuid.New()
+		member := testOrganizationMember(organizationID, "user-1", "Name@Email.com")
+		assert.Nil(t, db.Create(member).Error)
+		assert.Nil(t, repository.StoreInvitation(context.Background(), testOrganizationInvitation(organizationID, "name@email.com", "token-1")))
+		assert.Nil(t, repository.StoreInvitation(context.Background(), testOrganizationInvitation(organizationID, "other@email.com", "token-2")))
+
+		// Act
+		err := repository.DeleteMember(context.Background(), organizationID, member.ID)
+
+		// Assert
+		assert.Nil(t, err)
+
+		_, revokedErr := repository.LoadInvitationByToken(context.Background(), "token-1")
+		assert.Equal(t, ErrCodeNotFound, stacktrace.GetCode(revokedErr))
+
+		_, otherErr := repository.LoadInvitationByToken(context.Background(), "token-2")
+		assert.Nil(t, otherErr)
+
+		_, memberErr := repository.LoadMemberByID(context.Background(), organizationID, member.ID)
+		assert.Equal(t, ErrCodeNotFound, stacktrace.GetCode(memberErr))
+	})
+}
@@ -0,0 +1,159 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cockroachdb/cockroach-go/v2/crdb/crdbgorm"
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// gormPhoneAvailabilityRepository is responsible for persisting entities.PhoneAvailabilityPeriod
+type gormPhoneAvailabilityRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormPhoneAvailabilityRepository creates the GORM version of the PhoneAvailabilityRepository
+func NewGormPhoneAvailabilityRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) PhoneAvailabilityRepository {
+	return &gormPhoneAvailabilityRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormPhoneAvailabilityRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Transition ends the open period of a phone and starts the new period if the availability has changed
+func (repository *gormPhoneAvailabilityRepository) Transition(ctx context.Context, period *entities.PhoneAvailabilityPeriod) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, dbOperationDuration)
+	defer cancel()
+
+	err := crdbgorm.ExecuteTx(ctx, repository.db, nil, func(tx *gorm.DB) error {
+		current := new(entities.PhoneAvailabilityPeriod)
+		err := tx.WithContext(ctx).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("phone_id = ?", period.PhoneID).
+			Where("ended_at IS NULL").
+			Order("started_at desc").
+			First(current).
+			Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			msg := fmt.Sprintf("cannot fetch open availability period for phone with ID [%s]", period.PhoneID)
+			return stacktrace.Propagate(err, msg)
+		}
+
+		if err == nil && current.Online == period.Online {
+			return nil
+		}
+
+		if err == nil {
+			if period.StartedAt.Before(current.StartedAt) {
+				period.StartedAt = current.StartedAt
+			}
+			err = tx.WithContext(ctx).
+				Model(current).
+				Updates(map[string]any{
+					"ended_at":   period.StartedAt,
+					"updated_at": time.Now().UTC(),
+				}).Error
+			if err != nil {
+				msg := fmt.Sprintf("cannot end availability period with ID [%s]", current.ID)
+				return stacktrace.Propagate(err, msg)
+			}
+		}
+
+		if err = tx.WithContext(ctx).Create(period).Error; err != nil {
+			msg := fmt.Sprintf("cannot create availability period with ID [%s] for phone [%s]", period.ID, period.PhoneID)
+			return stacktrace.Propagate(err, msg)
+		}
+		return nil
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot transition availability of phone with ID [%s] to online [%t]", period.PhoneID, period.Online)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Index fetches the periods of a phone which overlap the time between from and to
+func (repository *gormPhoneAvailabilityRepository) Index(ctx context.Context, userID entities.UserID, phoneID uuid.UUID, from time.Time, to time.Time) ([]*entities.PhoneAvailabilityPeriod, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, dbOperationDuration)
+	defer cancel()
+
+	var periods []*entities.PhoneAvailabilityPeriod
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("phone_id = ?", phoneID).
+		Where("started_at < ?", to).
+		Where("(ended_at IS NULL OR ended_at > ?)", from).
+		Order("started_at asc").
+		Find(&periods).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch availability periods for phone [%s] between [%s] and [%s]", phoneID, from, to)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return periods, nil
+}
+
+// LoadFirst fetches the first recorded period of a phone
+func (repository *gormPhoneAvailabilityRepository) LoadFirst(ctx context.Context, userID entities.UserID, phoneID uuid.UUID) (*entities.PhoneAvailabilityPeriod, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, dbOperationDuration)
+	defer cancel()
+
+	period := new(entities.PhoneAvailabilityPeriod)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("phone_id = ?", phoneID).
+		Order("started_at asc").
+		First(period).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("phone [%s] has no availability period", phoneID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch the first availability period of phone [%s]", phoneID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return period, nil
+}
+
+// DeleteAllForUser deletes all the entities.PhoneAvailabilityPeriod of a user
+func (repository *gormPhoneAvailabilityRepository) DeleteAllForUser(ctx context.Context, userID entities.UserID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&entities.PhoneAvailabilityPeriod{}).Error; err != nil {
+		msg := fmt.Sprintf("cannot delete phone availability periods for user with ID [%s]", userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGormPhoneAvailabilityRepository_LoadFirst(t *testing.T) {
+	t.Run("the first recorded period of the phone is loaded", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testDB(t, &entities.PhoneAvailabilityPeriod{})
+		repository := NewGormPhoneAvailabilityRepository(logger, tracer, db)
+
+		// Arrange
+		phoneID := uuid.New()
+		startedAt := time.Now().UTC().Add(-2 * time.Hour).Truncate(time.Second)
+		for _, period := range []*entities.PhoneAvailabilityPeriod{
+			{ID: uuid.New(), PhoneID: phoneID, UserID: "user-id", Online: true, StartedAt: startedAt},
+			{ID: uuid.New(), PhoneID: phoneID, UserID: "user-id", Online: false, StartedAt: startedAt.Add(time.Hour)},
+			{ID: uuid.New(), PhoneID: uuid.New(), UserID: "user-id", Online: true, StartedAt: startedAt.Add(-time.Hour)},
+		} {
+			assert.Nil(t, db.Create(period).Error)
+		}
+
+		// Act
+		first, err := repository.LoadFirst(context.Background(), "user-id", phoneID)
+		_, notFoundErr := repository.LoadFirst(context.Background(), "user-id", uuid.New())
+
+		// Assert
+		assert.Nil(t, err)
+		assert.True(t, startedAt.Equal(first.StartedAt))
+		assert.Equal(t, ErrCodeNotFound, stacktrace.GetCode(notFoundErr))
+	})
+}
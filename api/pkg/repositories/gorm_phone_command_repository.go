@@ -0,0 +1,117 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormPhoneCommandRepository is responsible for persisting entities.PhoneCommand
+type gormPhoneCommandRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormPhoneCommandRepository creates the GORM version of the PhoneCommandRepository
+func NewGormPhoneCommandRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) PhoneCommandRepository {
+	return &gormPhoneCommandRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormPhoneCommandRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store a new entities.PhoneCommand
+func (repository *gormPhoneCommandRepository) Store(ctx context.Context, command *entities.PhoneCommand) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(command).Error; err != nil {
+		msg := fmt.Sprintf("cannot save phone command with ID [%s]", command.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Update an entities.PhoneCommand
+func (repository *gormPhoneCommandRepository) Update(ctx context.Context, command *entities.PhoneCommand) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(command).Error; err != nil {
+		msg := fmt.Sprintf("cannot update phone command with ID [%s]", command.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load an entities.PhoneCommand of a phone by ID
+func (repository *gormPhoneCommandRepository) Load(ctx context.Context, userID entities.UserID, phoneID uuid.UUID, commandID uuid.UUID) (*entities.PhoneCommand, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	command := new(entities.PhoneCommand)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("phone_id = ?", phoneID).
+		Where("id = ?", commandID).
+		First(command).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("phone command with ID [%s] for phone [%s] and user [%s] does not exist", commandID, phoneID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone command with ID [%s] for phone [%s] and user [%s]", commandID, phoneID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return command, nil
+}
+
+// Index fetches the entities.PhoneCommand of a phone
+func (repository *gormPhoneCommandRepository) Index(ctx context.Context, userID entities.UserID, phoneID uuid.UUID, statuses []entities.PhoneCommandStatus, params IndexParams) ([]*entities.PhoneCommand, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("phone_id = ?", phoneID)
+	if len(statuses) > 0 {
+		query = query.Where("status IN ?", statuses)
+	}
+
+	commands := make([]*entities.PhoneCommand, 0)
+	if err := paginate(query, params, "created_at", "created_at").Find(&commands).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch phone commands of phone [%s] for user [%s] with params [%+#v]", phoneID, userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return commands, nil
+}
+
+// DeleteAllForUser deletes all the entities.PhoneCommand of a user
+func (repository *gormPhoneCommandRepository) DeleteAllForUser(ctx context.Context, userID entities.UserID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&entities.PhoneCommand{}).Error; err != nil {
+		msg := fmt.Sprintf("cannot delete phone commands for user with ID [%s]", userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// gormPhoneConfigRepository is responsible for persisting entities.PhoneConfig
+type gormPhoneConfigRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormPhoneConfigRepository creates the GORM version of the PhoneConfigRepository
+func NewGormPhoneConfigRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) PhoneConfigRepository {
+	return &gormPhoneConfigRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormPhoneConfigRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// SaveNextVersion upserts the settings of an entities.PhoneConfig and atomically increments its version
+func (repository *gormPhoneConfigRepository) SaveNextVersion(ctx context.Context, config *entities.PhoneConfig) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	columns := []string{"receive_sims", "delivery_reports_enabled", "encryption_enabled", "document_hash", "updated_at"}
+	if err := repository.upsertNextVersion(ctx, config, columns); err != nil {
+		msg := fmt.Sprintf("cannot save the next version of the config of phone [%s]", config.PhoneID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// IncrementVersion atomically increments the version of an entities.PhoneConfig and stores its document hash without changing its settings
+func (repository *gormPhoneConfigRepository) IncrementVersion(ctx context.Context, config *entities.PhoneConfig) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.upsertNextVersion(ctx, config, []string{"document_hash", "updated_at"}); err != nil {
+		msg := fmt.Sprintf("cannot increment the version of the config of phone [%s]", config.PhoneID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// upsertNextVersion creates the first version of the config or updates the columns and increments the version
+// in a single statement so that concurrent updates never store the same version.
+func (repository *gormPhoneConfigRepository) upsertNextVersion(ctx context.Context, config *entities.PhoneConfig, columns []string) error {
+	config.Version = 1
+	assignments := append(
+		clause.AssignmentColumns(columns),
+		clause.Assignment{Column: clause.Column{Name: "version"}, Value: gorm.Expr("phone_configs.version + 1")},
+	)
+
+	return repository.db.WithContext(ctx).
+		Clauses(
+			clause.OnConflict{Columns: []clause.Column{{Name: "phone_id"}}, DoUpdates: assignments},
+			clause.Returning{},
+		).
+		Create(config).
+		Error
+}
+
+// Load the entities.PhoneConfig of a phone
+func (repository *gormPhoneConfigRepository) Load(ctx context.Context, userID entities.UserID, phoneID uuid.UUID) (*entities.PhoneConfig, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	config := new(entities.PhoneConfig)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("phone_id = ?", phoneID).First(config).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("config of phone [%s] for user [%s] does not exist", phoneID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load config of phone [%s] for user [%s]", phoneID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return config, nil
+}
+
+// DeleteAllForUser deletes all the entities.PhoneConfig of a user
+func (repository *gormPhoneConfigRepository) DeleteAllForUser(ctx context.Context, userID entities.UserID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&entities.PhoneConfig{}).Error; err != nil {
+		msg := fmt.Sprintf("cannot delete phone configs for user with ID [%s]", userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
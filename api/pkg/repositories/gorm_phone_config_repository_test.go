@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGormPhoneConfigRepository_SaveNextVersion(t *testing.T) {
+	t.Run("every save increments the version of the config", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testDB(t, &entities.PhoneConfig{})
+		repository := NewGormPhoneConfigRepository(logger, tracer, db)
+
+		// Arrange
+		phone := &entities.Phone{ID: uuid.New(), UserID: "user-id"}
+
+		// Act
+		first := entities.NewPhoneConfig(phone)
+		firstErr := repository.SaveNextVersion(context.Background(), first)
+
+		second := entities.NewPhoneConfig(phone)
+		second.ReceiveSIMs = pq.StringArray{entities.SIM1.String()}
+		secondErr := repository.SaveNextVersion(context.Background(), second)
+
+		// Assert
+		assert.Nil(t, firstErr)
+		assert.Nil(t, secondErr)
+		assert.Equal(t, uint(1), first.Version)
+		assert.Equal(t, uint(2), second.Version)
+
+		config, err := repository.Load(context.Background(), phone.UserID, phone.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, uint(2), config.Version)
+		assert.Equal(t, pq.StringArray{entities.SIM1.String()}, config.ReceiveSIMs)
+	})
+}
+
+func TestGormPhoneConfigRepository_IncrementVersion(t *testing.T) {
+	t.Run("the version is incremented without changing the settings", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testDB(t, &entities.PhoneConfig{})
+		repository := NewGormPhoneConfigRepository(logger, tracer, db)
+
+		// Arrange
+		phone := &entities.Phone{ID: uuid.New(), UserID: "user-id"}
+		stored := entities.NewPhoneConfig(phone)
+		stored.EncryptionEnabled = true
+		assert.Nil(t, repository.SaveNextVersion(context.Background(), stored))
+
+		// Act
+		config := entities.NewPhoneConfig(phone)
+		config.DocumentHash = "hash"
+		err := repository.IncrementVersion(context.Background(), config)
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, uint(2), config.Version)
+
+		loaded, err := repository.Load(context.Background(), phone.UserID, phone.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, uint(2), loaded.Version)
+		assert.Equal(t, "hash", loaded.DocumentHash)
+		assert.True(t, loaded.EncryptionEnabled)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/cockroachdb/cockroach-go/v2/crdb/crdbgorm"
@@ -13,6 +14,7 @@ import (
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 	"github.com/palantir/stacktrace"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // gormPhoneNotificationRepository is responsible for persisting entities.PhoneNotification
@@ -53,19 +55,82 @@ func (repository *gormPhoneNotificationRepository) UpdateStatus(ctx context.Cont
 	return nil
 }
 
+// Exists checks if an entities.PhoneNotification exists for a user
+func (repository *gormPhoneNotificationRepository) Exists(ctx context.Context, userID entities.UserID, notificationID uuid.UUID) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	var exists bool
+	err := repository.db.WithContext(ctx).
+		Model(&entities.PhoneNotification{}).
+		Select("count(*) > 0").
+		Where("user_id = ?", userID).
+		Where("id = ?", notificationID).
+		Find(&exists).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot check if notification [%s] exists for user [%s]", notificationID, userID)
+		return exists, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return exists, nil
+}
+
+// ClaimPending atomically marks the due entities.PhoneNotification of a phone as sent so that only one push is sent for them
+func (repository *gormPhoneNotificationRepository) ClaimPending(ctx context.Context, phoneID uuid.UUID, timestamp time.Time) ([]*entities.PhoneNotification, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	var notifications []*entities.PhoneNotification
+	err := repository.db.
+		WithContext(ctx).
+		Model(&notifications).
+		Clauses(clause.Returning{}).
+		Where("phone_id = ?", phoneID).
+		Where("status = ?", entities.PhoneNotificationStatusPending).
+		Where("scheduled_at <= ?", timestamp).
+		Update("status", entities.PhoneNotificationStatusSent).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot claim pending notifications of phone [%s] scheduled before [%s]", phoneID, timestamp)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return notifications, nil
+}
+
+// DeleteOlderThan deletes the oldest entities.PhoneNotification of a user which were created before the timestamp
+func (repository *gormPhoneNotificationRepository) DeleteOlderThan(ctx context.Context, userID entities.UserID, timestamp time.Time, limit int) (int64, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	subQuery := repository.db.Model(&entities.PhoneNotification{}).
+		Select("id").
+		Where("user_id = ?", userID).
+		Where("created_at < ?", timestamp).
+		Limit(limit)
+
+	result := repository.db.WithContext(ctx).Where("id IN (?)", subQuery).Delete(&entities.PhoneNotification{})
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot delete phone notifications older than [%s] for user with ID [%s]", timestamp, userID)
+		return 0, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected, nil
+}
+
 // Schedule a notification to be sent in the future
-func (repository *gormPhoneNotificationRepository) Schedule(ctx context.Context, messagesPerMinute uint, notification *entities.PhoneNotification) error {
+func (repository *gormPhoneNotificationRepository) Schedule(ctx context.Context, limit PhoneNotificationRateLimit, notification *entities.PhoneNotification) (*PhoneNotificationCap, error) {
 	ctx, span := repository.tracer.Start(ctx)
 	defer span.End()
 
-	if messagesPerMinute == 0 {
-		return repository.insert(ctx, notification)
+	if limit.MessagesPerMinute == 0 && !limit.HasCaps() {
+		return nil, repository.insert(ctx, notification)
 	}
 
+	var reached *PhoneNotificationCap
 	err := crdbgorm.ExecuteTx(ctx, repository.db, nil, func(tx *gorm.DB) error {
 		lastNotification := new(entities.PhoneNotification)
-		err := tx.WithContext(ctx).
-			Where("phone_id = ?", notification.PhoneID).
+		err := repository.lane(tx.WithContext(ctx), notification).
 			Order("scheduled_at desc").
 			First(lastNotification).
 			Error
@@ -75,13 +140,17 @@ func (repository *gormPhoneNotificationRepository) Schedule(ctx context.Context,
 		}
 
 		notification.ScheduledAt = time.Now().UTC()
-		if err == nil {
+		if err == nil && limit.MessagesPerMinute > 0 {
 			notification.ScheduledAt = repository.maxTime(
 				time.Now().UTC(),
-				lastNotification.ScheduledAt.Add(time.Duration(60/messagesPerMinute)*time.Second),
+				lastNotification.ScheduledAt.Add(time.Duration(60/limit.MessagesPerMinute)*time.Second),
 			)
 		}
 
+		if reached, err = repository.applyCaps(ctx, tx, limit, notification); err != nil {
+			return err
+		}
+
 		if err = tx.WithContext(ctx).Create(notification).Error; err != nil {
 			msg := fmt.Sprintf("cannot create new notification with id [%s] and schedule [%s]", notification.ID, notification.ScheduledAt.String())
 			return stacktrace.Propagate(err, msg)
@@ -90,10 +159,189 @@ func (repository *gormPhoneNotificationRepository) Schedule(ctx context.Context,
 	})
 	if err != nil {
 		msg := fmt.Sprintf("cannot schedule phone notification with ID [%s]", notification.ID)
-		return stacktrace.Propagate(err, msg)
+		return nil, stacktrace.Propagate(err, msg)
 	}
 
-	return nil
+	return reached, nil
+}
+
+// phoneNotificationWindowCap is a cap on the number of notifications which are scheduled in a window
+type phoneNotificationWindowCap struct {
+	name   string
+	limit  uint
+	sim    bool
+	window func(timestamp time.Time) (time.Time, time.Time)
+}
+
+// phoneNotificationSlot is the schedule of a notification which counts towards the caps of a phone
+type phoneNotificationSlot struct {
+	ScheduledAt time.Time
+	SIM         *entities.SIM
+}
+
+// applyCaps moves the schedule of a notification to the start of the next window while a window is full.
+// The schedule of the phone is loaded once so that the first free slot is found without a query per window.
+// The cap is only returned for the first notification in the next window so that it is reported once per window.
+func (repository *gormPhoneNotificationRepository) applyCaps(ctx context.Context, tx *gorm.DB, limit PhoneNotificationRateLimit, notification *entities.PhoneNotification) (*PhoneNotificationCap, error) {
+	if !limit.HasCaps() {
+		return nil, nil
+	}
+
+	location := limit.Location
+	if location == nil {
+		location = time.UTC
+	}
+
+	caps := []phoneNotificationWindowCap{
+		{name: "day", limit: limit.SIMMessagesPerDay, sim: true, window: repository.dayWindow(location)},
+		{name: "hour", limit: limit.MessagesPerHour, window: repository.hourWindow(location)},
+		{name: "day", limit: limit.MessagesPerDay, window: repository.dayWindow(location)},
+	}
+
+	from := notification.ScheduledAt
+	for _, windowCap := range caps {
+		if start, _ := windowCap.window(notification.ScheduledAt); start.Before(from) {
+			from = start
+		}
+	}
+
+	var slots []phoneNotificationSlot
+	err := tx.WithContext(ctx).
+		Model(&entities.PhoneNotification{}).
+		Select("scheduled_at, sim").
+		Where("phone_id = ?", notification.PhoneID).
+		Where("scheduled_at >= ?", from).
+		Order("scheduled_at asc").
+		Scan(&slots).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot load the notifications of phone [%s] scheduled after [%s]", notification.PhoneID, from)
+		return nil, stacktrace.Propagate(err, msg)
+	}
+
+	var reached *phoneNotificationWindowCap
+	for moved := true; moved; {
+		moved = false
+		for i := range caps {
+			if caps[i].limit == 0 {
+				continue
+			}
+
+			start, end := caps[i].window(notification.ScheduledAt)
+			if repository.countSlots(slots, caps[i], notification, start, end) >= caps[i].limit {
+				notification.ScheduledAt = repository.spread(slots, caps[i], limit, notification, end)
+				reached, moved = &caps[i], true
+			}
+		}
+	}
+
+	if reached == nil {
+		return nil, nil
+	}
+
+	if start, end := reached.window(notification.ScheduledAt); repository.countSlots(slots, *reached, notification, start, end) > 0 {
+		return nil, nil
+	}
+
+	result := &PhoneNotificationCap{Window: reached.name, Limit: reached.limit, Until: notification.ScheduledAt}
+	if reached.sim {
+		result.SIM = notification.SIM
+	}
+	return result, nil
+}
+
+// spread schedules a deferred notification in the window which starts at start.
+// Notifications without a per minute rate are spaced out at the rate of the cap so that they are not all sent when the window starts.
+func (repository *gormPhoneNotificationRepository) spread(slots []phoneNotificationSlot, windowCap phoneNotificationWindowCap, limit PhoneNotificationRateLimit, notification *entities.PhoneNotification, start time.Time) time.Time {
+	if limit.MessagesPerMinute > 0 {
+		return start
+	}
+
+	_, end := windowCap.window(start)
+	count := repository.countSlots(slots, windowCap, notification, start, end)
+	if count >= windowCap.limit {
+		return start
+	}
+
+	return start.Add(end.Sub(start) / time.Duration(windowCap.limit) * time.Duration(count))
+}
+
+// countSlots counts the slots which share a cap with the notification and are scheduled between start and end
+func (repository *gormPhoneNotificationRepository) countSlots(slots []phoneNotificationSlot, windowCap phoneNotificationWindowCap, notification *entities.PhoneNotification, start time.Time, end time.Time) uint {
+	var count uint
+	for i := sort.Search(len(slots), func(i int) bool { return !slots[i].ScheduledAt.Before(start) }); i < len(slots) && slots[i].ScheduledAt.Before(end); i++ {
+		if windowCap.sim && !repository.sameSIM(slots[i].SIM, notification.SIM) {
+			continue
+		}
+		count++
+	}
+	return count
+}
+
+func (repository *gormPhoneNotificationRepository) sameSIM(a *entities.SIM, b *entities.SIM) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// hourWindow returns the hour in the location which contains a timestamp
+func (repository *gormPhoneNotificationRepository) hourWindow(location *time.Location) func(timestamp time.Time) (time.Time, time.Time) {
+	return func(timestamp time.Time) (time.Time, time.Time) {
+		_, offset := timestamp.In(location).Zone()
+		shift := time.Duration(offset) * time.Second
+		start := timestamp.Add(shift).Truncate(time.Hour).Add(-shift)
+		return start, start.Add(time.Hour)
+	}
+}
+
+// dayWindow returns the day in the location which contains a timestamp
+func (repository *gormPhoneNotificationRepository) dayWindow(location *time.Location) func(timestamp time.Time) (time.Time, time.Time) {
+	return func(timestamp time.Time) (time.Time, time.Time) {
+		local := timestamp.In(location)
+		start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+		return start.UTC(), start.AddDate(0, 0, 1).UTC()
+	}
+}
+
+// CountScheduled counts the notifications of each phone of a user which are scheduled between from and to
+func (repository *gormPhoneNotificationRepository) CountScheduled(ctx context.Context, userID entities.UserID, from time.Time, to time.Time) (map[uuid.UUID]uint, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	var rows []struct {
+		PhoneID uuid.UUID
+		Count   uint
+	}
+	err := repository.db.
+		WithContext(ctx).
+		Model(&entities.PhoneNotification{}).
+		Select("phone_id, COUNT(*) AS count").
+		Where("user_id = ?", userID).
+		Where("scheduled_at >= ?", from).
+		Where("scheduled_at < ?", to).
+		Group("phone_id").
+		Scan(&rows).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot count notifications of user [%s] scheduled between [%s] and [%s]", userID, from, to)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	counts := make(map[uuid.UUID]uint, len(rows))
+	for _, row := range rows {
+		counts[row.PhoneID] = row.Count
+	}
+	return counts, nil
+}
+
+// lane filters the notifications which share the rate limit of the notification
+func (repository *gormPhoneNotificationRepository) lane(tx *gorm.DB, notification *entities.PhoneNotification) *gorm.DB {
+	tx = tx.Where("phone_id = ?", notification.PhoneID)
+	if notification.SIM == nil {
+		return tx.Where("sim IS NULL")
+	}
+	return tx.Where("sim = ?", *notification.SIM)
 }
 
 func (repository *gormPhoneNotificationRepository) maxTime(a, b time.Time) time.Time {
@@ -114,3 +362,16 @@ func (repository *gormPhoneNotificationRepository) insert(ctx context.Context, n
 	}
 	return nil
 }
+
+// DeleteAllForUser deletes all the entities.PhoneNotification of a user
+func (repository *gormPhoneNotificationRepository) DeleteAllForUser(ctx context.Context, userID entities.UserID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&entities.PhoneNotification{}).Error; err != nil {
+		msg := fmt.Sprintf("cannot delete phone notifications for user with ID [%s]", userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func testPhoneNotification(phoneID uuid.UUID, scheduledAt time.Time) *entities.PhoneNotification {
+	return &entities.PhoneNotification{
+		ID:          uuid.New(),
+		MessageID:   uuid.New(),
+		UserID:      "user-id",
+		PhoneID:     phoneID,
+		Status:      entities.PhoneNotificationStatusPending,
+		ScheduledAt: scheduledAt,
+		CreatedAt:   time.Now().UTC(),
+		UpdatedAt:   time.Now().UTC(),
+	}
+}
+
+func TestGormPhoneNotificationRepository_Schedule(t *testing.T) {
+	t.Run("a notification is deferred to the next hour of the user when the hourly cap is reached", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testDB(t, &entities.PhoneNotification{})
+		repository := NewGormPhoneNotificationRepository(logger, tracer, db)
+
+		// Arrange
+		location, err := time.LoadLocation("Asia/Kolkata")
+		assert.Nil(t, err)
+
+		phoneID := uuid.New()
+		for i := 0; i < 2; i++ {
+			assert.Nil(t, db.Create(testPhoneNotification(phoneID, time.Now().UTC())).Error)
+		}
+
+		limit := PhoneNotificationRateLimit{MessagesPerHour: 2, Location: location}
+
+		// Act
+		first := testPhoneNotification(phoneID, time.Now().UTC())
+		firstCap, firstErr := repository.Schedule(context.Background(), limit, first)
+
+		second := testPhoneNotification(phoneID, time.Now().UTC())
+		secondCap, secondErr := repository.Schedule(context.Background(), limit, second)
+
+		// Assert
+		assert.Nil(t, firstErr)
+		assert.Nil(t, secondErr)
+
+		local := first.ScheduledAt.In(location)
+		assert.Equal(t, 0, local.Minute())
+		assert.Equal(t, 0, local.Second())
+		assert.True(t, first.ScheduledAt.After(time.Now().UTC()))
+		assert.True(t, first.ScheduledAt.Before(time.Now().UTC().Add(time.Hour)))
+
+		assert.NotNil(t, firstCap)
+		assert.Equal(t, "hour", firstCap.Window)
+		assert.Equal(t, first.ScheduledAt, firstCap.Until)
+
+		assert.Nil(t, secondCap)
+		assert.Equal(t, first.ScheduledAt.Add(30*time.Minute), second.ScheduledAt)
+	})
+
+	t.Run("a notification is deferred to the next day of the user when the daily cap is reached", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testDB(t, &entities.PhoneNotification{})
+		repository := NewGormPhoneNotificationRepository(logger, tracer, db)
+
+		// Arrange
+		location, err := time.LoadLocation("America/New_York")
+		assert.Nil(t, err)
+
+		phoneID := uuid.New()
+		assert.Nil(t, db.Create(testPhoneNotification(phoneID, time.Now().UTC())).Error)
+		assert.Nil(t, db.Create(testPhoneNotification(uuid.New(), time.Now().UTC())).Error)
+
+		// Act
+		notification := testPhoneNotification(phoneID, time.Now().UTC())
+		reached, err := repository.Schedule(context.Background(), PhoneNotificationRateLimit{MessagesPerDay: 1, Location: location}, notification)
+
+		// Assert
+		assert.Nil(t, err)
+		assert.NotNil(t, reached)
+		assert.Equal(t, "day", reached.Window)
+
+		now := time.Now().In(location)
+		tomorrow := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location).AddDate(0, 0, 1)
+		assert.True(t, tomorrow.Equal(notification.ScheduledAt), "%s != %s", tomorrow, notification.ScheduledAt)
+	})
+
+	t.Run("the daily limit of a sim is reset at midnight of the user", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testDB(t, &entities.PhoneNotification{})
+		repository := NewGormPhoneNotificationRepository(logger, tracer, db)
+
+		// Arrange
+		location, err := time.LoadLocation("Asia/Tokyo")
+		assert.Nil(t, err)
+
+		sim1, sim2 := entities.SIM1, entities.SIM2
+		phoneID := uuid.New()
+		existing := testPhoneNotification(phoneID, time.Now().UTC())
+		existing.SIM = &sim1
+		assert.Nil(t, db.Create(existing).Error)
+
+		limit := PhoneNotificationRateLimit{SIMMessagesPerDay: 1, Location: location}
+
+		// Act
+		deferred := testPhoneNotification(phoneID, time.Now().UTC())
+		deferred.SIM = &sim1
+		reached, deferredErr := repository.Schedule(context.Background(), limit, deferred)
+
+		other := testPhoneNotification(phoneID, time.Now().UTC())
+		other.SIM = &sim2
+		otherReached, otherErr := repository.Schedule(context.Background(), limit, other)
+
+		// Assert
+		assert.Nil(t, deferredErr)
+		assert.Nil(t, otherErr)
+
+		now := time.Now().In(location)
+		tomorrow := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location).AddDate(0, 0, 1)
+		assert.True(t, tomorrow.Equal(deferred.ScheduledAt), "%s != %s", tomorrow, deferred.ScheduledAt)
+		assert.NotNil(t, reached)
+		assert.Equal(t, &sim1, reached.SIM)
+
+		assert.Nil(t, otherReached)
+		assert.True(t, other.ScheduledAt.Before(tomorrow))
+	})
+
+	t.Run("notifications without a rate are spread across the next day when the daily limit of the sim is reached", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testDB(t, &entities.PhoneNotification{})
+		repository := NewGormPhoneNotificationRepository(logger, tracer, db)
+
+		// Arrange
+		location, err := time.LoadLocation("Asia/Tokyo")
+		assert.Nil(t, err)
+
+		sim := entities.SIM1
+		phoneID := uuid.New()
+		for i := 0; i < 4; i++ {
+			existing := testPhoneNotification(phoneID, time.Now().UTC())
+			existing.SIM = &sim
+			assert.Nil(t, db.Create(existing).Error)
+		}
+
+		limit := PhoneNotificationRateLimit{SIMMessagesPerDay: 4, Location: location}
+
+		// Act
+		var scheduled []time.Time
+		for i := 0; i < 3; i++ {
+			notification := testPhoneNotification(phoneID, time.Now().UTC())
+			notification.SIM = &sim
+			_, err = repository.Schedule(context.Background(), limit, notification)
+			assert.Nil(t, err)
+			scheduled = append(scheduled, notification.ScheduledAt)
+		}
+
+		// Assert
+		now := time.Now().In(location)
+		tomorrow := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location).AddDate(0, 0, 1)
+		for i, timestamp := range scheduled {
+			expected := tomorrow.Add(time.Duration(i) * 6 * time.Hour)
+			assert.True(t, expected.Equal(timestamp), "%s != %s", expected, timestamp)
+		}
+	})
+}
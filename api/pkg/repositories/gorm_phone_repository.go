@@ -39,7 +39,7 @@ func (repository *gormPhoneRepository) LoadByID(ctx context.Context, userID enti
 
 	phone := new(entities.Phone)
 	err := repository.db.WithContext(ctx).
-		Where("user_id = ?", userID).
+		Where("user_id = ?", userID).Scopes(scopeOrganization(ctx, "phone_number")).
 		Where("id = ?", phoneID).
 		First(&phone).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -61,7 +61,7 @@ func (repository *gormPhoneRepository) Delete(ctx context.Context, userID entiti
 	defer span.End()
 
 	err := repository.db.WithContext(ctx).
-		Where("user_id = ?", userID).
+		Where("user_id = ?", userID).Scopes(scopeOrganization(ctx, "phone_number")).
 		Where("id = ?", phoneID).
 		Delete(&entities.Phone{}).Error
 	if err != nil {
@@ -103,7 +103,7 @@ func (repository *gormPhoneRepository) Load(ctx context.Context, userID entities
 	defer span.End()
 
 	phone := new(entities.Phone)
-	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("phone_number = ?", phoneNumber).First(phone).Error
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Scopes(scopeOrganization(ctx, "phone_number")).Where("phone_number = ?", phoneNumber).First(phone).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		msg := fmt.Sprintf("phone with userID [%s] and phoneNumber [%s] does not exist", userID, phoneNumber)
 		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
@@ -117,11 +117,32 @@ func (repository *gormPhoneRepository) Load(ctx context.Context, userID entities
 	return phone, nil
 }
 
+// LoadBySIMPhoneNumber loads the phone of a user which has a SIM with the phone number
+func (repository *gormPhoneRepository) LoadBySIMPhoneNumber(ctx context.Context, userID entities.UserID, phoneNumber string) (*entities.Phone, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	var phones []*entities.Phone
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Scopes(scopeOrganization(ctx, "phone_number")).Order("created_at ASC").Find(&phones).Error; err != nil {
+		msg := fmt.Sprintf("cannot load phones with userID [%s] to find the SIM with phoneNumber [%s]", userID, phoneNumber)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	for _, phone := range phones {
+		if phone.SIMWithPhoneNumber(phoneNumber) != nil {
+			return phone, nil
+		}
+	}
+
+	msg := fmt.Sprintf("phone with userID [%s] and a SIM with phoneNumber [%s] does not exist", userID, phoneNumber)
+	return nil, repository.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeNotFound, msg))
+}
+
 func (repository *gormPhoneRepository) Index(ctx context.Context, userID entities.UserID, params IndexParams) (*[]entities.Phone, error) {
 	ctx, span := repository.tracer.Start(ctx)
 	defer span.End()
 
-	query := repository.db.WithContext(ctx).Where("user_id = ?", userID)
+	query := repository.db.WithContext(ctx).Where("user_id = ?", userID).Scopes(scopeOrganization(ctx, "phone_number"))
 	if len(params.Query) > 0 {
 		queryPattern := "%" + params.Query + "%"
 		query.Where("phone_number ILIKE ?", queryPattern)
@@ -135,3 +156,16 @@ func (repository *gormPhoneRepository) Index(ctx context.Context, userID entitie
 
 	return phones, nil
 }
+
+// DeleteAllForUser deletes all the entities.Phone of a user
+func (repository *gormPhoneRepository) DeleteAllForUser(ctx context.Context, userID entities.UserID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&entities.Phone{}).Error; err != nil {
+		msg := fmt.Sprintf("cannot delete phones for user with ID [%s]", userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGormPhoneRepository_LoadBySIMPhoneNumber(t *testing.T) {
+	t.Run("the phone with a sim which has the phone number is loaded", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testDB(t, &entities.Phone{})
+		repository := NewGormPhoneRepository(logger, tracer, db)
+
+		// Arrange
+		sim2PhoneNumber := "+18005550100"
+		phone := &entities.Phone{
+			ID:          uuid.New(),
+			UserID:      "user-id",
+			PhoneNumber: "+18005550199",
+			SIM:         entities.SIM1,
+			SIMs: []entities.PhoneSIM{
+				{SIM: entities.SIM1},
+				{SIM: entities.SIM2, PhoneNumber: &sim2PhoneNumber},
+			},
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+		}
+		assert.Nil(t, db.Create(phone).Error)
+
+		// Act
+		loaded, err := repository.LoadBySIMPhoneNumber(context.Background(), "user-id", sim2PhoneNumber)
+		_, otherUserErr := repository.LoadBySIMPhoneNumber(context.Background(), "other-user-id", sim2PhoneNumber)
+		_, phoneNumberErr := repository.LoadBySIMPhoneNumber(context.Background(), "user-id", phone.PhoneNumber)
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, phone.ID, loaded.ID)
+		assert.Equal(t, entities.SIM2, loaded.SIMWithPhoneNumber(sim2PhoneNumber).SIM)
+		assert.Equal(t, ErrCodeNotFound, stacktrace.GetCode(otherUserErr))
+		assert.Equal(t, ErrCodeNotFound, stacktrace.GetCode(phoneNumberErr))
+	})
+}
+
+func TestGormPhoneRepository_Index(t *testing.T) {
+	t.Run("only the granted phones are returned in an organization scope", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testDB(t, &entities.Phone{})
+		repository := NewGormPhoneRepository(logger, tracer, db)
+
+		// Arrange
+		for _, phoneNumber := range []string{"+18005550199", "+18005550100"} {
+			assert.Nil(t, db.Create(&entities.Phone{
+				ID:          uuid.New(),
+				UserID:      "user-id",
+				PhoneNumber: phoneNumber,
+				CreatedAt:   time.Now().UTC(),
+				UpdatedAt:   time.Now().UTC(),
+			}).Error)
+		}
+
+		agent := &entities.OrganizationMember{
+			Role:        entities.OrganizationRoleAgent,
+			PhoneGrants: []entities.OrganizationPhoneGrant{{Owner: "+18005550199"}},
+		}
+		ctx := WithOrganizationScope(context.Background(), NewOrganizationScope(&entities.Organization{OwnerID: "user-id"}, agent))
+		params := IndexParams{Limit: 10}
+
+		// Act
+		scoped, err := repository.Index(ctx, "user-id", params)
+		unscoped, unscopedErr := repository.Index(context.Background(), "user-id", params)
+		_, loadErr := repository.Load(ctx, "user-id", "+18005550100")
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Nil(t, unscopedErr)
+		assert.Equal(t, 1, len(*scoped))
+		assert.Equal(t, "+18005550199", (*scoped)[0].PhoneNumber)
+		assert.Equal(t, 2, len(*unscoped))
+		assert.Equal(t, ErrCodeNotFound, stacktrace.GetCode(loadErr))
+	})
+}
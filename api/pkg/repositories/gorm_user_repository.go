@@ -52,12 +52,20 @@ func (repository *gormUserRepository) RotateAPIKey(ctx context.Context, userID e
 	}
 
 	user := new(entities.User)
+	previousHash := ""
 	err = crdbgorm.ExecuteTx(ctx, repository.db, nil,
 		func(tx *gorm.DB) error {
+			if err := tx.WithContext(ctx).First(user, userID).Error; err != nil {
+				return err
+			}
+			previousHash = user.APIKeyHash
 			return tx.WithContext(ctx).Model(user).
 				Clauses(clause.Returning{}).
 				Where("id = ?", userID).
-				Update("api_key", apiKey).Error
+				Updates(map[string]any{
+					"api_key_prefix": APIKeyPrefix(apiKey),
+					"api_key_hash":   HashAPIKey(apiKey),
+				}).Error
 		},
 	)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -65,6 +73,13 @@ func (repository *gormUserRepository) RotateAPIKey(ctx context.Context, userID e
 		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
 	}
 
+	if err != nil {
+		msg := fmt.Sprintf("cannot rotate api key for user with ID [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	repository.cache.Del(previousHash)
+	user.APIKey = apiKey
 	return user, nil
 }
 
@@ -114,33 +129,73 @@ func (repository *gormUserRepository) Update(ctx context.Context, user *entities
 	return nil
 }
 
+// UpdateRetention updates only the data retention policy and the scheduled retention purge of an entities.User
+func (repository *gormUserRepository) UpdateRetention(ctx context.Context, user *entities.User) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).Model(&entities.User{}).Where("id = ?", user.ID).Updates(map[string]any{
+		"message_retention_days":       user.MessageRetentionDays,
+		"heartbeat_retention_days":     user.HeartbeatRetentionDays,
+		"notification_retention_days":  user.NotificationRetentionDays,
+		"retention_purge_scheduled_at": user.RetentionPurgeScheduledAt,
+		"retention_purge_queue_id":     user.RetentionPurgeQueueID,
+		"updated_at":                   time.Now().UTC(),
+	}).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot update the retention policy of user with ID [%s]", user.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// UpdateRetentionPurge updates only the scheduled retention purge of an entities.User
+func (repository *gormUserRepository) UpdateRetentionPurge(ctx context.Context, user *entities.User) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).Model(&entities.User{}).Where("id = ?", user.ID).Updates(map[string]any{
+		"retention_purge_scheduled_at": user.RetentionPurgeScheduledAt,
+		"retention_purge_queue_id":     user.RetentionPurgeQueueID,
+	}).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot update the scheduled retention purge of user with ID [%s]", user.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
 func (repository *gormUserRepository) LoadAuthUser(ctx context.Context, apiKey string) (entities.AuthUser, error) {
 	ctx, span, ctxLogger := repository.tracer.StartWithLogger(ctx, repository.logger)
 	defer span.End()
 
-	if authUser, found := repository.cache.Get(apiKey); found {
+	hash := HashAPIKey(apiKey)
+	if authUser, found := repository.cache.Get(hash); found {
 		ctxLogger.Info(fmt.Sprintf("cache hit for user with ID [%s]", authUser.(entities.AuthUser).ID))
 		return authUser.(entities.AuthUser), nil
 	}
 
-	user := new(entities.User)
-	err := repository.db.WithContext(ctx).Where("api_key = ?", apiKey).First(user).Error
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		msg := fmt.Sprintf("user with api key [%s] does not exist", apiKey)
-		return entities.AuthUser{}, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
-	}
-
+	var users []*entities.User
+	err := repository.db.WithContext(ctx).Where("api_key_prefix = ?", APIKeyPrefix(apiKey)).Find(&users).Error
 	if err != nil {
-		msg := fmt.Sprintf("cannot load user with api key [%s]", apiKey)
+		msg := fmt.Sprintf("cannot load users with api key prefix [%s]", APIKeyPrefix(apiKey))
 		return entities.AuthUser{}, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
+	user := repository.userWithAPIKeyHash(users, hash)
+	if user == nil {
+		msg := fmt.Sprintf("user with api key prefix [%s] does not exist", APIKeyPrefix(apiKey))
+		return entities.AuthUser{}, repository.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeNotFound, msg))
+	}
+
 	authUser := entities.AuthUser{
 		ID:    user.ID,
 		Email: user.Email,
 	}
 
-	if result := repository.cache.SetWithTTL(apiKey, authUser, 1, 2*time.Hour); !result {
+	if result := repository.cache.SetWithTTL(hash, authUser, 1, 2*time.Hour); !result {
 		msg := fmt.Sprintf("cannot cache [%T] with ID [%s] and result [%t]", authUser, user.ID, result)
 		ctxLogger.Error(repository.tracer.WrapErrorSpan(span, stacktrace.NewError(msg)))
 	}
@@ -184,7 +239,8 @@ func (repository *gormUserRepository) LoadOrStore(ctx context.Context, authUser
 	user = &entities.User{
 		ID:               authUser.ID,
 		Email:            authUser.Email,
-		APIKey:           apiKey,
+		APIKeyPrefix:     APIKeyPrefix(apiKey),
+		APIKeyHash:       HashAPIKey(apiKey),
 		SubscriptionName: entities.SubscriptionNameFree,
 		CreatedAt:        time.Now().UTC(),
 		UpdatedAt:        time.Now().UTC(),
@@ -206,9 +262,23 @@ func (repository *gormUserRepository) LoadOrStore(ctx context.Context, authUser
 		return user, isNew, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
+	if isNew {
+		user.APIKey = apiKey
+	}
+
 	return user, isNew, nil
 }
 
+// userWithAPIKeyHash finds the entities.User whose API key hash matches the hash
+func (repository *gormUserRepository) userWithAPIKeyHash(users []*entities.User, hash string) *entities.User {
+	for _, user := range users {
+		if apiKeyHashMatches(hash, user.APIKeyHash) {
+			return user
+		}
+	}
+	return nil
+}
+
 // generateRandomBytes returns securely generated random bytes.
 // It will return an error if the system's secure random
 // number generator fails to function correctly, in which
@@ -232,3 +302,17 @@ func (repository *gormUserRepository) generateAPIKey(n int) (string, error) {
 	b, err := repository.generateRandomBytes(n)
 	return base64.URLEncoding.EncodeToString(b)[0:n], stacktrace.Propagate(err, "cannot generate random bytes")
 }
+
+// Delete an entities.User
+func (repository *gormUserRepository) Delete(ctx context.Context, user *entities.User) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Where("id = ?", user.ID).Delete(&entities.User{}).Error; err != nil {
+		msg := fmt.Sprintf("cannot delete user with ID [%s]", user.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	repository.cache.Del(user.APIKeyHash)
+	return nil
+}
@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/dgraph-io/ristretto"
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+)
+
+func testRistrettoCache(t *testing.T) *ristretto.Cache {
+	cache, err := ristretto.NewCache(&ristretto.Config{
+		MaxCost:     5000,
+		NumCounters: 5000 * 10,
+		BufferItems: 64,
+	})
+	assert.Nil(t, err)
+	return cache
+}
+
+func TestGormUserRepository_LoadAuthUser(t *testing.T) {
+	t.Run("the user is loaded with the hash of the api key which is not stored in plaintext", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testDB(t, &entities.User{})
+		repository := NewGormUserRepository(logger, tracer, testRistrettoCache(t), db)
+
+		// Arrange
+		user, _, err := repository.LoadOrStore(context.Background(), entities.AuthUser{ID: "user-id", Email: "name@example.com"})
+		assert.Nil(t, err)
+
+		// Act
+		authUser, err := repository.LoadAuthUser(context.Background(), user.APIKey)
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, entities.UserID("user-id"), authUser.ID)
+
+		stored, err := repository.Load(context.Background(), "user-id")
+		assert.Nil(t, err)
+		assert.Equal(t, "", stored.APIKey)
+		assert.Equal(t, HashAPIKey(user.APIKey), stored.APIKeyHash)
+		assert.NotEqual(t, user.APIKey, stored.APIKeyHash)
+	})
+
+	t.Run("a key with the same prefix and a different hash is rejected", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testDB(t, &entities.User{})
+		repository := NewGormUserRepository(logger, tracer, testRistrettoCache(t), db)
+
+		// Arrange
+		user, _, err := repository.LoadOrStore(context.Background(), entities.AuthUser{ID: "user-id", Email: "name@example.com"})
+		assert.Nil(t, err)
+
+		// Act
+		_, err = repository.LoadAuthUser(context.Background(), APIKeyPrefix(user.APIKey)+"-wrong-key")
+
+		// Assert
+		assert.Equal(t, ErrCodeNotFound, stacktrace.GetCode(err))
+	})
+
+	t.Run("the previous api key is rejected after it is rotated", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testDB(t, &entities.User{})
+		repository := NewGormUserRepository(logger, tracer, testRistrettoCache(t), db)
+
+		// Arrange
+		user, _, err := repository.LoadOrStore(context.Background(), entities.AuthUser{ID: "user-id", Email: "name@example.com"})
+		assert.Nil(t, err)
+
+		rotated, err := repository.RotateAPIKey(context.Background(), "user-id")
+		assert.Nil(t, err)
+
+		// Act
+		_, previousErr := repository.LoadAuthUser(context.Background(), user.APIKey)
+		authUser, err := repository.LoadAuthUser(context.Background(), rotated.APIKey)
+
+		// Assert
+		assert.Equal(t, ErrCodeNotFound, stacktrace.GetCode(previousErr))
+		assert.Nil(t, err)
+		assert.Equal(t, entities.UserID("user-id"), authUser.ID)
+	})
+}
+
+func TestGormUserRepository_UpdateRetentionPurge(t *testing.T) {
+	t.Run("only the scheduled retention purge is updated", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		db := testDB(t, &entities.User{})
+		repository := NewGormUserRepository(logger, tracer, testRistrettoCache(t), db)
+
+		// Arrange
+		user, _, err := repository.LoadOrStore(context.Background(), entities.AuthUser{ID: "user-id", Email: "name@example.com"})
+		assert.Nil(t, err)
+
+		days := uint(90)
+		stale := *user
+		user.MessageRetentionDays = &days
+		assert.Nil(t, repository.UpdateRetention(context.Background(), user))
+
+		scheduledAt := time.Now().UTC().Truncate(time.Second)
+		stale.RetentionPurgeScheduledAt = &scheduledAt
+		stale.RetentionPurgeQueueID = "queue-id"
+
+		// Act
+		err = repository.UpdateRetentionPurge(context.Background(), &stale)
+
+		// Assert
+		assert.Nil(t, err)
+
+		stored, err := repository.Load(context.Background(), "user-id")
+		assert.Nil(t, err)
+		assert.Equal(t, &days, stored.MessageRetentionDays)
+		assert.Equal(t, "queue-id", stored.RetentionPurgeQueueID)
+		assert.True(t, scheduledAt.Equal(*stored.RetentionPurgeScheduledAt))
+	})
+}
@@ -115,3 +115,16 @@ func (repository *gormWebhookRepository) Delete(ctx context.Context, userID enti
 
 	return nil
 }
+
+// DeleteAllForUser deletes all the entities.Webhook of a user
+func (repository *gormWebhookRepository) DeleteAllForUser(ctx context.Context, userID entities.UserID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&entities.Webhook{}).Error; err != nil {
+		msg := fmt.Sprintf("cannot delete webhooks for user with ID [%s]", userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -13,6 +13,9 @@ type HeartbeatMonitorRepository interface {
 	// Store a new entities.HeartbeatMonitor
 	Store(ctx context.Context, heartbeat *entities.HeartbeatMonitor) error
 
+	// Update the settings of an entities.HeartbeatMonitor
+	Update(ctx context.Context, monitor *entities.HeartbeatMonitor) error
+
 	// Load a phone by user and phone number
 	Load(ctx context.Context, userID entities.UserID, phoneNumber string) (*entities.HeartbeatMonitor, error)
 
@@ -27,4 +30,7 @@ type HeartbeatMonitorRepository interface {
 
 	// UpdatePhoneOnline updates the phone online status of a monitor
 	UpdatePhoneOnline(ctx context.Context, userID entities.UserID, monitorID uuid.UUID, online bool) error
+
+	// DeleteAllForUser deletes all the entities.HeartbeatMonitor of a user
+	DeleteAllForUser(ctx context.Context, userID entities.UserID) error
 }
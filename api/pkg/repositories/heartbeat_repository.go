@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/entities"
 )
@@ -16,4 +17,10 @@ type HeartbeatRepository interface {
 
 	// Last entities.Heartbeat returns the last heartbeat
 	Last(ctx context.Context, userID entities.UserID, owner string) (*entities.Heartbeat, error)
+
+	// DeleteOlderThan deletes at most limit entities.Heartbeat of a user which were received before the timestamp
+	DeleteOlderThan(ctx context.Context, userID entities.UserID, timestamp time.Time, limit int) (int64, error)
+
+	// DeleteAllForUser deletes all the entities.Heartbeat of a user
+	DeleteAllForUser(ctx context.Context, userID entities.UserID) error
 }
@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// MaintenanceWindowRepository loads and persists an entities.MaintenanceWindow
+type MaintenanceWindowRepository interface {
+	// Store a new entities.MaintenanceWindow
+	Store(ctx context.Context, window *entities.MaintenanceWindow) error
+
+	// Index fetches the entities.MaintenanceWindow of a phone
+	Index(ctx context.Context, userID entities.UserID, phoneID uuid.UUID) ([]*entities.MaintenanceWindow, error)
+
+	// LoadStarted fetches the entities.MaintenanceWindow of a phone number which started before the timestamp and can still be active
+	LoadStarted(ctx context.Context, userID entities.UserID, owner string, timestamp time.Time) ([]*entities.MaintenanceWindow, error)
+
+	// Delete an entities.MaintenanceWindow of a phone
+	Delete(ctx context.Context, userID entities.UserID, phoneID uuid.UUID, windowID uuid.UUID) error
+
+	// DeleteAllForUser deletes all the entities.MaintenanceWindow of a user
+	DeleteAllForUser(ctx context.Context, userID entities.UserID) error
+}
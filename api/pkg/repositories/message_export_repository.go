@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// MessageExportRepository loads and persists an entities.MessageExport
+type MessageExportRepository interface {
+	// Store a new entities.MessageExport
+	Store(ctx context.Context, export *entities.MessageExport) error
+
+	// Load an entities.MessageExport of a user by ID
+	Load(ctx context.Context, userID entities.UserID, exportID uuid.UUID) (*entities.MessageExport, error)
+
+	// Update an entities.MessageExport
+	Update(ctx context.Context, export *entities.MessageExport) error
+
+	// LoadByToken loads an entities.MessageExport by ID and the hash of the download token
+	LoadByToken(ctx context.Context, exportID uuid.UUID, token string) (*entities.MessageExport, error)
+
+	// StoreFile stores the generated file of an entities.MessageExport in chunks and marks the export as ready in a single transaction
+	StoreFile(ctx context.Context, export *entities.MessageExport, file io.Reader) error
+
+	// LoadChunk loads a chunk of the generated file of an entities.MessageExport
+	LoadChunk(ctx context.Context, exportID uuid.UUID, sequence int) (*entities.MessageExportChunk, error)
+
+	// DeleteExpired deletes the entities.MessageExport of a user and their files which expired before the timestamp
+	DeleteExpired(ctx context.Context, userID entities.UserID, timestamp time.Time) error
+
+	// DeleteAllForUser deletes all the entities.MessageExport of a user
+	DeleteAllForUser(ctx context.Context, userID entities.UserID) error
+}
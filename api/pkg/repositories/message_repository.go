@@ -2,11 +2,25 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/entities"
 	"github.com/google/uuid"
 )
 
+const (
+	// MessageSortByRank sorts the results of a full-text search by relevance
+	MessageSortByRank = "rank"
+
+	// MessageSortByOrderTimestamp sorts messages by timestamp in descending order and pages through them with a Cursor
+	MessageSortByOrderTimestamp = "order_timestamp"
+)
+
+// IsCursorPaginated checks if the search pages through messages with a Cursor instead of sorting them by IndexParams.SortBy
+func (params IndexParams) IsCursorPaginated() bool {
+	return params.Cursor != nil || params.SortBy == MessageSortByOrderTimestamp
+}
+
 // MessageRepository loads and persists an entities.Message
 type MessageRepository interface {
 	// Store a new entities.Message
@@ -21,18 +35,45 @@ type MessageRepository interface {
 	// Index entities.Message between 2 phone numbers
 	Index(ctx context.Context, userID entities.UserID, owner string, contact string, params IndexParams) (*[]entities.Message, error)
 
+	// IndexByRequestID fetches the entities.Message sent with the same request ID
+	IndexByRequestID(ctx context.Context, userID entities.UserID, requestID string, params IndexParams) (*[]entities.Message, error)
+
+	// LoadMany fetches the entities.Message with the given IDs
+	LoadMany(ctx context.Context, userID entities.UserID, messageIDs []uuid.UUID) ([]*entities.Message, error)
+
 	// LastMessage fetches the last message between an owner and a contact
 	LastMessage(ctx context.Context, userID entities.UserID, owner string, contact string) (*entities.Message, error)
 
-	// Search entities.Message for a user
+	// Search entities.Message for a user using full-text search on the content of messages which are not encrypted
 	Search(ctx context.Context, userID entities.UserID, owners []string, types []entities.MessageType, statuses []entities.MessageStatus, params IndexParams) ([]*entities.Message, error)
 
+	// Export streams the entities.Message of a user to the callback in chronological batches of IndexParams.Limit
+	Export(ctx context.Context, userID entities.UserID, owners []string, types []entities.MessageType, statuses []entities.MessageStatus, params IndexParams, callback func(messages []*entities.Message) error) error
+
 	// GetOutstanding fetches an entities.Message which is outstanding
 	GetOutstanding(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (*entities.Message, error)
 
+	// GetOutstandingBatch transitions at most limit scheduled entities.Message of a phone which are due at the timestamp to sending
+	GetOutstandingBatch(ctx context.Context, userID entities.UserID, owner string, timestamp time.Time, limit int) ([]*entities.Message, error)
+
+	// LoadDueOutstanding loads the oldest scheduled entities.Message of a phone which is due to be sent at the timestamp
+	LoadDueOutstanding(ctx context.Context, userID entities.UserID, owner string, timestamp time.Time) (*entities.Message, error)
+
+	// Reroute moves the pending and scheduled entities.Message of a phone which are due at the timestamp to another phone, deletes their pending entities.PhoneNotification and returns them
+	Reroute(ctx context.Context, userID entities.UserID, owner string, phone *entities.Phone, timestamp time.Time) ([]*entities.Message, error)
+
 	// Delete an entities.Message by ID
 	Delete(ctx context.Context, userID entities.UserID, messageID uuid.UUID) error
 
 	// DeleteByOwnerAndContact deletes messages between an owner and a contact
 	DeleteByOwnerAndContact(ctx context.Context, userID entities.UserID, owner string, contact string) error
+
+	// LoadOlderThan fetches at most limit entities.Message of a user which were sent or received before the timestamp and still have content, oldest first
+	LoadOlderThan(ctx context.Context, userID entities.UserID, timestamp time.Time, limit int) ([]*entities.Message, error)
+
+	// PurgeContent removes the content of the entities.Message with the given IDs without deleting the messages
+	PurgeContent(ctx context.Context, userID entities.UserID, messageIDs []uuid.UUID, timestamp time.Time) error
+
+	// DeleteAllForUser deletes all the entities.Message of a user
+	DeleteAllForUser(ctx context.Context, userID entities.UserID) error
 }
@@ -28,6 +28,12 @@ type MessageThreadRepository interface {
 	// UpdateAfterDeletedMessage updates a thread after the original message has been deleted
 	UpdateAfterDeletedMessage(ctx context.Context, userID entities.UserID, messageID uuid.UUID) error
 
+	// PurgeLastMessageContent removes the content of the last message of the threads whose last message is one of the messageIDs
+	PurgeLastMessageContent(ctx context.Context, userID entities.UserID, messageIDs []uuid.UUID) error
+
 	// Delete an entities.MessageThread by ID
 	Delete(ctx context.Context, userID entities.UserID, messageThreadID uuid.UUID) error
+
+	// DeleteAllForUser deletes all the entities.MessageThread of a user
+	DeleteAllForUser(ctx context.Context, userID entities.UserID) error
 }
@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// OrganizationRepository loads and persists an entities.Organization with its members and invitations
+type OrganizationRepository interface {
+	// Store a new entities.Organization together with the owner entities.OrganizationMember
+	Store(ctx context.Context, organization *entities.Organization, owner *entities.OrganizationMember) error
+
+	// Load an entities.Organization by ID
+	Load(ctx context.Context, organizationID uuid.UUID) (*entities.Organization, error)
+
+	// Index the entities.Organization which an entities.UserID is a member of
+	Index(ctx context.Context, userID entities.UserID) ([]*entities.Organization, error)
+
+	// LoadMember loads the entities.OrganizationMember of a user with the phone grants
+	LoadMember(ctx context.Context, organizationID uuid.UUID, userID entities.UserID) (*entities.OrganizationMember, error)
+
+	// LoadMemberByID loads an entities.OrganizationMember by ID with the phone grants
+	LoadMemberByID(ctx context.Context, organizationID uuid.UUID, memberID uuid.UUID) (*entities.OrganizationMember, error)
+
+	// IndexMembers fetches the entities.OrganizationMember of an organization
+	IndexMembers(ctx context.Context, organizationID uuid.UUID, params IndexParams) ([]*entities.OrganizationMember, error)
+
+	// UpdateMember updates an entities.OrganizationMember
+	UpdateMember(ctx context.Context, member *entities.OrganizationMember) error
+
+	// DeleteMember deletes an entities.OrganizationMember and the phone grants
+	DeleteMember(ctx context.Context, organizationID uuid.UUID, memberID uuid.UUID) error
+
+	// ReplacePhoneGrants replaces all the entities.OrganizationPhoneGrant of an entities.OrganizationMember
+	ReplacePhoneGrants(ctx context.Context, member *entities.OrganizationMember, grants []entities.OrganizationPhoneGrant) error
+
+	// StoreInvitation stores a new entities.OrganizationInvitation
+	StoreInvitation(ctx context.Context, invitation *entities.OrganizationInvitation) error
+
+	// LoadInvitationByToken loads an entities.OrganizationInvitation by the token
+	LoadInvitationByToken(ctx context.Context, token string) (*entities.OrganizationInvitation, error)
+
+	// AcceptInvitation marks the entities.OrganizationInvitation as accepted and stores the new entities.OrganizationMember
+	AcceptInvitation(ctx context.Context, invitation *entities.OrganizationInvitation, member *entities.OrganizationMember) error
+
+	// DeleteAllForUser deletes the entities.Organization owned by a user and the memberships of the user in other organizations
+	DeleteAllForUser(ctx context.Context, userID entities.UserID) error
+}
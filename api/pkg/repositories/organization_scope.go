@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type organizationScopeContextKey struct{}
+
+// OrganizationScope limits the resources which are loaded on behalf of an entities.OrganizationMember
+type OrganizationScope struct {
+	OrganizationID uuid.UUID
+	OwnerID        entities.UserID
+
+	// Owners are the phone numbers which the member can access. All the phones of the organization are accessible when it is nil.
+	Owners []string
+}
+
+// NewOrganizationScope creates the OrganizationScope of an entities.OrganizationMember in an entities.Organization
+func NewOrganizationScope(organization *entities.Organization, member *entities.OrganizationMember) *OrganizationScope {
+	scope := &OrganizationScope{OrganizationID: organization.ID, OwnerID: organization.OwnerID}
+	if member.Role.CanManage() {
+		return scope
+	}
+
+	scope.Owners = make([]string, 0, len(member.PhoneGrants))
+	for _, grant := range member.PhoneGrants {
+		scope.Owners = append(scope.Owners, grant.Owner)
+	}
+	return scope
+}
+
+// WithOrganizationScope stores the OrganizationScope in the context. A nil scope removes the scope from the context.
+func WithOrganizationScope(ctx context.Context, scope *OrganizationScope) context.Context {
+	return context.WithValue(ctx, organizationScopeContextKey{}, scope)
+}
+
+// OrganizationScopeFromContext returns the OrganizationScope in the context or nil if the request is not made on behalf of an organization
+func OrganizationScopeFromContext(ctx context.Context) *OrganizationScope {
+	scope, _ := ctx.Value(organizationScopeContextKey{}).(*OrganizationScope)
+	return scope
+}
+
+// scopeOrganization filters a query by the phone numbers in the column which the OrganizationScope in the context can access
+func scopeOrganization(ctx context.Context, column string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		scope := OrganizationScopeFromContext(ctx)
+		if scope == nil || scope.Owners == nil {
+			return db
+		}
+
+		if len(scope.Owners) == 0 {
+			return db.Where("1 = 0")
+		}
+		return db.Where(column+" IN ?", scope.Owners)
+	}
+}
@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// PhoneAvailabilityRepository loads and persists an entities.PhoneAvailabilityPeriod
+type PhoneAvailabilityRepository interface {
+	// Transition ends the open period of a phone and starts the new period if the availability has changed
+	Transition(ctx context.Context, period *entities.PhoneAvailabilityPeriod) error
+
+	// Index fetches the periods of a phone which overlap the time between from and to
+	Index(ctx context.Context, userID entities.UserID, phoneID uuid.UUID, from time.Time, to time.Time) ([]*entities.PhoneAvailabilityPeriod, error)
+
+	// LoadFirst fetches the first recorded period of a phone
+	LoadFirst(ctx context.Context, userID entities.UserID, phoneID uuid.UUID) (*entities.PhoneAvailabilityPeriod, error)
+
+	// DeleteAllForUser deletes all the entities.PhoneAvailabilityPeriod of a user
+	DeleteAllForUser(ctx context.Context, userID entities.UserID) error
+}
@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// PhoneCommandRepository loads and persists an entities.PhoneCommand
+type PhoneCommandRepository interface {
+	// Store a new entities.PhoneCommand
+	Store(ctx context.Context, command *entities.PhoneCommand) error
+
+	// Update an entities.PhoneCommand
+	Update(ctx context.Context, command *entities.PhoneCommand) error
+
+	// Load an entities.PhoneCommand of a phone by ID
+	Load(ctx context.Context, userID entities.UserID, phoneID uuid.UUID, commandID uuid.UUID) (*entities.PhoneCommand, error)
+
+	// Index fetches the entities.PhoneCommand of a phone, newest first, optionally filtered by status
+	Index(ctx context.Context, userID entities.UserID, phoneID uuid.UUID, statuses []entities.PhoneCommandStatus, params IndexParams) ([]*entities.PhoneCommand, error)
+
+	// DeleteAllForUser deletes all the entities.PhoneCommand of a user
+	DeleteAllForUser(ctx context.Context, userID entities.UserID) error
+}
@@ -0,0 +1,24 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// PhoneConfigRepository loads and persists an entities.PhoneConfig
+type PhoneConfigRepository interface {
+	// SaveNextVersion upserts the settings of an entities.PhoneConfig and atomically increments its version
+	SaveNextVersion(ctx context.Context, config *entities.PhoneConfig) error
+
+	// IncrementVersion atomically increments the version of an entities.PhoneConfig and stores its document hash without changing its settings
+	IncrementVersion(ctx context.Context, config *entities.PhoneConfig) error
+
+	// Load the entities.PhoneConfig of a phone
+	Load(ctx context.Context, userID entities.UserID, phoneID uuid.UUID) (*entities.PhoneConfig, error)
+
+	// DeleteAllForUser deletes all the entities.PhoneConfig of a user
+	DeleteAllForUser(ctx context.Context, userID entities.UserID) error
+}
@@ -2,17 +2,65 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/NdoleStudio/httpsms/pkg/entities"
 )
 
+// PhoneNotificationRateLimit limits how many entities.PhoneNotification are scheduled on a phone and on its SIMs
+type PhoneNotificationRateLimit struct {
+	// MessagesPerMinute spaces out the notifications of the phone or of the SIM. There is no limit when it is 0.
+	MessagesPerMinute uint
+
+	// SIMMessagesPerDay is the daily limit of the carrier of the SIM in the day of the user. There is no limit when it is 0.
+	SIMMessagesPerDay uint
+
+	// MessagesPerHour is the hourly cap of the phone across all its SIMs. There is no cap when it is 0.
+	MessagesPerHour uint
+
+	// MessagesPerDay is the daily cap of the phone across all its SIMs. There is no cap when it is 0.
+	MessagesPerDay uint
+
+	// Location is the timezone of the user which starts the hourly and daily windows of the phone and of its SIMs. UTC is used when it is nil.
+	Location *time.Location
+}
+
+// HasCaps checks if the notifications are capped in hourly or daily windows
+func (limit PhoneNotificationRateLimit) HasCaps() bool {
+	return limit.SIMMessagesPerDay > 0 || limit.MessagesPerHour > 0 || limit.MessagesPerDay > 0
+}
+
+// PhoneNotificationCap is the cap which deferred an entities.PhoneNotification into a later window
+type PhoneNotificationCap struct {
+	SIM    *entities.SIM
+	Window string
+	Limit  uint
+	Until  time.Time
+}
+
 // PhoneNotificationRepository loads and persists an entities.PhoneNotification
 type PhoneNotificationRepository interface {
-	// Schedule a new entities.PhoneNotification
-	Schedule(ctx context.Context, messagesPerMinute uint, notification *entities.PhoneNotification) error
+	// Schedule a new entities.PhoneNotification after the last notification on the same phone or SIM.
+	// The cap is returned when the notification is the first one which is deferred into the next window.
+	Schedule(ctx context.Context, limit PhoneNotificationRateLimit, notification *entities.PhoneNotification) (*PhoneNotificationCap, error)
+
+	// CountScheduled counts the entities.PhoneNotification of each phone of a user which are scheduled between from and to
+	CountScheduled(ctx context.Context, userID entities.UserID, from time.Time, to time.Time) (map[uuid.UUID]uint, error)
+
+	// ClaimPending marks the pending entities.PhoneNotification of a phone which are scheduled at or before the timestamp as sent and returns them
+	ClaimPending(ctx context.Context, phoneID uuid.UUID, timestamp time.Time) ([]*entities.PhoneNotification, error)
+
+	// Exists checks if an entities.PhoneNotification exists for a user
+	Exists(ctx context.Context, userID entities.UserID, notificationID uuid.UUID) (bool, error)
 
 	// UpdateStatus of a notification
 	UpdateStatus(ctx context.Context, notificationID uuid.UUID, status entities.PhoneNotificationStatus) error
+
+	// DeleteOlderThan deletes at most limit entities.PhoneNotification of a user which were created before the timestamp
+	DeleteOlderThan(ctx context.Context, userID entities.UserID, timestamp time.Time, limit int) (int64, error)
+
+	// DeleteAllForUser deletes all the entities.PhoneNotification of a user
+	DeleteAllForUser(ctx context.Context, userID entities.UserID) error
 }
@@ -19,9 +19,15 @@ type PhoneRepository interface {
 	// Load a phone by user and phone number
 	Load(ctx context.Context, userID entities.UserID, phoneNumber string) (*entities.Phone, error)
 
+	// LoadBySIMPhoneNumber loads the phone of a user which has a SIM with the phone number
+	LoadBySIMPhoneNumber(ctx context.Context, userID entities.UserID, phoneNumber string) (*entities.Phone, error)
+
 	// LoadByID a phone by ID
 	LoadByID(ctx context.Context, userID entities.UserID, phoneID uuid.UUID) (*entities.Phone, error)
 
 	// Delete an entities.Phone
 	Delete(ctx context.Context, userID entities.UserID, phoneID uuid.UUID) error
+
+	// DeleteAllForUser deletes all the entities.Phone of a user
+	DeleteAllForUser(ctx context.Context, userID entities.UserID) error
 }
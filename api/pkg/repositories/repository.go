@@ -1,23 +1,98 @@
 package repositories
 
 import (
+	"encoding/base64"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
 )
 
 // IndexParams parameters for indexing a database table
 type IndexParams struct {
-	Skip           int    `json:"skip"`
-	SortBy         string `json:"sort"`
-	SortDescending bool   `json:"sort_descending"`
-	Query          string `json:"query"`
-	Limit          int    `json:"take"`
+	Skip           int        `json:"skip"`
+	SortBy         string     `json:"sort"`
+	SortDescending bool       `json:"sort_descending"`
+	Query          string     `json:"query"`
+	Limit          int        `json:"take"`
+	Cursor         *Cursor    `json:"cursor"`
+	CreatedAfter   *time.Time `json:"created_after"`
+	CreatedBefore  *time.Time `json:"created_before"`
+}
+
+// Cursor is the position of the last item in a page which is used to fetch the next page
+type Cursor struct {
+	Timestamp time.Time
+	ID        uuid.UUID
+}
+
+// NewCursor creates a new Cursor
+func NewCursor(timestamp time.Time, ID uuid.UUID) *Cursor {
+	return &Cursor{Timestamp: timestamp, ID: ID}
+}
+
+// String returns the opaque representation of the Cursor
+func (cursor *Cursor) String() string {
+	value := fmt.Sprintf("%s|%s", cursor.Timestamp.UTC().Format(time.RFC3339Nano), cursor.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(value))
+}
+
+// ParseCursor decodes a Cursor from its opaque representation
+func ParseCursor(value string) (*Cursor, error) {
+	content, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot decode cursor [%s]", value))
+	}
+
+	parts := strings.Split(string(content), "|")
+	if len(parts) != 2 {
+		return nil, stacktrace.NewError(fmt.Sprintf("cursor [%s] has an invalid format", value))
+	}
+
+	timestamp, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cursor [%s] has an invalid timestamp", value))
+	}
+
+	ID, err := uuid.Parse(parts[1])
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cursor [%s] has an invalid ID", value))
+	}
+
+	return NewCursor(timestamp, ID), nil
 }
 
 const (
 	// ErrCodeNotFound is thrown when an entity does not exist in storage
 	ErrCodeNotFound = stacktrace.ErrorCode(1000)
 
+	// ErrCodeConflict is thrown when an entity conflicts with one which already exists in storage
+	ErrCodeConflict = stacktrace.ErrorCode(1001)
+
 	dbOperationDuration = 5 * time.Second
 )
+
+// paginate filters the query by the date range in IndexParams and orders it by timestampColumn.
+// The Cursor is used instead of the offset when it is set so that rows inserted while paging are not skipped.
+func paginate(query *gorm.DB, params IndexParams, timestampColumn string, createdColumn string) *gorm.DB {
+	query = filterCreatedAt(query, params, createdColumn).Order(timestampColumn + " DESC").Order("id DESC").Limit(params.Limit)
+	if params.Cursor != nil {
+		return query.Where(fmt.Sprintf("(%s, id) < (?, ?)", timestampColumn), params.Cursor.Timestamp, params.Cursor.ID)
+	}
+
+	return query.Offset(params.Skip)
+}
+
+// filterCreatedAt filters the query by the CreatedAfter and CreatedBefore dates in IndexParams
+func filterCreatedAt(query *gorm.DB, params IndexParams, column string) *gorm.DB {
+	if params.CreatedAfter != nil {
+		query = query.Where(column+" >= ?", *params.CreatedAfter)
+	}
+	if params.CreatedBefore != nil {
+		query = query.Where(column+" < ?", *params.CreatedBefore)
+	}
+	return query
+}
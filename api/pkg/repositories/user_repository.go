@@ -14,6 +14,12 @@ type UserRepository interface {
 	// Update a new entities.User
 	Update(ctx context.Context, user *entities.User) error
 
+	// UpdateRetention updates only the data retention policy and the scheduled retention purge of an entities.User
+	UpdateRetention(ctx context.Context, user *entities.User) error
+
+	// UpdateRetentionPurge updates only the scheduled retention purge of an entities.User
+	UpdateRetentionPurge(ctx context.Context, user *entities.User) error
+
 	// LoadAuthUser fetches an entities.AuthUser by apiKey
 	LoadAuthUser(ctx context.Context, apiKey string) (entities.AuthUser, error)
 
@@ -28,4 +34,7 @@ type UserRepository interface {
 
 	// LoadBySubscriptionID loads a user based on the lemonsqueezy subscriptionID
 	LoadBySubscriptionID(ctx context.Context, subscriptionID string) (*entities.User, error)
+
+	// Delete an entities.User
+	Delete(ctx context.Context, user *entities.User) error
 }
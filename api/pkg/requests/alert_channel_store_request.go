@@ -0,0 +1,58 @@
+package requests
+
+import (
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// AlertChannelStore is the payload for creating a new entities.AlertChannel
+type AlertChannelStore struct {
+	request
+	Name string `json:"name" example:"On-call SMS"`
+	Type string `json:"type" example:"sms"`
+
+	// Target is the email address, discord channel ID, slack webhook URL or backup phone number of the channel
+	Target                 string   `json:"target" example:"+18005550100"`
+	AlertTypes             []string `json:"alert_types" example:"phone.heartbeat.offline"`
+	PhoneNumbers           []string `json:"phone_numbers" example:"+18005550199"`
+	EscalationDelaySeconds uint     `json:"escalation_delay_seconds" example:"900"`
+}
+
+// Sanitize sets defaults to AlertChannelStore
+func (input *AlertChannelStore) Sanitize() AlertChannelStore {
+	input.Name = strings.TrimSpace(input.Name)
+	input.Type = strings.ToLower(strings.TrimSpace(input.Type))
+	input.Target = strings.TrimSpace(input.Target)
+	switch entities.AlertChannelType(input.Type) {
+	case entities.AlertChannelTypeSMS:
+		input.Target = input.sanitizeAddress(input.Target)
+	case entities.AlertChannelTypeSlack:
+		input.Target = input.sanitizeURL(input.Target)
+	}
+
+	input.AlertTypes = input.removeStringDuplicates(input.AlertTypes)
+
+	var phoneNumbers []string
+	for _, address := range input.PhoneNumbers {
+		phoneNumbers = append(phoneNumbers, input.sanitizeAddress(address))
+	}
+	input.PhoneNumbers = input.removeStringDuplicates(phoneNumbers)
+
+	return *input
+}
+
+// ToStoreParams converts AlertChannelStore to services.AlertChannelStoreParams
+func (input *AlertChannelStore) ToStoreParams(userID entities.UserID) *services.AlertChannelStoreParams {
+	return &services.AlertChannelStoreParams{
+		UserID:          userID,
+		Name:            input.Name,
+		Type:            entities.AlertChannelType(input.Type),
+		Target:          input.Target,
+		AlertTypes:      input.AlertTypes,
+		PhoneNumbers:    input.PhoneNumbers,
+		EscalationDelay: time.Duration(input.EscalationDelaySeconds) * time.Second,
+	}
+}
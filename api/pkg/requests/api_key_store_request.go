@@ -0,0 +1,45 @@
+package requests
+
+import (
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// APIKeyStore is the payload for creating a new entities.APIKey
+type APIKeyStore struct {
+	request
+	Name      string     `json:"name" example:"Android Phone"`
+	Scopes    []string   `json:"scopes" example:"heartbeats:write,phones:write"`
+	ExpiresAt *time.Time `json:"expires_at" example:"2023-06-05T14:26:02.302718+03:00"`
+}
+
+// Sanitize sets defaults to APIKeyStore
+func (input *APIKeyStore) Sanitize() APIKeyStore {
+	input.Name = strings.TrimSpace(input.Name)
+
+	var scopes []string
+	for _, scope := range input.Scopes {
+		scopes = append(scopes, strings.TrimSpace(scope))
+	}
+	input.Scopes = input.removeStringDuplicates(scopes)
+
+	if input.ExpiresAt != nil {
+		expiresAt := input.ExpiresAt.UTC()
+		input.ExpiresAt = &expiresAt
+	}
+
+	return *input
+}
+
+// ToStoreParams converts APIKeyStore to services.APIKeyStoreParams
+func (input *APIKeyStore) ToStoreParams(user entities.AuthUser) *services.APIKeyStoreParams {
+	return &services.APIKeyStoreParams{
+		UserID:    user.ID,
+		Name:      input.Name,
+		Scopes:    input.Scopes,
+		ExpiresAt: input.ExpiresAt,
+	}
+}
@@ -0,0 +1,43 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// EventStream is the payload for subscribing to the events of a user
+type EventStream struct {
+	request
+	Types  []string `json:"types" query:"types" example:"message.phone.received"`
+	Owners []string `json:"owners" query:"owners" example:"+18005550199"`
+}
+
+// Sanitize sets defaults to EventStream
+func (input *EventStream) Sanitize() EventStream {
+	var types []string
+	for _, eventType := range input.Types {
+		if eventType = strings.TrimSpace(eventType); eventType != "" {
+			types = append(types, eventType)
+		}
+	}
+	input.Types = input.removeStringDuplicates(types)
+
+	var owners []string
+	for _, owner := range input.Owners {
+		owners = append(owners, input.sanitizeAddress(owner))
+	}
+	input.Owners = input.removeStringDuplicates(owners)
+
+	return *input
+}
+
+// ToEventStreamParams converts EventStream to services.EventStreamParams
+func (input *EventStream) ToEventStreamParams(userID entities.UserID) *services.EventStreamParams {
+	return &services.EventStreamParams{
+		UserID: userID,
+		Types:  input.Types,
+		Owners: input.Owners,
+	}
+}
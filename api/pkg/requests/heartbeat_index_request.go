@@ -9,10 +9,13 @@ import (
 // HeartbeatIndex is the payload for fetching entities.Heartbeat of a phone number
 type HeartbeatIndex struct {
 	request
-	Skip  string `json:"skip" query:"skip"`
-	Owner string `json:"owner" query:"owner"`
-	Query string `json:"query" query:"query"`
-	Limit string `json:"limit" query:"limit"`
+	Skip          string `json:"skip" query:"skip"`
+	Owner         string `json:"owner" query:"owner"`
+	Query         string `json:"query" query:"query"`
+	Limit         string `json:"limit" query:"limit"`
+	Cursor        string `json:"cursor" query:"cursor"`
+	CreatedAfter  string `json:"created_after" query:"created_after"`
+	CreatedBefore string `json:"created_before" query:"created_before"`
 }
 
 // Sanitize sets defaults to MessageOutstanding
@@ -22,6 +25,9 @@ func (input *HeartbeatIndex) Sanitize() HeartbeatIndex {
 	}
 	input.Query = strings.TrimSpace(input.Query)
 	input.Owner = input.sanitizeAddress(input.Owner)
+	input.Cursor = strings.TrimSpace(input.Cursor)
+	input.CreatedAfter = strings.TrimSpace(input.CreatedAfter)
+	input.CreatedBefore = strings.TrimSpace(input.CreatedBefore)
 	input.Skip = strings.TrimSpace(input.Skip)
 	if input.Skip == "" {
 		input.Skip = "0"
@@ -32,8 +38,11 @@ func (input *HeartbeatIndex) Sanitize() HeartbeatIndex {
 // ToIndexParams converts HeartbeatIndex to repositories.IndexParams
 func (input *HeartbeatIndex) ToIndexParams() repositories.IndexParams {
 	return repositories.IndexParams{
-		Skip:  input.getInt(input.Skip),
-		Query: input.Query,
-		Limit: input.getInt(input.Limit),
+		Skip:          input.getInt(input.Skip),
+		Query:         input.Query,
+		Limit:         input.getInt(input.Limit),
+		Cursor:        input.getCursor(input.Cursor),
+		CreatedAfter:  input.getTime(input.CreatedAfter),
+		CreatedBefore: input.getTime(input.CreatedBefore),
 	}
 }
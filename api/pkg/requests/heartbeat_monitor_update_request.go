@@ -0,0 +1,48 @@
+package requests
+
+import (
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// HeartbeatMonitorUpdate is the payload for updating the settings of an entities.HeartbeatMonitor
+type HeartbeatMonitorUpdate struct {
+	request
+	Owner string `json:"owner" example:"+18005550199"`
+
+	// IntervalSeconds is the expected interval in seconds between heartbeats of the phone
+	IntervalSeconds uint `json:"interval_seconds" example:"960"`
+
+	// MissedThreshold is the number of missed intervals after which the phone is pushed to send a heartbeat
+	MissedThreshold uint `json:"missed_threshold" example:"1"`
+
+	// OfflineThreshold is the number of missed intervals after which the phone is considered offline
+	OfflineThreshold uint `json:"offline_threshold" example:"4"`
+}
+
+// Sanitize sets defaults to HeartbeatMonitorUpdate
+func (input *HeartbeatMonitorUpdate) Sanitize() HeartbeatMonitorUpdate {
+	input.Owner = input.sanitizeAddress(input.Owner)
+	if input.IntervalSeconds == 0 {
+		input.IntervalSeconds = entities.HeartbeatMonitorDefaultIntervalSeconds
+	}
+	if input.MissedThreshold == 0 {
+		input.MissedThreshold = entities.HeartbeatMonitorDefaultMissedThreshold
+	}
+	if input.OfflineThreshold == 0 {
+		input.OfflineThreshold = entities.HeartbeatMonitorDefaultOfflineThreshold
+	}
+	return *input
+}
+
+// ToUpdateParams converts HeartbeatMonitorUpdate to services.HeartbeatMonitorUpdateParams
+func (input *HeartbeatMonitorUpdate) ToUpdateParams(userID entities.UserID, source string) *services.HeartbeatMonitorUpdateParams {
+	return &services.HeartbeatMonitorUpdateParams{
+		Owner:            input.Owner,
+		UserID:           userID,
+		IntervalSeconds:  input.IntervalSeconds,
+		MissedThreshold:  input.MissedThreshold,
+		OfflineThreshold: input.OfflineThreshold,
+		Source:           source,
+	}
+}
@@ -1,6 +1,7 @@
 package requests
 
 import (
+	"strings"
 	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/entities"
@@ -12,22 +13,64 @@ type HeartbeatStore struct {
 	request
 	Charging bool   `json:"charging"`
 	Owner    string `json:"owner"`
+
+	// BatteryLevel is the battery percentage of the phone
+	BatteryLevel *uint `json:"battery_level" example:"87"`
+
+	// NetworkType is the type of the mobile network e.g. lte, 5g or wifi
+	NetworkType *string `json:"network_type" example:"lte"`
+
+	// SignalStrength is the strength of the mobile network signal in dBm
+	SignalStrength *int `json:"signal_strength" example:"-95"`
+
+	// SIMs is the carrier and state of each SIM slot of the phone
+	SIMs []entities.HeartbeatSIM `json:"sims"`
+
+	// FreeStorage is the available storage of the phone in bytes
+	FreeStorage *uint64 `json:"free_storage" example:"2147483648"`
+
+	// AppVersion is the version of the app. It is used when the X-Client-Version header is not set.
+	AppVersion string `json:"app_version" example:"344c10f"`
+
+	// PendingMessages is the number of messages in the local queue of the app which have not been sent yet
+	PendingMessages *uint `json:"pending_messages" example:"3"`
 }
 
 // Sanitize sets defaults to MessageOutstanding
 func (input *HeartbeatStore) Sanitize() HeartbeatStore {
 	input.Owner = input.sanitizeAddress(input.Owner)
+	input.AppVersion = strings.TrimSpace(input.AppVersion)
+	if input.NetworkType != nil {
+		input.NetworkType = input.sanitizeStringPointer(strings.ToLower(*input.NetworkType))
+	}
+	for index, sim := range input.SIMs {
+		input.SIMs[index].SIM = entities.SIM(input.sanitizeSIM(string(sim.SIM)))
+		input.SIMs[index].State = entities.HeartbeatSIMState(strings.ToLower(strings.TrimSpace(sim.State.String())))
+		if sim.Carrier != nil {
+			input.SIMs[index].Carrier = input.sanitizeStringPointer(*sim.Carrier)
+		}
+	}
 	return *input
 }
 
 // ToStoreParams converts HeartbeatIndex to repositories.IndexParams
 func (input *HeartbeatStore) ToStoreParams(user entities.AuthUser, source string, version string) services.HeartbeatStoreParams {
+	if strings.TrimSpace(version) == "" {
+		version = input.AppVersion
+	}
+
 	return services.HeartbeatStoreParams{
-		Owner:     input.Owner,
-		Version:   version,
-		Source:    source,
-		Charging:  input.Charging,
-		Timestamp: time.Now().UTC(),
-		UserID:    user.ID,
+		Owner:           input.Owner,
+		Version:         version,
+		Source:          source,
+		Charging:        input.Charging,
+		BatteryLevel:    input.BatteryLevel,
+		NetworkType:     input.NetworkType,
+		SignalStrength:  input.SignalStrength,
+		SIMs:            input.SIMs,
+		FreeStorage:     input.FreeStorage,
+		PendingMessages: input.PendingMessages,
+		Timestamp:       time.Now().UTC(),
+		UserID:          user.ID,
 	}
 }
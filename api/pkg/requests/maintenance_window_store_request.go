@@ -0,0 +1,49 @@
+package requests
+
+import (
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// MaintenanceWindowStore is the payload for creating a new entities.MaintenanceWindow
+type MaintenanceWindowStore struct {
+	request
+	PhoneID string `json:"phoneID" swaggerignore:"true"` // used internally for validation
+
+	// StartsAt is the start of the maintenance window, an ad-hoc maintenance window starts now when it is empty
+	StartsAt   string `json:"starts_at" example:"2022-06-05T02:00:00+03:00"`
+	EndsAt     string `json:"ends_at" example:"2022-06-05T02:30:00+03:00"`
+	Recurrence string `json:"recurrence" example:"daily"`
+	Reason     string `json:"reason" example:"Android system update"`
+}
+
+// Sanitize sets defaults to MaintenanceWindowStore
+func (input *MaintenanceWindowStore) Sanitize() MaintenanceWindowStore {
+	input.PhoneID = strings.TrimSpace(input.PhoneID)
+	input.StartsAt = strings.TrimSpace(input.StartsAt)
+	if input.StartsAt == "" {
+		input.StartsAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	input.EndsAt = strings.TrimSpace(input.EndsAt)
+	input.Recurrence = strings.ToLower(strings.TrimSpace(input.Recurrence))
+	if input.Recurrence == "" {
+		input.Recurrence = entities.MaintenanceWindowRecurrenceNone.String()
+	}
+	input.Reason = strings.TrimSpace(input.Reason)
+	return *input
+}
+
+// ToStoreParams converts MaintenanceWindowStore to services.MaintenanceWindowStoreParams
+func (input *MaintenanceWindowStore) ToStoreParams(userID entities.UserID, phone *entities.Phone) *services.MaintenanceWindowStoreParams {
+	return &services.MaintenanceWindowStoreParams{
+		UserID:     userID,
+		Phone:      phone,
+		Reason:     input.sanitizeStringPointer(input.Reason),
+		StartsAt:   *input.getTime(input.StartsAt),
+		EndsAt:     *input.getTime(input.EndsAt),
+		Recurrence: entities.MaintenanceWindowRecurrence(input.Recurrence),
+	}
+}
@@ -0,0 +1,32 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// MessageBatchGet is the payload for fetching multiple entities.Message by ID
+type MessageBatchGet struct {
+	request
+	IDs []string `json:"ids" example:"32343a19-da5e-4b1b-a767-3298a73703cb,153554b5-ae44-44a0-8f4f-7bbac5657ad4"`
+}
+
+// Sanitize sets defaults to MessageBatchGet
+func (input *MessageBatchGet) Sanitize() MessageBatchGet {
+	var ids []string
+	for _, id := range input.IDs {
+		ids = append(ids, strings.TrimSpace(id))
+	}
+	input.IDs = input.removeStringDuplicates(ids)
+	return *input
+}
+
+// MessageIDs returns the IDs of the messages as uuid.UUID
+func (input *MessageBatchGet) MessageIDs() []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(input.IDs))
+	for _, id := range input.IDs {
+		ids = append(ids, uuid.MustParse(id))
+	}
+	return ids
+}
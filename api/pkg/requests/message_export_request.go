@@ -0,0 +1,62 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// MessageExport is the payload for exporting entities.Message
+type MessageExport struct {
+	request
+	Format        string   `json:"format" query:"format" example:"csv"`
+	Owners        []string `json:"owners" query:"owners" example:"+18005550199"`
+	Types         []string `json:"types" query:"types" example:"mobile-terminated"`
+	Statuses      []string `json:"statuses" query:"statuses" example:"delivered"`
+	CreatedAfter  string   `json:"created_after" query:"created_after" example:"2022-06-05T14:26:02+03:00"`
+	CreatedBefore string   `json:"created_before" query:"created_before" example:"2022-07-05T14:26:02+03:00"`
+}
+
+// Sanitize sets defaults to MessageExport
+func (input *MessageExport) Sanitize() MessageExport {
+	input.Format = strings.ToLower(strings.TrimSpace(input.Format))
+	if input.Format == "" {
+		input.Format = entities.MessageExportFormatCSV.String()
+	}
+
+	var owners []string
+	for _, owner := range input.Owners {
+		owners = append(owners, input.sanitizeAddress(owner))
+	}
+	input.Owners = input.removeStringDuplicates(owners)
+
+	input.CreatedAfter = strings.TrimSpace(input.CreatedAfter)
+	input.CreatedBefore = strings.TrimSpace(input.CreatedBefore)
+
+	return *input
+}
+
+// ToExportParams converts MessageExport to services.MessageExportParams
+func (input *MessageExport) ToExportParams(user entities.AuthUser) *services.MessageExportParams {
+	var types []entities.MessageType
+	for _, t := range input.Types {
+		types = append(types, entities.MessageType(t))
+	}
+
+	var statuses []entities.MessageStatus
+	for _, s := range input.Statuses {
+		statuses = append(statuses, entities.MessageStatus(s))
+	}
+
+	return &services.MessageExportParams{
+		UserID:        user.ID,
+		Email:         user.Email,
+		Format:        entities.MessageExportFormat(input.Format),
+		Owners:        input.Owners,
+		Types:         types,
+		Statuses:      statuses,
+		CreatedAfter:  input.getTime(input.CreatedAfter),
+		CreatedBefore: input.getTime(input.CreatedBefore),
+	}
+}
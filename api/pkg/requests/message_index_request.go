@@ -11,13 +11,18 @@ import (
 	"github.com/NdoleStudio/httpsms/pkg/services"
 )
 
-// MessageIndex is the payload fetching entities.Message sent between 2 numbers
+// MessageIndex is the payload fetching entities.Message sent between 2 numbers or with the same request ID
 type MessageIndex struct {
-	Skip    string `json:"skip" query:"skip"`
-	Contact string `json:"contact" query:"contact"`
-	Owner   string `json:"owner" query:"owner"`
-	Query   string `json:"query" query:"query"`
-	Limit   string `json:"limit" query:"limit"`
+	request
+	Skip          string `json:"skip" query:"skip"`
+	Contact       string `json:"contact" query:"contact"`
+	Owner         string `json:"owner" query:"owner"`
+	RequestID     string `json:"request_id" query:"request_id"`
+	Query         string `json:"query" query:"query"`
+	Limit         string `json:"limit" query:"limit"`
+	Cursor        string `json:"cursor" query:"cursor"`
+	CreatedAfter  string `json:"created_after" query:"created_after"`
+	CreatedBefore string `json:"created_before" query:"created_before"`
 }
 
 // Sanitize sets defaults to MessageOutstanding
@@ -27,6 +32,10 @@ func (input *MessageIndex) Sanitize() MessageIndex {
 	}
 
 	input.Query = strings.TrimSpace(input.Query)
+	input.RequestID = strings.TrimSpace(input.RequestID)
+	input.Cursor = strings.TrimSpace(input.Cursor)
+	input.CreatedAfter = strings.TrimSpace(input.CreatedAfter)
+	input.CreatedBefore = strings.TrimSpace(input.CreatedBefore)
 
 	input.Owner = input.sanitizeAddress(input.Owner)
 	input.Contact = input.sanitizeAddress(input.Contact)
@@ -43,13 +52,17 @@ func (input *MessageIndex) Sanitize() MessageIndex {
 func (input *MessageIndex) ToGetParams(userID entities.UserID) services.MessageGetParams {
 	return services.MessageGetParams{
 		IndexParams: repositories.IndexParams{
-			Skip:  input.getInt(input.Skip),
-			Query: input.Query,
-			Limit: input.getInt(input.Limit),
+			Skip:          input.getInt(input.Skip),
+			Query:         input.Query,
+			Limit:         input.getInt(input.Limit),
+			Cursor:        input.getCursor(input.Cursor),
+			CreatedAfter:  input.getTime(input.CreatedAfter),
+			CreatedBefore: input.getTime(input.CreatedBefore),
 		},
-		UserID:  userID,
-		Owner:   input.Owner,
-		Contact: input.Contact,
+		UserID:    userID,
+		Owner:     input.Owner,
+		Contact:   input.Contact,
+		RequestID: input.RequestID,
 	}
 }
 
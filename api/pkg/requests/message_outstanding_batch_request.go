@@ -0,0 +1,36 @@
+package requests
+
+import (
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// MessageOutstandingBatch is the payload for fetching many outstanding entities.Message of a phone
+type MessageOutstandingBatch struct {
+	request
+	Owner string `json:"owner" query:"owner"`
+	Limit string `json:"limit" query:"limit"`
+}
+
+// Sanitize sets defaults to MessageOutstandingBatch
+func (input *MessageOutstandingBatch) Sanitize() MessageOutstandingBatch {
+	input.Owner = input.sanitizeAddress(input.Owner)
+	if strings.TrimSpace(input.Limit) == "" {
+		input.Limit = "10"
+	}
+	return *input
+}
+
+// ToGetOutstandingBatchParams converts MessageOutstandingBatch into services.MessageGetOutstandingBatchParams
+func (input *MessageOutstandingBatch) ToGetOutstandingBatchParams(source string, userID entities.UserID, timestamp time.Time) services.MessageGetOutstandingBatchParams {
+	return services.MessageGetOutstandingBatchParams{
+		Source:    source,
+		UserID:    userID,
+		Owner:     input.Owner,
+		Limit:     input.getInt(input.Limit),
+		Timestamp: timestamp,
+	}
+}
@@ -0,0 +1,35 @@
+package requests
+
+import (
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// MessagePollOutstanding is the payload for long-polling the outstanding entities.Message of a phone
+type MessagePollOutstanding struct {
+	request
+	Owner   string `json:"owner" query:"owner"`
+	Timeout string `json:"timeout" query:"timeout"`
+}
+
+// Sanitize sets defaults to MessagePollOutstanding
+func (input *MessagePollOutstanding) Sanitize() MessagePollOutstanding {
+	input.Owner = input.sanitizeAddress(input.Owner)
+	if strings.TrimSpace(input.Timeout) == "" {
+		input.Timeout = "30"
+	}
+	return *input
+}
+
+// ToPollOutstandingParams converts MessagePollOutstanding into services.MessagePollOutstandingParams
+func (input *MessagePollOutstanding) ToPollOutstandingParams(source string, userID entities.UserID) services.MessagePollOutstandingParams {
+	return services.MessagePollOutstandingParams{
+		Source:  source,
+		UserID:  userID,
+		Owner:   input.Owner,
+		Timeout: time.Duration(input.getInt(input.Timeout)) * time.Second,
+	}
+}
@@ -21,6 +21,9 @@ type MessageSearch struct {
 	SortBy         string   `json:"sort_by" query:"sort_by"`
 	SortDescending bool     `json:"sort_descending" query:"sort_descending"`
 	Limit          string   `json:"limit" query:"limit"`
+	Cursor         string   `json:"cursor" query:"cursor"`
+	CreatedAfter   string   `json:"created_after" query:"created_after"`
+	CreatedBefore  string   `json:"created_before" query:"created_before"`
 }
 
 // Sanitize sets defaults to MessageSearch
@@ -30,6 +33,10 @@ func (input *MessageSearch) Sanitize() MessageSearch {
 	}
 
 	input.Query = strings.TrimSpace(input.Query)
+	input.SortBy = strings.TrimSpace(input.SortBy)
+	input.Cursor = strings.TrimSpace(input.Cursor)
+	input.CreatedAfter = strings.TrimSpace(input.CreatedAfter)
+	input.CreatedBefore = strings.TrimSpace(input.CreatedBefore)
 
 	input.Skip = strings.TrimSpace(input.Skip)
 	if input.Skip == "" {
@@ -58,6 +65,9 @@ func (input *MessageSearch) ToSearchParams(userID entities.UserID) *services.Mes
 			SortBy:         input.SortBy,
 			SortDescending: input.SortDescending,
 			Limit:          input.getInt(input.Limit),
+			Cursor:         input.getCursor(input.Cursor),
+			CreatedAfter:   input.getTime(input.CreatedAfter),
+			CreatedBefore:  input.getTime(input.CreatedBefore),
 		},
 		UserID:   userID,
 		Owners:   input.Owners,
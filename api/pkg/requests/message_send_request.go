@@ -24,6 +24,8 @@ type MessageSend struct {
 	RequestID string `json:"request_id" example:"153554b5-ae44-44a0-8f4f-7bbac5657ad4" validate:"optional"`
 	// SendAt is an optional parameter used to schedule a message to be sent at a later time
 	SendAt *time.Time `json:"send_at" example:"2022-06-05T14:26:09.527976+03:00" validate:"optional"`
+	// SIM is an optional parameter used to send the message with a configured SIM of a dual-SIM phone instead of its default SIM
+	SIM string `json:"sim" example:"SIM1" validate:"optional"`
 }
 
 // Sanitize sets defaults to MessageReceive
@@ -31,12 +33,20 @@ func (input *MessageSend) Sanitize() MessageSend {
 	input.To = input.sanitizeAddress(input.To)
 	input.RequestID = strings.TrimSpace(input.RequestID)
 	input.From = input.sanitizeAddress(input.From)
+	input.SIM = strings.ToUpper(strings.TrimSpace(input.SIM))
 	return *input
 }
 
 // ToMessageSendParams converts MessageSend to services.MessageSendParams
 func (input *MessageSend) ToMessageSendParams(userID entities.UserID, source string) services.MessageSendParams {
 	from, _ := phonenumbers.Parse(input.From, phonenumbers.UNKNOWN_REGION)
+
+	var sim *entities.SIM
+	if input.SIM != "" {
+		value := entities.SIM(input.SIM)
+		sim = &value
+	}
+
 	return services.MessageSendParams{
 		Source:            source,
 		Owner:             from,
@@ -47,5 +57,6 @@ func (input *MessageSend) ToMessageSendParams(userID entities.UserID, source str
 		RequestReceivedAt: time.Now().UTC(),
 		Contact:           input.sanitizeAddress(input.To),
 		Content:           input.Content,
+		SIM:               sim,
 	}
 }
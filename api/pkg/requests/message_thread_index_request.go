@@ -13,11 +13,14 @@ import (
 // MessageThreadIndex is the payload fetching entities.MessageThread sent between 2 numbers
 type MessageThreadIndex struct {
 	request
-	IsArchived string `json:"is_archived" query:"is_archived" example:"false"`
-	Skip       string `json:"skip" query:"skip"`
-	Query      string `json:"query" query:"query"`
-	Limit      string `json:"limit" query:"limit"`
-	Owner      string `json:"owner" query:"owner"`
+	IsArchived    string `json:"is_archived" query:"is_archived" example:"false"`
+	Skip          string `json:"skip" query:"skip"`
+	Query         string `json:"query" query:"query"`
+	Limit         string `json:"limit" query:"limit"`
+	Owner         string `json:"owner" query:"owner"`
+	Cursor        string `json:"cursor" query:"cursor"`
+	CreatedAfter  string `json:"created_after" query:"created_after"`
+	CreatedBefore string `json:"created_before" query:"created_before"`
 }
 
 // Sanitize sets defaults to MessageOutstanding
@@ -33,6 +36,9 @@ func (input *MessageThreadIndex) Sanitize() MessageThreadIndex {
 	input.IsArchived = input.sanitizeBool(input.IsArchived)
 	input.Query = strings.TrimSpace(input.Query)
 	input.Owner = input.sanitizeAddress(input.Owner)
+	input.Cursor = strings.TrimSpace(input.Cursor)
+	input.CreatedAfter = strings.TrimSpace(input.CreatedAfter)
+	input.CreatedBefore = strings.TrimSpace(input.CreatedBefore)
 
 	input.Skip = strings.TrimSpace(input.Skip)
 	if input.Skip == "" {
@@ -46,9 +52,12 @@ func (input *MessageThreadIndex) Sanitize() MessageThreadIndex {
 func (input *MessageThreadIndex) ToGetParams(userID entities.UserID) services.MessageThreadGetParams {
 	return services.MessageThreadGetParams{
 		IndexParams: repositories.IndexParams{
-			Skip:  input.getInt(input.Skip),
-			Query: input.Query,
-			Limit: input.getInt(input.Limit),
+			Skip:          input.getInt(input.Skip),
+			Query:         input.Query,
+			Limit:         input.getInt(input.Limit),
+			Cursor:        input.getCursor(input.Cursor),
+			CreatedAfter:  input.getTime(input.CreatedAfter),
+			CreatedBefore: input.getTime(input.CreatedBefore),
 		},
 		UserID:     userID,
 		IsArchived: input.getBool(input.IsArchived),
@@ -0,0 +1,35 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// OrganizationInvite is the payload for inviting a user to an entities.Organization
+type OrganizationInvite struct {
+	request
+	Email          string `json:"email" example:"name@email.com"`
+	Role           string `json:"role" example:"agent"`
+	OrganizationID string `json:"organizationID" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize sets defaults to OrganizationInvite
+func (input *OrganizationInvite) Sanitize() OrganizationInvite {
+	input.Email = strings.ToLower(strings.TrimSpace(input.Email))
+	input.Role = strings.ToLower(strings.TrimSpace(input.Role))
+	return *input
+}
+
+// ToInviteParams converts OrganizationInvite to services.OrganizationInviteParams
+func (input *OrganizationInvite) ToInviteParams(user entities.AuthUser) *services.OrganizationInviteParams {
+	return &services.OrganizationInviteParams{
+		OrganizationID: uuid.MustParse(input.OrganizationID),
+		Email:          input.Email,
+		Role:           entities.OrganizationRole(input.Role),
+		InviterID:      user.ID,
+		InviterEmail:   user.Email,
+	}
+}
@@ -0,0 +1,38 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+)
+
+// OrganizationMemberIndex is the payload for fetching entities.OrganizationMember of an organization
+type OrganizationMemberIndex struct {
+	request
+	Skip           string `json:"skip" query:"skip"`
+	Query          string `json:"query" query:"query"`
+	Limit          string `json:"limit" query:"limit"`
+	OrganizationID string `json:"organizationID" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize sets defaults to OrganizationMemberIndex
+func (input *OrganizationMemberIndex) Sanitize() OrganizationMemberIndex {
+	if strings.TrimSpace(input.Limit) == "" {
+		input.Limit = "20"
+	}
+	input.Query = strings.TrimSpace(input.Query)
+	input.Skip = strings.TrimSpace(input.Skip)
+	if input.Skip == "" {
+		input.Skip = "0"
+	}
+	return *input
+}
+
+// ToIndexParams converts OrganizationMemberIndex to repositories.IndexParams
+func (input *OrganizationMemberIndex) ToIndexParams() repositories.IndexParams {
+	return repositories.IndexParams{
+		Skip:  input.getInt(input.Skip),
+		Query: input.Query,
+		Limit: input.getInt(input.Limit),
+	}
+}
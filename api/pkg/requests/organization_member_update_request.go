@@ -0,0 +1,48 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// OrganizationMemberUpdate is the payload for updating an entities.OrganizationMember
+type OrganizationMemberUpdate struct {
+	request
+	Role           string   `json:"role" example:"agent"`
+	PhoneIDs       []string `json:"phone_ids" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	OrganizationID string   `json:"organizationID" swaggerignore:"true"` // used internally for validation
+	MemberID       string   `json:"memberID" swaggerignore:"true"`       // used internally for validation
+}
+
+// Sanitize sets defaults to OrganizationMemberUpdate
+func (input *OrganizationMemberUpdate) Sanitize() OrganizationMemberUpdate {
+	input.Role = strings.ToLower(strings.TrimSpace(input.Role))
+
+	var phoneIDs []string
+	for _, phoneID := range input.PhoneIDs {
+		if strings.TrimSpace(phoneID) != "" {
+			phoneIDs = append(phoneIDs, strings.TrimSpace(phoneID))
+		}
+	}
+	input.PhoneIDs = input.removeStringDuplicates(phoneIDs)
+
+	return *input
+}
+
+// ToUpdateParams converts OrganizationMemberUpdate to services.OrganizationMemberUpdateParams
+func (input *OrganizationMemberUpdate) ToUpdateParams() *services.OrganizationMemberUpdateParams {
+	phoneIDs := make([]uuid.UUID, 0, len(input.PhoneIDs))
+	for _, phoneID := range input.PhoneIDs {
+		phoneIDs = append(phoneIDs, uuid.MustParse(phoneID))
+	}
+
+	return &services.OrganizationMemberUpdateParams{
+		OrganizationID: uuid.MustParse(input.OrganizationID),
+		MemberID:       uuid.MustParse(input.MemberID),
+		Role:           entities.OrganizationRole(input.Role),
+		PhoneIDs:       phoneIDs,
+	}
+}
@@ -0,0 +1,29 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// OrganizationStore is the payload for creating a new entities.Organization
+type OrganizationStore struct {
+	request
+	Name string `json:"name" example:"Acme Inc"`
+}
+
+// Sanitize sets defaults to OrganizationStore
+func (input *OrganizationStore) Sanitize() OrganizationStore {
+	input.Name = strings.TrimSpace(input.Name)
+	return *input
+}
+
+// ToStoreParams converts OrganizationStore to services.OrganizationStoreParams
+func (input *OrganizationStore) ToStoreParams(user entities.AuthUser) *services.OrganizationStoreParams {
+	return &services.OrganizationStoreParams{
+		UserID: user.ID,
+		Email:  user.Email,
+		Name:   input.Name,
+	}
+}
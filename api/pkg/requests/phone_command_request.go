@@ -0,0 +1,139 @@
+package requests
+
+import (
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// PhoneCommandStore is the payload for creating a new entities.PhoneCommand
+type PhoneCommandStore struct {
+	request
+	PhoneID string `json:"phoneID" swaggerignore:"true"` // used internally for validation
+
+	// Type is one of refresh_config, force_heartbeat, resync_outstanding, upload_logs or switch_sim
+	Type string `json:"type" example:"force_heartbeat"`
+
+	// SIM is the SIM card which the phone switches to for the switch_sim command
+	SIM string `json:"sim" example:"SIM2"`
+}
+
+// Sanitize sets defaults to PhoneCommandStore
+func (input *PhoneCommandStore) Sanitize() PhoneCommandStore {
+	input.PhoneID = strings.TrimSpace(input.PhoneID)
+	input.Type = strings.ToLower(strings.TrimSpace(input.Type))
+	if input.SIM != "" {
+		input.SIM = input.sanitizeSIM(input.SIM)
+	}
+	return *input
+}
+
+// ToStoreParams converts PhoneCommandStore to services.PhoneCommandStoreParams
+func (input *PhoneCommandStore) ToStoreParams(userID entities.UserID, phone *entities.Phone, source string) *services.PhoneCommandStoreParams {
+	var sim *entities.SIM
+	if input.Type == entities.PhoneCommandTypeSwitchSIM.String() {
+		value := entities.SIM(input.SIM)
+		sim = &value
+	}
+
+	return &services.PhoneCommandStoreParams{
+		Source: source,
+		UserID: userID,
+		Phone:  phone,
+		Type:   entities.PhoneCommandType(input.Type),
+		SIM:    sim,
+	}
+}
+
+// PhoneCommandIndex is the payload for fetching the entities.PhoneCommand of a phone
+type PhoneCommandIndex struct {
+	request
+	PhoneID string `json:"phoneID" swaggerignore:"true"` // used internally for validation
+
+	// Status filters the commands by a comma separated list of statuses e.g. pending,delivered
+	Status string `json:"status" query:"status"`
+	Skip   string `json:"skip" query:"skip"`
+	Limit  string `json:"limit" query:"limit"`
+}
+
+// Sanitize sets defaults to PhoneCommandIndex
+func (input *PhoneCommandIndex) Sanitize() PhoneCommandIndex {
+	input.PhoneID = strings.TrimSpace(input.PhoneID)
+	input.Status = strings.ToLower(strings.TrimSpace(input.Status))
+	if strings.TrimSpace(input.Limit) == "" {
+		input.Limit = "20"
+	}
+	input.Skip = strings.TrimSpace(input.Skip)
+	if input.Skip == "" {
+		input.Skip = "0"
+	}
+	return *input
+}
+
+// Statuses returns the statuses in the status filter
+func (input *PhoneCommandIndex) Statuses() []string {
+	var statuses []string
+	for _, status := range strings.Split(input.Status, ",") {
+		if status = strings.TrimSpace(status); status != "" {
+			statuses = append(statuses, status)
+		}
+	}
+	return input.removeStringDuplicates(statuses)
+}
+
+// ToIndexParams converts PhoneCommandIndex to services.PhoneCommandIndexParams
+func (input *PhoneCommandIndex) ToIndexParams(userID entities.UserID, phoneID uuid.UUID) *services.PhoneCommandIndexParams {
+	var statuses []entities.PhoneCommandStatus
+	for _, status := range input.Statuses() {
+		statuses = append(statuses, entities.PhoneCommandStatus(status))
+	}
+
+	return &services.PhoneCommandIndexParams{
+		UserID:   userID,
+		PhoneID:  phoneID,
+		Statuses: statuses,
+		IndexParams: repositories.IndexParams{
+			Skip:  input.getInt(input.Skip),
+			Limit: input.getInt(input.Limit),
+		},
+	}
+}
+
+// PhoneCommandAck is the payload for acknowledging an entities.PhoneCommand
+type PhoneCommandAck struct {
+	request
+	CommandID string `json:"commandID" swaggerignore:"true"` // used internally for validation
+
+	// Status is acked when the phone executed the command or failed when it could not execute it
+	Status       string `json:"status" example:"acked"`
+	ErrorMessage string `json:"error_message" example:"cannot upload logs"`
+	Timestamp    string `json:"timestamp" example:"2022-06-05T14:26:09.527976+03:00"`
+}
+
+// Sanitize sets defaults to PhoneCommandAck
+func (input *PhoneCommandAck) Sanitize() PhoneCommandAck {
+	input.CommandID = strings.TrimSpace(input.CommandID)
+	input.Status = strings.ToLower(strings.TrimSpace(input.Status))
+	input.ErrorMessage = strings.TrimSpace(input.ErrorMessage)
+	input.Timestamp = strings.TrimSpace(input.Timestamp)
+	if input.Timestamp == "" {
+		input.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	}
+	return *input
+}
+
+// ToAckParams converts PhoneCommandAck to services.PhoneCommandAckParams
+func (input *PhoneCommandAck) ToAckParams(userID entities.UserID, phoneID uuid.UUID) *services.PhoneCommandAckParams {
+	return &services.PhoneCommandAckParams{
+		UserID:       userID,
+		PhoneID:      phoneID,
+		CommandID:    uuid.MustParse(input.CommandID),
+		Status:       entities.PhoneCommandStatus(input.Status),
+		ErrorMessage: input.ErrorMessage,
+		Timestamp:    *input.getTime(input.Timestamp),
+	}
+}
@@ -0,0 +1,49 @@
+package requests
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// PhoneConfigUpdate is the payload for updating the entities.PhoneConfig of a phone
+type PhoneConfigUpdate struct {
+	request
+	PhoneID string `json:"phoneID" swaggerignore:"true"` // used internally for validation
+
+	// ReceiveSIMs are the SIM cards which forward received messages to the API
+	ReceiveSIMs []string `json:"receive_sims" example:"SIM1,SIM2"`
+
+	// DeliveryReportsEnabled determines if the phone requests delivery reports for sent messages
+	DeliveryReportsEnabled *bool `json:"delivery_reports_enabled" example:"true"`
+
+	// EncryptionEnabled determines if the phone encrypts and decrypts the content of messages
+	EncryptionEnabled *bool `json:"encryption_enabled" example:"false"`
+}
+
+// Sanitize sets defaults to PhoneConfigUpdate
+func (input *PhoneConfigUpdate) Sanitize() PhoneConfigUpdate {
+	input.PhoneID = strings.TrimSpace(input.PhoneID)
+
+	var sims []string
+	for _, sim := range input.ReceiveSIMs {
+		sims = append(sims, strings.ToUpper(strings.TrimSpace(sim)))
+	}
+	input.ReceiveSIMs = input.removeStringDuplicates(sims)
+	sort.Strings(input.ReceiveSIMs)
+
+	return *input
+}
+
+// ToUpdateParams converts PhoneConfigUpdate to services.PhoneConfigUpdateParams
+func (input *PhoneConfigUpdate) ToUpdateParams(phone *entities.Phone, source string) *services.PhoneConfigUpdateParams {
+	return &services.PhoneConfigUpdateParams{
+		Source:                 source,
+		Phone:                  phone,
+		ReceiveSIMs:            input.ReceiveSIMs,
+		DeliveryReportsEnabled: input.DeliveryReportsEnabled,
+		EncryptionEnabled:      input.EncryptionEnabled,
+	}
+}
@@ -4,12 +4,21 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/nyaruka/phonenumbers"
 
 	"github.com/NdoleStudio/httpsms/pkg/entities"
 	"github.com/NdoleStudio/httpsms/pkg/services"
 )
 
+// PhoneUpsertSIM is the configuration of a SIM slot of a dual-SIM phone
+type PhoneUpsertSIM struct {
+	SIM               string `json:"sim" example:"SIM2"`
+	PhoneNumber       string `json:"phone_number" example:"+18005550100"`
+	MessagesPerMinute uint   `json:"messages_per_minute" example:"10"`
+	MaxMessagesPerDay uint   `json:"max_messages_per_day" example:"200"`
+}
+
 // PhoneUpsert is the payload for updating a phone
 type PhoneUpsert struct {
 	request
@@ -24,20 +33,54 @@ type PhoneUpsert struct {
 
 	FcmToken string `json:"fcm_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJzd....."`
 
+	// PushEndpoint is the UnifiedPush endpoint URL of the phone when the delivery_mode is unifiedpush
+	PushEndpoint string `json:"push_endpoint" example:"https://ntfy.sh/upAbCdEfGhIjKl?up=1"`
+
 	MissedCallAutoReply *string `json:"missed_call_auto_reply" example:"e.g. This phone cannot receive calls. Please send an SMS instead."`
 
 	// SIM is the SIM slot of the phone in case the phone has more than 1 SIM slot
 	SIM string `json:"sim" example:"SIM1"`
+
+	// DeliveryMode is fcm or unifiedpush when the phone is notified with a push notification or poll when it long-polls for outgoing messages
+	DeliveryMode string `json:"delivery_mode" example:"fcm"`
+
+	// FailoverPhoneID is the ID of the phone which sends the queued messages while this phone is offline. Use an empty string to remove the failover phone.
+	FailoverPhoneID *string `json:"failover_phone_id" example:"32343a19-da5e-4b1b-a767-3298a73703ca"`
+
+	// CoalescePushes is true when the app fetches outstanding messages in batches so that one push is sent for many messages
+	CoalescePushes *bool `json:"coalesce_pushes" example:"false"`
+
+	// MaxMessagesPerHour is the number of messages which the phone sends in an hour before messages are deferred. Use 0 to remove the cap.
+	MaxMessagesPerHour *uint `json:"max_messages_per_hour" example:"100"`
+
+	// MaxMessagesPerDay is the number of messages which the phone sends in a day before messages are deferred. Use 0 to remove the cap.
+	MaxMessagesPerDay *uint `json:"max_messages_per_day" example:"1000"`
+
+	// SIMs configures the SIM slots which are rate limited independently on a dual-SIM phone. Use an empty list to remove the configuration.
+	SIMs []PhoneUpsertSIM `json:"sims"`
 }
 
 // Sanitize sets defaults to MessageOutstanding
 func (input *PhoneUpsert) Sanitize() PhoneUpsert {
 	input.FcmToken = strings.TrimSpace(input.FcmToken)
+	input.PushEndpoint = strings.TrimSpace(input.PushEndpoint)
 	input.PhoneNumber = input.sanitizeAddress(input.PhoneNumber)
 	input.SIM = input.sanitizeSIM(input.SIM)
+	input.DeliveryMode = strings.ToLower(strings.TrimSpace(input.DeliveryMode))
 	if input.MissedCallAutoReply != nil {
 		input.MissedCallAutoReply = input.sanitizeStringPointer(*input.MissedCallAutoReply)
 	}
+	if input.FailoverPhoneID != nil {
+		failoverPhoneID := strings.TrimSpace(*input.FailoverPhoneID)
+		input.FailoverPhoneID = &failoverPhoneID
+	}
+	for i := range input.SIMs {
+		input.SIMs[i].SIM = strings.ToUpper(strings.TrimSpace(input.SIMs[i].SIM))
+		input.SIMs[i].PhoneNumber = strings.TrimSpace(input.SIMs[i].PhoneNumber)
+		if input.SIMs[i].PhoneNumber != "" {
+			input.SIMs[i].PhoneNumber = input.sanitizeAddress(input.SIMs[i].PhoneNumber)
+		}
+	}
 	return *input
 }
 
@@ -57,6 +100,12 @@ func (input *PhoneUpsert) ToUpsertParams(user entities.AuthUser, source string)
 		fcmToken = &input.FcmToken
 	}
 
+	// ignore default
+	var pushEndpoint *string
+	if input.PushEndpoint != "" {
+		pushEndpoint = &input.PushEndpoint
+	}
+
 	// ignore default
 	var timeout *time.Duration
 	if input.MessageExpirationSeconds != 0 {
@@ -69,6 +118,33 @@ func (input *PhoneUpsert) ToUpsertParams(user entities.AuthUser, source string)
 		maxSendAttempts = &input.MaxSendAttempts
 	}
 
+	var deliveryMode *entities.PhoneDeliveryMode
+	if input.DeliveryMode != "" {
+		mode := entities.PhoneDeliveryMode(input.DeliveryMode)
+		deliveryMode = &mode
+	}
+
+	// uuid.Nil removes the failover phone
+	var failoverPhoneID *uuid.UUID
+	if input.FailoverPhoneID != nil {
+		id, _ := uuid.Parse(*input.FailoverPhoneID)
+		failoverPhoneID = &id
+	}
+
+	// an empty list removes the SIMs while nil keeps them
+	var sims []entities.PhoneSIM
+	if input.SIMs != nil {
+		sims = make([]entities.PhoneSIM, 0, len(input.SIMs))
+		for _, sim := range input.SIMs {
+			sims = append(sims, entities.PhoneSIM{
+				SIM:               entities.SIM(sim.SIM),
+				PhoneNumber:       input.sanitizeStringPointer(sim.PhoneNumber),
+				MessagesPerMinute: sim.MessagesPerMinute,
+				MaxMessagesPerDay: sim.MaxMessagesPerDay,
+			})
+		}
+	}
+
 	return &services.PhoneUpsertParams{
 		Source:                    source,
 		PhoneNumber:               phone,
@@ -77,7 +153,14 @@ func (input *PhoneUpsert) ToUpsertParams(user entities.AuthUser, source string)
 		MessageExpirationDuration: timeout,
 		MaxSendAttempts:           maxSendAttempts,
 		FcmToken:                  fcmToken,
+		PushEndpoint:              pushEndpoint,
 		UserID:                    user.ID,
 		SIM:                       entities.SIM(input.SIM),
+		DeliveryMode:              deliveryMode,
+		CoalescePushes:            input.CoalescePushes,
+		FailoverPhoneID:           failoverPhoneID,
+		SIMs:                      sims,
+		MaxMessagesPerHour:        input.MaxMessagesPerHour,
+		MaxMessagesPerDay:         input.MaxMessagesPerDay,
 	}
 }
@@ -0,0 +1,48 @@
+package requests
+
+import (
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// phoneUptimeDefaultRange is the range of the uptime report when the from timestamp is not set
+const phoneUptimeDefaultRange = 7 * 24 * time.Hour
+
+// PhoneUptime is the payload for fetching the uptime report of a phone
+type PhoneUptime struct {
+	request
+	PhoneID string `json:"phoneID" swaggerignore:"true"` // used internally for validation
+	From    string `json:"from" query:"from"`
+	To      string `json:"to" query:"to"`
+}
+
+// Sanitize sets defaults to PhoneUptime
+func (input *PhoneUptime) Sanitize() PhoneUptime {
+	input.PhoneID = strings.TrimSpace(input.PhoneID)
+
+	input.To = strings.TrimSpace(input.To)
+	if input.To == "" {
+		input.To = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	input.From = strings.TrimSpace(input.From)
+	if to := input.getTime(input.To); input.From == "" && to != nil {
+		input.From = to.Add(-phoneUptimeDefaultRange).Format(time.RFC3339)
+	}
+
+	return *input
+}
+
+// ToUptimeParams converts PhoneUptime to services.PhoneUptimeParams
+func (input *PhoneUptime) ToUptimeParams(userID entities.UserID) *services.PhoneUptimeParams {
+	return &services.PhoneUptimeParams{
+		UserID:  userID,
+		PhoneID: uuid.MustParse(input.PhoneID),
+		From:    *input.getTime(input.From),
+		To:      *input.getTime(input.To),
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
 
 	"github.com/nyaruka/phonenumbers"
 )
@@ -125,6 +127,24 @@ func (input *request) getInt(value string) int {
 	return val
 }
 
+// getTime parses an RFC3339 timestamp
+func (input *request) getTime(value string) *time.Time {
+	timestamp, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	return &timestamp
+}
+
+// getCursor decodes an opaque repositories.Cursor
+func (input *request) getCursor(value string) *repositories.Cursor {
+	cursor, err := repositories.ParseCursor(value)
+	if err != nil {
+		return nil
+	}
+	return cursor
+}
+
 func (input *request) isDigits(value string) bool {
 	for _, c := range value {
 		if !unicode.IsDigit(c) {
@@ -0,0 +1,26 @@
+package requests
+
+import (
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// UserRetentionUpdate is the payload for updating the data retention policy of a user.
+// The data is kept forever when the number of days is null.
+type UserRetentionUpdate struct {
+	request
+	MessageRetentionDays      *uint `json:"message_retention_days" example:"90"`
+	HeartbeatRetentionDays    *uint `json:"heartbeat_retention_days" example:"30"`
+	NotificationRetentionDays *uint `json:"notification_retention_days" example:"7"`
+}
+
+// ToUserRetentionUpdateParams converts UserRetentionUpdate to services.UserRetentionUpdateParams
+func (input *UserRetentionUpdate) ToUserRetentionUpdateParams(source string, userID entities.UserID) *services.UserRetentionUpdateParams {
+	return &services.UserRetentionUpdateParams{
+		Source:                    source,
+		UserID:                    userID,
+		MessageRetentionDays:      input.MessageRetentionDays,
+		HeartbeatRetentionDays:    input.HeartbeatRetentionDays,
+		NotificationRetentionDays: input.NotificationRetentionDays,
+	}
+}
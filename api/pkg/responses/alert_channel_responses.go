@@ -0,0 +1,15 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// AlertChannelResponse is the payload containing entities.AlertChannel
+type AlertChannelResponse struct {
+	response
+	Data entities.AlertChannel `json:"data"`
+}
+
+// AlertChannelsResponse is the payload containing []entities.AlertChannel
+type AlertChannelsResponse struct {
+	response
+	Data []entities.AlertChannel `json:"data"`
+}
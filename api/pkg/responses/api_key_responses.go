@@ -0,0 +1,15 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// APIKeyResponse is the payload containing entities.APIKey
+type APIKeyResponse struct {
+	response
+	Data entities.APIKey `json:"data"`
+}
+
+// APIKeysResponse is the payload containing []entities.APIKey
+type APIKeysResponse struct {
+	response
+	Data []entities.APIKey `json:"data"`
+}
@@ -5,7 +5,14 @@ import "github.com/NdoleStudio/httpsms/pkg/entities"
 // HeartbeatsResponse is the payload containing []entities.Heartbeat
 type HeartbeatsResponse struct {
 	response
-	Data []entities.Heartbeat `json:"data"`
+	Data       []entities.Heartbeat `json:"data"`
+	NextCursor *string              `json:"next_cursor" example:"MjAyMi0wNi0wNVQxNDoyNjowOS41Mjc5NzZafDMyMzQzYTE5LWRhNWUtNGIxYi1hNzY3LTMyOThhNzM3MDNjYQ"`
+}
+
+// HeartbeatMonitorResponse is the payload containing entities.HeartbeatMonitor
+type HeartbeatMonitorResponse struct {
+	response
+	Data entities.HeartbeatMonitor `json:"data"`
 }
 
 // HeartbeatResponse is the payload containing entities.Heartbeat
@@ -0,0 +1,15 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// MaintenanceWindowResponse is the payload containing entities.MaintenanceWindow
+type MaintenanceWindowResponse struct {
+	response
+	Data entities.MaintenanceWindow `json:"data"`
+}
+
+// MaintenanceWindowsResponse is the payload containing []entities.MaintenanceWindow
+type MaintenanceWindowsResponse struct {
+	response
+	Data []entities.MaintenanceWindow `json:"data"`
+}
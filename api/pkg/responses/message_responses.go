@@ -11,5 +11,12 @@ type MessageResponse struct {
 // MessagesResponse is the payload containing []entities.Message
 type MessagesResponse struct {
 	response
-	Data []entities.Message `json:"data"`
+	Data       []entities.Message `json:"data"`
+	NextCursor *string            `json:"next_cursor" example:"MjAyMi0wNi0wNVQxNDoyNjowOS41Mjc5NzZafDMyMzQzYTE5LWRhNWUtNGIxYi1hNzY3LTMyOThhNzM3MDNjYQ"`
+}
+
+// MessageExportResponse is the payload containing an entities.MessageExport
+type MessageExportResponse struct {
+	response
+	Data entities.MessageExport `json:"data"`
 }
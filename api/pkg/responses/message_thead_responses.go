@@ -5,5 +5,6 @@ import "github.com/NdoleStudio/httpsms/pkg/entities"
 // MessageThreadsResponse is the payload containing []entities.MessageThread
 type MessageThreadsResponse struct {
 	response
-	Data []entities.MessageThread `json:"data"`
+	Data       []entities.MessageThread `json:"data"`
+	NextCursor *string                  `json:"next_cursor" example:"MjAyMi0wNi0wNVQxNDoyNjowOS41Mjc5NzZafDMyMzQzYTE5LWRhNWUtNGIxYi1hNzY3LTMyOThhNzM3MDNjYQ"`
 }
@@ -0,0 +1,33 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// OrganizationResponse is the payload containing entities.Organization
+type OrganizationResponse struct {
+	response
+	Data entities.Organization `json:"data"`
+}
+
+// OrganizationsResponse is the payload containing []entities.Organization
+type OrganizationsResponse struct {
+	response
+	Data []entities.Organization `json:"data"`
+}
+
+// OrganizationMemberResponse is the payload containing entities.OrganizationMember
+type OrganizationMemberResponse struct {
+	response
+	Data entities.OrganizationMember `json:"data"`
+}
+
+// OrganizationMembersResponse is the payload containing []entities.OrganizationMember
+type OrganizationMembersResponse struct {
+	response
+	Data []entities.OrganizationMember `json:"data"`
+}
+
+// OrganizationInvitationResponse is the payload containing entities.OrganizationInvitation
+type OrganizationInvitationResponse struct {
+	response
+	Data entities.OrganizationInvitation `json:"data"`
+}
@@ -0,0 +1,15 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// PhoneCommandResponse is the payload containing entities.PhoneCommand
+type PhoneCommandResponse struct {
+	response
+	Data entities.PhoneCommand `json:"data"`
+}
+
+// PhoneCommandsResponse is the payload containing []entities.PhoneCommand
+type PhoneCommandsResponse struct {
+	response
+	Data []entities.PhoneCommand `json:"data"`
+}
@@ -0,0 +1,9 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// PhoneConfigResponse is the payload containing entities.PhoneConfigDocument
+type PhoneConfigResponse struct {
+	response
+	Data entities.PhoneConfigDocument `json:"data"`
+}
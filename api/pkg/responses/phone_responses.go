@@ -13,3 +13,9 @@ type PhoneResponse struct {
 	response
 	Data entities.Phone `json:"data"`
 }
+
+// PhoneUptimeResponse is the payload containing entities.PhoneUptime
+type PhoneUptimeResponse struct {
+	response
+	Data entities.PhoneUptime `json:"data"`
+}
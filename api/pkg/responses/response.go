@@ -17,6 +17,18 @@ type NotFound struct {
 	Message string `json:"message" example:"cannot find message with ID [32343a19-da5e-4b1b-a767-3298a73703ca]"`
 }
 
+// Conflict is the response with status code is 409
+type Conflict struct {
+	Status  string `json:"status" example:"error"`
+	Message string `json:"message" example:"you are already a member of this organization"`
+}
+
+// TooManyRequests is the response with status code is 429
+type TooManyRequests struct {
+	Status  string `json:"status" example:"error"`
+	Message string `json:"message" example:"you have too many concurrent streams, close a stream and try again"`
+}
+
 // BadRequest is the response with status code is 400
 type BadRequest struct {
 	Status  string `json:"status" example:"error"`
@@ -38,6 +50,12 @@ type Unauthorized struct {
 	Data    string `json:"data" example:"Make sure your API key is set in the [X-API-Key] header in the request"`
 }
 
+// Forbidden is the response with status code is 403
+type Forbidden struct {
+	Status  string `json:"status" example:"error"`
+	Message string `json:"message" example:"Forbidden"`
+}
+
 // NoContent is the response when status code is 204
 type NoContent struct {
 	Status  string `json:"status" example:"success"`
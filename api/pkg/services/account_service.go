@@ -0,0 +1,383 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"firebase.google.com/go/auth"
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+const accountExportBatchSize = 500
+
+// AccountService exports and deletes all the data of a user
+type AccountService struct {
+	service
+	logger                     telemetry.Logger
+	tracer                     telemetry.Tracer
+	userService                *UserService
+	marketingService           *MarketingService
+	heartbeatService           *HeartbeatService
+	dispatcher                 *EventDispatcher
+	authClient                 *auth.Client
+	accountRepository          repositories.AccountRepository
+	userRepository             repositories.UserRepository
+	phoneRepository            repositories.PhoneRepository
+	messageRepository          repositories.MessageRepository
+	threadRepository           repositories.MessageThreadRepository
+	heartbeatRepository        repositories.HeartbeatRepository
+	heartbeatMonitorRepository repositories.HeartbeatMonitorRepository
+	webhookRepository          repositories.WebhookRepository
+	discordRepository          repositories.DiscordRepository
+	integration3CXRepository   repositories.Integration3CxRepository
+	billingUsageRepository     repositories.BillingUsageRepository
+}
+
+// NewAccountService creates a new AccountService
+func NewAccountService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	userService *UserService,
+	marketingService *MarketingService,
+	heartbeatService *HeartbeatService,
+	dispatcher *EventDispatcher,
+	authClient *auth.Client,
+	accountRepository repositories.AccountRepository,
+	userRepository repositories.UserRepository,
+	phoneRepository repositories.PhoneRepository,
+	messageRepository repositories.MessageRepository,
+	threadRepository repositories.MessageThreadRepository,
+	heartbeatRepository repositories.HeartbeatRepository,
+	heartbeatMonitorRepository repositories.HeartbeatMonitorRepository,
+	webhookRepository repositories.WebhookRepository,
+	discordRepository repositories.DiscordRepository,
+	integration3CXRepository repositories.Integration3CxRepository,
+	billingUsageRepository repositories.BillingUsageRepository,
+) (s *AccountService) {
+	return &AccountService{
+		logger:                     logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:                     tracer,
+		userService:                userService,
+		marketingService:           marketingService,
+		heartbeatService:           heartbeatService,
+		dispatcher:                 dispatcher,
+		authClient:                 authClient,
+		accountRepository:          accountRepository,
+		userRepository:             userRepository,
+		phoneRepository:            phoneRepository,
+		messageRepository:          messageRepository,
+		threadRepository:           threadRepository,
+		heartbeatRepository:        heartbeatRepository,
+		heartbeatMonitorRepository: heartbeatMonitorRepository,
+		webhookRepository:          webhookRepository,
+		discordRepository:          discordRepository,
+		integration3CXRepository:   integration3CXRepository,
+		billingUsageRepository:     billingUsageRepository,
+	}
+}
+
+// Export writes all the data of a user to the writer as a zip archive.
+// Lists which can be large are written as newline delimited JSON so that they are not loaded in memory at once.
+func (service *AccountService) Export(ctx context.Context, userID entities.UserID, writer io.Writer) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	user, err := service.userRepository.Load(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load user with ID [%s]", userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	phones, err := service.phones(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phones for user with ID [%s]", userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	archive := zip.NewWriter(writer)
+
+	steps := []struct {
+		name  string
+		write func(writer io.Writer) error
+	}{
+		{"user.json", func(writer io.Writer) error { return service.writeJSON(writer, user) }},
+		{"phones.json", func(writer io.Writer) error { return service.writeJSON(writer, phones) }},
+		{"messages.ndjson", func(writer io.Writer) error { return service.exportMessages(ctx, userID, writer) }},
+		{"message_threads.ndjson", func(writer io.Writer) error { return service.exportThreads(ctx, userID, phones, writer) }},
+		{"heartbeats.ndjson", func(writer io.Writer) error { return service.exportHeartbeats(ctx, userID, phones, writer) }},
+		{"webhooks.json", func(writer io.Writer) error { return service.exportWebhooks(ctx, userID, writer) }},
+		{"discord_integrations.json", func(writer io.Writer) error { return service.exportDiscords(ctx, userID, writer) }},
+		{"3cx_integration.json", func(writer io.Writer) error { return service.export3CX(ctx, userID, writer) }},
+		{"billing_usages.json", func(writer io.Writer) error { return service.exportBillingUsages(ctx, userID, writer) }},
+	}
+
+	for _, step := range steps {
+		file, err := archive.Create(step.name)
+		if err != nil {
+			msg := fmt.Sprintf("cannot create file [%s] in the export of user [%s]", step.name, userID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		if err = step.write(file); err != nil {
+			msg := fmt.Sprintf("cannot write file [%s] in the export of user [%s]", step.name, userID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+	}
+
+	if err = archive.Close(); err != nil {
+		msg := fmt.Sprintf("cannot close the zip archive of the export of user [%s]", userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("exported the account of user with ID [%s]", userID))
+	return nil
+}
+
+// Delete removes all the data of a user in a single transaction.
+// The subscription is cancelled first so that a user is never billed for an account which no longer exists.
+// The heartbeats, queued retention purge, marketing contact and firebase user are removed after the transaction is committed.
+func (service *AccountService) Delete(ctx context.Context, userID entities.UserID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	user, err := service.userRepository.Load(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load user with ID [%s]", userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	phones, err := service.phones(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phones for user with ID [%s]", userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if service.hasActiveSubscription(user) {
+		if err = service.userService.CancelUserSubscription(ctx, user); err != nil {
+			msg := fmt.Sprintf("cannot cancel subscription [%s] before deleting user with ID [%s]", *user.SubscriptionID, userID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+	}
+
+	if err = service.accountRepository.Delete(ctx, user); err != nil {
+		msg := fmt.Sprintf("cannot delete the data of user with ID [%s]", userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	service.deleteHeartbeats(ctx, userID, phones)
+
+	if err = service.dispatcher.Cancel(ctx, user.RetentionPurgeQueueID); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot cancel retention purge [%s] of deleted user with ID [%s]", user.RetentionPurgeQueueID, userID)))
+	}
+
+	service.deleteMarketingContact(ctx, user)
+
+	if err = service.authClient.DeleteUser(ctx, string(userID)); err != nil && !auth.IsUserNotFound(err) {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot delete firebase user of deleted user with ID [%s]", userID)))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("deleted the account of user with ID [%s]", userID))
+	return nil
+}
+
+// deleteHeartbeats removes the heartbeats and heartbeat monitors of a user which are stored in the dedicated database
+func (service *AccountService) deleteHeartbeats(ctx context.Context, userID entities.UserID, phones []entities.Phone) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	for _, phone := range phones {
+		if err := service.heartbeatService.DeleteMonitor(ctx, userID, phone.PhoneNumber); err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot delete heartbeat monitor of phone [%s] for user with ID [%s]", phone.PhoneNumber, userID)))
+		}
+	}
+
+	if err := service.heartbeatMonitorRepository.DeleteAllForUser(ctx, userID); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot delete heartbeat monitors for user with ID [%s]", userID)))
+	}
+
+	if err := service.heartbeatRepository.DeleteAllForUser(ctx, userID); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot delete heartbeats for user with ID [%s]", userID)))
+	}
+}
+
+func (service *AccountService) hasActiveSubscription(user *entities.User) bool {
+	if user.SubscriptionID == nil || user.IsOnFreePlan() || user.SubscriptionName == entities.SubscriptionNameProLifetime || user.SubscriptionEndsAt != nil {
+		return false
+	}
+	return user.SubscriptionStatus == nil || (*user.SubscriptionStatus != "cancelled" && *user.SubscriptionStatus != "expired")
+}
+
+func (service *AccountService) deleteMarketingContact(ctx context.Context, user *entities.User) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	contactIDs, err := service.marketingService.ContactIDs(ctx, []string{user.Email})
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot find marketing contact of user with ID [%s]", user.ID)))
+		return
+	}
+
+	if len(contactIDs) == 0 {
+		return
+	}
+
+	if err = service.marketingService.DeleteContacts(ctx, contactIDs); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot delete marketing contacts [%v] of user with ID [%s]", contactIDs, user.ID)))
+	}
+}
+
+func (service *AccountService) phones(ctx context.Context, userID entities.UserID) ([]entities.Phone, error) {
+	var phones []entities.Phone
+	for skip := 0; ; skip += accountExportBatchSize {
+		batch, err := service.phoneRepository.Index(ctx, userID, repositories.IndexParams{Skip: skip, Limit: accountExportBatchSize})
+		if err != nil {
+			return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot index phones of user [%s] from [%d]", userID, skip))
+		}
+
+		phones = append(phones, *batch...)
+		if len(*batch) < accountExportBatchSize {
+			return phones, nil
+		}
+	}
+}
+
+func (service *AccountService) exportMessages(ctx context.Context, userID entities.UserID, writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	return service.messageRepository.Export(ctx, userID, nil, nil, nil, repositories.IndexParams{Limit: accountExportBatchSize}, func(messages []*entities.Message) error {
+		for _, message := range messages {
+			if err := encoder.Encode(message); err != nil {
+				return stacktrace.Propagate(err, fmt.Sprintf("cannot encode message with ID [%s]", message.ID))
+			}
+		}
+		return nil
+	})
+}
+
+func (service *AccountService) exportThreads(ctx context.Context, userID entities.UserID, phones []entities.Phone, writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	for _, phone := range phones {
+		for _, archived := range []bool{false, true} {
+			params := repositories.IndexParams{Limit: accountExportBatchSize}
+			for {
+				threads, err := service.threadRepository.Index(ctx, userID, phone.PhoneNumber, archived, params)
+				if err != nil {
+					return stacktrace.Propagate(err, fmt.Sprintf("cannot index threads of owner [%s] for user [%s]", phone.PhoneNumber, userID))
+				}
+
+				for _, thread := range *threads {
+					if err = encoder.Encode(thread); err != nil {
+						return stacktrace.Propagate(err, fmt.Sprintf("cannot encode thread with ID [%s]", thread.ID))
+					}
+				}
+
+				if len(*threads) < accountExportBatchSize {
+					break
+				}
+
+				last := (*threads)[len(*threads)-1]
+				params.Cursor = repositories.NewCursor(last.OrderTimestamp, last.ID)
+			}
+		}
+	}
+	return nil
+}
+
+func (service *AccountService) exportHeartbeats(ctx context.Context, userID entities.UserID, phones []entities.Phone, writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	for _, phone := range phones {
+		params := repositories.IndexParams{Limit: accountExportBatchSize}
+		for {
+			heartbeats, err := service.heartbeatRepository.Index(ctx, userID, phone.PhoneNumber, params)
+			if err != nil {
+				return stacktrace.Propagate(err, fmt.Sprintf("cannot index heartbeats of owner [%s] for user [%s]", phone.PhoneNumber, userID))
+			}
+
+			for _, heartbeat := range *heartbeats {
+				if err = encoder.Encode(heartbeat); err != nil {
+					return stacktrace.Propagate(err, fmt.Sprintf("cannot encode heartbeat with ID [%s]", heartbeat.ID))
+				}
+			}
+
+			if len(*heartbeats) < accountExportBatchSize {
+				break
+			}
+
+			last := (*heartbeats)[len(*heartbeats)-1]
+			params.Cursor = repositories.NewCursor(last.Timestamp, last.ID)
+		}
+	}
+	return nil
+}
+
+func (service *AccountService) exportWebhooks(ctx context.Context, userID entities.UserID, writer io.Writer) error {
+	var webhooks []*entities.Webhook
+	for skip := 0; ; skip += accountExportBatchSize {
+		batch, err := service.webhookRepository.Index(ctx, userID, repositories.IndexParams{Skip: skip, Limit: accountExportBatchSize})
+		if err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot index webhooks of user [%s] from [%d]", userID, skip))
+		}
+
+		webhooks = append(webhooks, batch...)
+		if len(batch) < accountExportBatchSize {
+			return service.writeJSON(writer, webhooks)
+		}
+	}
+}
+
+func (service *AccountService) exportDiscords(ctx context.Context, userID entities.UserID, writer io.Writer) error {
+	var discords []*entities.Discord
+	for skip := 0; ; skip += accountExportBatchSize {
+		batch, err := service.discordRepository.Index(ctx, userID, repositories.IndexParams{Skip: skip, Limit: accountExportBatchSize})
+		if err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot index discord integrations of user [%s] from [%d]", userID, skip))
+		}
+
+		discords = append(discords, batch...)
+		if len(batch) < accountExportBatchSize {
+			return service.writeJSON(writer, discords)
+		}
+	}
+}
+
+func (service *AccountService) export3CX(ctx context.Context, userID entities.UserID, writer io.Writer) error {
+	integration, err := service.integration3CXRepository.Load(ctx, userID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return service.writeJSON(writer, nil)
+	}
+
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot load 3CX integration of user [%s]", userID))
+	}
+
+	return service.writeJSON(writer, integration)
+}
+
+func (service *AccountService) exportBillingUsages(ctx context.Context, userID entities.UserID, writer io.Writer) error {
+	var usages []entities.BillingUsage
+	for skip := 0; ; skip += accountExportBatchSize {
+		batch, err := service.billingUsageRepository.GetHistory(ctx, userID, repositories.IndexParams{Skip: skip, Limit: accountExportBatchSize})
+		if err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot index billing usages of user [%s] from [%d]", userID, skip))
+		}
+
+		usages = append(usages, *batch...)
+		if len(*batch) < accountExportBatchSize {
+			return service.writeJSON(writer, usages)
+		}
+	}
+}
+
+func (service *AccountService) writeJSON(writer io.Writer, value any) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot encode [%T] as JSON", value))
+	}
+	return nil
+}
@@ -0,0 +1,501 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/nyaruka/phonenumbers"
+
+	"github.com/NdoleStudio/httpsms/pkg/cache"
+	"github.com/NdoleStudio/httpsms/pkg/discord"
+	"github.com/NdoleStudio/httpsms/pkg/emails"
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// alertRequestIDPrefix marks SMS alerts so that a failed SMS alert does not trigger another alert
+	alertRequestIDPrefix = "httpsms-alert:"
+
+	// alertSendFailedTimeout is the minimum time between send failure alerts of a phone on a channel
+	alertSendFailedTimeout = 15 * time.Minute
+)
+
+// AlertService routes phone alerts to the entities.AlertChannel of a user
+type AlertService struct {
+	service
+	logger                   telemetry.Logger
+	tracer                   telemetry.Tracer
+	repository               repositories.AlertChannelRepository
+	userRepository           repositories.UserRepository
+	phoneRepository          repositories.PhoneRepository
+	heartbeatRepository      repositories.HeartbeatRepository
+	monitorRepository        repositories.HeartbeatMonitorRepository
+	messageService           *MessageService
+	billingService           *BillingService
+	dispatcher               *EventDispatcher
+	userEmailFactory         emails.UserEmailFactory
+	notificationEmailFactory emails.NotificationEmailFactory
+	mailer                   emails.Mailer
+	discordClient            *discord.Client
+	httpClient               *http.Client
+	cache                    cache.Cache
+}
+
+// NewAlertService creates a new AlertService
+func NewAlertService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.AlertChannelRepository,
+	userRepository repositories.UserRepository,
+	phoneRepository repositories.PhoneRepository,
+	heartbeatRepository repositories.HeartbeatRepository,
+	monitorRepository repositories.HeartbeatMonitorRepository,
+	messageService *MessageService,
+	billingService *BillingService,
+	dispatcher *EventDispatcher,
+	userEmailFactory emails.UserEmailFactory,
+	notificationEmailFactory emails.NotificationEmailFactory,
+	mailer emails.Mailer,
+	discordClient *discord.Client,
+	httpClient *http.Client,
+	cache cache.Cache,
+) (s *AlertService) {
+	return &AlertService{
+		logger:                   logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:                   tracer,
+		repository:               repository,
+		userRepository:           userRepository,
+		phoneRepository:          phoneRepository,
+		heartbeatRepository:      heartbeatRepository,
+		monitorRepository:        monitorRepository,
+		messageService:           messageService,
+		billingService:           billingService,
+		dispatcher:               dispatcher,
+		userEmailFactory:         userEmailFactory,
+		notificationEmailFactory: notificationEmailFactory,
+		mailer:                   mailer,
+		discordClient:            discordClient,
+		httpClient:               httpClient,
+		cache:                    cache,
+	}
+}
+
+// alert is a notification which can be delivered on any entities.AlertChannelType
+type alert struct {
+	owner string
+	text  string
+	email func(user *entities.User) (*emails.Email, error)
+}
+
+// Index fetches the entities.AlertChannel of a user
+func (service *AlertService) Index(ctx context.Context, userID entities.UserID) ([]*entities.AlertChannel, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	channels, err := service.repository.Index(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch alert channels for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return channels, nil
+}
+
+// AlertChannelStoreParams are parameters for creating a new entities.AlertChannel
+type AlertChannelStoreParams struct {
+	UserID          entities.UserID
+	Name            string
+	Type            entities.AlertChannelType
+	Target          string
+	AlertTypes      []string
+	PhoneNumbers    []string
+	EscalationDelay time.Duration
+}
+
+// Store a new entities.AlertChannel
+func (service *AlertService) Store(ctx context.Context, params *AlertChannelStoreParams) (*entities.AlertChannel, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	channel := &entities.AlertChannel{
+		ID:                     uuid.New(),
+		UserID:                 params.UserID,
+		Name:                   params.Name,
+		Type:                   params.Type,
+		Target:                 params.Target,
+		AlertTypes:             pq.StringArray(params.AlertTypes),
+		PhoneNumbers:           pq.StringArray(params.PhoneNumbers),
+		EscalationDelaySeconds: uint(params.EscalationDelay.Seconds()),
+		CreatedAt:              time.Now().UTC(),
+		UpdatedAt:              time.Now().UTC(),
+	}
+
+	var err error
+	token := ""
+	if channel.Type == entities.AlertChannelTypeEmail {
+		if token, err = service.generateSecret(32); err != nil {
+			msg := fmt.Sprintf("cannot generate alert channel verification token for user [%s]", params.UserID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		channel.VerificationTokenHash = repositories.HashToken(token)
+	} else {
+		channel.VerifiedAt = &channel.CreatedAt
+	}
+
+	if err = service.repository.Store(ctx, channel); err != nil {
+		msg := fmt.Sprintf("cannot store [%s] alert channel for user [%s]", params.Type, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if channel.Type == entities.AlertChannelTypeEmail {
+		if err = service.sendVerificationEmail(ctx, channel, token); err != nil {
+			msg := fmt.Sprintf("cannot send verification email for alert channel [%s]", channel.ID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+	}
+
+	ctxLogger.Info(fmt.Sprintf("created [%s] alert channel [%s] for user [%s]", channel.Type, channel.ID, channel.UserID))
+	return channel, nil
+}
+
+// Verify marks an entities.AlertChannel as verified using the token in the link which was sent to its email address
+func (service *AlertService) Verify(ctx context.Context, channelID uuid.UUID, token string) (*entities.AlertChannel, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	channel, err := service.repository.Verify(ctx, channelID, token)
+	if err != nil {
+		msg := fmt.Sprintf("cannot verify alert channel with ID [%s]", channelID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("verified [%s] alert channel [%s] for user [%s]", channel.Type, channel.ID, channel.UserID))
+	return channel, nil
+}
+
+func (service *AlertService) sendVerificationEmail(ctx context.Context, channel *entities.AlertChannel, token string) error {
+	email, err := service.userEmailFactory.AlertChannelVerification(channel, token)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot create verification email for alert channel [%s]", channel.ID))
+	}
+	return service.mailer.Send(ctx, email)
+}
+
+// Delete an entities.AlertChannel
+func (service *AlertService) Delete(ctx context.Context, userID entities.UserID, channelID uuid.UUID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if _, err := service.repository.Load(ctx, userID, channelID); err != nil {
+		msg := fmt.Sprintf("cannot load alert channel with ID [%s] for user [%s]", channelID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err := service.repository.Delete(ctx, userID, channelID); err != nil {
+		msg := fmt.Sprintf("cannot delete alert channel with ID [%s] for user [%s]", channelID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("deleted alert channel [%s] for user [%s]", channelID, userID))
+	return nil
+}
+
+// NotifyPhoneOffline routes the events.EventTypePhoneHeartbeatOffline alert to the channels of a user
+func (service *AlertService) NotifyPhoneOffline(ctx context.Context, source string, payload *events.PhoneHeartbeatOfflinePayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	channels, user, err := service.channels(ctx, payload.UserID, events.EventTypePhoneHeartbeatOffline, payload.Owner)
+	if err != nil || len(channels) == 0 {
+		return service.tracer.WrapErrorSpan(span, err)
+	}
+
+	for _, channel := range channels {
+		if channel.EscalationDelaySeconds > 0 {
+			service.scheduleEscalation(ctx, source, channel, payload)
+			continue
+		}
+
+		if err = service.send(ctx, source, user, channel, service.phoneOfflineAlert(user, payload.Owner, payload.LastHeartbeatTimestamp)); err != nil {
+			msg := fmt.Sprintf("cannot send [%s] alert on channel [%s] for user [%s]", events.EventTypePhoneHeartbeatOffline, channel.ID, user.ID)
+			ctxLogger.Error(stacktrace.Propagate(err, msg))
+		}
+	}
+
+	return nil
+}
+
+// Escalate sends a delayed phone offline alert if the phone has not sent a heartbeat since it went offline
+func (service *AlertService) Escalate(ctx context.Context, source string, payload *events.AlertEscalationCheckPayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	channel, err := service.repository.Load(ctx, payload.UserID, payload.AlertChannelID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Info(fmt.Sprintf("alert channel [%s] has been deleted for user [%s]", payload.AlertChannelID, payload.UserID))
+		return nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load alert channel [%s] for user [%s]", payload.AlertChannelID, payload.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	heartbeat, err := service.heartbeatRepository.Last(ctx, payload.UserID, payload.Owner)
+	if err == nil && heartbeat.Timestamp.After(payload.LastHeartbeatTimestamp) {
+		ctxLogger.Info(fmt.Sprintf("phone [%s] recovered at [%s] before the escalation of alert channel [%s]", payload.Owner, heartbeat.Timestamp, channel.ID))
+		return nil
+	}
+
+	user, err := service.userRepository.Load(ctx, payload.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load user with ID [%s]", payload.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.send(ctx, source, user, channel, service.phoneOfflineAlert(user, payload.Owner, payload.LastHeartbeatTimestamp)); err != nil {
+		msg := fmt.Sprintf("cannot send escalated [%s] alert on channel [%s] for user [%s]", events.EventTypePhoneHeartbeatOffline, channel.ID, user.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// NotifyMessageFailed routes the events.EventTypeMessageSendFailed alert to the channels of a user
+func (service *AlertService) NotifyMessageFailed(ctx context.Context, source string, payload *events.MessageSendFailedPayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if payload.RequestID != nil && strings.HasPrefix(*payload.RequestID, alertRequestIDPrefix) {
+		ctxLogger.Info(fmt.Sprintf("message [%s] is an SMS alert, no alert is sent for its failure", payload.ID))
+		return nil
+	}
+
+	channels, user, err := service.channels(ctx, payload.UserID, events.EventTypeMessageSendFailed, payload.Owner)
+	if err != nil || len(channels) == 0 {
+		return service.tracer.WrapErrorSpan(span, err)
+	}
+
+	alert := &alert{
+		owner: payload.Owner,
+		text:  fmt.Sprintf("httpSMS alert: the message from %s to %s failed at %s with error: %s", payload.Owner, payload.Contact, user.UserTimeString(payload.Timestamp), payload.ErrorMessage),
+		email: func(user *entities.User) (*emails.Email, error) {
+			return service.notificationEmailFactory.MessageFailed(user, payload)
+		},
+	}
+
+	for _, channel := range channels {
+		cacheKey := fmt.Sprintf("alert:%s:%s:%s", channel.ID, events.EventTypeMessageSendFailed, payload.Owner)
+		if _, err = service.cache.Get(ctx, cacheKey); err == nil {
+			ctxLogger.Info(fmt.Sprintf("[%s] alert already sent on channel [%s] for owner [%s]", events.EventTypeMessageSendFailed, channel.ID, payload.Owner))
+			continue
+		}
+
+		if err = service.send(ctx, source, user, channel, alert); err != nil {
+			msg := fmt.Sprintf("cannot send [%s] alert on channel [%s] for user [%s]", events.EventTypeMessageSendFailed, channel.ID, user.ID)
+			ctxLogger.Error(stacktrace.Propagate(err, msg))
+			continue
+		}
+
+		if err = service.cache.Set(ctx, cacheKey, "", alertSendFailedTimeout); err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot set item in cache with key [%s]", cacheKey)))
+		}
+	}
+
+	return nil
+}
+
+// channels loads the user and the channels which route an alert type of a phone number
+func (service *AlertService) channels(ctx context.Context, userID entities.UserID, alertType string, owner string) ([]*entities.AlertChannel, *entities.User, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	channels, err := service.repository.LoadByAlertType(ctx, userID, alertType)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load [%s] alert channels for user [%s]", alertType, userID)
+		return nil, nil, stacktrace.Propagate(err, msg)
+	}
+
+	var routed []*entities.AlertChannel
+	for _, channel := range channels {
+		if channel.IsVerified() && channel.Routes(alertType, owner) {
+			routed = append(routed, channel)
+		}
+	}
+
+	if len(routed) == 0 {
+		return nil, nil, nil
+	}
+
+	user, err := service.userRepository.Load(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load user with ID [%s]", userID)
+		return nil, nil, stacktrace.Propagate(err, msg)
+	}
+
+	return routed, user, nil
+}
+
+func (service *AlertService) phoneOfflineAlert(user *entities.User, owner string, lastHeartbeatTimestamp time.Time) *alert {
+	return &alert{
+		owner: owner,
+		text:  fmt.Sprintf("httpSMS alert: your phone %s has been offline since its last heartbeat at %s", owner, user.UserTimeString(lastHeartbeatTimestamp)),
+		email: func(user *entities.User) (*emails.Email, error) {
+			return service.userEmailFactory.PhoneDead(user, lastHeartbeatTimestamp, owner)
+		},
+	}
+}
+
+func (service *AlertService) scheduleEscalation(ctx context.Context, source string, channel *entities.AlertChannel, payload *events.PhoneHeartbeatOfflinePayload) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	event, err := service.createEvent(events.EventTypeAlertEscalationCheck, source, &events.AlertEscalationCheckPayload{
+		AlertChannelID:         channel.ID,
+		UserID:                 payload.UserID,
+		PhoneID:                payload.PhoneID,
+		Owner:                  payload.Owner,
+		LastHeartbeatTimestamp: payload.LastHeartbeatTimestamp,
+		ScheduledAt:            time.Now().UTC().Add(channel.EscalationDelay()),
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create [%s] event for alert channel [%s]", events.EventTypeAlertEscalationCheck, channel.ID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return
+	}
+
+	if _, err = service.dispatcher.DispatchWithTimeout(ctx, event, channel.EscalationDelay()); err != nil {
+		msg := fmt.Sprintf("cannot dispatch [%s] event for alert channel [%s]", event.Type(), channel.ID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return
+	}
+
+	ctxLogger.Info(fmt.Sprintf("scheduled escalation of alert channel [%s] for phone [%s] in [%s]", channel.ID, payload.Owner, channel.EscalationDelay()))
+}
+
+// send delivers an alert on a channel
+func (service *AlertService) send(ctx context.Context, source string, user *entities.User, channel *entities.AlertChannel, alert *alert) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	var err error
+	switch channel.Type {
+	case entities.AlertChannelTypeEmail:
+		err = service.sendEmail(ctx, user, channel, alert)
+	case entities.AlertChannelTypeDiscord:
+		_, _, err = service.discordClient.Channel.CreateMessage(ctx, channel.Target, map[string]any{"content": alert.text})
+	case entities.AlertChannelTypeSlack:
+		err = service.sendSlack(ctx, channel, alert)
+	case entities.AlertChannelTypeSMS:
+		err = service.sendSMS(ctx, source, user, channel, alert)
+	default:
+		err = stacktrace.NewError(fmt.Sprintf("alert channel type [%s] is not supported", channel.Type))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot send alert on [%s] channel [%s]", channel.Type, channel.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("sent alert about [%s] on [%s] channel [%s] for user [%s]", alert.owner, channel.Type, channel.ID, user.ID))
+	return nil
+}
+
+func (service *AlertService) sendEmail(ctx context.Context, user *entities.User, channel *entities.AlertChannel, alert *alert) error {
+	email, err := alert.email(user)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot create alert email for user [%s]", user.ID))
+	}
+
+	email.ToName = ""
+	email.ToEmail = channel.Target
+	return service.mailer.Send(ctx, email)
+}
+
+func (service *AlertService) sendSlack(ctx context.Context, channel *entities.AlertChannel, alert *alert) error {
+	body, err := json.Marshal(map[string]string{"text": alert.text})
+	if err != nil {
+		return stacktrace.Propagate(err, "cannot marshal slack message")
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, channel.Target, bytes.NewReader(body))
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot create slack request for alert channel [%s]", channel.ID))
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := service.httpClient.Do(request)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot send slack request for alert channel [%s]", channel.ID))
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	if response.StatusCode >= http.StatusBadRequest {
+		return stacktrace.NewError(fmt.Sprintf("slack webhook of alert channel [%s] responded with status [%d]", channel.ID, response.StatusCode))
+	}
+	return nil
+}
+
+// sendSMS sends the alert to the backup number from another phone of the user which is online
+func (service *AlertService) sendSMS(ctx context.Context, source string, user *entities.User, channel *entities.AlertChannel, alert *alert) error {
+	if msg := service.billingService.IsEntitled(ctx, user.ID); msg != nil {
+		return stacktrace.NewError(fmt.Sprintf("user [%s] is not entitled to send the SMS alert of channel [%s]: %s", user.ID, channel.ID, *msg))
+	}
+
+	phone, err := service.healthyPhone(ctx, user.ID, alert.owner, channel.Target)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot find a healthy phone to send the SMS alert of channel [%s]", channel.ID))
+	}
+
+	owner, err := phonenumbers.Parse(phone.PhoneNumber, phonenumbers.UNKNOWN_REGION)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot parse phone number [%s]", phone.PhoneNumber))
+	}
+
+	requestID := alertRequestIDPrefix + channel.ID.String()
+	_, err = service.messageService.SendMessage(ctx, MessageSendParams{
+		Owner:             owner,
+		Contact:           channel.Target,
+		Content:           alert.text,
+		Source:            source,
+		RequestID:         &requestID,
+		UserID:            user.ID,
+		RequestReceivedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot send SMS alert from [%s] to [%s]", phone.PhoneNumber, channel.Target))
+	}
+	return nil
+}
+
+// healthyPhone finds a phone of the user other than the alerting phone which is online
+func (service *AlertService) healthyPhone(ctx context.Context, userID entities.UserID, owner string, contact string) (*entities.Phone, error) {
+	phones, err := service.phoneRepository.Index(ctx, userID, repositories.IndexParams{Limit: 100})
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot fetch phones of user [%s]", userID))
+	}
+
+	for _, phone := range *phones {
+		if phone.PhoneNumber == owner || phone.PhoneNumber == contact {
+			continue
+		}
+
+		monitor, err := service.monitorRepository.Load(ctx, userID, phone.PhoneNumber)
+		if err == nil && monitor.PhoneOnline {
+			return &phone, nil
+		}
+	}
+
+	return nil, stacktrace.NewError(fmt.Sprintf("user [%s] has no online phone other than [%s]", userID, owner))
+}
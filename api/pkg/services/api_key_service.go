@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/palantir/stacktrace"
+)
+
+// APIKeyService is handles entities.APIKey requests
+type APIKeyService struct {
+	service
+	logger     telemetry.Logger
+	tracer     telemetry.Tracer
+	repository repositories.APIKeyRepository
+}
+
+// NewAPIKeyService creates a new APIKeyService
+func NewAPIKeyService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.APIKeyRepository,
+) (s *APIKeyService) {
+	return &APIKeyService{
+		logger:     logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:     tracer,
+		repository: repository,
+	}
+}
+
+// Index fetches the entities.APIKey of a user
+func (service *APIKeyService) Index(ctx context.Context, userID entities.UserID, params repositories.IndexParams) ([]*entities.APIKey, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	apiKeys, err := service.repository.Index(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch api keys for user [%s] with params [%+#v]", userID, params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return apiKeys, nil
+}
+
+// APIKeyStoreParams are parameters for creating a new entities.APIKey
+type APIKeyStoreParams struct {
+	UserID    entities.UserID
+	Name      string
+	Scopes    pq.StringArray
+	ExpiresAt *time.Time
+}
+
+// Store a new entities.APIKey
+func (service *APIKeyService) Store(ctx context.Context, params *APIKeyStoreParams) (*entities.APIKey, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	key, err := service.generateSecret(48)
+	if err != nil {
+		msg := fmt.Sprintf("cannot generate api key for user [%s]", params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	apiKey := &entities.APIKey{
+		ID:        uuid.New(),
+		UserID:    params.UserID,
+		Name:      params.Name,
+		Key:       key,
+		KeyPrefix: repositories.APIKeyPrefix(key),
+		KeyHash:   repositories.HashAPIKey(key),
+		Scopes:    params.Scopes,
+		ExpiresAt: params.ExpiresAt,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	if err = service.repository.Store(ctx, apiKey); err != nil {
+		msg := fmt.Sprintf("cannot store api key with id [%s] for user [%s]", apiKey.ID, apiKey.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("created api key with ID [%s] and scopes [%s] for user [%s]", apiKey.ID, apiKey.Scopes, apiKey.UserID))
+	return apiKey, nil
+}
+
+// Delete an entities.APIKey
+func (service *APIKeyService) Delete(ctx context.Context, userID entities.UserID, apiKeyID uuid.UUID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	apiKey, err := service.repository.Load(ctx, userID, apiKeyID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load api key with ID [%s] for user [%s]", apiKeyID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err = service.repository.Delete(ctx, apiKey); err != nil {
+		msg := fmt.Sprintf("cannot delete api key with ID [%s] for user [%s]", apiKeyID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("deleted api key with ID [%s] for user [%s]", apiKeyID, userID))
+	return nil
+}
@@ -104,7 +104,7 @@ func (service *EmailNotificationService) NotifyMessageFailed(ctx context.Context
 
 	user, err := service.userRepository.Load(ctx, payload.UserID)
 	if err != nil {
-		msg := fmt.Sprintf("cannot load user with ID [%s] for [%s] message with ID [%s]", payload.UserID, payload.ID)
+		msg := fmt.Sprintf("cannot load user with ID [%s] for [%s] message with ID [%s]", payload.UserID, events.EventTypeMessageSendFailed, payload.ID)
 		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
 	}
 
@@ -115,7 +115,7 @@ func (service *EmailNotificationService) NotifyMessageFailed(ctx context.Context
 
 	email, err := service.factory.MessageFailed(user, payload)
 	if err != nil {
-		msg := fmt.Sprintf("cannot create email for user with ID [%s] for [%s] message with ID [%s]", payload.UserID, payload.ID)
+		msg := fmt.Sprintf("cannot create email for user with ID [%s] for [%s] message with ID [%s]", payload.UserID, events.EventTypeMessageSendFailed, payload.ID)
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
@@ -54,6 +54,15 @@ func (queue *emulatorPushQueue) Enqueue(ctx context.Context, task *PushQueueTask
 	return queueID, nil
 }
 
+// Delete is not supported by the emulator so the task is still pushed and the listener has to ignore it
+func (queue *emulatorPushQueue) Delete(ctx context.Context, queueID string) error {
+	_, span, ctxLogger := queue.tracer.StartWithLogger(ctx, queue.logger)
+	defer span.End()
+
+	ctxLogger.Info(fmt.Sprintf("cannot delete task with ID [%s] from the [%s] emulator queue", queueID, queue.config.Name))
+	return nil
+}
+
 func (queue *emulatorPushQueue) push(task PushQueueTask, queueID string) func() {
 	return func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	semconv "go.opentelemetry.io/otel/semconv/v1.18.0"
 
 	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/palantir/stacktrace"
@@ -100,6 +102,23 @@ func (dispatcher *EventDispatcher) enqueue(ctx context.Context, event cloudevent
 	return queueID, err
 }
 
+// Cancel removes an event which was dispatched with DispatchWithTimeout from the queue before it is processed
+func (dispatcher *EventDispatcher) Cancel(ctx context.Context, queueID string) error {
+	ctx, span := dispatcher.tracer.Start(ctx)
+	defer span.End()
+
+	if queueID == "" || strings.HasPrefix(queueID, "local-") {
+		return nil
+	}
+
+	if err := dispatcher.queue.Delete(ctx, queueID); err != nil {
+		msg := fmt.Sprintf("cannot delete event with queue ID [%s] from [%T]", queueID, dispatcher.queue)
+		return dispatcher.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
 // Dispatch a new event by adding it to the queue to be processed async
 func (dispatcher *EventDispatcher) Dispatch(ctx context.Context, event cloudevents.Event) error {
 	ctx, span := dispatcher.tracer.Start(ctx)
@@ -122,6 +141,9 @@ func (dispatcher *EventDispatcher) Publish(ctx context.Context, event cloudevent
 	ctx, span := dispatcher.tracer.Start(ctx)
 	defer span.End()
 
+	// listeners act on behalf of the account so they are not limited to the phones of the member who triggered the event
+	ctx = repositories.WithOrganizationScope(ctx, nil)
+
 	start := time.Now()
 
 	ctxLogger := dispatcher.tracer.CtxLogger(dispatcher.logger, span)
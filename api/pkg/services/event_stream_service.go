@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/pubsub"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// EventStreamService streams the events of a user to clients which are connected with SSE or WebSockets
+type EventStreamService struct {
+	service
+	logger        telemetry.Logger
+	tracer        telemetry.Tracer
+	pubSub        pubsub.PubSub
+	streamLimiter *pubsub.StreamLimiter
+}
+
+// NewEventStreamService creates a new EventStreamService
+func NewEventStreamService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	pubSub pubsub.PubSub,
+	streamLimiter *pubsub.StreamLimiter,
+) (s *EventStreamService) {
+	return &EventStreamService{
+		logger:        logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:        tracer,
+		pubSub:        pubSub,
+		streamLimiter: streamLimiter,
+	}
+}
+
+// eventStreamMessage is the message which is published to the subscribers of a user
+type eventStreamMessage struct {
+	Owner string            `json:"owner"`
+	Event cloudevents.Event `json:"event"`
+}
+
+// Publish an event to all the clients of a user which are connected to the stream
+func (service *EventStreamService) Publish(ctx context.Context, userID entities.UserID, owner string, event cloudevents.Event) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	message, err := json.Marshal(&eventStreamMessage{Owner: owner, Event: event})
+	if err != nil {
+		msg := fmt.Sprintf("cannot marshal [%s] event with ID [%s]", event.Type(), event.ID())
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.pubSub.Publish(ctx, service.channel(userID), string(message)); err != nil {
+		msg := fmt.Sprintf("cannot publish [%s] event with ID [%s] for user [%s]", event.Type(), event.ID(), userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// EventStreamParams are parameters for subscribing to the events of a user
+type EventStreamParams struct {
+	UserID entities.UserID
+	Types  []string
+	Owners []string
+}
+
+// Subscribe to the events of a user which match the filters until the context is done
+func (service *EventStreamService) Subscribe(ctx context.Context, params *EventStreamParams) (<-chan cloudevents.Event, error) {
+	release, ok := service.streamLimiter.Acquire(string(params.UserID))
+	if !ok {
+		return nil, stacktrace.NewErrorWithCode(ErrCodeTooManyStreams, fmt.Sprintf("user [%s] has too many concurrent streams", params.UserID))
+	}
+
+	messages, err := service.pubSub.Subscribe(ctx, service.channel(params.UserID))
+	if err != nil {
+		release()
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot subscribe to the events of user [%s]", params.UserID))
+	}
+
+	types := service.toSet(params.Types)
+	owners := service.toSet(params.Owners)
+
+	stream := make(chan cloudevents.Event)
+	go func() {
+		defer release()
+		defer close(stream)
+		for value := range messages {
+			message := new(eventStreamMessage)
+			if err := json.Unmarshal([]byte(value), message); err != nil {
+				service.logger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot unmarshal event stream message [%s]", value)))
+				continue
+			}
+
+			if (len(types) > 0 && !types[message.Event.Type()]) || (len(owners) > 0 && !owners[message.Owner]) {
+				continue
+			}
+
+			select {
+			case stream <- message.Event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return stream, nil
+}
+
+func (service *EventStreamService) channel(userID entities.UserID) string {
+	return fmt.Sprintf("event-stream:%s", userID)
+}
+
+func (service *EventStreamService) toSet(values []string) map[string]bool {
+	result := make(map[string]bool, len(values))
+	for _, value := range values {
+		result[value] = true
+	}
+	return result
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/pubsub"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+)
+
+// testEventStreamEvent creates a cloudevents.Event with the type and ID
+func testEventStreamEvent(eventType string, id string) cloudevents.Event {
+	event := cloudevents.NewEvent()
+	event.SetID(id)
+	event.SetType(eventType)
+	event.SetSource("test")
+	return event
+}
+
+func TestEventStreamService_Subscribe(t *testing.T) {
+	t.Run("streams only the events which match the types and owners", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		service := NewEventStreamService(logger, tracer, pubsub.NewMemoryPubSub(logger, tracer), pubsub.NewStreamLimiter(1))
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		// Arrange
+		userID := entities.UserID("user-id")
+		stream, err := service.Subscribe(ctx, &EventStreamParams{
+			UserID: userID,
+			Types:  []string{events.EventTypeMessagePhoneReceived},
+			Owners: []string{"+18005550199"},
+		})
+		assert.Nil(t, err)
+
+		// Act
+		assert.Nil(t, service.Publish(ctx, userID, "+18005550199", testEventStreamEvent(events.EventTypeMessagePhoneSent, "other-type")))
+		assert.Nil(t, service.Publish(ctx, userID, "+18005550100", testEventStreamEvent(events.EventTypeMessagePhoneReceived, "other-owner")))
+		assert.Nil(t, service.Publish(ctx, "other-user", "+18005550199", testEventStreamEvent(events.EventTypeMessagePhoneReceived, "other-user")))
+		assert.Nil(t, service.Publish(ctx, userID, "+18005550199", testEventStreamEvent(events.EventTypeMessagePhoneReceived, "match")))
+
+		// Assert
+		select {
+		case event := <-stream:
+			assert.Equal(t, "match", event.ID())
+		case <-ctx.Done():
+			t.Fatal("the matching event was not streamed")
+		}
+	})
+
+	t.Run("streams all the events of the user without filters", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		service := NewEventStreamService(logger, tracer, pubsub.NewMemoryPubSub(logger, tracer), pubsub.NewStreamLimiter(1))
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		// Arrange
+		userID := entities.UserID("user-id")
+		stream, err := service.Subscribe(ctx, &EventStreamParams{UserID: userID})
+		assert.Nil(t, err)
+
+		// Act
+		assert.Nil(t, service.Publish(ctx, userID, "+18005550199", testEventStreamEvent(events.EventTypeMessagePhoneSent, "first")))
+		assert.Nil(t, service.Publish(ctx, userID, "+18005550100", testEventStreamEvent(events.EventTypeMessagePhoneReceived, "second")))
+
+		// Assert
+		var ids []string
+		for len(ids) < 2 {
+			select {
+			case event := <-stream:
+				ids = append(ids, event.ID())
+			case <-ctx.Done():
+				t.Fatalf("only [%d] events were streamed", len(ids))
+			}
+		}
+		assert.Equal(t, []string{"first", "second"}, ids)
+	})
+
+	t.Run("rejects a stream when the user has too many streams", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		service := NewEventStreamService(logger, tracer, pubsub.NewMemoryPubSub(logger, tracer), pubsub.NewStreamLimiter(1))
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// Arrange
+		_, err := service.Subscribe(ctx, &EventStreamParams{UserID: "user-id"})
+		assert.Nil(t, err)
+
+		// Act
+		_, err = service.Subscribe(ctx, &EventStreamParams{UserID: "user-id"})
+
+		// Assert
+		assert.Equal(t, ErrCodeTooManyStreams, stacktrace.GetCode(err))
+	})
+}
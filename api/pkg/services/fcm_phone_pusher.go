@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"firebase.google.com/go/messaging"
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+type fcmPhonePusher struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	client *messaging.Client
+}
+
+// NewFCMPhonePusher creates a PhonePusher which uses Firebase Cloud Messaging
+func NewFCMPhonePusher(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	client *messaging.Client,
+) PhonePusher {
+	return &fcmPhonePusher{
+		logger: logger.WithService(fmt.Sprintf("%T", fcmPhonePusher{})),
+		tracer: tracer,
+		client: client,
+	}
+}
+
+// Push sends an FCM data message to the FcmToken of the phone
+func (pusher *fcmPhonePusher) Push(ctx context.Context, phone *entities.Phone, push *PhonePush) (string, error) {
+	ctx, span := pusher.tracer.Start(ctx)
+	defer span.End()
+
+	if phone.FcmToken == nil {
+		msg := fmt.Sprintf("phone with id [%s] has no FCM token", phone.ID)
+		return "", pusher.tracer.WrapErrorSpan(span, stacktrace.NewError(msg))
+	}
+
+	priority := "normal"
+	if push.HighPriority {
+		priority = "high"
+	}
+
+	result, err := pusher.client.Send(ctx, &messaging.Message{
+		Data: push.Data,
+		Android: &messaging.AndroidConfig{
+			Priority: priority,
+			TTL:      push.TTL,
+		},
+		Token: *phone.FcmToken,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot send FCM to phone with id [%s] for user [%s]", phone.ID, phone.UserID)
+		return "", pusher.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return result, nil
+}
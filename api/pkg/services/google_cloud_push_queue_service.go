@@ -12,6 +12,8 @@ import (
 	"cloud.google.com/go/cloudtasks/apiv2/cloudtaskspb"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 	"github.com/palantir/stacktrace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -96,6 +98,26 @@ func (queue *googlePushQueue) enqueueImpl(ctx context.Context, task *PushQueueTa
 	return queueTask.Name, nil
 }
 
+// Delete a task from the queue
+func (queue *googlePushQueue) Delete(ctx context.Context, queueID string) error {
+	ctx, span, ctxLogger := queue.tracer.StartWithLogger(ctx, queue.logger)
+	defer span.End()
+
+	err := queue.client.DeleteTask(ctx, &cloudtaskspb.DeleteTaskRequest{Name: queueID})
+	if status.Code(err) == codes.NotFound {
+		ctxLogger.Info(fmt.Sprintf("task with id [%s] does not exist in the [%s] queue", queueID, queue.queueConfig.Name))
+		return nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete task with id [%s] from the [%s] queue", queueID, queue.queueConfig.Name)
+		return queue.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("task with id [%s] deleted from the [%s] queue", queueID, queue.queueConfig.Name))
+	return nil
+}
+
 func (queue *googlePushQueue) httpMethodToProtoHTTPMethod(httpMethod string) cloudtaskspb.HttpMethod {
 	method, ok := map[string]cloudtaskspb.HttpMethod{
 		http.MethodGet:  cloudtaskspb.HttpMethod_GET,
@@ -17,11 +17,6 @@ import (
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 )
 
-const (
-	// select id, a.timestamp, a.owner,  a.timestamp - (SELECT timestamp from heartbeats b where  b.timestamp < a.timestamp and a.owner = b.owner and a.user_id = b.user_id order by b.timestamp desc  limit 1) as diff  from heartbeats a;
-	heartbeatCheckInterval = 16 * time.Minute
-)
-
 // HeartbeatService is handles heartbeat requests
 type HeartbeatService struct {
 	service
@@ -30,6 +25,8 @@ type HeartbeatService struct {
 	repository        repositories.HeartbeatRepository
 	monitorRepository repositories.HeartbeatMonitorRepository
 	dispatcher        *EventDispatcher
+	availability      *PhoneAvailabilityService
+	maintenance       *MaintenanceWindowService
 }
 
 // NewHeartbeatService creates a new HeartbeatService
@@ -39,6 +36,8 @@ func NewHeartbeatService(
 	repository repositories.HeartbeatRepository,
 	monitorRepository repositories.HeartbeatMonitorRepository,
 	dispatcher *EventDispatcher,
+	availability *PhoneAvailabilityService,
+	maintenance *MaintenanceWindowService,
 ) (s *HeartbeatService) {
 	return &HeartbeatService{
 		logger:            logger.WithService(fmt.Sprintf("%T", s)),
@@ -46,6 +45,8 @@ func NewHeartbeatService(
 		repository:        repository,
 		monitorRepository: monitorRepository,
 		dispatcher:        dispatcher,
+		availability:      availability,
+		maintenance:       maintenance,
 	}
 }
 
@@ -68,14 +69,23 @@ func (service *HeartbeatService) Index(ctx context.Context, userID entities.User
 
 // HeartbeatStoreParams are parameters for creating a new entities.Heartbeat
 type HeartbeatStoreParams struct {
-	Owner     string
-	Version   string
-	Charging  bool
-	Source    string
-	Timestamp time.Time
-	UserID    entities.UserID
+	Owner           string
+	Version         string
+	Charging        bool
+	BatteryLevel    *uint
+	NetworkType     *string
+	SignalStrength  *int
+	SIMs            []entities.HeartbeatSIM
+	FreeStorage     *uint64
+	PendingMessages *uint
+	Source          string
+	Timestamp       time.Time
+	UserID          entities.UserID
 }
 
+// heartbeatBatteryLowThreshold is the battery percentage at which the events.EventTypePhoneBatteryLow event is emitted
+const heartbeatBatteryLowThreshold = 15
+
 // Store a new entities.Heartbeat
 func (service *HeartbeatService) Store(ctx context.Context, params HeartbeatStoreParams) (*entities.Heartbeat, error) {
 	ctx, span := service.tracer.Start(ctx)
@@ -84,21 +94,35 @@ func (service *HeartbeatService) Store(ctx context.Context, params HeartbeatStor
 	ctxLogger := service.tracer.CtxLogger(service.logger, span)
 
 	heartbeat := &entities.Heartbeat{
-		ID:        uuid.New(),
-		Owner:     params.Owner,
-		Charging:  params.Charging,
-		Timestamp: params.Timestamp,
-		Version:   params.Version,
-		UserID:    params.UserID,
+		ID:              uuid.New(),
+		Owner:           params.Owner,
+		Charging:        params.Charging,
+		BatteryLevel:    params.BatteryLevel,
+		NetworkType:     params.NetworkType,
+		SignalStrength:  params.SignalStrength,
+		SIMs:            params.SIMs,
+		FreeStorage:     params.FreeStorage,
+		PendingMessages: params.PendingMessages,
+		Timestamp:       params.Timestamp,
+		Version:         params.Version,
+		UserID:          params.UserID,
+	}
+
+	previous, err := service.repository.Last(ctx, params.UserID, params.Owner)
+	if err != nil && stacktrace.GetCode(err) != repositories.ErrCodeNotFound {
+		msg := fmt.Sprintf("cannot load last heartbeat for owner [%s] and user [%s]", params.Owner, params.UserID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
 	}
 
-	if err := service.repository.Store(ctx, heartbeat); err != nil {
+	if err = service.repository.Store(ctx, heartbeat); err != nil {
 		msg := fmt.Sprintf("cannot save heartbeat with id [%s]", heartbeat.ID)
 		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
 	ctxLogger.Info(fmt.Sprintf("heartbeat saved with id [%s] for user [%s]", heartbeat.ID, heartbeat.UserID))
 
+	service.dispatchTelemetryEvents(ctx, params.Source, previous, heartbeat)
+
 	monitor, err := service.monitorRepository.Load(ctx, params.UserID, params.Owner)
 	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
 		ctxLogger.Info(fmt.Sprintf("heartbeat monitor does not exist for owner [%s] and user [%s]", params.Owner, params.UserID))
@@ -118,6 +142,66 @@ func (service *HeartbeatService) Store(ctx context.Context, params HeartbeatStor
 	return heartbeat, nil
 }
 
+// dispatchTelemetryEvents emits events when the battery becomes low or a SIM card is removed since the previous heartbeat
+func (service *HeartbeatService) dispatchTelemetryEvents(ctx context.Context, source string, previous *entities.Heartbeat, heartbeat *entities.Heartbeat) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if heartbeat.IsBatteryLow(heartbeatBatteryLowThreshold) && (previous == nil || !previous.IsBatteryLow(heartbeatBatteryLowThreshold)) {
+		service.dispatchTelemetryEvent(ctx, events.EventTypePhoneBatteryLow, source, heartbeat, &events.PhoneBatteryLowPayload{
+			HeartbeatID:  heartbeat.ID,
+			UserID:       heartbeat.UserID,
+			Owner:        heartbeat.Owner,
+			BatteryLevel: *heartbeat.BatteryLevel,
+			Timestamp:    heartbeat.Timestamp,
+		})
+	}
+
+	if previous == nil {
+		return
+	}
+
+	// only SIM slots which had a SIM card in the previous heartbeat are reported so that empty slots do not emit events
+	present := map[entities.SIM]bool{}
+	for _, sim := range previous.SIMs {
+		present[sim.SIM] = sim.State != entities.HeartbeatSIMStateAbsent
+	}
+
+	for _, sim := range heartbeat.MissingSIMs() {
+		if !present[sim] {
+			continue
+		}
+		ctxLogger.Info(fmt.Sprintf("[%s] of phone [%s] is missing for user [%s]", sim, heartbeat.Owner, heartbeat.UserID))
+		service.dispatchTelemetryEvent(ctx, events.EventTypePhoneSIMMissing, source, heartbeat, &events.PhoneSIMMissingPayload{
+			HeartbeatID: heartbeat.ID,
+			UserID:      heartbeat.UserID,
+			Owner:       heartbeat.Owner,
+			SIM:         sim,
+			Timestamp:   heartbeat.Timestamp,
+		})
+	}
+}
+
+func (service *HeartbeatService) dispatchTelemetryEvent(ctx context.Context, eventType string, source string, heartbeat *entities.Heartbeat, payload any) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	event, err := service.createEvent(eventType, source, payload)
+	if err != nil {
+		msg := fmt.Sprintf("cannot create [%s] event for heartbeat with ID [%s]", eventType, heartbeat.ID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return
+	}
+
+	if err = service.dispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch [%s] event for heartbeat with ID [%s]", eventType, heartbeat.ID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return
+	}
+
+	ctxLogger.Info(fmt.Sprintf("[%s] event dispatched with ID [%s] for heartbeat [%s] and user [%s]", event.Type(), event.ID(), heartbeat.ID, heartbeat.UserID))
+}
+
 // HeartbeatMonitorStoreParams are parameters for creating a new entities.Heartbeat
 type HeartbeatMonitorStoreParams struct {
 	Owner   string
@@ -135,6 +219,18 @@ func (service *HeartbeatService) handleHeartbeatWhenPhoneWasOffline(ctx context.
 		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
 	}
 
+	err := service.availability.Record(ctx, &PhoneAvailabilityRecordParams{
+		UserID:    monitor.UserID,
+		PhoneID:   monitor.PhoneID,
+		Owner:     monitor.Owner,
+		Online:    true,
+		Timestamp: heartbeat.Timestamp,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot record phone [%s] as online for heartbeat monitor [%s]", monitor.PhoneID, monitor.ID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+	}
+
 	event, err := service.createEvent(events.EventTypePhoneHeartbeatOnline, source, &events.PhoneHeartbeatOnlinePayload{
 		PhoneID:                monitor.PhoneID,
 		UserID:                 monitor.UserID,
@@ -182,6 +278,7 @@ func (service *HeartbeatService) StoreMonitor(ctx context.Context, params *Heart
 		PhoneID:   monitor.PhoneID,
 		UserID:    monitor.UserID,
 		MonitorID: monitor.ID,
+		Interval:  monitor.Interval(),
 		Source:    params.Source,
 	}
 	if err = service.scheduleHeartbeatCheck(ctx, time.Now().UTC(), monitorParams); err != nil {
@@ -225,13 +322,20 @@ func (service *HeartbeatService) phoneMonitor(ctx context.Context, params *Heart
 	return monitor, monitor.RequiresCheck(), nil
 }
 
-// DeleteMonitor an entities.HeartbeatMonitor
+// DeleteMonitor an entities.HeartbeatMonitor and the heartbeat check which is queued for it
 func (service *HeartbeatService) DeleteMonitor(ctx context.Context, userID entities.UserID, owner string) error {
 	ctx, span := service.tracer.Start(ctx)
 	defer span.End()
 
 	ctxLogger := service.tracer.CtxLogger(service.logger, span)
 
+	if monitor, err := service.monitorRepository.Load(ctx, userID, owner); err == nil {
+		if err = service.dispatcher.Cancel(ctx, monitor.QueueID); err != nil {
+			msg := fmt.Sprintf("cannot cancel queued heartbeat check [%s] for monitor [%s]", monitor.QueueID, monitor.ID)
+			ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		}
+	}
+
 	if err := service.monitorRepository.Delete(ctx, userID, owner); err != nil {
 		msg := fmt.Sprintf("cannot delete heartbeat monitor with userID [%s] and owner [%s]", userID, owner)
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
@@ -241,6 +345,77 @@ func (service *HeartbeatService) DeleteMonitor(ctx context.Context, userID entit
 	return nil
 }
 
+// HeartbeatMonitorUpdateParams are parameters for updating the settings of an entities.HeartbeatMonitor
+type HeartbeatMonitorUpdateParams struct {
+	Owner            string
+	UserID           entities.UserID
+	IntervalSeconds  uint
+	MissedThreshold  uint
+	OfflineThreshold uint
+	Source           string
+}
+
+// UpdateMonitor updates the interval and thresholds of an entities.HeartbeatMonitor and reschedules its heartbeat check
+func (service *HeartbeatService) UpdateMonitor(ctx context.Context, params *HeartbeatMonitorUpdateParams) (*entities.HeartbeatMonitor, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	monitor, err := service.monitorRepository.Load(ctx, params.UserID, params.Owner)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load heartbeat monitor with userID [%s] and owner [%s]", params.UserID, params.Owner)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	monitor.IntervalSeconds = params.IntervalSeconds
+	monitor.MissedThreshold = params.MissedThreshold
+	monitor.OfflineThreshold = params.OfflineThreshold
+
+	if err = service.monitorRepository.Update(ctx, monitor); err != nil {
+		msg := fmt.Sprintf("cannot update heartbeat monitor with ID [%s]", monitor.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	// the queued check was scheduled with the previous interval
+	if err = service.dispatcher.Cancel(ctx, monitor.QueueID); err != nil {
+		msg := fmt.Sprintf("cannot cancel queued heartbeat check [%s] for monitor [%s]", monitor.QueueID, monitor.ID)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+	}
+
+	monitorParams := &HeartbeatMonitorParams{
+		Owner:     monitor.Owner,
+		PhoneID:   monitor.PhoneID,
+		UserID:    monitor.UserID,
+		MonitorID: monitor.ID,
+		Interval:  monitor.Interval(),
+		Source:    params.Source,
+	}
+	if err = service.scheduleHeartbeatCheck(ctx, time.Now().UTC(), monitorParams); err != nil {
+		msg := fmt.Sprintf("cannot schedule healthcheck for monitor [%s] with owner [%s] and userID [%s]", monitor.ID, params.Owner, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	event, err := service.createEvent(events.EventTypePhoneHeartbeatMonitorUpdated, params.Source, &events.PhoneHeartbeatMonitorUpdatedPayload{
+		PhoneID:         monitor.PhoneID,
+		UserID:          monitor.UserID,
+		MonitorID:       monitor.ID,
+		Owner:           monitor.Owner,
+		IntervalSeconds: monitor.IntervalSeconds,
+		Timestamp:       time.Now().UTC(),
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create [%s] event for monitor with ID [%s]", events.EventTypePhoneHeartbeatMonitorUpdated, monitor.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.dispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event [%s] for heartbeat monitor [%s]", event.Type(), monitor.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("heartbeat monitor [%s] updated with interval [%s] for user [%s]", monitor.ID, monitor.Interval(), monitor.UserID))
+	return monitor, nil
+}
+
 // UpdatePhoneOnline updates the phone_online field in an entities.HeartbeatMonitor
 func (service *HeartbeatService) UpdatePhoneOnline(ctx context.Context, userID entities.UserID, monitorID uuid.UUID, phoneOnline bool) error {
 	ctx, span := service.tracer.Start(ctx)
@@ -263,6 +438,7 @@ type HeartbeatMonitorParams struct {
 	MonitorID uuid.UUID
 	PhoneID   uuid.UUID
 	UserID    entities.UserID
+	Interval  time.Duration
 	Source    string
 }
 
@@ -288,6 +464,7 @@ func (service *HeartbeatService) Monitor(ctx context.Context, params *HeartbeatM
 	// Update params in case of ID duplicate
 	params.PhoneID = monitor.PhoneID
 	params.MonitorID = monitor.ID
+	params.Interval = monitor.Interval()
 
 	heartbeat, err := service.repository.Last(ctx, params.UserID, params.Owner)
 	if err != nil {
@@ -296,14 +473,18 @@ func (service *HeartbeatService) Monitor(ctx context.Context, params *HeartbeatM
 		return nil
 	}
 
-	// send urgent FCM message if the last heartbeat is late
-	if time.Now().UTC().Sub(heartbeat.Timestamp) > heartbeatCheckInterval && time.Now().UTC().Sub(heartbeat.Timestamp) < (heartbeatCheckInterval*5) {
+	// the offline event is only sent once in the interval after the offline threshold is reached
+	elapsed := time.Now().UTC().Sub(heartbeat.Timestamp)
+	offlineWindowEnd := monitor.OfflineDuration() + monitor.Interval()
+
+	// send urgent push notification if the last heartbeat is late
+	if elapsed > monitor.MissedDuration() && elapsed < offlineWindowEnd {
 		ctxLogger.Info(fmt.Sprintf("sending missed heartbeat notification for userID [%s] and owner [%s] and monitor ID [%s]", params.UserID, params.Owner, params.MonitorID))
 		service.handleMissedMonitor(ctx, heartbeat.Timestamp, params)
 	}
 
-	if time.Now().UTC().Sub(heartbeat.Timestamp) > (heartbeatCheckInterval*4) &&
-		time.Now().UTC().Sub(heartbeat.Timestamp) < (heartbeatCheckInterval*5) {
+	// a phone which is still marked as online is past the offline window when the offline event was suppressed by a maintenance window
+	if elapsed > monitor.OfflineDuration() && (elapsed < offlineWindowEnd || monitor.PhoneOnline) {
 		return service.handleFailedMonitor(ctx, heartbeat.Timestamp, params)
 	}
 
@@ -328,7 +509,7 @@ func (service *HeartbeatService) handleMissedMonitor(ctx context.Context, lastTi
 		return
 	}
 
-	if _, err = service.dispatcher.DispatchWithTimeout(ctx, event, heartbeatCheckInterval); err != nil {
+	if _, err = service.dispatcher.DispatchWithTimeout(ctx, event, service.checkInterval(params)); err != nil {
 		msg := fmt.Sprintf("cannot dispatch event [%s] for heartbeat monitor with phone id [%s]", event.Type(), params.PhoneID)
 		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
 	}
@@ -344,6 +525,23 @@ func (service *HeartbeatService) handleFailedMonitor(ctx context.Context, lastTi
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
+	if service.maintenance.InMaintenance(ctx, params.UserID, params.Owner, time.Now().UTC()) {
+		ctxLogger.Info(fmt.Sprintf("suppressed offline event for heartbeat monitor with id [%s] and phone id [%s] in maintenance for user [%s]", params.MonitorID, params.PhoneID, params.UserID))
+		return nil
+	}
+
+	err = service.availability.Record(ctx, &PhoneAvailabilityRecordParams{
+		UserID:    params.UserID,
+		PhoneID:   params.PhoneID,
+		Owner:     params.Owner,
+		Online:    false,
+		Timestamp: lastTimestamp,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot record phone [%s] as offline for heartbeat monitor [%s]", params.PhoneID, params.MonitorID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+	}
+
 	event, err := service.createPhoneHeartbeatOfflineEvent(params.Source, &events.PhoneHeartbeatOfflinePayload{
 		PhoneID:                params.PhoneID,
 		UserID:                 params.UserID,
@@ -374,7 +572,7 @@ func (service *HeartbeatService) scheduleHeartbeatCheck(ctx context.Context, las
 		PhoneID:     params.PhoneID,
 		UserID:      params.UserID,
 		MonitorID:   params.MonitorID,
-		ScheduledAt: lastTimestamp.Add(heartbeatCheckInterval),
+		ScheduledAt: lastTimestamp.Add(service.checkInterval(params)),
 		Owner:       params.Owner,
 	})
 	if err != nil {
@@ -382,7 +580,7 @@ func (service *HeartbeatService) scheduleHeartbeatCheck(ctx context.Context, las
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	queueID, err := service.dispatcher.DispatchWithTimeout(ctx, event, heartbeatCheckInterval)
+	queueID, err := service.dispatcher.DispatchWithTimeout(ctx, event, service.checkInterval(params))
 	if err != nil {
 		msg := fmt.Sprintf("cannot dispatch event [%s] for heartbeat monitor with phone id [%s]", event.Type(), params.PhoneID)
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
@@ -398,6 +596,14 @@ func (service *HeartbeatService) scheduleHeartbeatCheck(ctx context.Context, las
 	return nil
 }
 
+// checkInterval returns the interval of the monitor with a default for checks which are scheduled before the monitor is loaded
+func (service *HeartbeatService) checkInterval(params *HeartbeatMonitorParams) time.Duration {
+	if params.Interval == 0 {
+		return entities.HeartbeatMonitorDefaultIntervalSeconds * time.Second
+	}
+	return params.Interval
+}
+
 func (service *HeartbeatService) createPhoneHeartbeatMissedEvent(source string, payload *events.PhoneHeartbeatMissedPayload) (cloudevents.Event, error) {
 	return service.createEvent(events.PhoneHeartbeatMissed, source, payload)
 }
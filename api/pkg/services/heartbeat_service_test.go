@@ -0,0 +1,325 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+)
+
+// testHeartbeatRepository is a repositories.HeartbeatRepository which returns the last stored entities.Heartbeat
+type testHeartbeatRepository struct {
+	repositories.HeartbeatRepository
+	last *entities.Heartbeat
+}
+
+func (repository *testHeartbeatRepository) Store(_ context.Context, heartbeat *entities.Heartbeat) error {
+	repository.last = heartbeat
+	return nil
+}
+
+func (repository *testHeartbeatRepository) Last(_ context.Context, _ entities.UserID, _ string) (*entities.Heartbeat, error) {
+	if repository.last == nil {
+		return nil, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, "heartbeat does not exist")
+	}
+	return repository.last, nil
+}
+
+// testHeartbeatMonitorRepository is a repositories.HeartbeatMonitorRepository with a single monitor
+type testHeartbeatMonitorRepository struct {
+	repositories.HeartbeatMonitorRepository
+	monitor *entities.HeartbeatMonitor
+}
+
+func (repository *testHeartbeatMonitorRepository) Load(_ context.Context, _ entities.UserID, _ string) (*entities.HeartbeatMonitor, error) {
+	if repository.monitor == nil {
+		return nil, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, "heartbeat monitor does not exist")
+	}
+	return repository.monitor, nil
+}
+
+func (repository *testHeartbeatMonitorRepository) UpdateQueueID(_ context.Context, _ uuid.UUID, _ string) error {
+	return nil
+}
+
+// testMaintenanceWindowRepository is a repositories.MaintenanceWindowRepository with fixed maintenance windows
+type testMaintenanceWindowRepository struct {
+	repositories.MaintenanceWindowRepository
+	windows []*entities.MaintenanceWindow
+}
+
+func (repository *testMaintenanceWindowRepository) LoadStarted(_ context.Context, _ entities.UserID, _ string, _ time.Time) ([]*entities.MaintenanceWindow, error) {
+	return repository.windows, nil
+}
+
+// testPhoneAvailabilityRepository is a repositories.PhoneAvailabilityRepository which records the transitions
+type testPhoneAvailabilityRepository struct {
+	repositories.PhoneAvailabilityRepository
+	mutex   sync.Mutex
+	periods []*entities.PhoneAvailabilityPeriod
+}
+
+func (repository *testPhoneAvailabilityRepository) Transition(_ context.Context, period *entities.PhoneAvailabilityPeriod) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+	repository.periods = append(repository.periods, period)
+	return nil
+}
+
+// testHeartbeatService creates a HeartbeatService for the monitor whose phone is in the maintenance windows
+func testHeartbeatService(
+	heartbeat *entities.Heartbeat,
+	monitor *entities.HeartbeatMonitor,
+	windows []*entities.MaintenanceWindow,
+) (*HeartbeatService, *testPushQueue, *testPhoneAvailabilityRepository) {
+	logger, tracer := testLoggerAndTracer()
+	dispatcher, queue := testEventDispatcher()
+	availabilityRepository := &testPhoneAvailabilityRepository{}
+
+	service := NewHeartbeatService(
+		logger,
+		tracer,
+		&testHeartbeatRepository{last: heartbeat},
+		&testHeartbeatMonitorRepository{monitor: monitor},
+		dispatcher,
+		NewPhoneAvailabilityService(logger, tracer, availabilityRepository, nil, nil),
+		NewMaintenanceWindowService(logger, tracer, &testMaintenanceWindowRepository{windows: windows}),
+	)
+	return service, queue, availabilityRepository
+}
+
+// testHeartbeatMonitor creates a monitor which expects a heartbeat every minute, is missed after 2 minutes and offline after 5 minutes
+func testHeartbeatMonitor(phoneOnline bool) *entities.HeartbeatMonitor {
+	return &entities.HeartbeatMonitor{
+		ID:               uuid.New(),
+		PhoneID:          uuid.New(),
+		UserID:           "user-id",
+		Owner:            "+18005550199",
+		PhoneOnline:      phoneOnline,
+		IntervalSeconds:  60,
+		MissedThreshold:  2,
+		OfflineThreshold: 5,
+	}
+}
+
+func TestHeartbeatService_Monitor(t *testing.T) {
+	testCases := []struct {
+		name        string
+		elapsed     time.Duration
+		phoneOnline bool
+		events      []string
+		offline     bool
+	}{
+		{
+			name:        "schedules a check before the missed threshold",
+			elapsed:     90 * time.Second,
+			phoneOnline: true,
+			events:      []string{events.EventTypePhoneHeartbeatCheck},
+		},
+		{
+			name:        "sends a missed event after the missed threshold",
+			elapsed:     150 * time.Second,
+			phoneOnline: true,
+			events:      []string{events.PhoneHeartbeatMissed, events.EventTypePhoneHeartbeatCheck},
+		},
+		{
+			name:        "sends an offline event in the interval after the offline threshold",
+			elapsed:     330 * time.Second,
+			phoneOnline: true,
+			events:      []string{events.PhoneHeartbeatMissed, events.EventTypePhoneHeartbeatCheck, events.EventTypePhoneHeartbeatOffline},
+			offline:     true,
+		},
+		{
+			name:        "only schedules a check after the offline window when the phone is offline",
+			elapsed:     400 * time.Second,
+			phoneOnline: false,
+			events:      []string{events.EventTypePhoneHeartbeatCheck},
+		},
+		{
+			name:        "sends an offline event after the offline window when the phone is still online",
+			elapsed:     400 * time.Second,
+			phoneOnline: true,
+			events:      []string{events.EventTypePhoneHeartbeatCheck, events.EventTypePhoneHeartbeatOffline},
+			offline:     true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			// Setup
+			t.Parallel()
+			monitor := testHeartbeatMonitor(testCase.phoneOnline)
+
+			// Arrange
+			heartbeat := &entities.Heartbeat{
+				ID:        uuid.New(),
+				UserID:    monitor.UserID,
+				Owner:     monitor.Owner,
+				Timestamp: time.Now().UTC().Add(-testCase.elapsed),
+			}
+			service, queue, availability := testHeartbeatService(heartbeat, monitor, nil)
+
+			// Act
+			err := service.Monitor(context.Background(), &HeartbeatMonitorParams{
+				Owner:  monitor.Owner,
+				UserID: monitor.UserID,
+				Source: "test",
+			})
+
+			// Assert
+			assert.Nil(t, err)
+			assert.Equal(t, testCase.events, queue.Events())
+			assert.Equal(t, testCase.offline, len(availability.periods) == 1)
+		})
+	}
+}
+
+func TestHeartbeatService_Monitor_Maintenance(t *testing.T) {
+	t.Run("suppresses the offline event while the phone is in maintenance", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		monitor := testHeartbeatMonitor(true)
+
+		// Arrange
+		heartbeat := &entities.Heartbeat{
+			ID:        uuid.New(),
+			UserID:    monitor.UserID,
+			Owner:     monitor.Owner,
+			Timestamp: time.Now().UTC().Add(-330 * time.Second),
+		}
+		window := &entities.MaintenanceWindow{
+			ID:         uuid.New(),
+			PhoneID:    monitor.PhoneID,
+			UserID:     monitor.UserID,
+			Owner:      monitor.Owner,
+			StartsAt:   time.Now().UTC().Add(-time.Hour),
+			EndsAt:     time.Now().UTC().Add(time.Hour),
+			Recurrence: entities.MaintenanceWindowRecurrenceNone,
+		}
+		service, queue, availability := testHeartbeatService(heartbeat, monitor, []*entities.MaintenanceWindow{window})
+
+		// Act
+		err := service.Monitor(context.Background(), &HeartbeatMonitorParams{
+			Owner:  monitor.Owner,
+			UserID: monitor.UserID,
+			Source: "test",
+		})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, []string{events.PhoneHeartbeatMissed, events.EventTypePhoneHeartbeatCheck}, queue.Events())
+		assert.Empty(t, availability.periods)
+	})
+
+	t.Run("sends the offline event after the maintenance window ended", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		monitor := testHeartbeatMonitor(true)
+
+		// Arrange
+		heartbeat := &entities.Heartbeat{
+			ID:        uuid.New(),
+			UserID:    monitor.UserID,
+			Owner:     monitor.Owner,
+			Timestamp: time.Now().UTC().Add(-400 * time.Second),
+		}
+		window := &entities.MaintenanceWindow{
+			ID:         uuid.New(),
+			PhoneID:    monitor.PhoneID,
+			UserID:     monitor.UserID,
+			Owner:      monitor.Owner,
+			StartsAt:   time.Now().UTC().Add(-time.Hour),
+			EndsAt:     time.Now().UTC().Add(-time.Minute),
+			Recurrence: entities.MaintenanceWindowRecurrenceNone,
+		}
+		service, queue, availability := testHeartbeatService(heartbeat, monitor, []*entities.MaintenanceWindow{window})
+
+		// Act
+		err := service.Monitor(context.Background(), &HeartbeatMonitorParams{
+			Owner:  monitor.Owner,
+			UserID: monitor.UserID,
+			Source: "test",
+		})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, []string{events.EventTypePhoneHeartbeatCheck, events.EventTypePhoneHeartbeatOffline}, queue.Events())
+		assert.Len(t, availability.periods, 1)
+	})
+}
+
+func TestHeartbeatService_Store(t *testing.T) {
+	testCases := []struct {
+		name     string
+		previous *entities.Heartbeat
+		params   HeartbeatStoreParams
+		events   []string
+	}{
+		{
+			name:     "sends a battery low event when the battery drops below the threshold",
+			previous: &entities.Heartbeat{BatteryLevel: testUint(50)},
+			params:   HeartbeatStoreParams{BatteryLevel: testUint(10)},
+			events:   []string{events.EventTypePhoneBatteryLow},
+		},
+		{
+			name:     "does not repeat the battery low event while the battery stays low",
+			previous: &entities.Heartbeat{BatteryLevel: testUint(12)},
+			params:   HeartbeatStoreParams{BatteryLevel: testUint(10)},
+			events:   []string{},
+		},
+		{
+			name:     "does not send a battery low event while the phone is charging",
+			previous: &entities.Heartbeat{BatteryLevel: testUint(50)},
+			params:   HeartbeatStoreParams{BatteryLevel: testUint(10), Charging: true},
+			events:   []string{},
+		},
+		{
+			name: "sends a SIM missing event when a SIM card is removed",
+			previous: &entities.Heartbeat{SIMs: []entities.HeartbeatSIM{
+				{SIM: entities.SIM1, State: entities.HeartbeatSIMStateReady},
+				{SIM: entities.SIM2, State: entities.HeartbeatSIMStateAbsent},
+			}},
+			params: HeartbeatStoreParams{SIMs: []entities.HeartbeatSIM{
+				{SIM: entities.SIM1, State: entities.HeartbeatSIMStateAbsent},
+				{SIM: entities.SIM2, State: entities.HeartbeatSIMStateAbsent},
+			}},
+			events: []string{events.EventTypePhoneSIMMissing},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			// Setup
+			t.Parallel()
+			service, queue, _ := testHeartbeatService(testCase.previous, nil, nil)
+
+			// Arrange
+			params := testCase.params
+			params.UserID = "user-id"
+			params.Owner = "+18005550199"
+			params.Source = "test"
+			params.Timestamp = time.Now().UTC()
+
+			// Act
+			heartbeat, err := service.Store(context.Background(), params)
+
+			// Assert
+			assert.Nil(t, err)
+			assert.Equal(t, params.BatteryLevel, heartbeat.BatteryLevel)
+			assert.Equal(t, testCase.events, queue.Events())
+		})
+	}
+}
+
+func testUint(value uint) *uint {
+	return &value
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+// MaintenanceWindowService manages the maintenance windows of phones
+type MaintenanceWindowService struct {
+	service
+	logger     telemetry.Logger
+	tracer     telemetry.Tracer
+	repository repositories.MaintenanceWindowRepository
+}
+
+// NewMaintenanceWindowService creates a new MaintenanceWindowService
+func NewMaintenanceWindowService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.MaintenanceWindowRepository,
+) (s *MaintenanceWindowService) {
+	return &MaintenanceWindowService{
+		logger:     logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:     tracer,
+		repository: repository,
+	}
+}
+
+// Index fetches the entities.MaintenanceWindow of a phone
+func (service *MaintenanceWindowService) Index(ctx context.Context, userID entities.UserID, phoneID uuid.UUID) ([]*entities.MaintenanceWindow, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	windows, err := service.repository.Index(ctx, userID, phoneID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch maintenance windows for phone [%s] and user [%s]", phoneID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("fetched [%d] maintenance windows for phone [%s] and user [%s]", len(windows), phoneID, userID))
+	return windows, nil
+}
+
+// MaintenanceWindowStoreParams are parameters for creating a new entities.MaintenanceWindow
+type MaintenanceWindowStoreParams struct {
+	UserID     entities.UserID
+	Phone      *entities.Phone
+	Reason     *string
+	StartsAt   time.Time
+	EndsAt     time.Time
+	Recurrence entities.MaintenanceWindowRecurrence
+}
+
+// Store a new entities.MaintenanceWindow
+func (service *MaintenanceWindowService) Store(ctx context.Context, params *MaintenanceWindowStoreParams) (*entities.MaintenanceWindow, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	window := &entities.MaintenanceWindow{
+		ID:         uuid.New(),
+		PhoneID:    params.Phone.ID,
+		UserID:     params.UserID,
+		Owner:      params.Phone.PhoneNumber,
+		Reason:     params.Reason,
+		StartsAt:   params.StartsAt,
+		EndsAt:     params.EndsAt,
+		Recurrence: params.Recurrence,
+		CreatedAt:  time.Now().UTC(),
+		UpdatedAt:  time.Now().UTC(),
+	}
+
+	if err := service.repository.Store(ctx, window); err != nil {
+		msg := fmt.Sprintf("cannot store maintenance window for phone [%s] and user [%s]", params.Phone.ID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("created [%s] maintenance window [%s] for phone [%s] and user [%s]", window.Recurrence, window.ID, window.PhoneID, window.UserID))
+	return window, nil
+}
+
+// Delete an entities.MaintenanceWindow of a phone
+func (service *MaintenanceWindowService) Delete(ctx context.Context, userID entities.UserID, phoneID uuid.UUID, windowID uuid.UUID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if err := service.repository.Delete(ctx, userID, phoneID, windowID); err != nil {
+		msg := fmt.Sprintf("cannot delete maintenance window [%s] for phone [%s] and user [%s]", windowID, phoneID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("deleted maintenance window [%s] for phone [%s] and user [%s]", windowID, phoneID, userID))
+	return nil
+}
+
+// ActiveUntil returns the end of the maintenance of a phone number at the timestamp or nil if the phone is not in maintenance
+func (service *MaintenanceWindowService) ActiveUntil(ctx context.Context, userID entities.UserID, owner string, timestamp time.Time) (*time.Time, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	windows, err := service.repository.LoadStarted(ctx, userID, owner, timestamp)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load maintenance windows for owner [%s] and user [%s]", owner, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	var activeUntil *time.Time
+	for _, window := range windows {
+		if end, ok := window.ActiveUntil(timestamp); ok && (activeUntil == nil || end.After(*activeUntil)) {
+			activeUntil = &end
+		}
+	}
+
+	return activeUntil, nil
+}
+
+// InMaintenance checks if a phone number is in maintenance at the timestamp. Errors are logged and treated as not in maintenance.
+func (service *MaintenanceWindowService) InMaintenance(ctx context.Context, userID entities.UserID, owner string, timestamp time.Time) bool {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	activeUntil, err := service.ActiveUntil(ctx, userID, owner, timestamp)
+	if err != nil {
+		msg := fmt.Sprintf("cannot check maintenance of owner [%s] and user [%s]", owner, userID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return false
+	}
+
+	return activeUntil != nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
 	"strings"
 
 	"github.com/sendgrid/sendgrid-go"
@@ -98,6 +99,50 @@ func (service *MarketingService) DeleteContacts(ctx context.Context, contactIDs
 	return nil
 }
 
+// ContactIDs fetches the IDs of the sendgrid contacts with the given emails
+func (service *MarketingService) ContactIDs(ctx context.Context, emails []string) ([]string, error) {
+	_, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	request := sendgrid.GetRequest(service.sendgridAPIKey, "/v3/marketing/contacts/search/emails", "https://api.sendgrid.com")
+	request.Method = "POST"
+
+	body, err := json.Marshal(map[string][]string{"emails": emails})
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot marshal emails [%s]", strings.Join(emails, ",")))
+	}
+
+	request.Body = body
+	response, err := sendgrid.API(request)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot search contacts with emails [%s]", strings.Join(emails, ",")))
+	}
+
+	if response.StatusCode == http.StatusNotFound {
+		return []string{}, nil
+	}
+
+	var payload struct {
+		Result map[string]struct {
+			Contact struct {
+				ID string `json:"id"`
+			} `json:"contact"`
+		} `json:"result"`
+	}
+	if err = json.Unmarshal([]byte(response.Body), &payload); err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot unmarshal sendgrid response [%s]", response.Body))
+	}
+
+	var contactIDs []string
+	for _, result := range payload.Result {
+		if result.Contact.ID != "" {
+			contactIDs = append(contactIDs, result.Contact.ID)
+		}
+	}
+
+	return contactIDs, nil
+}
+
 func (service *MarketingService) toSendgridContact(user *auth.UserRecord) sendgridContact {
 	name := strings.TrimSpace(user.DisplayName)
 	if name == "" {
@@ -0,0 +1,549 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/emails"
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/palantir/stacktrace"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	// messageExportBatchSize is the number of messages which are loaded from the database at once
+	messageExportBatchSize = 1000
+
+	// messageExportTTL is how long the download link of an entities.MessageExport is valid
+	messageExportTTL = 7 * 24 * time.Hour
+)
+
+// messageExportColumns are the columns of the CSV and XLSX exports
+var messageExportColumns = []string{
+	"id",
+	"request_id",
+	"owner",
+	"contact",
+	"type",
+	"status",
+	"sim",
+	"content",
+	"encrypted",
+	"failure_reason",
+	"created_at",
+	"sent_at",
+	"delivered_at",
+	"failed_at",
+	"expired_at",
+	"received_at",
+}
+
+// MessageExportService exports entities.Message of a user
+type MessageExportService struct {
+	service
+	logger            telemetry.Logger
+	tracer            telemetry.Tracer
+	repository        repositories.MessageExportRepository
+	messageRepository repositories.MessageRepository
+	mailer            emails.Mailer
+	emailFactory      emails.UserEmailFactory
+	dispatcher        *EventDispatcher
+}
+
+// NewMessageExportService creates a new MessageExportService
+func NewMessageExportService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.MessageExportRepository,
+	messageRepository repositories.MessageRepository,
+	mailer emails.Mailer,
+	emailFactory emails.UserEmailFactory,
+	dispatcher *EventDispatcher,
+) (s *MessageExportService) {
+	return &MessageExportService{
+		logger:            logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:            tracer,
+		repository:        repository,
+		messageRepository: messageRepository,
+		mailer:            mailer,
+		emailFactory:      emailFactory,
+		dispatcher:        dispatcher,
+	}
+}
+
+// MessageExportParams are parameters for exporting entities.Message
+type MessageExportParams struct {
+	UserID        entities.UserID
+	Email         string
+	Format        entities.MessageExportFormat
+	Owners        []string
+	Types         []entities.MessageType
+	Statuses      []entities.MessageStatus
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+}
+
+// Export writes the entities.Message matching the MessageExportParams to the io.Writer in batches so that the whole
+// history of a user is never loaded into memory at once.
+func (service *MessageExportService) Export(ctx context.Context, params *MessageExportParams, writer io.Writer) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	exportWriter, err := service.newWriter(params.Format, writer)
+	if err != nil {
+		msg := fmt.Sprintf("cannot create [%s] writer for user [%s]", params.Format, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	count := 0
+	indexParams := repositories.IndexParams{
+		Limit:         messageExportBatchSize,
+		CreatedAfter:  params.CreatedAfter,
+		CreatedBefore: params.CreatedBefore,
+	}
+
+	err = service.messageRepository.Export(ctx, params.UserID, params.Owners, params.Types, params.Statuses, indexParams, func(messages []*entities.Message) error {
+		for _, message := range messages {
+			if err := exportWriter.write(message); err != nil {
+				return stacktrace.Propagate(err, fmt.Sprintf("cannot write message with ID [%s]", message.ID))
+			}
+		}
+		count += len(messages)
+		return nil
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot export messages for user [%s] with params [%+#v]", params.UserID, params)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = exportWriter.close(); err != nil {
+		msg := fmt.Sprintf("cannot close [%s] writer for user [%s]", params.Format, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("exported [%d] messages as [%s] for user [%s]", count, params.Format, params.UserID))
+	return nil
+}
+
+// ExportFile writes the entities.Message matching the MessageExportParams to a temporary file so that the export is only served
+// when all the messages were exported. The temporary file is removed when the returned io.ReadCloser is closed.
+func (service *MessageExportService) ExportFile(ctx context.Context, params *MessageExportParams) (io.ReadCloser, int64, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	file, err := os.CreateTemp("", "message-export-*."+params.Format.String())
+	if err != nil {
+		msg := fmt.Sprintf("cannot create temporary file for [%s] export of user [%s]", params.Format, params.UserID)
+		return nil, 0, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	temporaryFile := &messageExportFile{file: file}
+	if err = service.Export(ctx, params, file); err != nil {
+		_ = temporaryFile.Close()
+		msg := fmt.Sprintf("cannot write [%s] export of user [%s] to [%s]", params.Format, params.UserID, file.Name())
+		return nil, 0, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		_ = temporaryFile.Close()
+		msg := fmt.Sprintf("cannot rewind [%s] export of user [%s] in [%s]", params.Format, params.UserID, file.Name())
+		return nil, 0, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return temporaryFile, size, nil
+}
+
+// Store creates an entities.MessageExport and dispatches the events.MessageExportRequested event which generates the file
+// in the background and emails the download link when the file is ready
+func (service *MessageExportService) Store(ctx context.Context, source string, params *MessageExportParams) (*entities.MessageExport, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if err := service.repository.DeleteExpired(ctx, params.UserID, time.Now().UTC()); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot delete expired message exports of user [%s]", params.UserID)))
+	}
+
+	export := &entities.MessageExport{
+		ID:            uuid.New(),
+		UserID:        params.UserID,
+		Email:         params.Email,
+		Format:        params.Format,
+		Owners:        params.Owners,
+		Types:         toStringArray(params.Types),
+		Statuses:      toStringArray(params.Statuses),
+		CreatedAfter:  params.CreatedAfter,
+		CreatedBefore: params.CreatedBefore,
+		Status:        entities.MessageExportStatusPending,
+		ExpiresAt:     time.Now().UTC().Add(messageExportTTL),
+		CreatedAt:     time.Now().UTC(),
+		UpdatedAt:     time.Now().UTC(),
+	}
+
+	if err := service.repository.Store(ctx, export); err != nil {
+		msg := fmt.Sprintf("cannot store message export with ID [%s] for user [%s]", export.ID, export.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	event, err := service.createEvent(events.MessageExportRequested, source, &events.MessageExportRequestedPayload{
+		MessageExportID: export.ID,
+		UserID:          export.UserID,
+		Email:           export.Email,
+		Format:          export.Format.String(),
+		Timestamp:       export.CreatedAt,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for message export [%s]", events.MessageExportRequested, export.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.dispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch [%s] event for message export [%s]", event.Type(), export.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("created message export with ID [%s] for user [%s]", export.ID, export.UserID))
+	return export, nil
+}
+
+// Generate creates the file of an entities.MessageExport and emails the download link when the file is stored.
+// The file is not generated again when the event is retried after the file was stored.
+func (service *MessageExportService) Generate(ctx context.Context, payload *events.MessageExportRequestedPayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	export, err := service.repository.Load(ctx, payload.UserID, payload.MessageExportID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load message export [%s] for user [%s]", payload.MessageExportID, payload.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !export.IsReady() {
+		if err = service.storeFile(ctx, export); err != nil {
+			msg := fmt.Sprintf("cannot generate the file of message export [%s] for user [%s]", export.ID, export.UserID)
+			return service.tracer.WrapErrorSpan(span, service.markFailed(ctx, export, stacktrace.Propagate(err, msg)))
+		}
+	}
+
+	token, err := service.generateSecret(32)
+	if err != nil {
+		msg := fmt.Sprintf("cannot generate message export token for export [%s]", export.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	export.TokenHash = repositories.HashToken(token)
+	export.ExpiresAt = time.Now().UTC().Add(messageExportTTL)
+	export.UpdatedAt = time.Now().UTC()
+	if err = service.repository.Update(ctx, export); err != nil {
+		msg := fmt.Sprintf("cannot store the download token of message export [%s]", export.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	email, err := service.emailFactory.MessageExportReady(export, token)
+	if err != nil {
+		msg := fmt.Sprintf("cannot create message export email for export [%s]", export.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.mailer.Send(ctx, email); err != nil {
+		msg := fmt.Sprintf("cannot send message export email for export [%s] to user [%s]", export.ID, export.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("message export email sent to [%s] for export [%s] of [%d] bytes", export.Email, export.ID, export.Size))
+	return nil
+}
+
+// Download returns the stored file of an entities.MessageExport which is read one chunk at a time
+func (service *MessageExportService) Download(ctx context.Context, export *entities.MessageExport) io.Reader {
+	return &messageExportChunkReader{ctx: ctx, repository: service.repository, export: export}
+}
+
+func (service *MessageExportService) storeFile(ctx context.Context, export *entities.MessageExport) error {
+	file, _, err := service.ExportFile(ctx, service.ExportParams(export))
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot export the messages of message export [%s]", export.ID))
+	}
+	defer func() { _ = file.Close() }()
+
+	export.Status = entities.MessageExportStatusReady
+	export.UpdatedAt = time.Now().UTC()
+	if err = service.repository.StoreFile(ctx, export, file); err != nil {
+		export.Status = entities.MessageExportStatusPending
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot store the file of message export [%s]", export.ID))
+	}
+
+	return nil
+}
+
+func (service *MessageExportService) markFailed(ctx context.Context, export *entities.MessageExport, err error) error {
+	export.Status = entities.MessageExportStatusFailed
+	export.UpdatedAt = time.Now().UTC()
+	if updateErr := service.repository.Update(ctx, export); updateErr != nil {
+		service.logger.Error(stacktrace.Propagate(updateErr, fmt.Sprintf("cannot mark message export [%s] as failed", export.ID)))
+	}
+	return err
+}
+
+// LoadByToken loads an entities.MessageExport using the token in the download link
+func (service *MessageExportService) LoadByToken(ctx context.Context, exportID uuid.UUID, token string) (*entities.MessageExport, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	export, err := service.repository.LoadByToken(ctx, exportID, token)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load message export with ID [%s]", exportID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	return export, nil
+}
+
+// ExportParams converts an entities.MessageExport into MessageExportParams
+func (service *MessageExportService) ExportParams(export *entities.MessageExport) *MessageExportParams {
+	params := &MessageExportParams{
+		UserID:        export.UserID,
+		Email:         export.Email,
+		Format:        export.Format,
+		Owners:        export.Owners,
+		CreatedAfter:  export.CreatedAfter,
+		CreatedBefore: export.CreatedBefore,
+	}
+
+	for _, messageType := range export.Types {
+		params.Types = append(params.Types, entities.MessageType(messageType))
+	}
+
+	for _, status := range export.Statuses {
+		params.Statuses = append(params.Statuses, entities.MessageStatus(status))
+	}
+
+	return params
+}
+
+// toStringArray converts a slice of string types into a pq.StringArray
+func toStringArray[T ~string](values []T) pq.StringArray {
+	result := make(pq.StringArray, 0, len(values))
+	for _, value := range values {
+		result = append(result, string(value))
+	}
+	return result
+}
+
+func (service *MessageExportService) newWriter(format entities.MessageExportFormat, writer io.Writer) (messageExportWriter, error) {
+	switch format {
+	case entities.MessageExportFormatCSV:
+		return newCSVMessageExportWriter(writer)
+	case entities.MessageExportFormatNDJSON:
+		return &ndjsonMessageExportWriter{encoder: json.NewEncoder(writer)}, nil
+	case entities.MessageExportFormatXLSX:
+		return newXLSXMessageExportWriter(writer)
+	default:
+		return nil, stacktrace.NewError(fmt.Sprintf("the export format [%s] is not supported", format))
+	}
+}
+
+// messageExportFile is a temporary file which is removed when it is closed
+type messageExportFile struct {
+	file *os.File
+}
+
+func (file *messageExportFile) Read(p []byte) (int, error) {
+	return file.file.Read(p)
+}
+
+func (file *messageExportFile) Close() error {
+	err := file.file.Close()
+	if removeErr := os.Remove(file.file.Name()); removeErr != nil && err == nil {
+		err = removeErr
+	}
+	return err
+}
+
+// messageExportChunkReader reads the file of an entities.MessageExport by loading one entities.MessageExportChunk at a time
+type messageExportChunkReader struct {
+	ctx        context.Context
+	repository repositories.MessageExportRepository
+	export     *entities.MessageExport
+	index      int
+	buffer     []byte
+}
+
+func (reader *messageExportChunkReader) Read(p []byte) (int, error) {
+	if len(reader.buffer) == 0 {
+		if reader.index >= reader.export.Chunks {
+			return 0, io.EOF
+		}
+
+		chunk, err := reader.repository.LoadChunk(reader.ctx, reader.export.ID, reader.index)
+		if err != nil {
+			return 0, stacktrace.Propagate(err, fmt.Sprintf("cannot load chunk [%d] of message export [%s]", reader.index, reader.export.ID))
+		}
+
+		reader.buffer = chunk.Data
+		reader.index++
+	}
+
+	n := copy(p, reader.buffer)
+	reader.buffer = reader.buffer[n:]
+	return n, nil
+}
+
+// messageExportWriter writes an entities.Message in an entities.MessageExportFormat
+type messageExportWriter interface {
+	write(message *entities.Message) error
+	close() error
+}
+
+type csvMessageExportWriter struct {
+	writer *csv.Writer
+}
+
+func newCSVMessageExportWriter(writer io.Writer) (*csvMessageExportWriter, error) {
+	csvWriter := csv.NewWriter(writer)
+	if err := csvWriter.Write(messageExportColumns); err != nil {
+		return nil, stacktrace.Propagate(err, "cannot write the CSV header")
+	}
+	return &csvMessageExportWriter{writer: csvWriter}, nil
+}
+
+func (writer *csvMessageExportWriter) write(message *entities.Message) error {
+	row := messageExportRow(message)
+	for index, value := range row {
+		row[index] = escapeCSVFormula(value)
+	}
+	return writer.writer.Write(row)
+}
+
+// escapeCSVFormula prefixes a value which starts with a formula character with a single quote so that
+// spreadsheet applications which open the CSV file do not run the value as a formula
+func escapeCSVFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func (writer *csvMessageExportWriter) close() error {
+	writer.writer.Flush()
+	return writer.writer.Error()
+}
+
+type ndjsonMessageExportWriter struct {
+	encoder *json.Encoder
+}
+
+func (writer *ndjsonMessageExportWriter) write(message *entities.Message) error {
+	return writer.encoder.Encode(message)
+}
+
+func (writer *ndjsonMessageExportWriter) close() error {
+	return nil
+}
+
+// xlsxMessageExportWriter uses the excelize.StreamWriter which flushes rows to a temporary file instead of keeping them in memory
+type xlsxMessageExportWriter struct {
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	writer io.Writer
+	row    int
+}
+
+func newXLSXMessageExportWriter(writer io.Writer) (*xlsxMessageExportWriter, error) {
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter("Sheet1")
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot create excel stream writer")
+	}
+
+	exportWriter := &xlsxMessageExportWriter{file: file, stream: stream, writer: writer, row: 1}
+	if err = exportWriter.writeRow(messageExportColumns); err != nil {
+		return nil, stacktrace.Propagate(err, "cannot write the excel header")
+	}
+
+	return exportWriter, nil
+}
+
+func (writer *xlsxMessageExportWriter) write(message *entities.Message) error {
+	return writer.writeRow(messageExportRow(message))
+}
+
+func (writer *xlsxMessageExportWriter) writeRow(values []string) error {
+	cell, err := excelize.CoordinatesToCellName(1, writer.row)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot get the cell name of row [%d]", writer.row))
+	}
+
+	row := make([]any, len(values))
+	for index, value := range values {
+		row[index] = value
+	}
+
+	writer.row++
+	return writer.stream.SetRow(cell, row)
+}
+
+func (writer *xlsxMessageExportWriter) close() error {
+	defer func() { _ = writer.file.Close() }()
+
+	if err := writer.stream.Flush(); err != nil {
+		return stacktrace.Propagate(err, "cannot flush the excel stream writer")
+	}
+
+	if err := writer.file.Write(writer.writer); err != nil {
+		return stacktrace.Propagate(err, "cannot write the excel file")
+	}
+
+	return nil
+}
+
+// messageExportRow returns the values of an entities.Message in the order of messageExportColumns
+func messageExportRow(message *entities.Message) []string {
+	formatTime := func(timestamp *time.Time) string {
+		if timestamp == nil {
+			return ""
+		}
+		return timestamp.UTC().Format(time.RFC3339)
+	}
+
+	formatString := func(value *string) string {
+		if value == nil {
+			return ""
+		}
+		return *value
+	}
+
+	return []string{
+		message.ID.String(),
+		formatString(message.RequestID),
+		message.Owner,
+		message.Contact,
+		string(message.Type),
+		string(message.Status),
+		message.SIM.String(),
+		message.Content,
+		strconv.FormatBool(message.Encrypted),
+		formatString(message.FailureReason),
+		formatTime(&message.CreatedAt),
+		formatTime(message.SentAt),
+		formatTime(message.DeliveredAt),
+		formatTime(message.FailedAt),
+		formatTime(message.ExpiredAt),
+		formatTime(message.ReceivedAt),
+	}
+}
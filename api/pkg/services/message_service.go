@@ -11,6 +11,7 @@ import (
 	"github.com/nyaruka/phonenumbers"
 
 	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/pubsub"
 	"github.com/NdoleStudio/httpsms/pkg/repositories"
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/google/uuid"
@@ -28,6 +29,9 @@ type MessageService struct {
 	eventDispatcher *EventDispatcher
 	phoneService    *PhoneService
 	repository      repositories.MessageRepository
+	pubSub          pubsub.PubSub
+	streamLimiter   *pubsub.StreamLimiter
+	maintenance     *MaintenanceWindowService
 }
 
 // NewMessageService creates a new MessageService
@@ -37,6 +41,9 @@ func NewMessageService(
 	repository repositories.MessageRepository,
 	eventDispatcher *EventDispatcher,
 	phoneService *PhoneService,
+	pubSub pubsub.PubSub,
+	streamLimiter *pubsub.StreamLimiter,
+	maintenance *MaintenanceWindowService,
 ) (s *MessageService) {
 	return &MessageService{
 		logger:          logger.WithService(fmt.Sprintf("%T", s)),
@@ -44,6 +51,9 @@ func NewMessageService(
 		repository:      repository,
 		phoneService:    phoneService,
 		eventDispatcher: eventDispatcher,
+		pubSub:          pubSub,
+		streamLimiter:   streamLimiter,
+		maintenance:     maintenance,
 	}
 }
 
@@ -60,19 +70,58 @@ func (service *MessageService) GetOutstanding(ctx context.Context, params Messag
 	ctx, span := service.tracer.Start(ctx)
 	defer span.End()
 
-	ctxLogger := service.tracer.CtxLogger(service.logger, span)
-
 	message, err := service.repository.GetOutstanding(ctx, params.UserID, params.MessageID)
 	if err != nil {
 		msg := fmt.Sprintf("could not fetch outstanding messages with params [%s]", spew.Sdump(params))
 		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
 	}
 
-	event, err := service.createMessagePhoneSendingEvent(params.Source, events.MessagePhoneSendingPayload{
+	if err = service.dispatchMessagePhoneSending(ctx, params.Source, message, params.Timestamp); err != nil {
+		return nil, service.tracer.WrapErrorSpan(span, err)
+	}
+
+	return message, nil
+}
+
+// MessageGetOutstandingBatchParams are parameters for fetching many outstanding messages of a phone
+type MessageGetOutstandingBatchParams struct {
+	Source    string
+	UserID    entities.UserID
+	Owner     string
+	Limit     int
+	Timestamp time.Time
+}
+
+// GetOutstandingBatch fetches the messages of a phone which are due to be sent, including the messages which were coalesced in the last push
+func (service *MessageService) GetOutstandingBatch(ctx context.Context, params MessageGetOutstandingBatchParams) ([]*entities.Message, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	messages, err := service.repository.GetOutstandingBatch(ctx, params.UserID, params.Owner, params.Timestamp.Add(PhoneNotificationCoalesceWindow), params.Limit)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch outstanding messages with params [%s]", spew.Sdump(params))
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	for _, message := range messages {
+		if err = service.dispatchMessagePhoneSending(ctx, params.Source, message, params.Timestamp); err != nil {
+			return nil, service.tracer.WrapErrorSpan(span, err)
+		}
+	}
+
+	ctxLogger.Info(fmt.Sprintf("fetched [%d] outstanding messages of phone [%s] for user [%s]", len(messages), params.Owner, params.UserID))
+	return messages, nil
+}
+
+func (service *MessageService) dispatchMessagePhoneSending(ctx context.Context, source string, message *entities.Message, timestamp time.Time) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	event, err := service.createMessagePhoneSendingEvent(source, events.MessagePhoneSendingPayload{
 		ID:        message.ID,
 		Owner:     message.Owner,
 		Contact:   message.Contact,
-		Timestamp: params.Timestamp,
+		Timestamp: timestamp,
 		Encrypted: message.Encrypted,
 		UserID:    message.UserID,
 		Content:   message.Content,
@@ -80,18 +129,90 @@ func (service *MessageService) GetOutstanding(ctx context.Context, params Messag
 	})
 	if err != nil {
 		msg := fmt.Sprintf("cannot create [%T] for message with ID [%s]", event, message.ID)
-		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		return stacktrace.Propagate(err, msg)
 	}
 
 	ctxLogger.Info(fmt.Sprintf("created event [%s] with id [%s] for message [%s]", event.Type(), event.ID(), message.ID))
 
 	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
 		msg := fmt.Sprintf("cannot dispatch event [%s] with id [%s] for message [%s]", event.Type(), event.ID(), message.ID)
-		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		return stacktrace.Propagate(err, msg)
 	}
 
 	ctxLogger.Info(fmt.Sprintf("dispatched event [%s] with id [%s] for message [%s]", event.Type(), event.ID(), message.ID))
-	return message, nil
+	return nil
+}
+
+// MessagePollOutstandingParams are parameters for long-polling the outstanding messages of a phone
+type MessagePollOutstandingParams struct {
+	Source  string
+	UserID  entities.UserID
+	Owner   string
+	Timeout time.Duration
+}
+
+// PollOutstanding waits until a message of the phone is due to be sent and fetches it like GetOutstanding.
+// It returns a nil message when no message is due before the timeout.
+func (service *MessageService) PollOutstanding(ctx context.Context, params MessagePollOutstandingParams) (*entities.Message, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phone, err := service.phoneService.Load(ctx, params.UserID, params.Owner)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone [%s] for user [%s]", params.Owner, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	release, ok := service.streamLimiter.Acquire(string(params.UserID))
+	if !ok {
+		msg := fmt.Sprintf("user [%s] has too many concurrent streams to long-poll phone [%s]", params.UserID, phone.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeTooManyStreams, msg))
+	}
+	defer release()
+
+	waitCtx, cancel := context.WithTimeout(ctx, params.Timeout)
+	defer cancel()
+
+	// subscribe before checking the database so that a message which becomes due in between is not missed
+	wakeups, err := service.pubSub.Subscribe(waitCtx, phoneOutstandingChannel(phone.ID))
+	if err != nil {
+		msg := fmt.Sprintf("cannot subscribe to outstanding messages of phone [%s]", phone.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	for {
+		message, err := service.repository.LoadDueOutstanding(ctx, params.UserID, phone.PhoneNumber, time.Now().UTC())
+		if err == nil {
+			message, err = service.GetOutstanding(ctx, MessageGetOutstandingParams{
+				Source:    params.Source,
+				UserID:    params.UserID,
+				Timestamp: time.Now().UTC(),
+				MessageID: message.ID,
+			})
+		}
+
+		if err == nil {
+			return message, nil
+		}
+
+		if stacktrace.GetCode(err) != repositories.ErrCodeNotFound {
+			msg := fmt.Sprintf("cannot fetch outstanding message of phone [%s]", phone.ID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		select {
+		case <-waitCtx.Done():
+			ctxLogger.Info(fmt.Sprintf("no outstanding message for phone [%s] after [%s]", phone.ID, params.Timeout))
+			return nil, nil
+		case messageID, ok := <-wakeups:
+			if !ok {
+				// the subscription is closed when the timeout is reached
+				wakeups = nil
+				continue
+			}
+			ctxLogger.Info(fmt.Sprintf("long-poll of phone [%s] woken up for message [%s]", phone.ID, messageID))
+		}
+	}
 }
 
 // DeleteMessage deletes a message from the database
@@ -202,9 +323,10 @@ func (service *MessageService) RespondToMissedCall(ctx context.Context, source s
 // MessageGetParams parameters for sending a new message
 type MessageGetParams struct {
 	repositories.IndexParams
-	UserID  entities.UserID
-	Owner   string
-	Contact string
+	UserID    entities.UserID
+	Owner     string
+	Contact   string
+	RequestID string
 }
 
 // GetMessages fetches sent between 2 phone numbers
@@ -214,6 +336,15 @@ func (service *MessageService) GetMessages(ctx context.Context, params MessageGe
 
 	ctxLogger := service.tracer.CtxLogger(service.logger, span)
 
+	if params.RequestID != "" {
+		messages, err := service.repository.IndexByRequestID(ctx, params.UserID, params.RequestID, params.IndexParams)
+		if err != nil {
+			msg := fmt.Sprintf("could not fetch messages with parms [%+#v]", params)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		return messages, nil
+	}
+
 	messages, err := service.repository.Index(ctx, params.UserID, params.Owner, params.Contact, params.IndexParams)
 	if err != nil {
 		msg := fmt.Sprintf("could not fetch messages with parms [%+#v]", params)
@@ -224,6 +355,20 @@ func (service *MessageService) GetMessages(ctx context.Context, params MessageGe
 	return messages, nil
 }
 
+// GetMessagesByIDs fetches the messages with the given IDs
+func (service *MessageService) GetMessagesByIDs(ctx context.Context, userID entities.UserID, messageIDs []uuid.UUID) ([]*entities.Message, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	messages, err := service.repository.LoadMany(ctx, userID, messageIDs)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch [%d] messages for user [%s]", len(messageIDs), userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return messages, nil
+}
+
 // GetMessage fetches a message by the ID
 func (service *MessageService) GetMessage(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (*entities.Message, error) {
 	ctx, span := service.tracer.Start(ctx)
@@ -420,6 +565,8 @@ type MessageSendParams struct {
 	RequestID         *string
 	UserID            entities.UserID
 	RequestReceivedAt time.Time
+	// SIM overrides the default SIM of the phone. It is ignored when the message is routed to a failover phone.
+	SIM *entities.SIM
 }
 
 // SendMessage a new message
@@ -429,7 +576,7 @@ func (service *MessageService) SendMessage(ctx context.Context, params MessageSe
 
 	ctxLogger := service.tracer.CtxLogger(service.logger, span)
 
-	sendAttempts, sim := service.phoneSettings(ctx, params.UserID, phonenumbers.Format(params.Owner, phonenumbers.E164))
+	owner, sendAttempts, sim := service.phoneSettings(ctx, params.UserID, phonenumbers.Format(params.Owner, phonenumbers.E164), params.SIM)
 
 	eventPayload := events.MessageAPISentPayload{
 		MessageID:         uuid.New(),
@@ -437,7 +584,7 @@ func (service *MessageService) SendMessage(ctx context.Context, params MessageSe
 		Encrypted:         params.Encrypted,
 		MaxSendAttempts:   sendAttempts,
 		RequestID:         params.RequestID,
-		Owner:             phonenumbers.Format(params.Owner, phonenumbers.E164),
+		Owner:             owner,
 		Contact:           params.Contact,
 		RequestReceivedAt: params.RequestReceivedAt,
 		Content:           params.Content,
@@ -866,6 +1013,16 @@ func (service *MessageService) CheckExpired(ctx context.Context, params MessageC
 		return nil
 	}
 
+	activeUntil, err := service.maintenance.ActiveUntil(ctx, message.UserID, message.Owner, time.Now().UTC())
+	if err != nil {
+		msg := fmt.Sprintf("cannot check maintenance of owner [%s] for message [%s]", message.Owner, message.ID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+	}
+
+	if activeUntil != nil {
+		return service.holdExpiration(ctx, params.Source, message, *activeUntil)
+	}
+
 	event, err := service.createMessageSendExpiredEvent(params.Source, events.MessageSendExpiredPayload{
 		MessageID:        message.ID,
 		Owner:            message.Owner,
@@ -893,6 +1050,35 @@ func (service *MessageService) CheckExpired(ctx context.Context, params MessageC
 	return nil
 }
 
+// holdExpiration reschedules the expiration check of a message whose phone is in maintenance to after the maintenance ends
+func (service *MessageService) holdExpiration(ctx context.Context, source string, message *entities.Message, activeUntil time.Time) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phone, err := service.phoneService.Load(ctx, message.UserID, message.Owner)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone with owner [%s] for message [%s]", message.Owner, message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	now := time.Now().UTC()
+	err = service.ScheduleExpirationCheck(ctx, MessageScheduleExpirationParams{
+		MessageID:                 message.ID,
+		UserID:                    message.UserID,
+		NotificationSentAt:        now,
+		PhoneID:                   phone.ID,
+		MessageExpirationDuration: activeUntil.Sub(now) + phone.MessageExpirationDuration(),
+		Source:                    source,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot hold expiration of message [%s] until maintenance of phone [%s] ends", message.ID, phone.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("held expiration of message [%s] because phone [%s] is in maintenance until [%s]", message.ID, phone.ID, activeUntil))
+	return nil
+}
+
 // MessageSearchParams are parameters for searching messages
 type MessageSearchParams struct {
 	repositories.IndexParams
@@ -919,20 +1105,50 @@ func (service *MessageService) SearchMessages(ctx context.Context, params *Messa
 	return messages, nil
 }
 
-func (service *MessageService) phoneSettings(ctx context.Context, userID entities.UserID, owner string) (uint, entities.SIM) {
+// phoneSettings returns the phone number which sends a message of the owner with its max send attempts and SIM. Messages are sent by the failover phone while the phone is offline.
+func (service *MessageService) phoneSettings(ctx context.Context, userID entities.UserID, owner string, sim *entities.SIM) (string, uint, entities.SIM) {
 	ctx, span := service.tracer.Start(ctx)
 	defer span.End()
 
 	ctxLogger := service.tracer.CtxLogger(service.logger, span)
 
-	phone, err := service.phoneService.Load(ctx, userID, owner)
+	phone, err := service.phoneService.LoadSender(ctx, userID, owner)
 	if err != nil {
 		msg := fmt.Sprintf("cannot load phone for userID [%s] and owner [%s]. using default max send attempt of 2", userID, owner)
 		ctxLogger.Error(stacktrace.Propagate(err, msg))
-		return 2, entities.SIM1
+		if sim != nil {
+			return owner, 2, *sim
+		}
+		return owner, 2, entities.SIM1
+	}
+
+	// a message which is sent from the phone number of a SIM is sent by the phone with that SIM
+	if settings := phone.SIMWithPhoneNumber(owner); settings != nil && phone.PhoneNumber != owner {
+		owner, sim = phone.PhoneNumber, &settings.SIM
+	}
+
+	if sim == nil {
+		sim = &phone.SIM
+	}
+
+	if !phone.IsFailingOver() {
+		return owner, phone.MaxSendAttemptsSanitized(), *sim
+	}
+
+	failover, err := service.phoneService.LoadByID(ctx, userID, *phone.FailoverPhoneID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load failover phone [%s] of owner [%s] for user [%s]", *phone.FailoverPhoneID, owner, userID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return owner, phone.MaxSendAttemptsSanitized(), *sim
+	}
+
+	if failover.FailoverActiveAt != nil {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("failover phone [%s] of owner [%s] is also offline for user [%s]", failover.ID, owner, userID)))
+		return owner, phone.MaxSendAttemptsSanitized(), *sim
 	}
 
-	return phone.MaxSendAttemptsSanitized(), phone.SIM
+	ctxLogger.Info(fmt.Sprintf("routing message of offline phone [%s] to failover phone [%s] for user [%s]", phone.ID, failover.ID, userID))
+	return failover.PhoneNumber, failover.MaxSendAttemptsSanitized(), failover.SIM
 }
 
 // storeSentMessage a new message
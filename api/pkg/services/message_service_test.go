@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/pubsub"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+)
+
+// testPhoneRepository is a repositories.PhoneRepository with a single phone
+type testPhoneRepository struct {
+	repositories.PhoneRepository
+	phone *entities.Phone
+}
+
+func (repository *testPhoneRepository) Load(_ context.Context, _ entities.UserID, phoneNumber string) (*entities.Phone, error) {
+	if repository.phone == nil || repository.phone.PhoneNumber != phoneNumber {
+		return nil, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, "phone does not exist")
+	}
+	return repository.phone, nil
+}
+
+func (repository *testPhoneRepository) LoadByID(_ context.Context, _ entities.UserID, phoneID uuid.UUID) (*entities.Phone, error) {
+	if repository.phone == nil || repository.phone.ID != phoneID {
+		return nil, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, "phone does not exist")
+	}
+	return repository.phone, nil
+}
+
+// testOutstandingMessageRepository is a repositories.MessageRepository with at most one due message which signals every lookup
+type testOutstandingMessageRepository struct {
+	repositories.MessageRepository
+	mutex   sync.Mutex
+	due     *entities.Message
+	lookups chan struct{}
+}
+
+func (repository *testOutstandingMessageRepository) SetDue(message *entities.Message) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+	repository.due = message
+}
+
+func (repository *testOutstandingMessageRepository) LoadDueOutstanding(_ context.Context, _ entities.UserID, _ string, _ time.Time) (*entities.Message, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	select {
+	case repository.lookups <- struct{}{}:
+	default:
+	}
+
+	if repository.due == nil {
+		return nil, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, "no message is due")
+	}
+	return repository.due, nil
+}
+
+func (repository *testOutstandingMessageRepository) GetOutstanding(_ context.Context, _ entities.UserID, messageID uuid.UUID) (*entities.Message, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if repository.due == nil || repository.due.ID != messageID {
+		return nil, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, "message is not outstanding")
+	}
+	return repository.due, nil
+}
+
+// testPollMessageService creates a MessageService for long-polling the outstanding messages of the phone
+func testPollMessageService(phone *entities.Phone, repository repositories.MessageRepository, pubSub pubsub.PubSub) (*MessageService, *testPushQueue) {
+	logger, tracer := testLoggerAndTracer()
+	dispatcher, queue := testEventDispatcher()
+	phoneService := NewPhoneService(logger, tracer, &testPhoneRepository{phone: phone}, dispatcher, nil, nil)
+	return NewMessageService(logger, tracer, repository, dispatcher, phoneService, pubSub, pubsub.NewStreamLimiter(1), nil), queue
+}
+
+func TestMessageService_PollOutstanding(t *testing.T) {
+	t.Run("wakes up when a message of the phone becomes due", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		pubSub := pubsub.NewMemoryPubSub(logger, tracer)
+		phone := &entities.Phone{ID: uuid.New(), UserID: "user-id", PhoneNumber: "+18005550199"}
+		repository := &testOutstandingMessageRepository{lookups: make(chan struct{}, 1)}
+		service, queue := testPollMessageService(phone, repository, pubSub)
+
+		// Arrange
+		message := &entities.Message{ID: uuid.New(), UserID: phone.UserID, Owner: phone.PhoneNumber}
+		type result struct {
+			message *entities.Message
+			err     error
+		}
+		results := make(chan result, 1)
+		start := time.Now()
+		go func() {
+			polled, err := service.PollOutstanding(context.Background(), MessagePollOutstandingParams{
+				Source:  "test",
+				UserID:  phone.UserID,
+				Owner:   phone.PhoneNumber,
+				Timeout: 10 * time.Second,
+			})
+			results <- result{message: polled, err: err}
+		}()
+		<-repository.lookups
+
+		// Act
+		repository.SetDue(message)
+		assert.Nil(t, pubSub.Publish(context.Background(), phoneOutstandingChannel(phone.ID), message.ID.String()))
+
+		// Assert
+		polled := <-results
+		assert.Nil(t, polled.err)
+		assert.Equal(t, message, polled.message)
+		assert.Less(t, time.Since(start), 10*time.Second)
+		assert.Equal(t, []string{events.EventTypeMessagePhoneSending}, queue.Events())
+	})
+
+	t.Run("returns no message when no message becomes due before the timeout", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		phone := &entities.Phone{ID: uuid.New(), UserID: "user-id", PhoneNumber: "+18005550199"}
+		service, queue := testPollMessageService(phone, &testOutstandingMessageRepository{}, pubsub.NewMemoryPubSub(logger, tracer))
+
+		// Arrange
+		timeout := 50 * time.Millisecond
+		start := time.Now()
+
+		// Act
+		message, err := service.PollOutstanding(context.Background(), MessagePollOutstandingParams{
+			Source:  "test",
+			UserID:  phone.UserID,
+			Owner:   phone.PhoneNumber,
+			Timeout: timeout,
+		})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Nil(t, message)
+		assert.GreaterOrEqual(t, time.Since(start), timeout)
+		assert.Empty(t, queue.Events())
+	})
+
+	t.Run("returns not found when the phone does not exist", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		logger, tracer := testLoggerAndTracer()
+		service, _ := testPollMessageService(nil, &testOutstandingMessageRepository{}, pubsub.NewMemoryPubSub(logger, tracer))
+
+		// Act
+		message, err := service.PollOutstanding(context.Background(), MessagePollOutstandingParams{
+			Source:  "test",
+			UserID:  "user-id",
+			Owner:   "+18005550199",
+			Timeout: time.Second,
+		})
+
+		// Assert
+		assert.Nil(t, message)
+		assert.Equal(t, repositories.ErrCodeNotFound, stacktrace.GetCode(err))
+	})
+}
@@ -158,6 +158,20 @@ func (service *MessageThreadService) UpdateAfterDeletedMessage(ctx context.Conte
 	return nil
 }
 
+// PurgeLastMessageContent removes the last message content of the threads whose last message was purged by the retention policy
+func (service *MessageThreadService) PurgeLastMessageContent(ctx context.Context, payload *events.MessageContentPurgedPayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if err := service.repository.PurgeLastMessageContent(ctx, payload.UserID, payload.MessageIDs); err != nil {
+		msg := fmt.Sprintf("cannot purge the last message content of threads for user with ID [%s]", payload.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("purged the last message content of threads with [%d] purged messages for user with ID [%s]", len(payload.MessageIDs), payload.UserID))
+	return nil
+}
+
 func (service *MessageThreadService) createThread(ctx context.Context, params MessageThreadUpdateParams) error {
 	ctx, span := service.tracer.Start(ctx)
 	defer span.End()
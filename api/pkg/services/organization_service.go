@@ -0,0 +1,336 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/emails"
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+const organizationInvitationTTL = 7 * 24 * time.Hour
+
+// OrganizationService is handles organization requests
+type OrganizationService struct {
+	service
+	logger          telemetry.Logger
+	tracer          telemetry.Tracer
+	mailer          emails.Mailer
+	emailFactory    emails.UserEmailFactory
+	repository      repositories.OrganizationRepository
+	phoneRepository repositories.PhoneRepository
+}
+
+// NewOrganizationService creates a new OrganizationService
+func NewOrganizationService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.OrganizationRepository,
+	phoneRepository repositories.PhoneRepository,
+	mailer emails.Mailer,
+	emailFactory emails.UserEmailFactory,
+) (s *OrganizationService) {
+	return &OrganizationService{
+		logger:          logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:          tracer,
+		mailer:          mailer,
+		emailFactory:    emailFactory,
+		repository:      repository,
+		phoneRepository: phoneRepository,
+	}
+}
+
+// OrganizationStoreParams are parameters for creating a new entities.Organization
+type OrganizationStoreParams struct {
+	UserID entities.UserID
+	Email  string
+	Name   string
+}
+
+// Store a new entities.Organization where the user is the owner
+func (service *OrganizationService) Store(ctx context.Context, params *OrganizationStoreParams) (*entities.Organization, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	organization := &entities.Organization{
+		ID:        uuid.New(),
+		Name:      params.Name,
+		OwnerID:   params.UserID,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	owner := &entities.OrganizationMember{
+		ID:             uuid.New(),
+		OrganizationID: organization.ID,
+		UserID:         params.UserID,
+		Email:          params.Email,
+		Role:           entities.OrganizationRoleOwner,
+		CreatedAt:      time.Now().UTC(),
+		UpdatedAt:      time.Now().UTC(),
+	}
+
+	if err := service.repository.Store(ctx, organization, owner); err != nil {
+		msg := fmt.Sprintf("cannot store organization with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("created organization [%s] for user [%s]", organization.ID, organization.OwnerID))
+	return organization, nil
+}
+
+// Index fetches the entities.Organization which a user is a member of
+func (service *OrganizationService) Index(ctx context.Context, userID entities.UserID) ([]*entities.Organization, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	organizations, err := service.repository.Index(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch organizations for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return organizations, nil
+}
+
+// Get fetches an entities.Organization by ID
+func (service *OrganizationService) Get(ctx context.Context, organizationID uuid.UUID) (*entities.Organization, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	organization, err := service.repository.Load(ctx, organizationID)
+	if err != nil {
+		msg := fmt.Sprintf("could not load organization with ID [%s]", organizationID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	return organization, nil
+}
+
+// Member fetches the entities.OrganizationMember of a user in an organization
+func (service *OrganizationService) Member(ctx context.Context, organizationID uuid.UUID, userID entities.UserID) (*entities.OrganizationMember, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	member, err := service.repository.LoadMember(ctx, organizationID, userID)
+	if err != nil {
+		msg := fmt.Sprintf("could not load member [%s] of organization [%s]", userID, organizationID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	return member, nil
+}
+
+// IndexMembers fetches the entities.OrganizationMember of an organization
+func (service *OrganizationService) IndexMembers(ctx context.Context, organizationID uuid.UUID, params repositories.IndexParams) ([]*entities.OrganizationMember, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	members, err := service.repository.IndexMembers(ctx, organizationID, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch members of organization [%s] with params [%+#v]", organizationID, params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return members, nil
+}
+
+// OrganizationMemberUpdateParams are parameters for updating an entities.OrganizationMember
+type OrganizationMemberUpdateParams struct {
+	OrganizationID uuid.UUID
+	MemberID       uuid.UUID
+	Role           entities.OrganizationRole
+	PhoneIDs       []uuid.UUID
+	ActorRole      entities.OrganizationRole
+}
+
+// UpdateMember updates the role and phone grants of an entities.OrganizationMember
+func (service *OrganizationService) UpdateMember(ctx context.Context, params *OrganizationMemberUpdateParams) (*entities.OrganizationMember, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	member, err := service.repository.LoadMemberByID(ctx, params.OrganizationID, params.MemberID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load member with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	organization, err := service.repository.Load(ctx, params.OrganizationID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load organization with ID [%s]", params.OrganizationID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if member.Role != entities.OrganizationRoleOwner && (!params.ActorRole.CanChange(member.Role) || !params.ActorRole.CanChange(params.Role)) {
+		msg := fmt.Sprintf("a member with role [%s] cannot change member [%s] from role [%s] to [%s]", params.ActorRole, member.ID, member.Role, params.Role)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeForbidden, msg))
+	}
+
+	if member.Role != entities.OrganizationRoleOwner {
+		member.Role = params.Role
+		member.UpdatedAt = time.Now().UTC()
+		if err = service.repository.UpdateMember(ctx, member); err != nil {
+			msg := fmt.Sprintf("cannot update member [%s] with params [%+#v]", member.ID, params)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+	}
+
+	grants := make([]entities.OrganizationPhoneGrant, 0, len(params.PhoneIDs))
+	for _, phoneID := range params.PhoneIDs {
+		phone, err := service.phoneRepository.LoadByID(ctx, organization.OwnerID, phoneID)
+		if err != nil {
+			msg := fmt.Sprintf("cannot load phone [%s] of organization [%s]", phoneID, organization.ID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+		}
+
+		grants = append(grants, entities.OrganizationPhoneGrant{
+			ID:             uuid.New(),
+			OrganizationID: organization.ID,
+			MemberID:       member.ID,
+			PhoneID:        phone.ID,
+			Owner:          phone.PhoneNumber,
+			CreatedAt:      time.Now().UTC(),
+		})
+	}
+
+	if err = service.repository.ReplacePhoneGrants(ctx, member, grants); err != nil {
+		msg := fmt.Sprintf("cannot update phone grants for member [%s]", member.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("updated member [%s] of organization [%s] with role [%s] and [%d] phone grants", member.ID, organization.ID, member.Role, len(grants)))
+	return member, nil
+}
+
+// DeleteMember removes an entities.OrganizationMember from an organization
+func (service *OrganizationService) DeleteMember(ctx context.Context, organizationID uuid.UUID, memberID uuid.UUID, actorRole entities.OrganizationRole) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	member, err := service.repository.LoadMemberByID(ctx, organizationID, memberID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load member [%s] of organization [%s]", memberID, organizationID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if member.Role == entities.OrganizationRoleOwner {
+		msg := fmt.Sprintf("cannot delete the owner [%s] of organization [%s]", member.UserID, organizationID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.NewError(msg))
+	}
+
+	if !actorRole.CanChange(member.Role) {
+		msg := fmt.Sprintf("a member with role [%s] cannot delete member [%s] with role [%s]", actorRole, member.ID, member.Role)
+		return service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeForbidden, msg))
+	}
+
+	if err = service.repository.DeleteMember(ctx, organizationID, memberID); err != nil {
+		msg := fmt.Sprintf("cannot delete member [%s] of organization [%s]", memberID, organizationID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("deleted member [%s] with user ID [%s] from organization [%s]", member.ID, member.UserID, organizationID))
+	return nil
+}
+
+// OrganizationInviteParams are parameters for inviting a user to an entities.Organization
+type OrganizationInviteParams struct {
+	OrganizationID uuid.UUID
+	Email          string
+	Role           entities.OrganizationRole
+	InviterID      entities.UserID
+	InviterEmail   string
+}
+
+// Invite a user to join an entities.Organization by email
+func (service *OrganizationService) Invite(ctx context.Context, params *OrganizationInviteParams) (*entities.OrganizationInvitation, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	organization, err := service.repository.Load(ctx, params.OrganizationID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load organization with ID [%s]", params.OrganizationID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	token, err := service.generateSecret(32)
+	if err != nil {
+		msg := fmt.Sprintf("cannot generate invitation token for organization [%s]", organization.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	invitation := &entities.OrganizationInvitation{
+		ID:             uuid.New(),
+		OrganizationID: organization.ID,
+		Email:          params.Email,
+		Role:           params.Role,
+		TokenHash:      repositories.HashToken(token),
+		InvitedBy:      params.InviterID,
+		ExpiresAt:      time.Now().UTC().Add(organizationInvitationTTL),
+		CreatedAt:      time.Now().UTC(),
+		UpdatedAt:      time.Now().UTC(),
+	}
+
+	if err = service.repository.StoreInvitation(ctx, invitation); err != nil {
+		msg := fmt.Sprintf("cannot store invitation with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	email, err := service.emailFactory.OrganizationInvitation(invitation, organization, params.InviterEmail, token)
+	if err != nil {
+		msg := fmt.Sprintf("cannot create invitation email for invitation [%s]", invitation.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.mailer.Send(ctx, email); err != nil {
+		msg := fmt.Sprintf("canot send invitation email for invitation [%s]", invitation.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("invitation [%s] sent to join organization [%s] as [%s]", invitation.ID, organization.ID, invitation.Role))
+	return invitation, nil
+}
+
+// AcceptInvitation adds the authenticated user to the entities.Organization of an entities.OrganizationInvitation
+func (service *OrganizationService) AcceptInvitation(ctx context.Context, token string, authUser entities.AuthUser) (*entities.OrganizationMember, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	invitation, err := service.repository.LoadInvitationByToken(ctx, token)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load invitation for user [%s]", authUser.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if invitation.IsExpired(time.Now().UTC()) || !strings.EqualFold(invitation.Email, authUser.Email) {
+		msg := fmt.Sprintf("invitation [%s] is expired or was not sent to user [%s]", invitation.ID, authUser.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, msg))
+	}
+
+	acceptedAt := time.Now().UTC()
+	invitation.AcceptedAt = &acceptedAt
+	invitation.UpdatedAt = acceptedAt
+
+	member := &entities.OrganizationMember{
+		ID:             uuid.New(),
+		OrganizationID: invitation.OrganizationID,
+		UserID:         authUser.ID,
+		Email:          authUser.Email,
+		Role:           invitation.Role,
+		CreatedAt:      time.Now().UTC(),
+		UpdatedAt:      time.Now().UTC(),
+	}
+
+	if err = service.repository.AcceptInvitation(ctx, invitation, member); err != nil {
+		msg := fmt.Sprintf("cannot accept invitation [%s] for user [%s]", invitation.ID, authUser.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("user [%s] joined organization [%s] as [%s]", member.UserID, member.OrganizationID, member.Role))
+	return member, nil
+}
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+// PhoneAvailabilityService records when phones go online and offline and computes uptime reports
+type PhoneAvailabilityService struct {
+	service
+	logger          telemetry.Logger
+	tracer          telemetry.Tracer
+	repository      repositories.PhoneAvailabilityRepository
+	phoneRepository repositories.PhoneRepository
+	userRepository  repositories.UserRepository
+}
+
+// NewPhoneAvailabilityService creates a new PhoneAvailabilityService
+func NewPhoneAvailabilityService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.PhoneAvailabilityRepository,
+	phoneRepository repositories.PhoneRepository,
+	userRepository repositories.UserRepository,
+) (s *PhoneAvailabilityService) {
+	return &PhoneAvailabilityService{
+		logger:          logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:          tracer,
+		repository:      repository,
+		phoneRepository: phoneRepository,
+		userRepository:  userRepository,
+	}
+}
+
+// PhoneAvailabilityRecordParams are parameters for recording a change in the availability of a phone
+type PhoneAvailabilityRecordParams struct {
+	UserID    entities.UserID
+	PhoneID   uuid.UUID
+	Owner     string
+	Online    bool
+	Timestamp time.Time
+}
+
+// Record starts a new entities.PhoneAvailabilityPeriod when the availability of a phone changes
+func (service *PhoneAvailabilityService) Record(ctx context.Context, params *PhoneAvailabilityRecordParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	period := &entities.PhoneAvailabilityPeriod{
+		ID:        uuid.New(),
+		PhoneID:   params.PhoneID,
+		UserID:    params.UserID,
+		Owner:     params.Owner,
+		Online:    params.Online,
+		StartedAt: params.Timestamp,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	if err := service.repository.Transition(ctx, period); err != nil {
+		msg := fmt.Sprintf("cannot record availability [online=%t] of phone [%s] for user [%s]", params.Online, params.PhoneID, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("recorded availability [online=%t] of phone [%s] at [%s] for user [%s]", params.Online, params.PhoneID, params.Timestamp, params.UserID))
+	return nil
+}
+
+// PhoneUptimeParams are parameters for computing the uptime of a phone
+type PhoneUptimeParams struct {
+	UserID  entities.UserID
+	PhoneID uuid.UUID
+	From    time.Time
+	To      time.Time
+}
+
+// Uptime computes the entities.PhoneUptime of a phone. Time which is not covered by an offline period counts as online.
+func (service *PhoneAvailabilityService) Uptime(ctx context.Context, params *PhoneUptimeParams) (*entities.PhoneUptime, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phone, err := service.phoneRepository.LoadByID(ctx, params.UserID, params.PhoneID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone with ID [%s] for user [%s]", params.PhoneID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	user, err := service.userRepository.Load(ctx, params.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load user with ID [%s]", params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	now := time.Now().UTC()
+	to := params.To
+	if to.After(now) {
+		to = now
+	}
+
+	from, err := service.trackedFrom(ctx, phone, params.From)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch the start of the availability of phone [%s] for user [%s]", params.PhoneID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	if from.After(to) {
+		from = to
+	}
+
+	periods, err := service.repository.Index(ctx, params.UserID, params.PhoneID, from, to)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch availability periods of phone [%s] for user [%s]", params.PhoneID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	var outages []*entities.PhoneAvailabilityPeriod
+	for _, period := range periods {
+		if !period.Online {
+			outages = append(outages, period)
+		}
+	}
+
+	location := user.Location()
+	uptime := &entities.PhoneUptime{
+		PhoneID:                   phone.ID,
+		Owner:                     phone.PhoneNumber,
+		From:                      from.In(location),
+		To:                        to.In(location),
+		Timezone:                  location.String(),
+		Outages:                   service.outages(outages, now),
+		MeanTimeToRecoverySeconds: service.meanTimeToRecovery(outages),
+		Days:                      service.days(outages, from, to, location),
+	}
+	uptime.UptimePercentage, uptime.DowntimeSeconds = service.availability(outages, from, to)
+
+	ctxLogger.Info(fmt.Sprintf("computed uptime [%.3f%%] with [%d] outages for phone [%s] and user [%s]", uptime.UptimePercentage, len(outages), params.PhoneID, params.UserID))
+	return uptime, nil
+}
+
+// trackedFrom moves from to when the phone was created or when the availability of the phone was first recorded
+// so that the time before the availability was tracked is not counted as uptime.
+func (service *PhoneAvailabilityService) trackedFrom(ctx context.Context, phone *entities.Phone, from time.Time) (time.Time, error) {
+	if from.Before(phone.CreatedAt) {
+		from = phone.CreatedAt
+	}
+
+	first, err := service.repository.LoadFirst(ctx, phone.UserID, phone.ID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return from, nil
+	}
+
+	if err != nil {
+		return from, stacktrace.Propagate(err, fmt.Sprintf("cannot load the first availability period of phone [%s]", phone.ID))
+	}
+
+	if from.Before(first.StartedAt) {
+		from = first.StartedAt
+	}
+	return from, nil
+}
+
+func (service *PhoneAvailabilityService) outages(periods []*entities.PhoneAvailabilityPeriod, now time.Time) []entities.PhoneOutage {
+	outages := make([]entities.PhoneOutage, 0, len(periods))
+	for _, period := range periods {
+		end := now
+		if period.EndedAt != nil {
+			end = *period.EndedAt
+		}
+		outages = append(outages, entities.PhoneOutage{
+			StartedAt:       period.StartedAt,
+			EndedAt:         period.EndedAt,
+			DurationSeconds: int64(end.Sub(period.StartedAt).Seconds()),
+		})
+	}
+	return outages
+}
+
+func (service *PhoneAvailabilityService) meanTimeToRecovery(periods []*entities.PhoneAvailabilityPeriod) *int64 {
+	var total time.Duration
+	count := 0
+	for _, period := range periods {
+		if period.IsOpen() {
+			continue
+		}
+		total += period.EndedAt.Sub(period.StartedAt)
+		count++
+	}
+
+	if count == 0 {
+		return nil
+	}
+
+	mean := int64((total / time.Duration(count)).Seconds())
+	return &mean
+}
+
+func (service *PhoneAvailabilityService) days(periods []*entities.PhoneAvailabilityPeriod, from time.Time, to time.Time, location *time.Location) []entities.PhoneUptimeDay {
+	var days []entities.PhoneUptimeDay
+
+	start := from.In(location)
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, location)
+	for day.Before(to) {
+		next := day.AddDate(0, 0, 1)
+
+		dayFrom, dayTo := day, next
+		if dayFrom.Before(from) {
+			dayFrom = from
+		}
+		if dayTo.After(to) {
+			dayTo = to
+		}
+
+		percentage, downtime := service.availability(periods, dayFrom, dayTo)
+		days = append(days, entities.PhoneUptimeDay{
+			Date:             day.Format(time.DateOnly),
+			UptimePercentage: percentage,
+			DowntimeSeconds:  downtime,
+		})
+		day = next
+	}
+
+	return days
+}
+
+// availability returns the uptime percentage and the downtime in seconds between from and to
+func (service *PhoneAvailabilityService) availability(periods []*entities.PhoneAvailabilityPeriod, from time.Time, to time.Time) (float64, int64) {
+	window := to.Sub(from)
+	if window <= 0 {
+		return 100, 0
+	}
+
+	var downtime time.Duration
+	for _, period := range periods {
+		downtime += period.Overlap(from, to)
+	}
+
+	percentage := 100 * float64(window-downtime) / float64(window)
+	return math.Round(percentage*1000) / 1000, int64(downtime.Seconds())
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// PhoneCommandService pushes commands to phones and tracks their acknowledgements
+type PhoneCommandService struct {
+	service
+	logger          telemetry.Logger
+	tracer          telemetry.Tracer
+	repository      repositories.PhoneCommandRepository
+	phoneRepository repositories.PhoneRepository
+	pusher          PhonePusher
+	dispatcher      *EventDispatcher
+}
+
+// NewPhoneCommandService creates a new PhoneCommandService
+func NewPhoneCommandService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.PhoneCommandRepository,
+	phoneRepository repositories.PhoneRepository,
+	pusher PhonePusher,
+	dispatcher *EventDispatcher,
+) (s *PhoneCommandService) {
+	return &PhoneCommandService{
+		logger:          logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:          tracer,
+		repository:      repository,
+		phoneRepository: phoneRepository,
+		pusher:          pusher,
+		dispatcher:      dispatcher,
+	}
+}
+
+// PhoneCommandIndexParams are parameters for fetching the entities.PhoneCommand of a phone
+type PhoneCommandIndexParams struct {
+	UserID      entities.UserID
+	PhoneID     uuid.UUID
+	Statuses    []entities.PhoneCommandStatus
+	IndexParams repositories.IndexParams
+}
+
+// Index fetches the entities.PhoneCommand of a phone
+func (service *PhoneCommandService) Index(ctx context.Context, params *PhoneCommandIndexParams) ([]*entities.PhoneCommand, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	commands, err := service.repository.Index(ctx, params.UserID, params.PhoneID, params.Statuses, params.IndexParams)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch phone commands with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("fetched [%d] commands of phone [%s] for user [%s]", len(commands), params.PhoneID, params.UserID))
+	return commands, nil
+}
+
+// PhoneCommandStoreParams are parameters for creating a new entities.PhoneCommand
+type PhoneCommandStoreParams struct {
+	Source string
+	UserID entities.UserID
+	Phone  *entities.Phone
+	Type   entities.PhoneCommandType
+	SIM    *entities.SIM
+}
+
+// Store a new entities.PhoneCommand which is pushed to the phone asynchronously
+func (service *PhoneCommandService) Store(ctx context.Context, params *PhoneCommandStoreParams) (*entities.PhoneCommand, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	command := &entities.PhoneCommand{
+		ID:        uuid.New(),
+		PhoneID:   params.Phone.ID,
+		UserID:    params.UserID,
+		Owner:     params.Phone.PhoneNumber,
+		Type:      params.Type,
+		Status:    entities.PhoneCommandStatusPending,
+		SIM:       params.SIM,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	if err := service.repository.Store(ctx, command); err != nil {
+		msg := fmt.Sprintf("cannot store [%s] command for phone [%s] and user [%s]", params.Type, params.Phone.ID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	event, err := service.createEvent(events.EventTypePhoneCommandCreated, params.Source, &events.PhoneCommandCreatedPayload{
+		CommandID: command.ID,
+		PhoneID:   command.PhoneID,
+		UserID:    command.UserID,
+		Owner:     command.Owner,
+		Type:      command.Type,
+		Timestamp: command.CreatedAt,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create [%s] event for command [%s]", events.EventTypePhoneCommandCreated, command.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.dispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch [%s] event for command [%s]", event.Type(), command.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("created [%s] command [%s] for phone [%s] and user [%s]", command.Type, command.ID, command.PhoneID, command.UserID))
+	return command, nil
+}
+
+// Deliver pushes a pending entities.PhoneCommand to the phone. Commands of polling phones stay pending until the phone fetches them.
+func (service *PhoneCommandService) Deliver(ctx context.Context, payload *events.PhoneCommandCreatedPayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	command, err := service.repository.Load(ctx, payload.UserID, payload.PhoneID, payload.CommandID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load command [%s] of phone [%s] for user [%s]", payload.CommandID, payload.PhoneID, payload.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !command.IsPending() {
+		ctxLogger.Info(fmt.Sprintf("command [%s] of phone [%s] has status [%s] and will not be pushed", command.ID, command.PhoneID, command.Status))
+		return nil
+	}
+
+	phone, err := service.phoneRepository.LoadByID(ctx, payload.UserID, payload.PhoneID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone [%s] for user [%s]", payload.PhoneID, payload.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if phone.IsPolling() {
+		ctxLogger.Info(fmt.Sprintf("command [%s] is not pushed to phone [%s] which uses [%s] delivery", command.ID, phone.ID, phone.DeliveryMode))
+		return nil
+	}
+
+	data := map[string]string{
+		"KEY_COMMAND_ID":   command.ID.String(),
+		"KEY_COMMAND_TYPE": command.Type.String(),
+	}
+	if command.SIM != nil {
+		data["KEY_COMMAND_SIM"] = command.SIM.String()
+	}
+
+	result, err := service.pusher.Push(ctx, phone, &PhonePush{Data: data, HighPriority: true})
+	if err != nil {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot push command [%s] to phone [%s] with [%s] delivery", command.ID, phone.ID, phone.DeliveryMode)))
+		command.Failed(fmt.Sprintf("cannot send the command to your phone [%s]. Reinstall the httpSMS app on your Android phone.", phone.PhoneNumber), time.Now().UTC())
+	} else {
+		command.Delivered(time.Now().UTC())
+	}
+
+	command.UpdatedAt = time.Now().UTC()
+	if err = service.repository.Update(ctx, command); err != nil {
+		msg := fmt.Sprintf("cannot update command [%s] with status [%s]", command.ID, command.Status)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("command [%s] of phone [%s] is [%s] with push result [%s]", command.ID, phone.ID, command.Status, result))
+	return nil
+}
+
+// PhoneCommandAckParams are parameters for acknowledging an entities.PhoneCommand
+type PhoneCommandAckParams struct {
+	UserID       entities.UserID
+	PhoneID      uuid.UUID
+	CommandID    uuid.UUID
+	Status       entities.PhoneCommandStatus
+	ErrorMessage string
+	Timestamp    time.Time
+}
+
+// Ack records the result of an entities.PhoneCommand which was executed by the phone. Commands which are already acked or failed are not changed.
+func (service *PhoneCommandService) Ack(ctx context.Context, params *PhoneCommandAckParams) (*entities.PhoneCommand, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	command, err := service.repository.Load(ctx, params.UserID, params.PhoneID, params.CommandID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load command [%s] of phone [%s] for user [%s]", params.CommandID, params.PhoneID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if command.IsFinal() {
+		ctxLogger.Info(fmt.Sprintf("command [%s] of phone [%s] is already [%s]", command.ID, command.PhoneID, command.Status))
+		return command, nil
+	}
+
+	if params.Status == entities.PhoneCommandStatusFailed {
+		command.Failed(params.ErrorMessage, params.Timestamp)
+	} else {
+		command.Acked(params.Timestamp)
+	}
+
+	command.UpdatedAt = time.Now().UTC()
+	if err = service.repository.Update(ctx, command); err != nil {
+		msg := fmt.Sprintf("cannot update command [%s] with status [%s]", command.ID, command.Status)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("command [%s] of phone [%s] is [%s]", command.ID, command.PhoneID, command.Status))
+	return command, nil
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+)
+
+// testPhoneCommandRepository is a repositories.PhoneCommandRepository which keeps the commands in memory and counts the updates
+type testPhoneCommandRepository struct {
+	repositories.PhoneCommandRepository
+	commands map[uuid.UUID]*entities.PhoneCommand
+	updates  int
+}
+
+func (repository *testPhoneCommandRepository) Store(_ context.Context, command *entities.PhoneCommand) error {
+	repository.commands[command.ID] = command
+	return nil
+}
+
+func (repository *testPhoneCommandRepository) Update(_ context.Context, command *entities.PhoneCommand) error {
+	repository.updates++
+	repository.commands[command.ID] = command
+	return nil
+}
+
+func (repository *testPhoneCommandRepository) Load(_ context.Context, _ entities.UserID, _ uuid.UUID, commandID uuid.UUID) (*entities.PhoneCommand, error) {
+	command, ok := repository.commands[commandID]
+	if !ok {
+		return nil, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, "command does not exist")
+	}
+	return command, nil
+}
+
+// testPhonePusher is a PhonePusher which fails when err is set
+type testPhonePusher struct {
+	err    error
+	pushes []*PhonePush
+}
+
+func (pusher *testPhonePusher) Push(_ context.Context, _ *entities.Phone, push *PhonePush) (string, error) {
+	pusher.pushes = append(pusher.pushes, push)
+	return "push-id", pusher.err
+}
+
+// testPhoneCommandService creates a PhoneCommandService for commands of the phone
+func testPhoneCommandService(phone *entities.Phone, pusher *testPhonePusher) (*PhoneCommandService, *testPhoneCommandRepository, *testPushQueue) {
+	logger, tracer := testLoggerAndTracer()
+	dispatcher, queue := testEventDispatcher()
+	repository := &testPhoneCommandRepository{commands: map[uuid.UUID]*entities.PhoneCommand{}}
+	return NewPhoneCommandService(logger, tracer, repository, &testPhoneRepository{phone: phone}, pusher, dispatcher), repository, queue
+}
+
+// testPhoneCommandPhone creates a phone which receives commands with the delivery mode
+func testPhoneCommandPhone(deliveryMode entities.PhoneDeliveryMode) *entities.Phone {
+	return &entities.Phone{ID: uuid.New(), UserID: "user-id", PhoneNumber: "+18005550199", DeliveryMode: deliveryMode}
+}
+
+func TestPhoneCommandService_Ack(t *testing.T) {
+	t.Run("a command is pending, delivered and then acked by the phone", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		ctx := context.Background()
+		phone := testPhoneCommandPhone(entities.PhoneDeliveryModeFCM)
+		pusher := &testPhonePusher{}
+		service, repository, queue := testPhoneCommandService(phone, pusher)
+
+		// Arrange
+		command, err := service.Store(ctx, &PhoneCommandStoreParams{
+			Source: "test",
+			UserID: phone.UserID,
+			Phone:  phone,
+			Type:   entities.PhoneCommandTypeForceHeartbeat,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, entities.PhoneCommandStatusPending, command.Status)
+
+		err = service.Deliver(ctx, &events.PhoneCommandCreatedPayload{CommandID: command.ID, PhoneID: phone.ID, UserID: phone.UserID})
+		assert.Nil(t, err)
+		assert.Equal(t, entities.PhoneCommandStatusDelivered, repository.commands[command.ID].Status)
+
+		// Act
+		ackedAt := time.Now().UTC()
+		acked, err := service.Ack(ctx, &PhoneCommandAckParams{
+			UserID:    phone.UserID,
+			PhoneID:   phone.ID,
+			CommandID: command.ID,
+			Status:    entities.PhoneCommandStatusAcked,
+			Timestamp: ackedAt,
+		})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, entities.PhoneCommandStatusAcked, acked.Status)
+		assert.Equal(t, &ackedAt, acked.AckedAt)
+		assert.NotNil(t, acked.DeliveredAt)
+		assert.Nil(t, acked.FailedAt)
+		assert.Equal(t, command.ID.String(), pusher.pushes[0].Data["KEY_COMMAND_ID"])
+		assert.Equal(t, []string{events.EventTypePhoneCommandCreated}, queue.Events())
+	})
+
+	t.Run("a command fails with the error message of the phone", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		ctx := context.Background()
+		phone := testPhoneCommandPhone(entities.PhoneDeliveryModeFCM)
+		service, repository, _ := testPhoneCommandService(phone, &testPhonePusher{})
+
+		// Arrange
+		command := &entities.PhoneCommand{ID: uuid.New(), PhoneID: phone.ID, UserID: phone.UserID, Status: entities.PhoneCommandStatusDelivered}
+		repository.commands[command.ID] = command
+
+		// Act
+		failed, err := service.Ack(ctx, &PhoneCommandAckParams{
+			UserID:       phone.UserID,
+			PhoneID:      phone.ID,
+			CommandID:    command.ID,
+			Status:       entities.PhoneCommandStatusFailed,
+			ErrorMessage: "SIM2 is not available",
+			Timestamp:    time.Now().UTC(),
+		})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, entities.PhoneCommandStatusFailed, failed.Status)
+		assert.Equal(t, "SIM2 is not available", *failed.ErrorMessage)
+		assert.NotNil(t, failed.FailedAt)
+		assert.Nil(t, failed.AckedAt)
+	})
+
+	t.Run("an acked command is not changed by a later ack", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		ctx := context.Background()
+		phone := testPhoneCommandPhone(entities.PhoneDeliveryModeFCM)
+		service, repository, _ := testPhoneCommandService(phone, &testPhonePusher{})
+
+		// Arrange
+		ackedAt := time.Now().UTC().Add(-time.Minute)
+		command := (&entities.PhoneCommand{ID: uuid.New(), PhoneID: phone.ID, UserID: phone.UserID}).Acked(ackedAt)
+		repository.commands[command.ID] = command
+
+		// Act
+		result, err := service.Ack(ctx, &PhoneCommandAckParams{
+			UserID:       phone.UserID,
+			PhoneID:      phone.ID,
+			CommandID:    command.ID,
+			Status:       entities.PhoneCommandStatusFailed,
+			ErrorMessage: "duplicate",
+			Timestamp:    time.Now().UTC(),
+		})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, entities.PhoneCommandStatusAcked, result.Status)
+		assert.Equal(t, &ackedAt, result.AckedAt)
+		assert.Nil(t, result.ErrorMessage)
+		assert.Equal(t, 0, repository.updates)
+	})
+
+	t.Run("returns not found when the command does not exist", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		phone := testPhoneCommandPhone(entities.PhoneDeliveryModeFCM)
+		service, _, _ := testPhoneCommandService(phone, &testPhonePusher{})
+
+		// Act
+		command, err := service.Ack(context.Background(), &PhoneCommandAckParams{
+			UserID:    phone.UserID,
+			PhoneID:   phone.ID,
+			CommandID: uuid.New(),
+			Status:    entities.PhoneCommandStatusAcked,
+			Timestamp: time.Now().UTC(),
+		})
+
+		// Assert
+		assert.Nil(t, command)
+		assert.Equal(t, repositories.ErrCodeNotFound, stacktrace.GetCode(err))
+	})
+}
+
+func TestPhoneCommandService_Deliver(t *testing.T) {
+	t.Run("a command of a polling phone stays pending", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		phone := testPhoneCommandPhone(entities.PhoneDeliveryModePoll)
+		pusher := &testPhonePusher{}
+		service, repository, _ := testPhoneCommandService(phone, pusher)
+
+		// Arrange
+		command := &entities.PhoneCommand{ID: uuid.New(), PhoneID: phone.ID, UserID: phone.UserID, Status: entities.PhoneCommandStatusPending}
+		repository.commands[command.ID] = command
+
+		// Act
+		err := service.Deliver(context.Background(), &events.PhoneCommandCreatedPayload{CommandID: command.ID, PhoneID: phone.ID, UserID: phone.UserID})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, entities.PhoneCommandStatusPending, command.Status)
+		assert.Empty(t, pusher.pushes)
+	})
+
+	t.Run("a command fails when it cannot be pushed to the phone", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		phone := testPhoneCommandPhone(entities.PhoneDeliveryModeFCM)
+		service, repository, _ := testPhoneCommandService(phone, &testPhonePusher{err: errors.New("token is not registered")})
+
+		// Arrange
+		command := &entities.PhoneCommand{ID: uuid.New(), PhoneID: phone.ID, UserID: phone.UserID, Status: entities.PhoneCommandStatusPending}
+		repository.commands[command.ID] = command
+
+		// Act
+		err := service.Deliver(context.Background(), &events.PhoneCommandCreatedPayload{CommandID: command.ID, PhoneID: phone.ID, UserID: phone.UserID})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, entities.PhoneCommandStatusFailed, command.Status)
+		assert.NotNil(t, command.ErrorMessage)
+	})
+}
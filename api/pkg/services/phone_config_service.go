@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/palantir/stacktrace"
+)
+
+// PhoneConfigService manages the remote config of phones
+type PhoneConfigService struct {
+	service
+	logger            telemetry.Logger
+	tracer            telemetry.Tracer
+	repository        repositories.PhoneConfigRepository
+	phoneRepository   repositories.PhoneRepository
+	monitorRepository repositories.HeartbeatMonitorRepository
+	commandService    *PhoneCommandService
+	dispatcher        *EventDispatcher
+}
+
+// NewPhoneConfigService creates a new PhoneConfigService
+func NewPhoneConfigService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.PhoneConfigRepository,
+	phoneRepository repositories.PhoneRepository,
+	monitorRepository repositories.HeartbeatMonitorRepository,
+	commandService *PhoneCommandService,
+	dispatcher *EventDispatcher,
+) (s *PhoneConfigService) {
+	return &PhoneConfigService{
+		logger:            logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:            tracer,
+		repository:        repository,
+		phoneRepository:   phoneRepository,
+		monitorRepository: monitorRepository,
+		commandService:    commandService,
+		dispatcher:        dispatcher,
+	}
+}
+
+// Load the entities.PhoneConfigDocument of a phone
+func (service *PhoneConfigService) Load(ctx context.Context, phone *entities.Phone) (*entities.PhoneConfigDocument, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	config, err := service.config(ctx, phone)
+	if err != nil {
+		return nil, service.tracer.WrapErrorSpan(span, err)
+	}
+
+	return service.document(ctx, phone, config), nil
+}
+
+// PhoneConfigUpdateParams are parameters for updating an entities.PhoneConfig
+type PhoneConfigUpdateParams struct {
+	Source                 string
+	Phone                  *entities.Phone
+	ReceiveSIMs            []string
+	DeliveryReportsEnabled *bool
+	EncryptionEnabled      *bool
+}
+
+// Update the entities.PhoneConfig of a phone and push a refresh_config command to the phone
+func (service *PhoneConfigService) Update(ctx context.Context, params *PhoneConfigUpdateParams) (*entities.PhoneConfigDocument, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	config, err := service.config(ctx, params.Phone)
+	if err != nil {
+		return nil, service.tracer.WrapErrorSpan(span, err)
+	}
+
+	if len(params.ReceiveSIMs) > 0 {
+		config.ReceiveSIMs = pq.StringArray(params.ReceiveSIMs)
+	}
+	if params.DeliveryReportsEnabled != nil {
+		config.DeliveryReportsEnabled = *params.DeliveryReportsEnabled
+	}
+	if params.EncryptionEnabled != nil {
+		config.EncryptionEnabled = *params.EncryptionEnabled
+	}
+
+	document := service.document(ctx, params.Phone, config)
+	config.DocumentHash = document.ContentHash()
+	config.UpdatedAt = time.Now().UTC()
+	if err = service.repository.SaveNextVersion(ctx, config); err != nil {
+		msg := fmt.Sprintf("cannot save the next version of the config of phone [%s]", config.PhoneID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	document.Version = config.Version
+
+	ctxLogger.Info(fmt.Sprintf("updated config of phone [%s] to version [%d] for user [%s]", config.PhoneID, config.Version, config.UserID))
+
+	if err = service.dispatchPhoneUpdated(ctx, params.Source, params.Phone, config); err != nil {
+		return nil, service.tracer.WrapErrorSpan(span, err)
+	}
+
+	if err = service.pushRefresh(ctx, params.Source, params.Phone); err != nil {
+		return nil, service.tracer.WrapErrorSpan(span, err)
+	}
+
+	return document, nil
+}
+
+// Refresh increments the version of the config of a phone and pushes a refresh_config command to the phone
+// when the settings of the phone or its heartbeat monitor have changed the entities.PhoneConfigDocument.
+func (service *PhoneConfigService) Refresh(ctx context.Context, source string, userID entities.UserID, phoneID uuid.UUID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phone, err := service.phoneRepository.LoadByID(ctx, userID, phoneID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Info(fmt.Sprintf("phone [%s] of user [%s] has been deleted, the config is not refreshed", phoneID, userID))
+		return nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone [%s] for user [%s]", phoneID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	config, err := service.config(ctx, phone)
+	if err != nil {
+		return service.tracer.WrapErrorSpan(span, err)
+	}
+
+	hash := service.document(ctx, phone, config).ContentHash()
+	if hash == config.DocumentHash {
+		return nil
+	}
+
+	config.DocumentHash = hash
+	config.UpdatedAt = time.Now().UTC()
+	if err = service.repository.IncrementVersion(ctx, config); err != nil {
+		msg := fmt.Sprintf("cannot increment the version of the config of phone [%s]", config.PhoneID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("refreshed config of phone [%s] to version [%d] for user [%s]", config.PhoneID, config.Version, config.UserID))
+
+	if err = service.pushRefresh(ctx, source, phone); err != nil {
+		return service.tracer.WrapErrorSpan(span, err)
+	}
+
+	return nil
+}
+
+func (service *PhoneConfigService) pushRefresh(ctx context.Context, source string, phone *entities.Phone) error {
+	_, err := service.commandService.Store(ctx, &PhoneCommandStoreParams{
+		Source: source,
+		UserID: phone.UserID,
+		Phone:  phone,
+		Type:   entities.PhoneCommandTypeRefreshConfig,
+	})
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot push [%s] command to phone [%s]", entities.PhoneCommandTypeRefreshConfig, phone.ID))
+	}
+	return nil
+}
+
+// config loads the entities.PhoneConfig of a phone or the default config if the phone has no config
+func (service *PhoneConfigService) config(ctx context.Context, phone *entities.Phone) (*entities.PhoneConfig, error) {
+	config, err := service.repository.Load(ctx, phone.UserID, phone.ID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return entities.NewPhoneConfig(phone), nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load config of phone [%s] for user [%s]", phone.ID, phone.UserID)
+		return nil, stacktrace.Propagate(err, msg)
+	}
+
+	return config, nil
+}
+
+// document combines the phone and its config with the heartbeat interval of its monitor
+func (service *PhoneConfigService) document(ctx context.Context, phone *entities.Phone, config *entities.PhoneConfig) *entities.PhoneConfigDocument {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	interval := time.Duration(entities.HeartbeatMonitorDefaultIntervalSeconds) * time.Second
+	monitor, err := service.monitorRepository.Load(ctx, phone.UserID, phone.PhoneNumber)
+	if err == nil {
+		interval = monitor.Interval()
+	} else if stacktrace.GetCode(err) != repositories.ErrCodeNotFound {
+		msg := fmt.Sprintf("cannot load heartbeat monitor of phone [%s], using the default heartbeat interval", phone.ID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+	}
+
+	return entities.NewPhoneConfigDocument(phone, config, interval)
+}
+
+func (service *PhoneConfigService) dispatchPhoneUpdated(ctx context.Context, source string, phone *entities.Phone, config *entities.PhoneConfig) error {
+	event, err := service.createEvent(events.EventTypePhoneUpdated, source, events.PhoneUpdatedPayload{
+		PhoneID:   phone.ID,
+		UserID:    phone.UserID,
+		Timestamp: config.UpdatedAt,
+		Owner:     phone.PhoneNumber,
+		SIM:       phone.SIM,
+	})
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot create [%s] event for phone [%s]", events.EventTypePhoneUpdated, phone.ID))
+	}
+
+	if err = service.dispatcher.Dispatch(ctx, event); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot dispatch [%s] event for phone [%s]", event.Type(), phone.ID))
+	}
+	return nil
+}
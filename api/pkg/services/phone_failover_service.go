@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// PhoneFailoverService routes the messages of an offline phone to its failover phone
+type PhoneFailoverService struct {
+	service
+	logger            telemetry.Logger
+	tracer            telemetry.Tracer
+	phoneRepository   repositories.PhoneRepository
+	messageRepository repositories.MessageRepository
+	monitorRepository repositories.HeartbeatMonitorRepository
+	dispatcher        *EventDispatcher
+}
+
+// NewPhoneFailoverService creates a new PhoneFailoverService
+func NewPhoneFailoverService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	phoneRepository repositories.PhoneRepository,
+	messageRepository repositories.MessageRepository,
+	monitorRepository repositories.HeartbeatMonitorRepository,
+	dispatcher *EventDispatcher,
+) (s *PhoneFailoverService) {
+	return &PhoneFailoverService{
+		logger:            logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:            tracer,
+		phoneRepository:   phoneRepository,
+		messageRepository: messageRepository,
+		monitorRepository: monitorRepository,
+		dispatcher:        dispatcher,
+	}
+}
+
+// PhoneFailoverParams are parameters for starting or stopping the failover of a phone
+type PhoneFailoverParams struct {
+	Source    string
+	UserID    entities.UserID
+	PhoneID   uuid.UUID
+	Timestamp time.Time
+}
+
+// Activate routes the messages of an offline phone to its failover phone and moves the queued messages which are due
+func (service *PhoneFailoverService) Activate(ctx context.Context, params *PhoneFailoverParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phone, err := service.phoneRepository.LoadByID(ctx, params.UserID, params.PhoneID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone with ID [%s] for user [%s]", params.PhoneID, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if phone.FailoverPhoneID == nil {
+		ctxLogger.Info(fmt.Sprintf("phone [%s] of user [%s] has no failover phone", phone.ID, phone.UserID))
+		return nil
+	}
+
+	failover, err := service.phoneRepository.LoadByID(ctx, params.UserID, *phone.FailoverPhoneID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("failover phone [%s] of phone [%s] does not exist", *phone.FailoverPhoneID, phone.ID)))
+		return nil
+	}
+	if err != nil {
+		msg := fmt.Sprintf("cannot load failover phone with ID [%s] for user [%s]", *phone.FailoverPhoneID, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	// messages are not moved to a failover phone which is offline so they don't bounce between phones which fail over to each other
+	if !service.isOnline(ctx, failover) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("failover phone [%s] of phone [%s] is offline, messages are not rerouted", failover.ID, phone.ID)))
+		return nil
+	}
+
+	if phone.FailoverActiveAt == nil {
+		phone.FailoverActiveAt = &params.Timestamp
+		if err = service.phoneRepository.Save(ctx, phone); err != nil {
+			msg := fmt.Sprintf("cannot activate failover of phone [%s] to phone [%s]", phone.ID, failover.ID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+	}
+
+	// messages which are scheduled in the future are sent by the phone when they are due since it may be back online
+	messages, err := service.messageRepository.Reroute(ctx, phone.UserID, phone.PhoneNumber, failover, time.Now().UTC())
+	if err != nil {
+		msg := fmt.Sprintf("cannot reroute messages of phone [%s] to phone [%s]", phone.ID, failover.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	// the messages are already rerouted so a retry would not find them again if we returned on the first failure
+	failed := 0
+	for _, message := range messages {
+		if err = service.dispatchMessageSendRerouted(ctx, params.Source, phone, failover, message); err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot notify failover phone [%s] about rerouted message [%s]", failover.ID, message.ID)))
+			failed++
+		}
+	}
+
+	ctxLogger.Info(fmt.Sprintf("rerouted [%d] messages of phone [%s] to failover phone [%s] for user [%s] with [%d] failed events", len(messages), phone.ID, failover.ID, phone.UserID, failed))
+	return nil
+}
+
+// Deactivate routes the messages of a phone which is back online to the phone again
+func (service *PhoneFailoverService) Deactivate(ctx context.Context, params *PhoneFailoverParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phone, err := service.phoneRepository.LoadByID(ctx, params.UserID, params.PhoneID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone with ID [%s] for user [%s]", params.PhoneID, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if phone.FailoverActiveAt == nil {
+		return nil
+	}
+
+	phone.FailoverActiveAt = nil
+	if err = service.phoneRepository.Save(ctx, phone); err != nil {
+		msg := fmt.Sprintf("cannot deactivate failover of phone [%s]", phone.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("messages of phone [%s] for user [%s] are no longer routed to the failover phone", phone.ID, phone.UserID))
+	return nil
+}
+
+// isOnline checks if a phone can receive rerouted messages. A phone which is failing over is offline even if its heartbeat monitor was not updated yet.
+func (service *PhoneFailoverService) isOnline(ctx context.Context, phone *entities.Phone) bool {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if phone.FailoverActiveAt != nil {
+		return false
+	}
+
+	monitor, err := service.monitorRepository.Load(ctx, phone.UserID, phone.PhoneNumber)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return true
+	}
+
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot load heartbeat monitor of phone [%s] for user [%s]", phone.ID, phone.UserID)))
+		return false
+	}
+
+	return monitor.PhoneOnline
+}
+
+func (service *PhoneFailoverService) dispatchMessageSendRerouted(ctx context.Context, source string, phone *entities.Phone, failover *entities.Phone, message *entities.Message) error {
+	event, err := service.createEvent(events.EventTypeMessageSendRerouted, source, &events.MessageSendReroutedPayload{
+		MessageID:       message.ID,
+		UserID:          message.UserID,
+		PreviousPhoneID: phone.ID,
+		PreviousOwner:   phone.PhoneNumber,
+		PhoneID:         failover.ID,
+		Owner:           failover.PhoneNumber,
+		Contact:         message.Contact,
+		Encrypted:       message.Encrypted,
+		Content:         message.Content,
+		SIM:             failover.SIM,
+		Timestamp:       time.Now().UTC(),
+	})
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot create [%s] event for message [%s]", events.EventTypeMessageSendRerouted, message.ID))
+	}
+
+	if err = service.dispatcher.Dispatch(ctx, event); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot dispatch event [%s] for message [%s]", event.Type(), message.ID))
+	}
+	return nil
+}
@@ -4,13 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/events"
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 
-	"firebase.google.com/go/messaging"
 	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/pubsub"
 	"github.com/NdoleStudio/httpsms/pkg/repositories"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 	"github.com/google/uuid"
@@ -24,30 +25,39 @@ type PhoneNotificationService struct {
 	tracer                      telemetry.Tracer
 	phoneNotificationRepository repositories.PhoneNotificationRepository
 	phoneRepository             repositories.PhoneRepository
-	messagingClient             *messaging.Client
+	messageRepository           repositories.MessageRepository
+	userRepository              repositories.UserRepository
+	pusher                      PhonePusher
 	eventDispatcher             *EventDispatcher
+	pubSub                      pubsub.PubSub
 }
 
 // NewNotificationService creates a new PhoneNotificationService
 func NewNotificationService(
 	logger telemetry.Logger,
 	tracer telemetry.Tracer,
-	messagingClient *messaging.Client,
+	pusher PhonePusher,
 	phoneRepository repositories.PhoneRepository,
 	phoneNotificationRepository repositories.PhoneNotificationRepository,
 	dispatcher *EventDispatcher,
+	pubSub pubsub.PubSub,
+	messageRepository repositories.MessageRepository,
+	userRepository repositories.UserRepository,
 ) (s *PhoneNotificationService) {
 	return &PhoneNotificationService{
 		logger:                      logger.WithService(fmt.Sprintf("%T", s)),
 		tracer:                      tracer,
-		messagingClient:             messagingClient,
+		pusher:                      pusher,
 		phoneNotificationRepository: phoneNotificationRepository,
 		phoneRepository:             phoneRepository,
+		messageRepository:           messageRepository,
+		userRepository:              userRepository,
 		eventDispatcher:             dispatcher,
+		pubSub:                      pubSub,
 	}
 }
 
-// SendHeartbeatFCM sends a heartbeat message so the phone can request a heartbeat
+// SendHeartbeatFCM sends a heartbeat push notification so the phone can request a heartbeat
 func (service *PhoneNotificationService) SendHeartbeatFCM(ctx context.Context, payload *events.PhoneHeartbeatMissedPayload) error {
 	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
@@ -58,27 +68,24 @@ func (service *PhoneNotificationService) SendHeartbeatFCM(ctx context.Context, p
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	if phone.FcmToken == nil {
-		msg := fmt.Sprintf("phone with id [%s] has no FCM token", phone.ID)
-		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	if phone.IsPolling() {
+		ctxLogger.Info(fmt.Sprintf("skipping heartbeat push for phone with ID [%s] which uses [%s] delivery", phone.ID, phone.DeliveryMode))
+		return nil
 	}
 
-	result, err := service.messagingClient.Send(ctx, &messaging.Message{
+	result, err := service.pusher.Push(ctx, phone, &PhonePush{
 		Data: map[string]string{
 			"KEY_HEARTBEAT_ID": time.Now().UTC().Format(time.RFC3339),
 		},
-		Android: &messaging.AndroidConfig{
-			Priority: "high",
-		},
-		Token: *phone.FcmToken,
+		HighPriority: true,
 	})
 	if err != nil {
-		msg := fmt.Sprintf("cannot send heartbeat FCM to phone with id [%s] for user [%s]", phone.ID, phone.UserID)
+		msg := fmt.Sprintf("cannot send heartbeat [%s] push to phone with id [%s] for user [%s]", phone.DeliveryMode, phone.ID, phone.UserID)
 		ctxLogger.Warn(stacktrace.Propagate(err, msg))
 		return nil
 	}
 
-	ctxLogger.Info(fmt.Sprintf("successfully sent heartbeat FCM [%s] to phone with ID [%s] for user [%s] and monitor [%s]", result, payload.PhoneID, payload.UserID, payload.MonitorID))
+	ctxLogger.Info(fmt.Sprintf("successfully sent heartbeat push [%s] to phone with ID [%s] for user [%s] and monitor [%s]", result, payload.PhoneID, payload.UserID, payload.MonitorID))
 	return nil
 }
 
@@ -97,30 +104,40 @@ func (service *PhoneNotificationService) Send(ctx context.Context, params *Phone
 	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
 
+	exists, err := service.phoneNotificationRepository.Exists(ctx, params.UserID, params.PhoneNotificationID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot check if notification [%s] exists for user [%s]", params.PhoneNotificationID, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !exists {
+		ctxLogger.Info(fmt.Sprintf("notification [%s] for message [%s] was cancelled because the message was rerouted", params.PhoneNotificationID, params.MessageID))
+		return nil
+	}
+
 	phone, err := service.phoneRepository.LoadByID(ctx, params.UserID, params.PhoneID)
 	if err != nil {
 		msg := fmt.Sprintf("cannot load phone with userID [%s] and phoneID [%s]", params.UserID, params.PhoneID)
 		return service.handleNotificationFailed(ctx, errors.New(msg), params)
 	}
 
-	if phone.FcmToken == nil {
-		msg := fmt.Sprintf("phone with id [%s] has no FCM token", phone.ID)
-		return service.handleNotificationFailed(ctx, errors.New(msg), params)
+	if phone.IsPolling() {
+		return service.sendPoll(ctx, phone, params)
+	}
+
+	if phone.CoalescePushes {
+		return service.sendCoalesced(ctx, phone, params)
 	}
 
 	ttl := phone.MessageExpirationDuration()
-	result, err := service.messagingClient.Send(ctx, &messaging.Message{
+	result, err := service.pusher.Push(ctx, phone, &PhonePush{
 		Data: map[string]string{
 			"KEY_MESSAGE_ID": params.MessageID.String(),
 		},
-		Android: &messaging.AndroidConfig{
-			Priority: "normal",
-			TTL:      &ttl,
-		},
-		Token: *phone.FcmToken,
+		TTL: &ttl,
 	})
 	if err != nil {
-		ctxLogger.Warn(stacktrace.Propagate(err, "cannot send FCM to phone"))
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot send [%s] push to phone", phone.DeliveryMode)))
 		msg := fmt.Sprintf("cannot send notification for to your phone [%s]. Reinstall the httpSMS app on your Android phone.", phone.PhoneNumber)
 		return service.handleNotificationFailed(ctx, errors.New(msg), params)
 	}
@@ -128,6 +145,94 @@ func (service *PhoneNotificationService) Send(ctx context.Context, params *Phone
 	return service.handleNotificationSent(ctx, phone, result, params)
 }
 
+// sendPoll wakes up the long-poll requests of a phone which does not use FCM
+func (service *PhoneNotificationService) sendPoll(ctx context.Context, phone *entities.Phone, params *PhoneNotificationSendParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	// The phone will still get the message on its next poll if the wake-up is lost
+	if err := service.pubSub.Publish(ctx, phoneOutstandingChannel(phone.ID), params.MessageID.String()); err != nil {
+		msg := fmt.Sprintf("cannot wake up long-poll requests of phone [%s] for message [%s]", phone.ID, params.MessageID)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+	}
+
+	return service.handleNotificationSent(ctx, phone, phone.DeliveryMode.String(), params)
+}
+
+// PhoneNotificationCoalesceWindow is how far ahead notifications are included in a coalesced push
+const PhoneNotificationCoalesceWindow = 5 * time.Second
+
+// sendCoalesced sends one push for all the notifications of a phone which are due within the PhoneNotificationCoalesceWindow
+func (service *PhoneNotificationService) sendCoalesced(ctx context.Context, phone *entities.Phone, params *PhoneNotificationSendParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	timestamp := time.Now().UTC()
+	if params.ScheduledAt.After(timestamp) {
+		timestamp = params.ScheduledAt
+	}
+
+	notifications, err := service.phoneNotificationRepository.ClaimPending(ctx, phone.ID, timestamp.Add(PhoneNotificationCoalesceWindow))
+	if err != nil {
+		msg := fmt.Sprintf("cannot claim pending notifications of phone [%s]", phone.ID)
+		return service.handleNotificationFailed(ctx, stacktrace.Propagate(err, msg), params)
+	}
+
+	var batch []*PhoneNotificationSendParams
+	var messageIDs []string
+	claimed := false
+	for _, notification := range notifications {
+		claimed = claimed || notification.ID == params.PhoneNotificationID
+		messageIDs = append(messageIDs, notification.MessageID.String())
+		batch = append(batch, &PhoneNotificationSendParams{
+			UserID:              notification.UserID,
+			PhoneID:             notification.PhoneID,
+			PhoneNotificationID: notification.ID,
+			Source:              params.Source,
+			ScheduledAt:         notification.ScheduledAt,
+			MessageID:           notification.MessageID,
+		})
+	}
+
+	if !claimed {
+		ctxLogger.Info(fmt.Sprintf("notification [%s] for message [%s] was already sent in a coalesced push", params.PhoneNotificationID, params.MessageID))
+		return nil
+	}
+
+	ttl := phone.MessageExpirationDuration()
+	result, err := service.pusher.Push(ctx, phone, &PhonePush{
+		Data: map[string]string{
+			"KEY_MESSAGE_ID":  params.MessageID.String(),
+			"KEY_MESSAGE_IDS": strings.Join(messageIDs, ","),
+		},
+		TTL: &ttl,
+	})
+	if err != nil {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot send coalesced [%s] push for [%d] messages to phone", phone.DeliveryMode, len(batch))))
+		msg := fmt.Sprintf("cannot send notification for to your phone [%s]. Reinstall the httpSMS app on your Android phone.", phone.PhoneNumber)
+		for _, item := range batch {
+			if err = service.handleNotificationFailed(ctx, errors.New(msg), item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	ctxLogger.Info(fmt.Sprintf("sent coalesced push [%s] for [%d] messages to phone [%s]", result, len(batch), phone.ID))
+	for _, item := range batch {
+		if err = service.handleNotificationSent(ctx, phone, result, item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// phoneOutstandingChannel is the pubsub.PubSub channel which is notified when a message is due on a polling phone
+func phoneOutstandingChannel(phoneID uuid.UUID) string {
+	return fmt.Sprintf("phone-outstanding:%s", phoneID)
+}
+
 // PhoneNotificationScheduleParams are parameters for sending a notification
 type PhoneNotificationScheduleParams struct {
 	UserID    entities.UserID
@@ -147,12 +252,30 @@ func (service *PhoneNotificationService) Schedule(ctx context.Context, params *P
 
 	ctxLogger := service.tracer.CtxLogger(service.logger, span)
 
+	// a message which is rerouted while its event is in flight is scheduled on the new phone by the rerouted event
+	message, err := service.messageRepository.Load(ctx, params.UserID, params.MessageID)
+	if err != nil && stacktrace.GetCode(err) != repositories.ErrCodeNotFound {
+		msg := fmt.Sprintf("cannot load message [%s] for user [%s]", params.MessageID, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err == nil && message.Owner != params.Owner {
+		ctxLogger.Info(fmt.Sprintf("message [%s] was rerouted from [%s] to [%s], notification is not scheduled", params.MessageID, params.Owner, message.Owner))
+		return nil
+	}
+
 	phone, err := service.phoneRepository.Load(ctx, params.UserID, params.Owner)
 	if err != nil {
 		msg := fmt.Sprintf("cannot load phone with userID [%s] and phone [%s]", params.UserID, params.Owner)
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
+	user, err := service.userRepository.Load(ctx, params.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load user with ID [%s]", params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
 	notification := &entities.PhoneNotification{
 		ID:          uuid.New(),
 		MessageID:   params.MessageID,
@@ -164,11 +287,33 @@ func (service *PhoneNotificationService) Schedule(ctx context.Context, params *P
 		UpdatedAt:   time.Now().UTC(),
 	}
 
-	if err = service.phoneNotificationRepository.Schedule(ctx, phone.MessagesPerMinute, notification); err != nil {
+	limit := repositories.PhoneNotificationRateLimit{
+		MessagesPerMinute: phone.MessagesPerMinute,
+		MessagesPerHour:   phone.MaxMessagesPerHour,
+		MessagesPerDay:    phone.MaxMessagesPerDay,
+		Location:          user.Location(),
+	}
+	if settings := phone.SIMSettings(params.SIM); settings != nil {
+		notification.SIM = &settings.SIM
+		limit.SIMMessagesPerDay = settings.MaxMessagesPerDay
+		if settings.MessagesPerMinute > 0 {
+			limit.MessagesPerMinute = settings.MessagesPerMinute
+		}
+	}
+
+	reached, err := service.phoneNotificationRepository.Schedule(ctx, limit, notification)
+	if err != nil {
 		msg := fmt.Sprintf("cannot schedule notification for message [%s] to phone [%s]", params.MessageID, phone.ID)
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
+	if reached != nil {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("[%s] cap of [%d] reached on phone [%s], message [%s] is deferred until [%s]", reached.Window, reached.Limit, phone.ID, params.MessageID, reached.Until)))
+		if err = service.dispatchPhoneCapReached(ctx, params.Source, phone, params.MessageID, reached); err != nil {
+			ctxLogger.Error(err)
+		}
+	}
+
 	if err = service.dispatchMessageNotificationScheduled(ctx, params, notification); err != nil {
 		ctxLogger.Error(err)
 	}
@@ -181,6 +326,28 @@ func (service *PhoneNotificationService) Schedule(ctx context.Context, params *P
 	return nil
 }
 
+func (service *PhoneNotificationService) dispatchPhoneCapReached(ctx context.Context, source string, phone *entities.Phone, messageID uuid.UUID, reached *repositories.PhoneNotificationCap) error {
+	event, err := service.createEvent(events.EventTypePhoneCapReached, source, &events.PhoneCapReachedPayload{
+		PhoneID:       phone.ID,
+		UserID:        phone.UserID,
+		Owner:         phone.PhoneNumber,
+		MessageID:     messageID,
+		SIM:           reached.SIM,
+		Window:        reached.Window,
+		Limit:         reached.Limit,
+		DeferredUntil: reached.Until,
+		Timestamp:     time.Now().UTC(),
+	})
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot create [%s] event for phone [%s]", events.EventTypePhoneCapReached, phone.ID))
+	}
+
+	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot dispatch event [%s] for phone [%s]", event.Type(), phone.ID))
+	}
+	return nil
+}
+
 func (service *PhoneNotificationService) dispatchMessageNotificationSend(ctx context.Context, source string, notification *entities.PhoneNotification) error {
 	event, err := service.createMessageNotificationSendEvent(source, &events.MessageNotificationSendPayload{
 		MessageID:      notification.MessageID,
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/palantir/stacktrace"
+)
+
+// PhonePush is a push notification which wakes up the httpSMS app on a mobile phone
+type PhonePush struct {
+	Data         map[string]string
+	HighPriority bool
+	TTL          *time.Duration
+}
+
+// PhonePusher sends push notifications to mobile phones
+type PhonePusher interface {
+	// Push sends a notification to the phone and returns the ID of the notification
+	Push(ctx context.Context, phone *entities.Phone, push *PhonePush) (string, error)
+}
+
+type deliveryModePhonePusher struct {
+	pushers map[entities.PhoneDeliveryMode]PhonePusher
+}
+
+// NewDeliveryModePhonePusher creates a PhonePusher which sends notifications with the PhonePusher of the phone's delivery mode
+func NewDeliveryModePhonePusher(pushers map[entities.PhoneDeliveryMode]PhonePusher) PhonePusher {
+	return &deliveryModePhonePusher{
+		pushers: pushers,
+	}
+}
+
+// Push sends a notification with the PhonePusher of the phone's delivery mode
+func (pusher *deliveryModePhonePusher) Push(ctx context.Context, phone *entities.Phone, push *PhonePush) (string, error) {
+	mode := phone.DeliveryMode
+	if mode == "" {
+		mode = entities.PhoneDeliveryModeFCM
+	}
+
+	delegate, ok := pusher.pushers[mode]
+	if !ok {
+		return "", stacktrace.NewError(fmt.Sprintf("no pusher is configured for delivery mode [%s] of phone with ID [%s]", mode, phone.ID))
+	}
+
+	return delegate.Push(ctx, phone, push)
+}
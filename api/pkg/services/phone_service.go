@@ -21,10 +21,12 @@ import (
 // PhoneService is handles phone requests
 type PhoneService struct {
 	service
-	logger     telemetry.Logger
-	tracer     telemetry.Tracer
-	repository repositories.PhoneRepository
-	dispatcher *EventDispatcher
+	logger                 telemetry.Logger
+	tracer                 telemetry.Tracer
+	repository             repositories.PhoneRepository
+	dispatcher             *EventDispatcher
+	notificationRepository repositories.PhoneNotificationRepository
+	userRepository         repositories.UserRepository
 }
 
 // NewPhoneService creates a new PhoneService
@@ -33,12 +35,16 @@ func NewPhoneService(
 	tracer telemetry.Tracer,
 	repository repositories.PhoneRepository,
 	dispatcher *EventDispatcher,
+	notificationRepository repositories.PhoneNotificationRepository,
+	userRepository repositories.UserRepository,
 ) (s *PhoneService) {
 	return &PhoneService{
-		logger:     logger.WithService(fmt.Sprintf("%T", s)),
-		tracer:     tracer,
-		dispatcher: dispatcher,
-		repository: repository,
+		logger:                 logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:                 tracer,
+		dispatcher:             dispatcher,
+		repository:             repository,
+		notificationRepository: notificationRepository,
+		userRepository:         userRepository,
 	}
 }
 
@@ -55,10 +61,49 @@ func (service *PhoneService) Index(ctx context.Context, authUser entities.AuthUs
 		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
+	counted := make([]*entities.Phone, len(*phones))
+	for i := range *phones {
+		counted[i] = &(*phones)[i]
+	}
+
+	if err = service.setCounters(ctx, authUser.ID, counted...); err != nil {
+		return nil, service.tracer.WrapErrorSpan(span, err)
+	}
+
 	ctxLogger.Info(fmt.Sprintf("fetched [%d] phones with prams [%+#v]", len(*phones), params))
 	return phones, nil
 }
 
+// setCounters sets the number of messages which are scheduled on the phones in the current hour and day of the user
+func (service *PhoneService) setCounters(ctx context.Context, userID entities.UserID, phones ...*entities.Phone) error {
+	user, err := service.userRepository.Load(ctx, userID)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot load user with ID [%s]", userID))
+	}
+
+	now := time.Now().In(user.Location())
+	_, offset := now.Zone()
+	shift := time.Duration(offset) * time.Second
+	hourStart := now.Add(shift).Truncate(time.Hour).Add(-shift)
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	hour, err := service.notificationRepository.CountScheduled(ctx, userID, hourStart.UTC(), hourStart.Add(time.Hour).UTC())
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot count the messages of user [%s] in the current hour", userID))
+	}
+
+	day, err := service.notificationRepository.CountScheduled(ctx, userID, dayStart.UTC(), dayStart.AddDate(0, 0, 1).UTC())
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot count the messages of user [%s] in the current day", userID))
+	}
+
+	for i := range phones {
+		phones[i].MessagesThisHour = hour[phones[i].ID]
+		phones[i].MessagesToday = day[phones[i].ID]
+	}
+	return nil
+}
+
 // Load a phone by userID and owner
 func (service *PhoneService) Load(ctx context.Context, userID entities.UserID, owner string) (*entities.Phone, error) {
 	ctx, span := service.tracer.Start(ctx)
@@ -67,16 +112,44 @@ func (service *PhoneService) Load(ctx context.Context, userID entities.UserID, o
 	return service.repository.Load(ctx, userID, owner)
 }
 
+// LoadSender loads the phone which sends messages from a phone number.
+// The phone number is either the number of the phone or the number of one of its SIMs.
+func (service *PhoneService) LoadSender(ctx context.Context, userID entities.UserID, phoneNumber string) (*entities.Phone, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	phone, err := service.repository.Load(ctx, userID, phoneNumber)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return service.repository.LoadBySIMPhoneNumber(ctx, userID, phoneNumber)
+	}
+	return phone, err
+}
+
+// LoadByID loads a phone by userID and phoneID
+func (service *PhoneService) LoadByID(ctx context.Context, userID entities.UserID, phoneID uuid.UUID) (*entities.Phone, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	return service.repository.LoadByID(ctx, userID, phoneID)
+}
+
 // PhoneUpsertParams are parameters for creating a new entities.Phone
 type PhoneUpsertParams struct {
 	PhoneNumber               *phonenumbers.PhoneNumber
 	FcmToken                  *string
+	PushEndpoint              *string
 	MessagesPerMinute         *uint
 	MaxSendAttempts           *uint
 	WebhookURL                *string
 	MessageExpirationDuration *time.Duration
 	MissedCallAutoReply       *string
 	SIM                       entities.SIM
+	DeliveryMode              *entities.PhoneDeliveryMode
+	CoalescePushes            *bool
+	FailoverPhoneID           *uuid.UUID
+	SIMs                      []entities.PhoneSIM
+	MaxMessagesPerHour        *uint
+	MaxMessagesPerDay         *uint
 	Source                    string
 	UserID                    entities.UserID
 }
@@ -104,6 +177,11 @@ func (service *PhoneService) Upsert(ctx context.Context, params *PhoneUpsertPara
 	}
 
 	ctxLogger.Info(fmt.Sprintf("phone updated with id [%s] in the phone repository for user [%s]", phone.ID, phone.UserID))
+
+	if err = service.setCounters(ctx, phone.UserID, phone); err != nil {
+		return nil, service.tracer.WrapErrorSpan(span, err)
+	}
+
 	return phone, service.dispatchPhoneUpdatedEvent(ctx, params.Source, phone)
 }
 
@@ -175,21 +253,40 @@ func (service *PhoneService) createPhone(ctx context.Context, params *PhoneUpser
 	defer span.End()
 
 	phone := &entities.Phone{
-		ID:       uuid.New(),
-		UserID:   params.UserID,
-		FcmToken: params.FcmToken,
+		ID:           uuid.New(),
+		UserID:       params.UserID,
+		FcmToken:     params.FcmToken,
+		PushEndpoint: params.PushEndpoint,
 		// Android has a limit of 30 SMS messages per minute without user permission, to be safe let's use 10 messages per minute
 		// https://android.googlesource.com/platform/frameworks/opt/telephony/+/master/src/java/com/android/internal/telephony/SmsUsageMonitor.java#80
 		MessagesPerMinute:        10,
 		MessageExpirationSeconds: 10 * 60, // 10 minutes
 		MaxSendAttempts:          2,
 		SIM:                      params.SIM,
+		SIMs:                     params.SIMs,
+		DeliveryMode:             entities.PhoneDeliveryModeFCM,
 		MissedCallAutoReply:      nil,
 		PhoneNumber:              phonenumbers.Format(params.PhoneNumber, phonenumbers.E164),
 		CreatedAt:                time.Now().UTC(),
 		UpdatedAt:                time.Now().UTC(),
 	}
 
+	if params.DeliveryMode != nil {
+		phone.DeliveryMode = *params.DeliveryMode
+	}
+
+	if params.CoalescePushes != nil {
+		phone.CoalescePushes = *params.CoalescePushes
+	}
+
+	if params.MaxMessagesPerHour != nil {
+		phone.MaxMessagesPerHour = *params.MaxMessagesPerHour
+	}
+
+	if params.MaxMessagesPerDay != nil {
+		phone.MaxMessagesPerDay = *params.MaxMessagesPerDay
+	}
+
 	if err := service.repository.Save(ctx, phone); err != nil {
 		msg := fmt.Sprintf("cannot create phone with id [%s] and number [%s]", phone.ID, phone.PhoneNumber)
 		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
@@ -229,5 +326,36 @@ func (service *PhoneService) update(phone *entities.Phone, params *PhoneUpsertPa
 
 	phone.SIM = params.SIM
 
+	if params.SIMs != nil {
+		phone.SIMs = params.SIMs
+	}
+
+	if params.MaxMessagesPerHour != nil {
+		phone.MaxMessagesPerHour = *params.MaxMessagesPerHour
+	}
+
+	if params.MaxMessagesPerDay != nil {
+		phone.MaxMessagesPerDay = *params.MaxMessagesPerDay
+	}
+
+	if params.PushEndpoint != nil {
+		phone.PushEndpoint = params.PushEndpoint
+	}
+
+	if params.DeliveryMode != nil {
+		phone.DeliveryMode = *params.DeliveryMode
+	}
+
+	if params.CoalescePushes != nil {
+		phone.CoalescePushes = *params.CoalescePushes
+	}
+
+	if params.FailoverPhoneID != nil && *params.FailoverPhoneID == uuid.Nil {
+		phone.FailoverPhoneID = nil
+		phone.FailoverActiveAt = nil
+	} else if params.FailoverPhoneID != nil {
+		phone.FailoverPhoneID = params.FailoverPhoneID
+	}
+
 	return phone
 }
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/palantir/stacktrace"
+)
+
+// carrierGradeNAT is the shared address space of RFC 6598 which is not reachable from the internet
+var carrierGradeNAT = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// NewPublicHTTPTransport creates an http.Transport for URLs which are supplied by users.
+// The host is resolved before dialing and the connection is refused when it resolves to a private, loopback or link-local IP address.
+func NewPublicHTTPTransport() *http.Transport {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network string, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot split host and port of address [%s]", address))
+		}
+
+		addresses, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot resolve host [%s]", host))
+		}
+
+		for _, ip := range addresses {
+			if !IsPublicIP(ip.IP) {
+				return nil, stacktrace.NewError(fmt.Sprintf("host [%s] resolves to the non public IP address [%s]", host, ip.IP))
+			}
+		}
+
+		if len(addresses) == 0 {
+			return nil, stacktrace.NewError(fmt.Sprintf("host [%s] has no IP address", host))
+		}
+
+		// the resolved IP is dialed so that the host cannot resolve to a different address after it was checked
+		return dialer.DialContext(ctx, network, net.JoinHostPort(addresses[0].IP.String(), port))
+	}
+
+	return transport
+}
+
+// IsPublicIP checks if an IP address is reachable on the public internet
+func IsPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified() ||
+		carrierGradeNAT.Contains(ip))
+}
@@ -27,4 +27,7 @@ type PushQueueConfig struct {
 type PushQueue interface {
 	// Enqueue adds a message to the push queue
 	Enqueue(ctx context.Context, task *PushQueueTask, timeout time.Duration) (string, error)
+
+	// Delete removes a task which has not been pushed yet from the queue
+	Delete(ctx context.Context, queueID string) error
 }
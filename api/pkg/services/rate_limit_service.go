@@ -0,0 +1,254 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/cache"
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// RateLimitGroup is a group of routes which share the same rate limit
+type RateLimitGroup string
+
+const (
+	// RateLimitGroupSend is for routes which send messages
+	RateLimitGroupSend = RateLimitGroup("send")
+
+	// RateLimitGroupPhone is for routes which are called by the android app
+	RateLimitGroupPhone = RateLimitGroup("phone")
+
+	// RateLimitGroupDefault is for all the other routes
+	RateLimitGroupDefault = RateLimitGroup("default")
+)
+
+// RateLimit is the configuration of a token bucket
+type RateLimit struct {
+	// Burst is the maximum number of requests which can be made at once
+	Burst uint `json:"burst"`
+	// PerMinute is the number of requests which are added to the bucket every minute
+	PerMinute uint `json:"per_minute"`
+}
+
+func (limit RateLimit) refillPerSecond() float64 {
+	return float64(limit.PerMinute) / 60
+}
+
+// RateLimits are the rate limits for each entities.SubscriptionName and RateLimitGroup
+type RateLimits map[RateLimitGroup]map[entities.SubscriptionName]RateLimit
+
+// Merge returns a copy of the RateLimits where the limits in overrides replace the existing limits
+func (limits RateLimits) Merge(overrides RateLimits) RateLimits {
+	result := RateLimits{}
+	for _, values := range []RateLimits{limits, overrides} {
+		for group, plans := range values {
+			if _, ok := result[group]; !ok {
+				result[group] = map[entities.SubscriptionName]RateLimit{}
+			}
+			for subscription, limit := range plans {
+				result[group][subscription] = limit
+			}
+		}
+	}
+	return result
+}
+
+// ParseRateLimits overrides the DefaultRateLimits with the JSON encoded RateLimits in value
+// e.g. {"send": {"free": {"burst": 60, "per_minute": 60}}}
+func ParseRateLimits(value string) (RateLimits, error) {
+	limits := DefaultRateLimits()
+	if strings.TrimSpace(value) == "" {
+		return limits, nil
+	}
+
+	overrides := RateLimits{}
+	if err := json.Unmarshal([]byte(value), &overrides); err != nil {
+		return limits, stacktrace.Propagate(err, fmt.Sprintf("cannot decode rate limits [%s]", value))
+	}
+
+	return limits.Merge(overrides), nil
+}
+
+// DefaultRateLimits returns the RateLimits used when no custom limit is configured
+func DefaultRateLimits() RateLimits {
+	plans := func(free, pro, ultra, enterprise RateLimit) map[entities.SubscriptionName]RateLimit {
+		return map[entities.SubscriptionName]RateLimit{
+			entities.SubscriptionNameFree:         free,
+			entities.SubscriptionNameProMonthly:   pro,
+			entities.SubscriptionNameProYearly:    pro,
+			entities.SubscriptionNameProLifetime:  pro,
+			entities.SubscriptionNameUltraMonthly: ultra,
+			entities.SubscriptionNameUltraYearly:  ultra,
+			entities.SubscriptionName20KMonthly:   ultra,
+			entities.SubscriptionName20KYearly:    ultra,
+			entities.SubscriptionName50KMonthly:   enterprise,
+			entities.SubscriptionName100KMonthly:  enterprise,
+			entities.SubscriptionName200KMonthly:  enterprise,
+		}
+	}
+
+	return RateLimits{
+		RateLimitGroupSend: plans(
+			RateLimit{Burst: 30, PerMinute: 30},
+			RateLimit{Burst: 120, PerMinute: 120},
+			RateLimit{Burst: 300, PerMinute: 300},
+			RateLimit{Burst: 600, PerMinute: 600},
+		),
+		RateLimitGroupPhone: plans(
+			RateLimit{Burst: 300, PerMinute: 300},
+			RateLimit{Burst: 600, PerMinute: 600},
+			RateLimit{Burst: 1200, PerMinute: 1200},
+			RateLimit{Burst: 2400, PerMinute: 2400},
+		),
+		RateLimitGroupDefault: plans(
+			RateLimit{Burst: 120, PerMinute: 120},
+			RateLimit{Burst: 300, PerMinute: 300},
+			RateLimit{Burst: 600, PerMinute: 600},
+			RateLimit{Burst: 1200, PerMinute: 1200},
+		),
+	}
+}
+
+// RateLimitResult is the outcome of consuming a token from a rate limit bucket
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      uint
+	Remaining  uint
+	ResetAt    time.Time
+	RetryAfter time.Duration
+}
+
+const rateLimitSubscriptionTTL = 15 * time.Minute
+
+// RateLimitService limits the rate of requests of users and API keys
+type RateLimitService struct {
+	service
+	logger         telemetry.Logger
+	tracer         telemetry.Tracer
+	cache          cache.Cache
+	counter        metric.Int64Counter
+	limits         RateLimits
+	userRepository repositories.UserRepository
+}
+
+// NewRateLimitService creates a new RateLimitService
+func NewRateLimitService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	cache cache.Cache,
+	counter metric.Int64Counter,
+	limits RateLimits,
+	userRepository repositories.UserRepository,
+) (s *RateLimitService) {
+	return &RateLimitService{
+		logger:         logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:         tracer,
+		cache:          cache,
+		counter:        counter,
+		limits:         limits,
+		userRepository: userRepository,
+	}
+}
+
+// Consume takes a token from the bucket of the entities.AuthUser for the RateLimitGroup.
+// Errors from the cache.Cache are logged and the request is allowed so the API stays available.
+func (service *RateLimitService) Consume(ctx context.Context, authUser entities.AuthUser, group RateLimitGroup) RateLimitResult {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	subscription := service.subscription(ctx, authUser.ID)
+	limit := service.limit(group, subscription)
+
+	key := fmt.Sprintf("rate-limit:%s:user:%s", group, authUser.ID)
+	if authUser.APIKeyID != nil {
+		key = fmt.Sprintf("rate-limit:%s:api-key:%s", group, authUser.APIKeyID)
+	}
+
+	result, err := service.consume(ctx, key, limit, time.Now().UTC())
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot consume rate limit token with key [%s]", key)))
+	}
+
+	service.counter.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("rate_limit.group", string(group)),
+		attribute.String("rate_limit.subscription", string(subscription)),
+		attribute.Bool("rate_limit.allowed", result.Allowed),
+	))
+
+	if !result.Allowed {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("rate limit exceeded for key [%s] with limit [%+#v]", key, limit)))
+	}
+
+	return result
+}
+
+func (service *RateLimitService) consume(ctx context.Context, key string, limit RateLimit, timestamp time.Time) (RateLimitResult, error) {
+	tokens, taken, err := service.cache.TakeToken(ctx, key, limit.Burst, limit.refillPerSecond(), timestamp)
+	if err != nil {
+		return RateLimitResult{Allowed: true, Limit: limit.Burst, Remaining: limit.Burst, ResetAt: timestamp}, stacktrace.Propagate(err, fmt.Sprintf("cannot take token from bucket with key [%s]", key))
+	}
+
+	result := RateLimitResult{
+		Allowed:   taken,
+		Limit:     limit.Burst,
+		Remaining: uint(math.Floor(math.Max(0, tokens))),
+		ResetAt:   timestamp.Add(service.refillDuration(float64(limit.Burst)-tokens, limit)),
+	}
+
+	if !taken {
+		result.RetryAfter = service.refillDuration(1-tokens, limit)
+	}
+
+	return result, nil
+}
+
+func (service *RateLimitService) refillDuration(tokens float64, limit RateLimit) time.Duration {
+	if limit.PerMinute == 0 {
+		return time.Minute
+	}
+	return time.Duration(math.Ceil(tokens / limit.refillPerSecond() * float64(time.Second)))
+}
+
+func (service *RateLimitService) limit(group RateLimitGroup, subscription entities.SubscriptionName) RateLimit {
+	limits, ok := service.limits[group]
+	if !ok {
+		limits = service.limits[RateLimitGroupDefault]
+	}
+
+	if limit, ok := limits[subscription]; ok {
+		return limit
+	}
+	return limits[entities.SubscriptionNameFree]
+}
+
+// subscription fetches the entities.SubscriptionName of a user from the cache.Cache or the database
+func (service *RateLimitService) subscription(ctx context.Context, userID entities.UserID) entities.SubscriptionName {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	key := fmt.Sprintf("rate-limit:subscription:%s", userID)
+	if value, err := service.cache.Get(ctx, key); err == nil {
+		return entities.SubscriptionName(value)
+	}
+
+	user, err := service.userRepository.Load(ctx, userID)
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot load user with ID [%s] for rate limiting", userID)))
+		return entities.SubscriptionNameFree
+	}
+
+	if err = service.cache.Set(ctx, key, string(user.SubscriptionName), rateLimitSubscriptionTTL); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot cache subscription of user with ID [%s]", userID)))
+	}
+
+	return user.SubscriptionName
+}
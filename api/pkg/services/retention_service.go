@@ -0,0 +1,284 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	retentionPurgeBatchSize = 500
+	retentionPurgeInterval  = 24 * time.Hour
+)
+
+// DefaultEventListenerLogRetention is how long the event listener logs are kept when no retention is configured
+const DefaultEventListenerLogRetention = 30 * 24 * time.Hour
+
+// RetentionService deletes the data of a user which is older than the retention policy
+type RetentionService struct {
+	service
+	logger                      telemetry.Logger
+	tracer                      telemetry.Tracer
+	userRepository              repositories.UserRepository
+	messageRepository           repositories.MessageRepository
+	heartbeatRepository         repositories.HeartbeatRepository
+	phoneNotificationRepository repositories.PhoneNotificationRepository
+	eventListenerLogRepository  repositories.EventListenerLogRepository
+	eventListenerLogRetention   time.Duration
+	dispatcher                  *EventDispatcher
+}
+
+// NewRetentionService creates a new RetentionService
+func NewRetentionService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	userRepository repositories.UserRepository,
+	messageRepository repositories.MessageRepository,
+	heartbeatRepository repositories.HeartbeatRepository,
+	phoneNotificationRepository repositories.PhoneNotificationRepository,
+	eventListenerLogRepository repositories.EventListenerLogRepository,
+	eventListenerLogRetention time.Duration,
+	dispatcher *EventDispatcher,
+) (s *RetentionService) {
+	return &RetentionService{
+		logger:                      logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:                      tracer,
+		userRepository:              userRepository,
+		messageRepository:           messageRepository,
+		heartbeatRepository:         heartbeatRepository,
+		phoneNotificationRepository: phoneNotificationRepository,
+		eventListenerLogRepository:  eventListenerLogRepository,
+		eventListenerLogRetention:   eventListenerLogRetention,
+		dispatcher:                  dispatcher,
+	}
+}
+
+// UserRetentionUpdateParams are parameters for updating the data retention policy of a user
+type UserRetentionUpdateParams struct {
+	Source                    string
+	UserID                    entities.UserID
+	MessageRetentionDays      *uint
+	HeartbeatRetentionDays    *uint
+	NotificationRetentionDays *uint
+}
+
+// UpdateSettings updates the retention policy of an entities.User and schedules the purge job
+func (service *RetentionService) UpdateSettings(ctx context.Context, params *UserRetentionUpdateParams) (*entities.User, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	user, err := service.userRepository.Load(ctx, params.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("could not load [%T] with ID [%s]", user, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	user.MessageRetentionDays = params.MessageRetentionDays
+	user.HeartbeatRetentionDays = params.HeartbeatRetentionDays
+	user.NotificationRetentionDays = params.NotificationRetentionDays
+
+	// a purge which is overdue is rescheduled in case the previous job was lost
+	if !user.HasRetention() {
+		user.RetentionPurgeScheduledAt = nil
+	} else if user.RetentionPurgeScheduledAt == nil || time.Now().UTC().Sub(*user.RetentionPurgeScheduledAt) > time.Hour {
+		if err = service.schedulePurge(ctx, params.Source, user, time.Second); err != nil {
+			msg := fmt.Sprintf("cannot schedule retention purge for user with ID [%s]", user.ID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+	}
+
+	if err = service.userRepository.UpdateRetention(ctx, user); err != nil {
+		msg := fmt.Sprintf("cannot save retention settings of user with id [%s] in [%T]", user.ID, service.userRepository)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("updated retention settings for [%T] with ID [%s] in the [%T]", user, user.ID, service.userRepository))
+	return user, nil
+}
+
+// Purge deletes a batch of the data of a user which is older than the retention policy and schedules the next purge.
+// The next purge runs immediately when a full batch was deleted, otherwise it runs after the retentionPurgeInterval.
+func (service *RetentionService) Purge(ctx context.Context, source string, payload *events.UserRetentionPurgePayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	user, err := service.userRepository.Load(ctx, payload.UserID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Info(fmt.Sprintf("skipping retention purge for user [%s] which has been deleted", payload.UserID))
+		return nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("could not load [%T] with ID [%s]", user, payload.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if user.RetentionPurgeScheduledAt == nil || !user.RetentionPurgeScheduledAt.Equal(payload.ScheduledAt) {
+		ctxLogger.Info(fmt.Sprintf("retention purge scheduled at [%s] for user [%s] has been replaced", payload.ScheduledAt, user.ID))
+		return nil
+	}
+
+	hasMore := false
+	if user.MessageRetentionDays != nil {
+		count, err := service.purgeMessages(ctx, source, user.ID, service.retentionTimestamp(*user.MessageRetentionDays))
+		if err != nil {
+			msg := fmt.Sprintf("cannot purge messages for user with ID [%s]", user.ID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		hasMore = hasMore || count == retentionPurgeBatchSize
+	}
+
+	if user.HeartbeatRetentionDays != nil {
+		count, err := service.heartbeatRepository.DeleteOlderThan(ctx, user.ID, service.retentionTimestamp(*user.HeartbeatRetentionDays), retentionPurgeBatchSize)
+		if err != nil {
+			msg := fmt.Sprintf("cannot purge heartbeats for user with ID [%s]", user.ID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		ctxLogger.Info(fmt.Sprintf("deleted [%d] heartbeats for user with ID [%s]", count, user.ID))
+		hasMore = hasMore || count == retentionPurgeBatchSize
+	}
+
+	if user.NotificationRetentionDays != nil {
+		count, err := service.phoneNotificationRepository.DeleteOlderThan(ctx, user.ID, service.retentionTimestamp(*user.NotificationRetentionDays), retentionPurgeBatchSize)
+		if err != nil {
+			msg := fmt.Sprintf("cannot purge phone notifications for user with ID [%s]", user.ID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		ctxLogger.Info(fmt.Sprintf("deleted [%d] phone notifications for user with ID [%s]", count, user.ID))
+		hasMore = hasMore || count == retentionPurgeBatchSize
+	}
+
+	// event listener logs are not owned by a user so they are trimmed by every purge job
+	count, err := service.eventListenerLogRepository.DeleteOlderThan(ctx, time.Now().UTC().Add(-service.eventListenerLogRetention), retentionPurgeBatchSize)
+	if err != nil {
+		msg := fmt.Sprintf("cannot purge event listener logs older than [%s]", service.eventListenerLogRetention)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+	}
+	ctxLogger.Info(fmt.Sprintf("deleted [%d] event listener logs", count))
+
+	if !user.HasRetention() {
+		user.RetentionPurgeScheduledAt = nil
+	} else {
+		delay := retentionPurgeInterval
+		if hasMore {
+			delay = time.Second
+		}
+		if err = service.schedulePurge(ctx, source, user, delay); err != nil {
+			msg := fmt.Sprintf("cannot schedule retention purge for user with ID [%s]", user.ID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+	}
+
+	if err = service.userRepository.UpdateRetentionPurge(ctx, user); err != nil {
+		msg := fmt.Sprintf("cannot save retention purge of user with id [%s] in [%T]", user.ID, service.userRepository)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// purgeMessages removes the content of the oldest messages of a user.
+// The messages are kept without their content so that the usage history of the user does not change.
+// The events.MessageContentPurged event is dispatched first so that the message threads are purged even if this purge fails and is retried.
+func (service *RetentionService) purgeMessages(ctx context.Context, source string, userID entities.UserID, timestamp time.Time) (int, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	messages, err := service.messageRepository.LoadOlderThan(ctx, userID, timestamp, retentionPurgeBatchSize)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load messages older than [%s] for user with ID [%s]", timestamp, userID)
+		return 0, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if len(messages) == 0 {
+		return 0, nil
+	}
+
+	messageIDs := service.messageIDs(messages)
+	event, err := service.createEvent(events.MessageContentPurged, source, &events.MessageContentPurgedPayload{
+		UserID:     userID,
+		MessageIDs: messageIDs,
+		Timestamp:  time.Now().UTC(),
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create [%s] event for [%d] messages of user with ID [%s]", events.MessageContentPurged, len(messageIDs), userID)
+		return 0, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.dispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch [%s] event for [%d] messages of user with ID [%s]", event.Type(), len(messageIDs), userID)
+		return 0, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.messageRepository.PurgeContent(ctx, userID, messageIDs, time.Now().UTC()); err != nil {
+		msg := fmt.Sprintf("cannot purge the content of [%d] messages for user with ID [%s]", len(messages), userID)
+		return 0, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("purged the content of [%d] messages for user with ID [%s]", len(messages), userID))
+	return len(messages), nil
+}
+
+// ParseEventListenerLogRetention parses the number of days the event listener logs are kept from value
+// and returns the DefaultEventListenerLogRetention when value is empty.
+func ParseEventListenerLogRetention(value string) (time.Duration, error) {
+	if strings.TrimSpace(value) == "" {
+		return DefaultEventListenerLogRetention, nil
+	}
+
+	days, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || days <= 0 {
+		return DefaultEventListenerLogRetention, stacktrace.NewError(fmt.Sprintf("the event listener log retention [%s] is not a positive number of days", value))
+	}
+
+	return time.Duration(days) * 24 * time.Hour, nil
+}
+
+// schedulePurge dispatches the events.UserRetentionPurge event after the delay and stores the schedule on the entities.User
+// so that a purge which was scheduled before the last update of the settings is ignored.
+func (service *RetentionService) schedulePurge(ctx context.Context, source string, user *entities.User, delay time.Duration) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	scheduledAt := time.Now().UTC().Add(delay).Truncate(time.Microsecond)
+	event, err := service.createEvent(events.UserRetentionPurge, source, &events.UserRetentionPurgePayload{
+		UserID:      user.ID,
+		ScheduledAt: scheduledAt,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create [%s] event for user with ID [%s]", events.UserRetentionPurge, user.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	queueID, err := service.dispatcher.DispatchWithTimeout(ctx, event, delay)
+	if err != nil {
+		msg := fmt.Sprintf("cannot dispatch event [%s] with id [%s] for user [%s]", event.Type(), event.ID(), user.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	user.RetentionPurgeScheduledAt = &scheduledAt
+	user.RetentionPurgeQueueID = queueID
+	ctxLogger.Info(fmt.Sprintf("scheduled retention purge with queue ID [%s] for user [%s] at [%s]", queueID, user.ID, scheduledAt))
+	return nil
+}
+
+func (service *RetentionService) retentionTimestamp(days uint) time.Time {
+	return time.Now().UTC().Add(-time.Duration(days) * 24 * time.Hour)
+}
+
+func (service *RetentionService) messageIDs(messages []*entities.Message) []uuid.UUID {
+	IDs := make([]uuid.UUID, 0, len(messages))
+	for _, message := range messages {
+		IDs = append(IDs, message.ID)
+	}
+	return IDs
+}
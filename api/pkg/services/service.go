@@ -1,6 +1,8 @@
 package services
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"fmt"
 	"regexp"
 	"time"
@@ -13,6 +15,14 @@ import (
 	"github.com/palantir/stacktrace"
 )
 
+const (
+	// ErrCodeForbidden is thrown when the user is not allowed to perform an action
+	ErrCodeForbidden = stacktrace.ErrorCode(2000)
+
+	// ErrCodeTooManyStreams is thrown when the user already has the maximum number of concurrent streams
+	ErrCodeTooManyStreams = stacktrace.ErrorCode(2001)
+)
+
 type service struct{}
 
 func (service *service) createEvent(eventType string, source string, payload any) (cloudevents.Event, error) {
@@ -31,6 +41,15 @@ func (service *service) createEvent(eventType string, source string, payload any
 	return event, nil
 }
 
+// generateSecret returns a URL-safe, base64 encoded securely generated random string
+func (service *service) generateSecret(length int) (string, error) {
+	b := make([]byte, length)
+	if _, err := rand.Read(b); err != nil {
+		return "", stacktrace.Propagate(err, fmt.Sprintf("cannot generate [%d] random bytes", length))
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
 func (service *service) getFormattedNumber(ctxLogger telemetry.Logger, phoneNumber string) string {
 	matched, err := regexp.MatchString("^\\+?[1-9]\\d{9,14}$", phoneNumber)
 	if err != nil {
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/hirosassa/zerodriver"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// testLoggerAndTracer creates a telemetry.Logger which discards the logs and a telemetry.Tracer for tests
+func testLoggerAndTracer() (telemetry.Logger, telemetry.Tracer) {
+	nop := zerolog.Nop()
+	logger := telemetry.NewZerologLogger("test", nil, &zerodriver.Logger{Logger: &nop}, nil)
+	return logger, telemetry.NewOtelLogger("test", logger)
+}
+
+// testPushQueue is a PushQueue which records the types of the enqueued events
+type testPushQueue struct {
+	mutex  sync.Mutex
+	events []string
+}
+
+func (queue *testPushQueue) Enqueue(_ context.Context, task *PushQueueTask, _ time.Duration) (string, error) {
+	event := cloudevents.NewEvent()
+	if err := json.Unmarshal(task.Body, &event); err != nil {
+		return "", err
+	}
+
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	queue.events = append(queue.events, event.Type())
+	return event.ID(), nil
+}
+
+func (queue *testPushQueue) Delete(_ context.Context, _ string) error {
+	return nil
+}
+
+// Events returns the types of the enqueued events in order
+func (queue *testPushQueue) Events() []string {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	return append([]string{}, queue.events...)
+}
+
+// testEventDispatcher creates an EventDispatcher which enqueues the events in a testPushQueue
+func testEventDispatcher() (*EventDispatcher, *testPushQueue) {
+	logger, tracer := testLoggerAndTracer()
+	meter, _ := noop.NewMeterProvider().Meter("test").Float64Histogram("test")
+	queue := &testPushQueue{}
+	return NewEventDispatcher(logger, tracer, meter, queue, PushQueueConfig{}), queue
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+type unifiedPushPhonePusher struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	client *http.Client
+}
+
+// NewUnifiedPushPhonePusher creates a PhonePusher which posts to the UnifiedPush endpoint of a phone e.g. an ntfy topic.
+// The endpoint is supplied by the user so the client must not follow redirects or connect to private IP addresses.
+func NewUnifiedPushPhonePusher(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	client *http.Client,
+) PhonePusher {
+	return &unifiedPushPhonePusher{
+		logger: logger.WithService(fmt.Sprintf("%T", unifiedPushPhonePusher{})),
+		tracer: tracer,
+		client: client,
+	}
+}
+
+// Push posts the data of the push as JSON to the PushEndpoint of the phone
+func (pusher *unifiedPushPhonePusher) Push(ctx context.Context, phone *entities.Phone, push *PhonePush) (string, error) {
+	ctx, span, ctxLogger := pusher.tracer.StartWithLogger(ctx, pusher.logger)
+	defer span.End()
+
+	if phone.PushEndpoint == nil {
+		msg := fmt.Sprintf("phone with id [%s] has no UnifiedPush endpoint", phone.ID)
+		return "", pusher.tracer.WrapErrorSpan(span, stacktrace.NewError(msg))
+	}
+
+	if endpoint, err := url.Parse(*phone.PushEndpoint); err != nil || endpoint.Scheme != "https" {
+		msg := fmt.Sprintf("the UnifiedPush endpoint [%s] of phone with id [%s] is not an https URL", *phone.PushEndpoint, phone.ID)
+		return "", pusher.tracer.WrapErrorSpan(span, stacktrace.NewError(msg))
+	}
+
+	payload, err := json.Marshal(push.Data)
+	if err != nil {
+		msg := fmt.Sprintf("cannot marshal push data for phone with id [%s]", phone.ID)
+		return "", pusher.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, *phone.PushEndpoint, bytes.NewReader(payload))
+	if err != nil {
+		msg := fmt.Sprintf("cannot create UnifiedPush request for phone with id [%s]", phone.ID)
+		return "", pusher.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	// The TTL and Urgency headers are from the web push protocol (RFC 8030) which UnifiedPush distributors understand
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Urgency", "normal")
+	if push.HighPriority {
+		request.Header.Set("Urgency", "high")
+	}
+	if push.TTL != nil {
+		request.Header.Set("TTL", strconv.Itoa(int(push.TTL.Seconds())))
+	}
+
+	response, err := pusher.client.Do(request)
+	if err != nil {
+		msg := fmt.Sprintf("cannot send UnifiedPush notification to phone with id [%s]", phone.ID)
+		return "", pusher.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	defer func() {
+		if err = response.Body.Close(); err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot close UnifiedPush response body for phone with id [%s]", phone.ID)))
+		}
+	}()
+
+	if response.StatusCode >= 400 {
+		msg := fmt.Sprintf("cannot send UnifiedPush notification to phone with id [%s] with response code [%d]", phone.ID, response.StatusCode)
+		return "", pusher.tracer.WrapErrorSpan(span, stacktrace.NewError(msg))
+	}
+
+	if location := response.Header.Get("Location"); location != "" {
+		return location, nil
+	}
+	return uuid.NewString(), nil
+}
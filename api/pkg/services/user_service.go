@@ -276,7 +276,7 @@ func (service *UserService) StartSubscription(ctx context.Context, params *event
 
 // InitiateSubscriptionCancel initiates the cancelling of a subscription on lemonsqueezy
 func (service *UserService) InitiateSubscriptionCancel(ctx context.Context, userID entities.UserID) error {
-	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	ctx, span := service.tracer.Start(ctx)
 	defer span.End()
 
 	user, err := service.repository.Load(ctx, userID)
@@ -285,7 +285,20 @@ func (service *UserService) InitiateSubscriptionCancel(ctx context.Context, user
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	if _, _, err = service.lemonsqueezyClient.Subscriptions.Cancel(ctx, *user.SubscriptionID); err != nil {
+	if err = service.CancelUserSubscription(ctx, user); err != nil {
+		msg := fmt.Sprintf("could not cancel subscription [%s] for [%T] with with ID [%s]", *user.SubscriptionID, user, user.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// CancelUserSubscription cancels the subscription of an entities.User which is already loaded on lemonsqueezy
+func (service *UserService) CancelUserSubscription(ctx context.Context, user *entities.User) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if _, _, err := service.lemonsqueezyClient.Subscriptions.Cancel(ctx, *user.SubscriptionID); err != nil {
 		msg := fmt.Sprintf("could not cancel subscription [%s] for [%T] with with ID [%s]", *user.SubscriptionID, user, user.ID)
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
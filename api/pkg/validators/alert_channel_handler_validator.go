@@ -0,0 +1,134 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+
+	"github.com/NdoleStudio/httpsms/pkg/discord"
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/nyaruka/phonenumbers"
+	"github.com/palantir/stacktrace"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// AlertChannelHandlerValidator validates models used in handlers.AlertChannelHandler
+type AlertChannelHandlerValidator struct {
+	validator
+	logger         telemetry.Logger
+	tracer         telemetry.Tracer
+	discordClient  *discord.Client
+	discordService *services.DiscordService
+}
+
+// NewAlertChannelHandlerValidator creates a new handlers.AlertChannelHandler validator
+func NewAlertChannelHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	discordClient *discord.Client,
+	discordService *services.DiscordService,
+) (v *AlertChannelHandlerValidator) {
+	return &AlertChannelHandlerValidator{
+		logger:         logger.WithService(fmt.Sprintf("%T", v)),
+		tracer:         tracer,
+		discordClient:  discordClient,
+		discordService: discordService,
+	}
+}
+
+// ValidateStore validates the requests.AlertChannelStore request
+func (validator *AlertChannelHandlerValidator) ValidateStore(ctx context.Context, userID entities.UserID, request requests.AlertChannelStore) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"name": []string{
+				"required",
+				"max:50",
+			},
+			"type": []string{
+				"required",
+				"in:" + entities.AlertChannelTypeEmail.String() + "," + entities.AlertChannelTypeDiscord.String() + "," + entities.AlertChannelTypeSlack.String() + "," + entities.AlertChannelTypeSMS.String(),
+			},
+			"target": []string{
+				"required",
+				"max:255",
+			},
+			"alert_types": []string{
+				"required",
+				multipleInRule + ":" + events.EventTypePhoneHeartbeatOffline + "," + events.EventTypeMessageSendFailed,
+			},
+			"phone_numbers": []string{
+				multipleContactPhoneNumberRule,
+			},
+			"escalation_delay_seconds": []string{
+				"max:86400",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if len(result) > 0 {
+		return result
+	}
+
+	switch entities.AlertChannelType(request.Type) {
+	case entities.AlertChannelTypeEmail:
+		if address, err := mail.ParseAddress(request.Target); err != nil || address.Address != request.Target {
+			result.Add("target", "The target field must be a valid email address for an email alert channel")
+		}
+	case entities.AlertChannelTypeDiscord:
+		if match, err := regexp.MatchString("^[0-9]{17,20}$", request.Target); err != nil || !match {
+			result.Add("target", "The target field must be a discord channel ID for a discord alert channel")
+		} else if !validator.isOwnDiscordChannel(ctx, userID, request.Target) {
+			result.Add("target", "The target field must be a channel in a discord server which is connected to your account")
+		}
+	case entities.AlertChannelTypeSlack:
+		if target, err := url.Parse(request.Target); err != nil || target.Scheme != "https" || target.Host != "hooks.slack.com" {
+			result.Add("target", "The target field must be a slack incoming webhook URL e.g https://hooks.slack.com/services/T000/B000/XXXX")
+		}
+	case entities.AlertChannelTypeSMS:
+		if _, err := phonenumbers.Parse(request.Target, phonenumbers.UNKNOWN_REGION); err != nil {
+			result.Add("target", "The target field must be a valid E.164 phone number for an SMS alert channel")
+		}
+	}
+
+	if request.EscalationDelaySeconds > 0 && (len(request.AlertTypes) != 1 || request.AlertTypes[0] != events.EventTypePhoneHeartbeatOffline) {
+		result.Add("escalation_delay_seconds", fmt.Sprintf("The escalation_delay_seconds field can only be set on a channel which routes only [%s] alerts", events.EventTypePhoneHeartbeatOffline))
+	}
+
+	return result
+}
+
+// isOwnDiscordChannel checks if a discord channel is in a server of one of the discord integrations of the user
+func (validator *AlertChannelHandlerValidator) isOwnDiscordChannel(ctx context.Context, userID entities.UserID, channelID string) bool {
+	ctx, span, ctxLogger := validator.tracer.StartWithLogger(ctx, validator.logger)
+	defer span.End()
+
+	channel, _, err := validator.discordClient.Channel.Get(ctx, channelID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch discord channel with ID [%s]", channelID)
+		ctxLogger.Warn(validator.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return false
+	}
+
+	integrations, err := validator.discordService.Index(ctx, userID, repositories.IndexParams{Limit: 100})
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch discord integrations of user [%s]", userID)
+		ctxLogger.Error(validator.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return false
+	}
+
+	for _, integration := range integrations {
+		if integration.ServerID == channel["guild_id"] {
+			return true
+		}
+	}
+	return false
+}
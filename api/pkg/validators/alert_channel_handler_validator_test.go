@@ -0,0 +1,98 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/discord"
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+const (
+	testOwnChannelID   = "1095780203256627291"
+	testOtherChannelID = "1095780203256627292"
+	testOwnServerID    = "1095778291488653372"
+)
+
+// testAlertChannelValidator creates an AlertChannelHandlerValidator for a user who has connected the discord server testOwnServerID
+func testAlertChannelValidator(t *testing.T) *AlertChannelHandlerValidator {
+	logger, tracer := testLoggerAndTracer()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		guildID := "1095778291488653373"
+		if r.URL.Path == "/channels/"+testOwnChannelID {
+			guildID = testOwnServerID
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"id":"%s","guild_id":"%s"}`, r.URL.Path, guildID)
+	}))
+	t.Cleanup(server.Close)
+
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", uuid.NewString())), &gorm.Config{
+		Logger: gormLogger.Default.LogMode(gormLogger.Silent),
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, db.AutoMigrate(&entities.Discord{}))
+	assert.Nil(t, db.Create(&entities.Discord{
+		ID:        uuid.New(),
+		UserID:    "user-id",
+		Name:      "Game Server",
+		ServerID:  testOwnServerID,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}).Error)
+
+	client := discord.New(discord.WithHTTPClient(server.Client()), discord.WithBaseURL(server.URL))
+	discordService := services.NewDiscordService(logger, tracer, client, repositories.NewGormDiscordRepository(logger, tracer, db), nil)
+	return NewAlertChannelHandlerValidator(logger, tracer, client, discordService)
+}
+
+func TestAlertChannelHandlerValidator_ValidateStore(t *testing.T) {
+	validator := testAlertChannelValidator(t)
+
+	tests := []struct {
+		name      string
+		userID    entities.UserID
+		channelID string
+		valid     bool
+	}{
+		{"channel in a connected discord server", "user-id", testOwnChannelID, true},
+		{"channel in a discord server which is not connected", "user-id", testOtherChannelID, false},
+		{"channel in the discord server of another user", "other-user-id", testOwnChannelID, false},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			// Setup
+			t.Parallel()
+
+			// Arrange
+			request := requests.AlertChannelStore{
+				Name:       "Alerts",
+				Type:       entities.AlertChannelTypeDiscord.String(),
+				Target:     test.channelID,
+				AlertTypes: []string{events.EventTypePhoneHeartbeatOffline},
+			}
+
+			// Act
+			result := validator.ValidateStore(context.Background(), test.userID, request)
+
+			// Assert
+			assert.Equal(t, test.valid, len(result) == 0, result)
+		})
+	}
+}
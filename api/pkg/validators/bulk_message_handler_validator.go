@@ -239,7 +239,7 @@ func (v *BulkMessageHandlerValidator) validateOwners(ctx context.Context, userID
 
 	result := url.Values{}
 	for number, rows := range numbers {
-		_, err := v.phoneService.Load(ctx, userID, strings.TrimSpace(number))
+		_, err := v.phoneService.LoadSender(ctx, userID, strings.TrimSpace(number))
 		if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
 			result.Add("document", fmt.Sprintf("Rows [%s]: The FromPhoneNumber [%s] is not registered on your account", v.toString(rows), number))
 		}
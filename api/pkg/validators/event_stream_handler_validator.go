@@ -0,0 +1,50 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// EventStreamHandlerValidator validates models used in handlers.EventStreamHandler
+type EventStreamHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewEventStreamHandlerValidator creates a new handlers.EventStreamHandler validator
+func NewEventStreamHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *EventStreamHandlerValidator) {
+	return &EventStreamHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateStream validates the requests.EventStream request
+func (validator *EventStreamHandlerValidator) ValidateStream(_ context.Context, request requests.EventStream) url.Values {
+	rules := govalidator.MapData{
+		"owners": []string{
+			multipleContactPhoneNumberRule,
+		},
+	}
+
+	if len(request.Types) > 0 {
+		rules["types"] = []string{
+			webhookEventsRule,
+		}
+	}
+
+	v := govalidator.New(govalidator.Options{
+		Data:  &request,
+		Rules: rules,
+	})
+	return v.ValidateStruct()
+}
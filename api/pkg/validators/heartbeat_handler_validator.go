@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/url"
 
+	"github.com/NdoleStudio/httpsms/pkg/entities"
 	"github.com/NdoleStudio/httpsms/pkg/requests"
 
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
@@ -52,6 +53,15 @@ func (validator *HeartbeatHandlerValidator) ValidateIndex(_ context.Context, req
 				"required",
 				phoneNumberRule,
 			},
+			"cursor": []string{
+				cursorRule,
+			},
+			"created_after": []string{
+				timestampRule,
+			},
+			"created_before": []string{
+				timestampRule,
+			},
 		},
 	})
 	return v.ValidateStruct()
@@ -66,7 +76,88 @@ func (validator *HeartbeatHandlerValidator) ValidateStore(_ context.Context, req
 				"required",
 				phoneNumberRule,
 			},
+			"app_version": []string{
+				"max:50",
+			},
 		},
 	})
-	return v.ValidateStruct()
+
+	result := v.ValidateStruct()
+	if len(result) > 0 {
+		return result
+	}
+
+	if request.BatteryLevel != nil && *request.BatteryLevel > 100 {
+		result.Add("battery_level", "The battery_level field must be between 0 and 100")
+	}
+
+	if request.SignalStrength != nil && (*request.SignalStrength < -150 || *request.SignalStrength > 0) {
+		result.Add("signal_strength", "The signal_strength field must be between -150 and 0 dBm")
+	}
+
+	if request.NetworkType != nil && len(*request.NetworkType) > 20 {
+		result.Add("network_type", "The network_type field must be maximum 20 char")
+	}
+
+	if len(request.SIMs) > 2 {
+		result.Add("sims", "The sims field must have maximum 2 items")
+	}
+
+	states := map[entities.HeartbeatSIMState]bool{
+		entities.HeartbeatSIMStateReady:    true,
+		entities.HeartbeatSIMStateAbsent:   true,
+		entities.HeartbeatSIMStateLocked:   true,
+		entities.HeartbeatSIMStateNotReady: true,
+		entities.HeartbeatSIMStateError:    true,
+		entities.HeartbeatSIMStateUnknown:  true,
+	}
+	for index, sim := range request.SIMs {
+		if !states[sim.State] {
+			result.Add("sims", fmt.Sprintf("The sims.%d.state field has an invalid state [%s]", index, sim.State))
+		}
+		if sim.Carrier != nil && len(*sim.Carrier) > 100 {
+			result.Add("sims", fmt.Sprintf("The sims.%d.carrier field must be maximum 100 char", index))
+		}
+	}
+
+	return result
+}
+
+// ValidateMonitorUpdate validates the requests.HeartbeatMonitorUpdate request
+func (validator *HeartbeatHandlerValidator) ValidateMonitorUpdate(_ context.Context, request requests.HeartbeatMonitorUpdate) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"owner": []string{
+				"required",
+				phoneNumberRule,
+			},
+			"interval_seconds": []string{
+				"required",
+				"min:60",
+				"max:3600",
+			},
+			"missed_threshold": []string{
+				"required",
+				"min:1",
+				"max:10",
+			},
+			"offline_threshold": []string{
+				"required",
+				"min:1",
+				"max:24",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if len(result) > 0 {
+		return result
+	}
+
+	if request.MissedThreshold > request.OfflineThreshold {
+		result.Add("missed_threshold", "The missed_threshold field cannot be greater than the offline_threshold field")
+	}
+
+	return result
 }
@@ -0,0 +1,89 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// maintenanceWindowMaxDuration is the maximum duration of an occurrence of a maintenance window
+const maintenanceWindowMaxDuration = 7 * 24 * time.Hour
+
+// MaintenanceWindowHandlerValidator validates models used in handlers.MaintenanceWindowHandler
+type MaintenanceWindowHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewMaintenanceWindowHandlerValidator creates a new handlers.MaintenanceWindowHandler validator
+func NewMaintenanceWindowHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *MaintenanceWindowHandlerValidator) {
+	return &MaintenanceWindowHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateStore validates the requests.MaintenanceWindowStore request
+func (validator *MaintenanceWindowHandlerValidator) ValidateStore(_ context.Context, request requests.MaintenanceWindowStore) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"phoneID": []string{
+				"required",
+				"uuid",
+			},
+			"starts_at": []string{
+				"required",
+				timestampRule,
+			},
+			"ends_at": []string{
+				"required",
+				timestampRule,
+			},
+			"recurrence": []string{
+				"required",
+				"in:" + entities.MaintenanceWindowRecurrenceNone.String() + "," + entities.MaintenanceWindowRecurrenceDaily.String() + "," + entities.MaintenanceWindowRecurrenceWeekly.String(),
+			},
+			"reason": []string{
+				"max:255",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if len(result) > 0 {
+		return result
+	}
+
+	startsAt, _ := time.Parse(time.RFC3339, request.StartsAt)
+	endsAt, _ := time.Parse(time.RFC3339, request.EndsAt)
+	if !startsAt.Before(endsAt) {
+		result.Add("ends_at", "The ends_at field must be after the starts_at field")
+		return result
+	}
+
+	if endsAt.Sub(startsAt) > maintenanceWindowMaxDuration {
+		result.Add("ends_at", "The maintenance window cannot be longer than 7 days")
+	}
+
+	recurrence := entities.MaintenanceWindowRecurrence(request.Recurrence)
+	if period := recurrence.Period(); period > 0 && endsAt.Sub(startsAt) >= period {
+		result.Add("ends_at", fmt.Sprintf("The %s maintenance window must be shorter than %s", recurrence, period))
+	}
+
+	if recurrence == entities.MaintenanceWindowRecurrenceNone && !endsAt.After(time.Now().UTC()) {
+		result.Add("ends_at", "The ends_at field must be in the future")
+	}
+
+	return result
+}
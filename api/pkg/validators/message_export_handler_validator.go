@@ -0,0 +1,75 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// MessageExportHandlerValidator validates models used in handlers.MessageExportHandler
+type MessageExportHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewMessageExportHandlerValidator creates a new handlers.MessageExportHandler validator
+func NewMessageExportHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *MessageExportHandlerValidator) {
+	return &MessageExportHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateExport validates the requests.MessageExport request
+func (validator *MessageExportHandlerValidator) ValidateExport(_ context.Context, request requests.MessageExport) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"format": []string{
+				"required",
+				"in:" + strings.Join([]string{
+					entities.MessageExportFormatCSV.String(),
+					entities.MessageExportFormatNDJSON.String(),
+					entities.MessageExportFormatXLSX.String(),
+				}, ","),
+			},
+			"owners": []string{
+				multipleContactPhoneNumberRule,
+			},
+			"types": []string{
+				multipleInRule + ":" + strings.Join([]string{
+					entities.MessageTypeCallMissed,
+					entities.MessageTypeMobileOriginated,
+					entities.MessageTypeMobileTerminated,
+				}, ","),
+			},
+			"statuses": []string{
+				multipleInRule + ":" + strings.Join([]string{
+					entities.MessageStatusPending,
+					entities.MessageStatusSent,
+					entities.MessageStatusDelivered,
+					entities.MessageStatusFailed,
+					entities.MessageStatusExpired,
+					entities.MessageStatusReceived,
+				}, ","),
+			},
+			"created_after": []string{
+				timestampRule,
+			},
+			"created_before": []string{
+				timestampRule,
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/NdoleStudio/httpsms/pkg/requests"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
 	"github.com/thedevsaddam/govalidator"
 )
 
@@ -94,6 +95,12 @@ func (validator MessageHandlerValidator) ValidateMessageSend(ctx context.Context
 				"min:1",
 				"max:2048",
 			},
+			"sim": []string{
+				"in:" + strings.Join([]string{
+					string(entities.SIM1),
+					string(entities.SIM2),
+				}, ","),
+			},
 		},
 	})
 
@@ -102,14 +109,24 @@ func (validator MessageHandlerValidator) ValidateMessageSend(ctx context.Context
 		return result
 	}
 
-	_, err := validator.phoneService.Load(ctx, userID, request.From)
+	phone, err := validator.phoneService.LoadSender(ctx, userID, request.From)
 	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
 		result.Add("from", fmt.Sprintf("no phone found with with 'from' number [%s]. install the android app on your phone to start sending messages", request.From))
+		return result
 	}
 
 	if err != nil {
 		ctxLogger.Error(validator.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("could not load phone for user [%s] and phone [%s]", userID, request.From))))
 		result.Add("from", fmt.Sprintf("could not validate 'from' number [%s], please try again later", request.From))
+		return result
+	}
+
+	if request.SIM != "" && !phone.HasSIM(entities.SIM(request.SIM)) {
+		result.Add("sim", fmt.Sprintf("the sim [%s] is not configured on the phone with number [%s]", request.SIM, request.From))
+	}
+
+	if settings := phone.SIMWithPhoneNumber(request.From); request.SIM != "" && phone.PhoneNumber != request.From && settings != nil && settings.SIM != entities.SIM(request.SIM) {
+		result.Add("sim", fmt.Sprintf("the 'from' number [%s] belongs to sim [%s] and cannot be sent with sim [%s]", request.From, settings.SIM, request.SIM))
 	}
 
 	return result
@@ -148,7 +165,7 @@ func (validator MessageHandlerValidator) ValidateMessageBulkSend(ctx context.Con
 		return result
 	}
 
-	_, err := validator.phoneService.Load(ctx, userID, request.From)
+	_, err := validator.phoneService.LoadSender(ctx, userID, request.From)
 	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
 		result.Add("from", fmt.Sprintf("no phone found with with 'from' number [%s]. Install the android app on your phone to start sending messages", request.From))
 	}
@@ -175,36 +192,115 @@ func (validator MessageHandlerValidator) ValidateMessageOutstanding(_ context.Co
 	return v.ValidateStruct()
 }
 
-// ValidateMessageIndex validates the requests.MessageIndex request
-func (validator MessageHandlerValidator) ValidateMessageIndex(_ context.Context, request requests.MessageIndex) url.Values {
+// ValidateMessageOutstandingBatch validates the requests.MessageOutstandingBatch request
+func (validator MessageHandlerValidator) ValidateMessageOutstandingBatch(_ context.Context, request requests.MessageOutstandingBatch) url.Values {
 	v := govalidator.New(govalidator.Options{
 		Data: &request,
 		Rules: govalidator.MapData{
+			"owner": []string{
+				"required",
+				phoneNumberRule,
+			},
 			"limit": []string{
 				"required",
 				"numeric",
 				"min:1",
-				"max:20",
+				"max:50",
 			},
-			"skip": []string{
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateMessagePollOutstanding validates the requests.MessagePollOutstanding request
+func (validator MessageHandlerValidator) ValidateMessagePollOutstanding(_ context.Context, request requests.MessagePollOutstanding) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"owner": []string{
 				"required",
-				"numeric",
-				"min:0",
+				phoneNumberRule,
 			},
-			"contact": []string{
+			"timeout": []string{
 				"required",
+				"numeric",
 				"min:1",
+				"max:60",
 			},
-			"query": []string{
-				"max:100",
-			},
-			"owner": []string{
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateMessageIndex validates the requests.MessageIndex request
+func (validator MessageHandlerValidator) ValidateMessageIndex(_ context.Context, request requests.MessageIndex) url.Values {
+	rules := govalidator.MapData{
+		"limit": []string{
+			"required",
+			"numeric",
+			"min:1",
+			"max:20",
+		},
+		"skip": []string{
+			"required",
+			"numeric",
+			"min:0",
+		},
+		"contact": []string{
+			"required",
+			"min:1",
+		},
+		"query": []string{
+			"max:100",
+		},
+		"owner": []string{
+			"required",
+			phoneNumberRule,
+		},
+		"cursor": []string{
+			cursorRule,
+		},
+		"created_after": []string{
+			timestampRule,
+		},
+		"created_before": []string{
+			timestampRule,
+		},
+	}
+
+	if request.RequestID != "" {
+		rules["request_id"] = []string{"max:255"}
+		delete(rules, "contact")
+		delete(rules, "owner")
+	}
+
+	v := govalidator.New(govalidator.Options{
+		Data:  &request,
+		Rules: rules,
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateMessageBatchGet validates the requests.MessageBatchGet request
+func (validator MessageHandlerValidator) ValidateMessageBatchGet(_ context.Context, request requests.MessageBatchGet) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"ids": []string{
 				"required",
-				phoneNumberRule,
+				"max:500",
 			},
 		},
 	})
-	return v.ValidateStruct()
+
+	result := v.ValidateStruct()
+	for index, id := range request.IDs {
+		if _, err := uuid.Parse(id); err != nil {
+			result.Add("ids", fmt.Sprintf("The ids field in index [%d] must be a valid UUID", index))
+		}
+	}
+
+	return result
 }
 
 // ValidateMessageSearch validates the requests.MessageSearch request
@@ -239,6 +335,8 @@ func (validator MessageHandlerValidator) ValidateMessageSearch(_ context.Context
 					"contact",
 					"type",
 					"status",
+					repositories.MessageSortByRank,
+					repositories.MessageSortByOrderTimestamp,
 				}, ","),
 			},
 			"limit": []string{
@@ -255,9 +353,28 @@ func (validator MessageHandlerValidator) ValidateMessageSearch(_ context.Context
 			"query": []string{
 				"max:100",
 			},
+			"cursor": []string{
+				cursorRule,
+			},
+			"created_after": []string{
+				timestampRule,
+			},
+			"created_before": []string{
+				timestampRule,
+			},
 		},
 	})
-	return v.ValidateStruct()
+
+	result := v.ValidateStruct()
+	if request.Cursor != "" && request.SortBy != "" && request.SortBy != repositories.MessageSortByOrderTimestamp {
+		result.Add("cursor", fmt.Sprintf("The cursor field can only be used when the sort_by field is [%s]", repositories.MessageSortByOrderTimestamp))
+	}
+
+	if request.SortBy == repositories.MessageSortByRank && request.Query == "" {
+		result.Add("sort_by", fmt.Sprintf("The sort_by field can only be [%s] when the query field is set", repositories.MessageSortByRank))
+	}
+
+	return result
 }
 
 // ValidateMessageEvent validates the requests.MessageEvent request
@@ -55,6 +55,15 @@ func (validator *MessageThreadHandlerValidator) ValidateMessageThreadIndex(_ con
 				"required",
 				phoneNumberRule,
 			},
+			"cursor": []string{
+				cursorRule,
+			},
+			"created_after": []string{
+				timestampRule,
+			},
+			"created_before": []string{
+				timestampRule,
+			},
 		},
 	})
 	return v.ValidateStruct()
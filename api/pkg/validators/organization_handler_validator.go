@@ -0,0 +1,139 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// OrganizationHandlerValidator validates models used in handlers.OrganizationHandler
+type OrganizationHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewOrganizationHandlerValidator creates a new handlers.OrganizationHandler validator
+func NewOrganizationHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *OrganizationHandlerValidator) {
+	return &OrganizationHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateStore validates the requests.OrganizationStore request
+func (validator *OrganizationHandlerValidator) ValidateStore(_ context.Context, request requests.OrganizationStore) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"name": []string{
+				"required",
+				"min:1",
+				"max:255",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateInvite validates the requests.OrganizationInvite request
+func (validator *OrganizationHandlerValidator) ValidateInvite(_ context.Context, request requests.OrganizationInvite) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"email": []string{
+				"required",
+				"email",
+				"max:255",
+			},
+			"role": []string{
+				"required",
+				validator.memberRolesRule(),
+			},
+			"organizationID": []string{
+				"required",
+				"uuid",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateMemberIndex validates the requests.OrganizationMemberIndex request
+func (validator *OrganizationHandlerValidator) ValidateMemberIndex(_ context.Context, request requests.OrganizationMemberIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+			"query": []string{
+				"max:100",
+			},
+			"organizationID": []string{
+				"required",
+				"uuid",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateMemberUpdate validates the requests.OrganizationMemberUpdate request
+func (validator *OrganizationHandlerValidator) ValidateMemberUpdate(_ context.Context, request requests.OrganizationMemberUpdate) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"role": []string{
+				"required",
+				validator.memberRolesRule(),
+			},
+			"phone_ids": []string{
+				"max:100",
+			},
+			"organizationID": []string{
+				"required",
+				"uuid",
+			},
+			"memberID": []string{
+				"required",
+				"uuid",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	for index, phoneID := range request.PhoneIDs {
+		if _, err := uuid.Parse(phoneID); err != nil {
+			result.Add("phone_ids", fmt.Sprintf("The phone_ids field in index [%d] must be a valid UUID", index))
+		}
+	}
+
+	return result
+}
+
+func (validator *OrganizationHandlerValidator) memberRolesRule() string {
+	return "in:" + strings.Join([]string{
+		entities.OrganizationRoleAdmin.String(),
+		entities.OrganizationRoleAgent.String(),
+		entities.OrganizationRoleReadOnly.String(),
+	}, ",")
+}
@@ -0,0 +1,143 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// PhoneCommandHandlerValidator validates models used in handlers.PhoneCommandHandler
+type PhoneCommandHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewPhoneCommandHandlerValidator creates a new handlers.PhoneCommandHandler validator
+func NewPhoneCommandHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *PhoneCommandHandlerValidator) {
+	return &PhoneCommandHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateStore validates the requests.PhoneCommandStore request
+func (validator *PhoneCommandHandlerValidator) ValidateStore(_ context.Context, request requests.PhoneCommandStore) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"phoneID": []string{
+				"required",
+				"uuid",
+			},
+			"type": []string{
+				"required",
+				"in:" + strings.Join([]string{
+					entities.PhoneCommandTypeRefreshConfig.String(),
+					entities.PhoneCommandTypeForceHeartbeat.String(),
+					entities.PhoneCommandTypeResyncOutstanding.String(),
+					entities.PhoneCommandTypeUploadLogs.String(),
+					entities.PhoneCommandTypeSwitchSIM.String(),
+				}, ","),
+			},
+			"sim": []string{
+				"in:" + strings.Join([]string{entities.SIM1.String(), entities.SIM2.String()}, ","),
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if len(result) > 0 {
+		return result
+	}
+
+	if request.Type == entities.PhoneCommandTypeSwitchSIM.String() && request.SIM == "" {
+		result.Add("sim", "sim is required when the type is switch_sim")
+	}
+
+	return result
+}
+
+// ValidateIndex validates the requests.PhoneCommandIndex request
+func (validator *PhoneCommandHandlerValidator) ValidateIndex(_ context.Context, request requests.PhoneCommandIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"phoneID": []string{
+				"required",
+				"uuid",
+			},
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+
+	statuses := map[string]bool{
+		entities.PhoneCommandStatusPending.String():   true,
+		entities.PhoneCommandStatusDelivered.String(): true,
+		entities.PhoneCommandStatusAcked.String():     true,
+		entities.PhoneCommandStatusFailed.String():    true,
+	}
+	for _, status := range request.Statuses() {
+		if !statuses[status] {
+			result.Add("status", fmt.Sprintf("The status field has an invalid status [%s]", status))
+		}
+	}
+
+	return result
+}
+
+// ValidateAck validates the requests.PhoneCommandAck request
+func (validator *PhoneCommandHandlerValidator) ValidateAck(_ context.Context, request requests.PhoneCommandAck) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"commandID": []string{
+				"required",
+				"uuid",
+			},
+			"status": []string{
+				"required",
+				"in:" + entities.PhoneCommandStatusAcked.String() + "," + entities.PhoneCommandStatusFailed.String(),
+			},
+			"error_message": []string{
+				"max:1000",
+			},
+			"timestamp": []string{
+				"required",
+				timestampRule,
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if len(result) > 0 {
+		return result
+	}
+
+	if request.Status == entities.PhoneCommandStatusFailed.String() && request.ErrorMessage == "" {
+		result.Add("error_message", "error_message is required when the status is failed")
+	}
+
+	return result
+}
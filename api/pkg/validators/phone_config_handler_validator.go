@@ -0,0 +1,57 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// PhoneConfigHandlerValidator validates models used in handlers.PhoneConfigHandler
+type PhoneConfigHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewPhoneConfigHandlerValidator creates a new handlers.PhoneConfigHandler validator
+func NewPhoneConfigHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *PhoneConfigHandlerValidator) {
+	return &PhoneConfigHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateUpdate validates the requests.PhoneConfigUpdate request
+func (validator *PhoneConfigHandlerValidator) ValidateUpdate(_ context.Context, request requests.PhoneConfigUpdate) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"phoneID": []string{
+				"required",
+				"uuid",
+			},
+			"receive_sims": []string{
+				multipleInRule + ":" + entities.SIM1.String() + "," + entities.SIM2.String(),
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if len(result) > 0 {
+		return result
+	}
+
+	if len(request.ReceiveSIMs) == 0 && request.DeliveryReportsEnabled == nil && request.EncryptionEnabled == nil {
+		result.Add("receive_sims", "at least one of receive_sims, delivery_reports_enabled or encryption_enabled is required")
+	}
+
+	return result
+}
@@ -3,13 +3,18 @@ package validators
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/entities"
 
 	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/nyaruka/phonenumbers"
 	"github.com/thedevsaddam/govalidator"
 )
 
@@ -68,6 +73,10 @@ func (validator *PhoneHandlerValidator) ValidateUpsert(_ context.Context, reques
 				"min:0",
 				"max:1000",
 			},
+			"push_endpoint": []string{
+				"url",
+				"max:1000",
+			},
 			"messages_per_minute": []string{
 				"min:0",
 				"max:60",
@@ -84,6 +93,9 @@ func (validator *PhoneHandlerValidator) ValidateUpsert(_ context.Context, reques
 				"min:60",
 				"max:3600",
 			},
+			"delivery_mode": []string{
+				"in:" + strings.Join([]string{entities.PhoneDeliveryModeFCM.String(), entities.PhoneDeliveryModeUnifiedPush.String(), entities.PhoneDeliveryModePoll.String()}, ","),
+			},
 		},
 	})
 
@@ -96,9 +108,63 @@ func (validator *PhoneHandlerValidator) ValidateUpsert(_ context.Context, reques
 		result.Add("message_expiration_seconds", "message_expiration_seconds cannot be 0 when max_send_attempts is greater than 0")
 	}
 
+	if request.DeliveryMode == entities.PhoneDeliveryModeUnifiedPush.String() && request.PushEndpoint == "" {
+		result.Add("push_endpoint", "push_endpoint is required when the delivery_mode is unifiedpush")
+	}
+
+	if request.PushEndpoint != "" {
+		if endpoint, err := url.Parse(request.PushEndpoint); err != nil || endpoint.Scheme != "https" || endpoint.Hostname() == "" {
+			result.Add("push_endpoint", "push_endpoint must be an https URL")
+		} else if ip := net.ParseIP(endpoint.Hostname()); ip != nil && !services.IsPublicIP(ip) {
+			result.Add("push_endpoint", "push_endpoint cannot be a private, loopback or link-local IP address")
+		}
+	}
+
+	if request.FailoverPhoneID != nil && *request.FailoverPhoneID != "" {
+		if _, err := uuid.Parse(*request.FailoverPhoneID); err != nil {
+			result.Add("failover_phone_id", "failover_phone_id must be a valid UUID")
+		}
+	}
+
+	if request.MaxMessagesPerHour != nil && request.MaxMessagesPerDay != nil && *request.MaxMessagesPerDay > 0 && *request.MaxMessagesPerHour > *request.MaxMessagesPerDay {
+		result.Add("max_messages_per_hour", "max_messages_per_hour cannot be greater than max_messages_per_day")
+	}
+
+	validator.validateSIMs(request, result)
+
 	return result
 }
 
+// validateSIMs validates the SIM slots of a dual-SIM phone
+func (validator *PhoneHandlerValidator) validateSIMs(request requests.PhoneUpsert, result url.Values) {
+	sims := map[string]bool{}
+	for _, sim := range request.SIMs {
+		if sim.SIM != entities.SIM1.String() && sim.SIM != entities.SIM2.String() {
+			result.Add("sims", fmt.Sprintf("The sim [%s] must be one of %s or %s", sim.SIM, entities.SIM1, entities.SIM2))
+			continue
+		}
+
+		if sims[sim.SIM] {
+			result.Add("sims", fmt.Sprintf("The sim [%s] is configured more than once", sim.SIM))
+		}
+		sims[sim.SIM] = true
+
+		if sim.PhoneNumber != "" {
+			if _, err := phonenumbers.Parse(sim.PhoneNumber, phonenumbers.UNKNOWN_REGION); err != nil {
+				result.Add("sims", fmt.Sprintf("The phone number [%s] of sim [%s] is not a valid phone number", sim.PhoneNumber, sim.SIM))
+			}
+		}
+
+		if sim.MessagesPerMinute > 60 {
+			result.Add("sims", fmt.Sprintf("The messages_per_minute of sim [%s] cannot be greater than 60", sim.SIM))
+		}
+	}
+
+	if len(request.SIMs) > 0 && !sims[request.SIM] {
+		result.Add("sim", fmt.Sprintf("The default sim [%s] must be one of the configured sims", request.SIM))
+	}
+}
+
 // ValidateDelete ValidateUpsert validates requests.PhoneDelete
 func (validator *PhoneHandlerValidator) ValidateDelete(_ context.Context, request requests.PhoneDelete) url.Values {
 	v := govalidator.New(govalidator.Options{
@@ -113,3 +179,45 @@ func (validator *PhoneHandlerValidator) ValidateDelete(_ context.Context, reques
 
 	return v.ValidateStruct()
 }
+
+// ValidateUptime validates requests.PhoneUptime
+func (validator *PhoneHandlerValidator) ValidateUptime(_ context.Context, request requests.PhoneUptime) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"phoneID": []string{
+				"required",
+				"uuid",
+			},
+			"from": []string{
+				"required",
+				timestampRule,
+			},
+			"to": []string{
+				"required",
+				timestampRule,
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if len(result) > 0 {
+		return result
+	}
+
+	from, _ := time.Parse(time.RFC3339, request.From)
+	to, _ := time.Parse(time.RFC3339, request.To)
+	if !from.Before(to) {
+		result.Add("from", "The from field must be before the to field")
+	}
+
+	if to.Sub(from) > 92*24*time.Hour {
+		result.Add("to", "The time between the from and to fields must be maximum 92 days")
+	}
+
+	if from.After(time.Now().UTC()) {
+		result.Add("from", "The from field cannot be in the future")
+	}
+
+	return result
+}
@@ -0,0 +1,58 @@
+package validators
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/hirosassa/zerodriver"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func testLoggerAndTracer() (telemetry.Logger, telemetry.Tracer) {
+	nop := zerolog.Nop()
+	logger := telemetry.NewZerologLogger("test", nil, &zerodriver.Logger{Logger: &nop}, nil)
+	return logger, telemetry.NewOtelLogger("test", logger)
+}
+
+func TestPhoneHandlerValidator_ValidateUpsert(t *testing.T) {
+	logger, tracer := testLoggerAndTracer()
+	validator := NewPhoneHandlerValidator(logger, tracer)
+
+	tests := []struct {
+		name         string
+		pushEndpoint string
+		valid        bool
+	}{
+		{"https endpoint", "https://ntfy.sh/upAbCdEfGhIjKl?up=1", true},
+		{"http endpoint", "http://ntfy.sh/upAbCdEfGhIjKl?up=1", false},
+		{"loopback endpoint", "https://127.0.0.1/up", false},
+		{"private endpoint", "https://10.0.0.5/up", false},
+		{"link-local endpoint", "https://169.254.169.254/latest/meta-data", false},
+		{"ipv6 loopback endpoint", "https://[::1]/up", false},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			// Setup
+			t.Parallel()
+
+			// Arrange
+			request := requests.PhoneUpsert{
+				PhoneNumber:  "+18005550199",
+				SIM:          "SIM1",
+				DeliveryMode: "unifiedpush",
+				PushEndpoint: test.pushEndpoint,
+			}
+
+			// Act
+			result := validator.ValidateUpsert(context.Background(), request)
+
+			// Assert
+			assert.Equal(t, test.valid, !result.Has("push_endpoint"), result.Get("push_endpoint"))
+		})
+	}
+}
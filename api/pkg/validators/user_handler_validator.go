@@ -41,3 +41,21 @@ func (validator *UserHandlerValidator) ValidateUpdate(_ context.Context, request
 
 	return v.ValidateStruct()
 }
+
+// ValidateRetention validates requests.UserRetentionUpdate
+func (validator *UserHandlerValidator) ValidateRetention(_ context.Context, request requests.UserRetentionUpdate) url.Values {
+	result := url.Values{}
+	days := map[string]*uint{
+		"message_retention_days":      request.MessageRetentionDays,
+		"heartbeat_retention_days":    request.HeartbeatRetentionDays,
+		"notification_retention_days": request.NotificationRetentionDays,
+	}
+
+	for field, value := range days {
+		if value != nil && (*value < 1 || *value > 3650) {
+			result.Add(field, fmt.Sprintf("The %s field must be between 1 and 3650 days", field))
+		}
+	}
+
+	return result
+}
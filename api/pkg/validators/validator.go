@@ -6,8 +6,10 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
 
 	"github.com/nyaruka/phonenumbers"
 	"github.com/thedevsaddam/govalidator"
@@ -21,6 +23,8 @@ const (
 	multipleContactPhoneNumberRule = "multipleContactPhoneNumber"
 	multipleInRule                 = "multipleIn"
 	webhookEventsRule              = "webhookEvents"
+	cursorRule                     = "cursor"
+	timestampRule                  = "timestamp"
 )
 
 func init() {
@@ -95,6 +99,32 @@ func init() {
 		return nil
 	})
 
+	govalidator.AddCustomRule(cursorRule, func(field string, rule string, message string, value interface{}) error {
+		cursor, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("The %s field must be a cursor returned in the next_cursor field of a previous response", field)
+		}
+
+		if _, err := repositories.ParseCursor(cursor); err != nil {
+			return fmt.Errorf("The %s field must be a cursor returned in the next_cursor field of a previous response", field)
+		}
+
+		return nil
+	})
+
+	govalidator.AddCustomRule(timestampRule, func(field string, rule string, message string, value interface{}) error {
+		timestamp, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("The %s field must be an RFC3339 timestamp e.g 2022-06-05T14:26:09+03:00", field)
+		}
+
+		if _, err := time.Parse(time.RFC3339, timestamp); err != nil {
+			return fmt.Errorf("The %s field must be an RFC3339 timestamp e.g 2022-06-05T14:26:09+03:00", field)
+		}
+
+		return nil
+	})
+
 	govalidator.AddCustomRule(webhookEventsRule, func(field string, rule string, message string, value interface{}) error {
 		input, ok := value.([]string)
 		if !ok {
@@ -114,6 +144,10 @@ func init() {
 			events.EventTypePhoneHeartbeatOnline:  true,
 			events.EventTypePhoneHeartbeatOffline: true,
 			events.MessageCallMissed:              true,
+			events.EventTypePhoneBatteryLow:       true,
+			events.EventTypePhoneSIMMissing:       true,
+			events.EventTypeMessageSendRerouted:   true,
+			events.EventTypePhoneCapReached:       true,
 		}
 
 		for _, event := range input {
@@ -206,7 +206,9 @@ export interface EntitiesUser {
   /** @example "32343a19-da5e-4b1b-a767-3298a73703cb" */
   active_phone_id: string
   /** @example "x-api-key" */
-  api_key: string
+  api_key?: string
+  /** @example "x-api-ke" */
+  api_key_prefix: string
   /** @example "2022-06-05T14:26:02.302718+03:00" */
   created_at: string
   /** @example "name@email.com" */
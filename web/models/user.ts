@@ -1,7 +1,8 @@
 export interface User {
   id: string
   email: string
-  api_key: string
+  api_key?: string
+  api_key_prefix: string
   active_phone_id: string | null
   subscription_ends_at: string
   /** @example "8f9c71b8-b84e-4417-8408-a62274f65a08" */